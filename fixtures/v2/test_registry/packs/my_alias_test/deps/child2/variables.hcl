@@ -18,4 +18,5 @@ variable "complex" {
       b = string
     }))
   })
+  default = null
 }
@@ -47,3 +47,17 @@ func ExactArgs(n int) ValidationFn {
 		return nil
 	}
 }
+
+// Returns an error unless exactly one pack is provided, or none when a job
+// file is set, as the job file is deployed in place of a pack
+func PackOrJobFileArgs(jobFile *string) ValidationFn {
+	return func(c *baseCommand, args []string) error {
+		if *jobFile == "" {
+			return ExactArgs(1)(c, args)
+		}
+		if len(args) != 0 {
+			return errors.New("--job-file can not be used with a pack argument")
+		}
+		return nil
+	}
+}
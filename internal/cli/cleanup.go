@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// CleanupCommand lists the stopped jobs of pack deployments, and purges them
+// when asked, so packs whose jobs were stopped outside nomad-pack are no
+// longer shown by status.
+type CleanupCommand struct {
+	*baseCommand
+
+	// purge is whether the stale jobs are purged, rather than only listed.
+	purge bool
+}
+
+func (c *CleanupCommand) Run(args []string) int {
+	c.cmdKey = "cleanup"
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithNoArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	client, err := c.getAPIClient()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to initialize client")
+		return 1
+	}
+
+	staleJobs, err := getStalePackJobs(client)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to find stale pack jobs")
+		return 1
+	}
+
+	if len(staleJobs) == 0 {
+		c.ui.Info("No stale pack jobs found.")
+		return 0
+	}
+
+	// The stale jobs are displayed in the same format as status.
+	columns, err := parseStatusColumns(defaultStatusColumns)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to display stale pack jobs")
+		return 1
+	}
+	c.ui.Table(formatDeployedPackJobs(staleJobs, columns))
+
+	// Purging permanently removes the jobs, so is only done when asked.
+	if !c.purge {
+		c.ui.Info(fmt.Sprintf("%d stale pack job(s) would be purged. Pass --purge to purge them.", len(staleJobs)))
+		return 0
+	}
+
+	confirmed, err := c.confirmCleanup(len(staleJobs))
+	if err != nil {
+		c.ui.ErrorWithContext(err, "error confirming cleanup")
+		return 1
+	}
+	if !confirmed {
+		c.ui.Info("Cleanup aborted by user")
+		return 0
+	}
+
+	var errs []error
+	for _, staleJob := range staleJobs {
+		_, _, err = client.Jobs().DeregisterOpts(staleJob.jobID, &api.DeregisterOptions{Purge: true}, &api.WriteOptions{Namespace: staleJob.namespace})
+		if err != nil {
+			errs = append(errs, err)
+			c.ui.ErrorWithContext(err, fmt.Sprintf("error purging job: %q", staleJob.jobID))
+			continue
+		}
+		c.ui.Success(fmt.Sprintf("Job %q purged", staleJob.jobID))
+	}
+
+	if len(errs) > 0 {
+		c.ui.Warning(fmt.Sprintf("Cleanup complete with %d error(s)", len(errs)))
+		return 1
+	}
+
+	c.ui.Success(fmt.Sprintf("Purged %d stale pack job(s)", len(staleJobs)))
+	return 0
+}
+
+// confirmCleanup asks the user to confirm the purge of the stale jobs, as this
+// permanently removes them from the Nomad server state.
+func (c *CleanupCommand) confirmCleanup(count int) (bool, error) {
+	if c.autoApproved {
+		return true, nil
+	}
+
+	// For non-interactive UIs, the value must be passed by flag.
+	if !c.ui.Interactive() {
+		return false, errors.New("purging jobs requires confirmation; use --auto-approve when running non-interactively")
+	}
+
+	for {
+		purge, err := c.ui.Input(&terminal.Input{
+			Prompt: fmt.Sprintf("%d stale pack job(s) will be purged from the cluster, continue? [y/n] ", count),
+			Style:  terminal.WarningBoldStyle,
+		})
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(purge) {
+		case "y":
+			return true, nil
+		case "n":
+			return false, nil
+		default:
+			c.ui.Output("Please select a valid option.\n", terminal.WithStyle(terminal.ErrorBoldStyle))
+		}
+	}
+}
+
+func (c *CleanupCommand) Flags() *flag.Sets {
+	return c.flagSet(flagSetNeedsApproval|flagSetNomadClient, func(set *flag.Sets) {
+		f := set.NewSet("Cleanup Options")
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "purge",
+			Target:  &c.purge,
+			Default: false,
+			Usage: `Purge the stale pack jobs, rather than only listing them.
+					Requires confirmation unless --auto-approve is set.`,
+		})
+	})
+}
+
+func (c *CleanupCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CleanupCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *CleanupCommand) Synopsis() string {
+	return "Purge the stopped jobs of pack deployments."
+}
+
+func (c *CleanupCommand) Help() string {
+	c.Example = `
+	# List the stopped pack jobs that would be purged
+	nomad-pack cleanup
+
+	# Purge the stopped pack jobs without prompting for confirmation
+	nomad-pack cleanup --purge --auto-approve
+	`
+	return formatHelp(`
+	Usage: nomad-pack cleanup [options]
+
+	Reconcile pack deployments against the jobs in the Nomad cluster. Pack
+	metadata is stored on the jobs themselves, so jobs which were stopped
+	outside nomad-pack continue to be shown by status until purged. Cleanup
+	lists these stopped jobs, and purges them, removing their pack metadata,
+	when --purge is passed.
+
+` + c.GetExample() + c.Flags().Help())
+}
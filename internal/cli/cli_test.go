@@ -4,18 +4,27 @@
 package cli
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/command/agent"
 	"github.com/mitchellh/cli"
@@ -24,14 +33,19 @@ import (
 
 	ct "github.com/hashicorp/nomad-pack/internal/cli/testhelper"
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	flag "github.com/hashicorp/nomad-pack/internal/pkg/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
 	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+	"github.com/hashicorp/nomad-pack/internal/pkg/plugin"
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
 	"github.com/hashicorp/nomad-pack/internal/pkg/testfixture"
 	"github.com/hashicorp/nomad-pack/internal/pkg/version"
 	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/hashicorp/nomad-pack/internal/testui"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/terminal"
 )
 
 // TODO: Test job run with diffs
@@ -81,6 +95,146 @@ func TestCLI_CreateTestRegistry(t *testing.T) {
 	must.Zero(t, result.exitCode)
 }
 
+func TestCLI_PackList_JSONL(t *testing.T) {
+	reg, _, regPath := createTestRegistries(t)
+	defer cleanTestRegistry(t, regPath)
+
+	result := runPackCmd(t, []string{"list", "--format=jsonl", "--registry=" + reg.Name})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+	lines := strings.Split(strings.TrimSpace(result.cmdOut.String()), "\n")
+	must.Len(t, 2, lines)
+	refs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		var entry map[string]string
+		must.NoError(t, json.Unmarshal([]byte(line), &entry), must.Sprintf("line: %q", line))
+		must.Eq(t, testPack, entry["name"])
+		must.Eq(t, reg.Name, entry["registry"])
+		must.MapContainsKeys(t, entry, []string{"version", "description"})
+		refs = append(refs, entry["ref"])
+	}
+	must.SliceContainsAll(t, []string{"latest", testRef}, refs)
+}
+
+func TestCLI_CacheDir(t *testing.T) {
+	// The directory is created when missing.
+	cacheDir := path.Join(t.TempDir(), "nested", "cache")
+	result := runPackCmd(t, []string{"registry", "list", "--cache-dir=" + cacheDir})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.DirExists(t, cacheDir)
+
+	reg, _, _ := createTestRegistriesIn(t, cacheDir)
+	result = runPackCmd(t, []string{"render", testPack, "--registry=" + reg.Name, "--cache-dir=" + cacheDir})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "simple_raw_exec.nomad")
+
+	// Registries in the cache directory are not found in the default cache.
+	result = runPackCmd(t, []string{"render", testPack, "--registry=" + reg.Name})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "Failed To Find Pack")
+
+	t.Setenv(EnvCacheDir, cacheDir)
+	result = runPackCmd(t, []string{"list", "--format=jsonl", "--registry=" + reg.Name})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `"registry":"`+reg.Name+`"`)
+
+	notDir := path.Join(t.TempDir(), "file")
+	must.NoError(t, os.WriteFile(notDir, nil, 0644))
+	result = runPackCmd(t, []string{"registry", "list", "--cache-dir=" + notDir})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), fmt.Sprintf("cache directory %q is not a directory", notDir))
+}
+
+func TestCLI_Functions(t *testing.T) {
+	t.Parallel()
+
+	result := runPackCmd(t, []string{"functions"})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "must_var")
+	must.StrContains(t, result.cmdOut.String(), "Returns the named variable of the current pack, erroring if it is not set.")
+
+	result = runPackCmd(t, []string{"functions", "--format=json"})
+	must.Zero(t, result.exitCode)
+
+	var funcs []renderer.Function
+	must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &funcs))
+	must.SliceContainsFunc(t, funcs, "toStringList", func(fn renderer.Function, name string) bool {
+		return fn.Name == name && fn.Source == renderer.FunctionSourcePack
+	})
+}
+
+func TestCLI_InfoVar(t *testing.T) {
+	t.Parallel()
+
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+
+	f, err := os.OpenFile(path.Join(packPath, "variables.hcl"), os.O_APPEND|os.O_WRONLY, 0)
+	must.NoError(t, err)
+	_, err = f.WriteString(`
+variable "replicas" {
+  description = "The number of replicas"
+  type        = number
+  default     = 3
+  validation {
+    condition     = var.replicas > 0
+    error_message = "At least one replica is required."
+  }
+}
+`)
+	must.NoError(t, err)
+	must.NoError(t, f.Close())
+
+	result := runPackCmd(t, []string{"info", packPath, "--var=replicas"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	out := result.cmdOut.String()
+	must.StrContains(t, out, "Type          number")
+	must.StrContains(t, out, "Default       3")
+	must.StrContains(t, out, "Description   The number of replicas")
+	must.StrContains(t, out, "condition:     var.replicas > 0")
+	must.StrContains(t, out, "error message: At least one replica is required.")
+
+	result = runPackCmd(t, []string{"info", packPath, "--var=replicas", "--format=json"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+	var v infoVariable
+	must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &v))
+	must.Eq(t, "replicas", v.Name)
+	must.Eq(t, testPack, v.Pack)
+	must.Eq(t, "number", v.Type)
+	must.Eq(t, any(float64(3)), v.Default)
+	must.Len(t, 1, v.Validations)
+	must.Eq(t, "var.replicas > 0", v.Validations[0].Condition)
+	must.Eq(t, "At least one replica is required.", v.Validations[0].ErrorMessage)
+
+	result = runPackCmd(t, []string{"info", packPath, "--format=json"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+	var info infoPack
+	must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &info))
+	must.Eq(t, testPack, info.Name)
+	must.SliceContainsFunc(t, info.Variables, "replicas", func(v *infoVariable, name string) bool {
+		return v.Name == name
+	})
+
+	result = runPackCmd(t, []string{"info", packPath, "--var=replica"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(),
+		`variable "replica" is not declared by the pack, available variables: command, count`)
+}
+
+func TestCLI_InfoVariableName(t *testing.T) {
+	t.Parallel()
+
+	root := &pack.Pack{Metadata: &pack.Metadata{Pack: &pack.MetadataPack{Name: "my_pack"}}}
+
+	// Variables of dependencies are named as --var expects them, without the
+	// ID of the root pack.
+	must.Eq(t, "count", infoVariableName(root, "my_pack", "count"))
+	must.Eq(t, "child.count", infoVariableName(root, "my_pack.child", "count"))
+	must.Eq(t, "child.grandchild.count", infoVariableName(root, "my_pack.child.grandchild", "count"))
+}
+
 func TestCLI_Version(t *testing.T) {
 	t.Parallel()
 	// This test doesn't require a Nomad cluster.
@@ -88,12 +242,330 @@ func TestCLI_Version(t *testing.T) {
 	must.Zero(t, exitCode)
 }
 
+func TestCLI_VersionCheckUpdates(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version": "999.0.0"}`))
+	}))
+	defer srv.Close()
+
+	result := runPackCmd(t, []string{"version", "--check-updates", "--release-url=" + srv.URL})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "Nomad Pack "+version.HumanVersion())
+	must.StrContains(t, result.cmdOut.String(), "A newer version of Nomad Pack is available: v999.0.0")
+
+	// Failing to reach the endpoint still prints the local version.
+	srv.Close()
+	result = runPackCmd(t, []string{"version", "--check-updates", "--release-url=" + srv.URL})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "Nomad Pack "+version.HumanVersion())
+	must.StrContains(t, result.cmdOut.String(), "Unable to check for updates")
+}
+
 func TestCLI_JobRun(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
 		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
 	})
 }
 
+func TestCLI_JobRunOnlyChanged(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--only-changed"}))
+
+		// Running the same pack again should skip the unchanged job
+		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--only-changed"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), "unchanged, skipping")
+		must.StrNotContains(t, result.cmdOut.String(), "registered successfully")
+
+		// Changing a variable should cause the job to be submitted
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--only-changed", "--var=count=2"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrNotContains(t, result.cmdOut.String(), "unchanged, skipping")
+		must.StrContains(t, result.cmdOut.String(), "registered successfully")
+	})
+}
+
+func TestCLI_JobRunCheckIndex(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--check-current-index"}))
+
+		// The job is unchanged since the render, so it can be updated.
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--check-current-index", "--var=count=2"}))
+
+		// A zero index requires that the job does not exist.
+		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--check-index=0"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "job already exists")
+
+		// A stale index reports the current one.
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--check-index=1"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "Failed To Register Job Due To Check Index Failure")
+		must.StrContains(t, result.cmdOut.String(), "render and plan the pack again before running it")
+		must.StrContains(t, result.cmdOut.String(), "Current Job Modify Index:")
+
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--check-index=1", "--check-current-index"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--check-current-index can not be used with --check-index")
+	})
+}
+
+func TestCLI_JobRunDependencyOrder(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		result := runTestPackCmd(t, s, []string{"run", writeDependentJobsPack(t, "db", "app")})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "jobs have a dependency cycle: app -> db -> app")
+		must.StrNotContains(t, result.cmdOut.String(), "registered successfully")
+
+		// The db job is registered first, as the app job depends on it.
+		result = runTestPackCmd(t, s, []string{"run", writeDependentJobsPack(t, "db", "")})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		out := result.cmdOut.String()
+		dbIdx := strings.Index(out, "Job 'db' in pack deployment")
+		must.NonNegative(t, dbIdx)
+		must.True(t, dbIdx < strings.Index(out, "Job 'app' in pack deployment"))
+	})
+}
+
+func TestCLI_JobRunHooks(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		// Copy the test pack and add hooks which record that they ran.
+		packPath := path.Join(t.TempDir(), testPack)
+		must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+		must.NoError(t, os.Mkdir(path.Join(packPath, "hooks"), 0755))
+		must.NoError(t, os.WriteFile(path.Join(packPath, "hooks", "pre-deploy.tpl"),
+			[]byte(`echo "count=[[ var "count" . ]] $NOMAD_PACK_DEPLOYMENT_NAME" > pre.out`), 0644))
+		must.NoError(t, os.WriteFile(path.Join(packPath, "hooks", "post-deploy.tpl"),
+			[]byte("echo post > post.out\nexit 3\n"), 0644))
+
+		// Without --run-hooks, the hooks are not run.
+		result := runTestPackCmd(t, s, []string{"run", packPath})
+		expectGoodPackDeploy(t, result)
+		must.StrContains(t, result.cmdOut.String(), "only run when --run-hooks is set")
+		must.FileNotExists(t, path.Join(packPath, "pre.out"))
+
+		// The failing post-deploy hook only warns by default.
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--run-hooks", "--var=count=2"})
+		expectGoodPackDeploy(t, result)
+		must.StrContains(t, result.cmdOut.String(), "post-deploy hook failed: exit status 3")
+		pre, err := os.ReadFile(path.Join(packPath, "pre.out"))
+		must.NoError(t, err)
+		must.Eq(t, "count=2 "+testPack+"\n", string(pre))
+		must.FileExists(t, path.Join(packPath, "post.out"))
+
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--run-hooks", "--post-hook-failure=abort"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "post-deploy hook failed")
+
+		// A failing pre-deploy hook aborts the run before the job is updated.
+		must.NoError(t, os.WriteFile(path.Join(packPath, "hooks", "pre-deploy.tpl"), []byte("exit 1"), 0644))
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--run-hooks", "--var=count=3"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "pre-deploy hook failed")
+		job, _, err := client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+		must.Eq(t, 1, *job.TaskGroups[0].Count)
+	})
+}
+
+func TestCLI_JobRunDetach(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--detach"})
+		expectGoodPackDeploy(t, result)
+		must.StrContains(t, result.cmdOut.String(), "Evaluation ID:")
+		must.StrNotContains(t, result.cmdOut.String(), "finished with status")
+
+		// Without detach, the evaluation is monitored until it completes.
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--var=count=2"})
+		expectGoodPackDeploy(t, result)
+		must.StrContains(t, result.cmdOut.String(), `finished with status "complete"`)
+	})
+}
+
+func TestCLI_JobRunFollowLogs(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		// The logs are followed until the context is cancelled, which stands
+		// in for an interrupt.
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+		args := append([]string{
+			"run", getTestPackPath(t, testPack), "--follow-logs",
+			`--var=command=echo hello from the pack; sleep 300`,
+		}, AddressFromTestServer(s)...)
+		result := runPackCmdContext(t, ctx, args)
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.RegexMatch(t, regexp.MustCompile(`\[[0-9a-f]{8} server stdout\] hello from the pack`), result.cmdOut.String())
+
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--follow-logs", "--detach"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--follow-logs can not be used with --detach")
+	})
+}
+
+func TestCLI_JobRunDatacenters(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		// Copy the test pack, rendering its datacenters with toStringList.
+		packPath := path.Join(t.TempDir(), testPack)
+		must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+		jobTpl := path.Join(packPath, "templates", testPack+".nomad.tpl")
+		b, err := os.ReadFile(jobTpl)
+		must.NoError(t, err)
+		b = bytes.Replace(b, []byte(`[[ var "datacenters" . | toJson ]]`), []byte(`[[ var "datacenters" . | toStringList ]]`), 1)
+		must.NoError(t, os.WriteFile(jobTpl, b, 0644))
+
+		result := runPackCmd(t, []string{"render", packPath, `--var=datacenters=["dc1","dc2"]`})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), `datacenters = ["dc1", "dc2"]`)
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", packPath, `--var=datacenters=["dc1","dc2"]`}))
+
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+		job, _, err := client.Jobs().Info(testPack, &api.QueryOptions{})
+		must.NoError(t, err)
+		must.Eq(t, []string{"dc1", "dc2"}, job.Datacenters)
+
+		// An empty list is rejected, as the job could never be placed.
+		result = runTestPackCmd(t, s, []string{"run", packPath, `--var=datacenters=[]`})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "datacenters must not be empty")
+	})
+}
+
+func TestCLI_JobRunPriority(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--priority=80"}))
+
+		job, _, err := client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+		must.Eq(t, 80, *job.Priority)
+
+		// Plan applies the override before diffing, so shows the change run
+		// would submit.
+		result := runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--priority=80"})
+		must.NotEq(t, 255, result.exitCode)
+		must.StrNotContains(t, result.cmdOut.String(), "Priority")
+
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--priority=70"})
+		must.NotEq(t, 255, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `Priority: "80" => "70"`)
+
+		// The range is validated by the servers, whose job_max_priority
+		// defaults to 100.
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--priority=101"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "priority")
+	})
+}
+
+func TestCLI_JobRunSubmissionSource(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		sub, _, err := client.Jobs().Submission(testPack, 0, nil)
+		must.NoError(t, err)
+		must.Eq(t, "hcl2", sub.Format)
+		must.StrContains(t, sub.Source, `job "`+testPack+`"`)
+
+		// With --no-source the new version of the job has no stored source, so
+		// Nomad returns the source of the previous version.
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--var=count=2", "--no-source"}))
+
+		job, _, err := client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+		must.Eq(t, 1, *job.Version)
+
+		sub, _, err = client.Jobs().Submission(testPack, 1, nil)
+		must.NoError(t, err)
+		must.StrNotContains(t, sub.Source, "count = 2")
+	})
+}
+
+func TestCLI_JobRunPatch(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		patchPath := path.Join(t.TempDir(), "patch.json")
+		must.NoError(t, os.WriteFile(patchPath, []byte(`[
+			{"op": "test", "path": "/TaskGroups/0/Count", "value": 1},
+			{"op": "replace", "path": "/TaskGroups/0/Count", "value": 2},
+			{"op": "add", "path": "/Meta/patched", "value": "true"}
+		]`), 0644))
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--patch=" + patchPath, "--vault-token=s.supersecret"}))
+
+		patched, _, err := client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+		must.Eq(t, 2, *patched.TaskGroups[0].Count)
+		must.Eq(t, "true", patched.Meta["patched"])
+		must.Eq(t, testPack, patched.Meta[job.PackNameKey])
+
+		// The patched job is stored as the source, as it no longer matches
+		// the template.
+		sub, _, err := client.Jobs().Submission(testPack, 0, nil)
+		must.NoError(t, err)
+		must.Eq(t, "json", sub.Format)
+		must.StrContains(t, sub.Source, `"patched": "true"`)
+
+		// The source never includes the tokens passed to the run.
+		must.StrNotContains(t, sub.Source, "s.supersecret")
+
+		// A failing operation fails the run without submitting the job.
+		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--var=count=3", "--patch=" + patchPath})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `patch operation 0 (test "/TaskGroups/0/Count") failed: value is 3, not 1`)
+
+		patched, _, err = client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+		must.Eq(t, 0, *patched.Version)
+	})
+}
+
+func TestCLI_JobRunJobFile(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		jobFile := getTestNomadJobPath(t, testPack)
+
+		result := runTestPackCmd(t, s, []string{"run", "--job-file=" + jobFile})
+		expectNoStdErrOutput(t, result)
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), `Job file successfully deployed as pack deployment "simple_raw_exec"`)
+
+		deployed, _, err := client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+		must.Eq(t, testPack, deployed.Meta[job.PackNameKey])
+		must.Eq(t, testPack, deployed.Meta[job.PackDeploymentNameKey])
+		must.Eq(t, jobFile, deployed.Meta[job.PackPathKey])
+
+		// Planning the unchanged job file shows no changes to the job. The
+		// allocation may not be placed yet, so the exit code is not checked.
+		result = runTestPackCmd(t, s, []string{"plan", "--job-file=" + jobFile})
+		must.StrContains(t, result.cmdOut.String(), "Plan succeeded")
+		must.StrContains(t, result.cmdOut.String(), `Job: "simple_raw_exec"`)
+		must.StrNotContains(t, result.cmdOut.String(), `+/- Job: "simple_raw_exec"`)
+
+		// The job file is deployed in place of a pack, so both can not be
+		// passed.
+		result = runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--job-file=" + jobFile})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--job-file can not be used with a pack argument")
+	})
+}
+
 // Confirm that another pack with the same job names but a different deployment name fails
 func TestCLI_JobRunConflictingDeployment(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
@@ -197,6 +669,114 @@ func TestCLI_JobPlan_ConflictingNonPackJob(t *testing.T) {
 	})
 }
 
+func TestCLI_PackPlan_FormatPatch(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		decodePatch := func(t *testing.T, out string) []map[string]any {
+			t.Helper()
+			var ops []map[string]any
+			must.NoError(t, json.Unmarshal([]byte(out), &ops), must.Sprintf("output:\n%s", out))
+			return ops
+		}
+
+		// Jobs which are not deployed are added whole.
+		result := runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=patch"})
+		must.One(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+		ops := decodePatch(t, result.cmdOut.String())
+		must.Len(t, 1, ops)
+		must.Eq(t, "add", ops[0]["op"])
+		must.Eq(t, "", ops[0]["path"])
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=patch", "--var=count=2"})
+		must.One(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+		ops = decodePatch(t, result.cmdOut.String())
+		must.Eq(t, []map[string]any{{"op": "replace", "path": "/TaskGroups/0/Count", "value": float64(2)}}, ops)
+
+		// An unchanged job produces an empty patch. The exit code is not
+		// checked, as it also reflects allocations which are still being
+		// placed from the previous run.
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=patch"})
+		must.NotEq(t, 255, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+		must.SliceEmpty(t, decodePatch(t, result.cmdOut.String()))
+	})
+}
+
+func TestCLI_PackPlan_DiffIgnore(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		// Changes only within ignored fields produce an empty patch and count
+		// as no changes.
+		result := runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=patch", "--var=count=2", "--diff-ignore=$.TaskGroups[*].Count"})
+		must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s\nstderr:\n%s", result.cmdOut.String(), result.cmdErr.String()))
+		var ops []map[string]any
+		must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &ops))
+		must.SliceEmpty(t, ops)
+
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=patch", "--diff-ignore=$.TaskGroups[0"})
+		must.Eq(t, 255, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "missing closing ]")
+
+		// The diff format is computed by the Nomad servers, so still shows
+		// ignored fields, but they do not count towards the exit code.
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--var=count=2", "--diff-ignore=$.TaskGroups[*].Count"})
+		must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s\nstderr:\n%s", result.cmdOut.String(), result.cmdErr.String()))
+		must.StrContains(t, result.cmdOut.String(), "Count")
+		must.StrContains(t, result.cmdOut.String(), "still shows changes to fields ignored by --diff-ignore")
+
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--var=count=2"})
+		must.One(t, result.exitCode)
+	})
+}
+
+func TestCLI_PackPlan_FormatJSON(t *testing.T) {
+	type resources struct {
+		CPU      int `json:"cpu_mhz"`
+		MemoryMB int `json:"memory_mb"`
+	}
+	type summary struct {
+		Job       string    `json:"job"`
+		DiffType  string    `json:"diff_type"`
+		Changes   bool      `json:"changes"`
+		Resources resources `json:"resources"`
+		Groups    []struct {
+			Name string `json:"name"`
+			resources
+		} `json:"groups"`
+	}
+
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		decodeSummary := func(t *testing.T, out string) summary {
+			t.Helper()
+			var sum summary
+			must.NoError(t, json.Unmarshal([]byte(out), &sum), must.Sprintf("output:\n%s", out))
+			return sum
+		}
+
+		// Jobs which are not deployed add all their resources, which are the
+		// Nomad defaults for the test pack.
+		result := runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=json"})
+		must.One(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+		sum := decodeSummary(t, result.cmdOut.String())
+		must.Eq(t, testPack, sum.Job)
+		must.Eq(t, "Added", sum.DiffType)
+		must.True(t, sum.Changes)
+		must.Eq(t, resources{CPU: 100, MemoryMB: 300}, sum.Resources)
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		result = runTestPackCmd(t, s, []string{"plan", getTestPackPath(t, testPack), "--format=json", "--var=count=3"})
+		must.One(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+		sum = decodeSummary(t, result.cmdOut.String())
+		must.Eq(t, "Edited", sum.DiffType)
+		must.Eq(t, resources{CPU: 200, MemoryMB: 600}, sum.Resources)
+		must.Len(t, 1, sum.Groups)
+		must.Eq(t, "app", sum.Groups[0].Name)
+		must.Eq(t, resources{CPU: 200, MemoryMB: 600}, sum.Groups[0].resources)
+	})
+}
+
 func TestCLI_PackPlan_OverrideExitCodes(t *testing.T) {
 	ct.HTTPTest(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
 		testPlanCommand := func(t *testing.T) []string {
@@ -280,13 +860,51 @@ func TestCLI_PackStop(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
 		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
 
-		result := runTestPackCmd(t, s, []string{"stop", getTestPackPath(t, testPack), "--purge=true"})
+		result := runTestPackCmd(t, s, []string{"stop", getTestPackPath(t, testPack), "--purge=true", "--auto-approve"})
 		must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
 		must.StrContains(t, result.cmdOut.String(), `Pack "`+testPack+`" destroyed`)
 		must.Zero(t, result.exitCode)
 	})
 }
 
+func TestCLI_PackStop_KeepMeta(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		result := runTestPackCmd(t, s, []string{"stop", getTestPackPath(t, testPack), "--keep-meta", "--purge"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--keep-meta can not be used with --purge")
+
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		result = runTestPackCmd(t, s, []string{"stop", getTestPackPath(t, testPack), "--keep-meta"})
+		must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s\n\nstderr:\n%s\n", result.cmdOut.String(), result.cmdErr.String()))
+		must.StrContains(t, result.cmdOut.String(), `Pack "`+testPack+`" stopped`)
+
+		j, _, err := client.Jobs().Info(testPack, &api.QueryOptions{})
+		must.NoError(t, err)
+		must.True(t, *j.Stop)
+		must.Eq(t, "true", j.Meta[job.PackStoppedKey])
+
+		// The pack is listed as stopped, and is not stale.
+		result = runTestPackCmd(t, s, []string{"status", testPack, "--columns=job,status"})
+		must.Zero(t, result.exitCode)
+		must.RegexMatch(t, regexp.MustCompile(testPack+`\s+\|\s+stopped`), result.cmdOut.String())
+
+		result = runTestPackCmd(t, s, []string{"cleanup"})
+		must.Zero(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "No stale pack jobs found.")
+
+		// Running the pack again clears the mark.
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+		j, _, err = client.Jobs().Info(testPack, &api.QueryOptions{})
+		must.NoError(t, err)
+		must.False(t, *j.Stop)
+		must.MapNotContainsKey(t, j.Meta, job.PackStoppedKey)
+	})
+}
+
 func TestCLI_PackStop_Conflicts(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
 
@@ -356,231 +974,1802 @@ func TestCLI_PackStop_Conflicts(t *testing.T) {
 	})
 }
 
-// Destroy is just an alias for stop --purge so we only need to
-// test that specific functionality
-func TestCLI_PackDestroy(t *testing.T) {
-	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
-		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+func TestCLI_PackStop_Deployment(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		// Deploy the same pack twice into the namespace.
+		for _, name := range []string{"one", "two"} {
+			expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{
+				"run", getTestPackPath(t, testPack), "--name=" + name, "--var=job_name=" + name}))
+		}
+
+		var deployment *api.Deployment
+		must.Wait(t, wait.InitialSuccess(
+			wait.ErrorFunc(func() error {
+				deployment, _, err = client.Jobs().LatestDeployment("two", &api.QueryOptions{})
+				if err == nil && deployment == nil {
+					err = fmt.Errorf("no deployment for job %q", "two")
+				}
+				return err
+			}),
+			wait.Timeout(30*time.Second),
+			wait.Gap(500*time.Millisecond),
+		), must.Sprint("test job deployment not created"))
+
+		result := runTestPackCmd(t, s, []string{"stop", getTestPackPath(t, testPack), "--deployment=deadbeef"})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `deployment "deadbeef" not found among jobs of pack`)
+
+		result = runTestPackCmd(t, s, []string{"stop", getTestPackPath(t, testPack), "--deployment=" + deployment.ID})
+		must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s\n\nstderr:\n%s\n", result.cmdOut.String(), result.cmdErr.String()))
+		must.StrContains(t, result.cmdOut.String(), `Job "two" stopped`)
+		must.StrNotContains(t, result.cmdOut.String(), `Job "one" stopped`)
+
+		// Assert the other pack deployment is untouched.
+		j, _, err := client.Jobs().Info("one", &api.QueryOptions{})
+		must.NoError(t, err)
+		must.False(t, *j.Stop)
+	})
+}
+
+// Destroy is just an alias for stop --purge so we only need to
+// test that specific functionality
+func TestCLI_PackDestroy(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		result := runTestPackCmd(t, s, []string{"destroy", getTestPackPath(t, testPack), "--auto-approve"})
+		must.StrContains(t, result.cmdOut.String(), `Pack "`+testPack+`" destroyed`)
+		must.Zero(t, result.exitCode)
+
+		// Assert job no longer queryable
+		c, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		r, _, err := c.Jobs().Info(testPack, &api.QueryOptions{})
+		must.Nil(t, r)
+		must.EqError(t, err, "Unexpected response code: 404 (job not found)")
+	})
+}
+
+func TestCLI_PackRun_Alias(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		packPath := getTestPackPath(t, testPack)
+
+		// The same pack is run twice under different aliases.
+		for _, alias := range []string{"tenant-a", "tenant-b"} {
+			result := runTestPackCmd(t, s, []string{"run", packPath, "--var=job_name=web", "--alias=" + alias})
+			must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+			must.StrContains(t, result.cmdOut.String(), "Job '"+alias+"-web' in pack deployment '"+alias+"-"+testPack+"' registered successfully")
+			must.StrContains(t, result.cmdOut.String(), "with --alias="+alias+" to manage")
+		}
+
+		result := runTestPackCmd(t, s, []string{"status", testPack, "--alias=tenant-a"})
+		must.Zero(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "tenant-a-web")
+		must.StrNotContains(t, result.cmdOut.String(), "tenant-b-web")
+
+		// Each alias is managed independently.
+		result = runTestPackCmd(t, s, []string{"destroy", packPath, "--alias=tenant-a", "--auto-approve"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+		c, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+		_, _, err = c.Jobs().Info("tenant-a-web", &api.QueryOptions{})
+		must.EqError(t, err, "Unexpected response code: 404 (job not found)")
+		j, _, err := c.Jobs().Info("tenant-b-web", &api.QueryOptions{})
+		must.NoError(t, err)
+		must.Eq(t, "tenant-b-"+testPack, j.Meta[job.PackNameKey])
+
+		// Aliases and the aliased job names must be DNS-safe.
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--alias=Tenant_A"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "must only contain lowercase letters, digits, and hyphens")
+
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--alias=tenant-c"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `aliased job name "tenant-c-`+testPack+`" is not DNS-safe`)
+	})
+}
+
+func TestCLI_PackRun_PolicyDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test opa is a shell script")
+	}
+
+	// The fake opa denies jobs named "forbidden", standing in for a Rego
+	// policy within the policy directory.
+	binDir := t.TempDir()
+	opa := "#!/bin/sh\n" +
+		`if grep -q '"Name":"forbidden"'; then echo '{"result":[{"expressions":[{"value":["job name is forbidden"]}]}]}'; ` +
+		`else echo '{"result":[{"expressions":[{"value":[]}]}]}'; fi` + "\n"
+	must.NoError(t, os.WriteFile(filepath.Join(binDir, "opa"), []byte(opa), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	ct.HTTPTest(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		packPath := getTestPackPath(t, testPack)
+		policyDir := t.TempDir()
+
+		result := runTestPackCmd(t, s, []string{"run", packPath, "--policy-dir=" + policyDir, "--var=job_name=forbidden"})
+		must.One(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), "Failed Policy Check")
+		must.StrContains(t, result.cmdOut.String(), "job name is forbidden")
+
+		c, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+		_, _, err = c.Jobs().Info("forbidden", &api.QueryOptions{})
+		must.EqError(t, err, "Unexpected response code: 404 (job not found)")
+
+		// Denies only warn with --policy-warn-only, so the job is submitted.
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--policy-dir=" + policyDir, "--policy-warn-only", "--var=job_name=forbidden"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), `Job "forbidden" denied by policy: job name is forbidden`)
+
+		result = runTestPackCmd(t, s, []string{"run", packPath, "--policy-dir=" + policyDir, "--var=job_name=allowed"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrNotContains(t, result.cmdOut.String(), "denied by policy")
+	})
+}
+
+// Purging is destructive, so non-interactive destroys must be approved
+func TestCLI_PackDestroy_RequiresApproval(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+
+		result := runTestPackCmd(t, s, []string{"destroy", getTestPackPath(t, testPack)})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "purging jobs requires confirmation")
+
+		// Assert job is still queryable
+		c, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		r, _, err := c.Jobs().Info(testPack, &api.QueryOptions{})
+		must.NoError(t, err)
+		must.NotNil(t, r)
+	})
+}
+
+// scriptedUI is an interactive UI which answers each prompt with the next of
+// its answers.
+type scriptedUI struct {
+	terminal.UI
+	answers []string
+}
+
+func (ui *scriptedUI) Interactive() bool { return true }
+
+func (ui *scriptedUI) Input(*terminal.Input) (string, error) {
+	if len(ui.answers) == 0 {
+		return "", io.EOF
+	}
+	answer := ui.answers[0]
+	ui.answers = ui.answers[1:]
+	return answer, nil
+}
+
+func TestCLI_PackDestroy_ConfirmImpact(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack), "--var=count=2"}))
+
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+		jobs, err := getPackJobsByDeploy(client, &cache.PackConfig{Name: testPack, Registry: cache.DevRegistryName}, testPack)
+		must.NoError(t, err)
+		must.Len(t, 1, jobs)
+
+		impact, err := getPurgeImpact(client, jobs)
+		must.NoError(t, err)
+		must.Len(t, 1, impact)
+		must.Eq(t, testPack, impact[0].jobID)
+		must.Eq(t, api.DefaultNamespace, impact[0].namespace)
+		must.Eq(t, api.JobTypeService, impact[0].jobType)
+		must.Eq(t, 1, impact[0].taskGroups)
+		must.Eq(t, 2, impact[0].allocs)
+
+		testCases := []struct {
+			name      string
+			answers   []string
+			confirmed bool
+		}{
+			{name: "pack name", answers: []string{testPack}, confirmed: true},
+			{name: "wrong name then pack name", answers: []string{"y", testPack}, confirmed: true},
+			{name: "empty", answers: []string{""}, confirmed: false},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var cmdOut, cmdErr bytes.Buffer
+				ui := &scriptedUI{
+					UI:      testui.NonInteractiveTestUI(context.Background(), &cmdOut, &cmdErr),
+					answers: tc.answers,
+				}
+				c := &StopCommand{
+					baseCommand: &baseCommand{ui: ui},
+					packConfig:  &cache.PackConfig{Name: testPack},
+					purge:       true,
+				}
+
+				confirmed, err := c.confirmPurge(client, jobs)
+				must.NoError(t, err)
+				must.Eq(t, tc.confirmed, confirmed)
+				must.StrContains(t, cmdOut.String(), "2 allocation(s)")
+				must.StrContains(t, cmdOut.String(), api.JobTypeService)
+				if len(tc.answers) > 1 {
+					must.StrContains(t, cmdOut.String(), "The pack name does not match")
+				}
+			})
+		}
+
+		// The job is only purged once confirmed.
+		_, _, err = client.Jobs().Info(testPack, &api.QueryOptions{})
+		must.NoError(t, err)
+	})
+}
+
+// Test that destroy properly uses var overrides to target the job
+func TestCLI_PackDestroy_WithOverrides(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		c, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+		// Because this test uses ref, it requires a populated pack cache.
+		reg, _, regPath := createTestRegistries(t)
+		defer cleanTestRegistry(t, regPath)
+
+		// 	TODO: Table Testing
+		// Create multiple jobs in the same pack deployment
+
+		jobNames := []string{"foo", "bar"}
+		for _, j := range jobNames {
+			expectGoodPackDeploy(t, runTestPackCmd(
+				t, s, []string{"run", testPack, "--var=job_name=" + j, "--registry=" + reg.Name}))
+		}
+
+		// Stop nonexistent job
+		result := runTestPackCmd(t, s, []string{"destroy", testPack, "--var=job_name=baz", "--registry=" + reg.Name, "--auto-approve"})
+		must.Eq(t, 1, result.exitCode, must.Sprintf(
+			"expected exitcode 1; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
+
+		// Stop job with var override
+		result = runTestPackCmd(t, s, []string{"destroy", testPack, "--var=job_name=foo", "--registry=" + reg.Name, "--auto-approve"})
+		must.Zero(t, result.exitCode, must.Sprintf(
+			"expected exitcode 0; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
+
+		// Assert job "bar" still exists
+		job, _, err := c.Jobs().Info("bar", &api.QueryOptions{WaitTime: 5 * time.Second})
+		must.NoError(t, err)
+		must.NotNil(t, job)
+
+		// Stop job with no overrides passed
+		result = runTestPackCmd(t, s, []string{"destroy", testPack, "--registry=" + reg.Name, "--auto-approve"})
+		must.Zero(t, result.exitCode, must.Sprintf(
+			"expected exitcode 0; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
+
+		// Assert job bar is gone
+		job, _, err = c.Jobs().Info("bar", &api.QueryOptions{WaitTime: 5 * time.Second})
+		must.Error(t, err)
+		must.Eq(t, "Unexpected response code: 404 (job not found)", err.Error())
+		must.Nil(t, job)
+	})
+}
+
+func TestCLI_CLIFlag_NotDefined(t *testing.T) {
+	t.Parallel() // nomad not required
+
+	// There is no job flag. This tests that adding an unspecified flag does not
+	// create an invalid memory address error
+	// Posix case
+	result := runPackCmd(t, []string{"run", "nginx", "--job=provided-but-not-defined"})
+	must.Eq(t, 1, result.exitCode)
+
+	// std go case
+	result = runPackCmd(t, []string{"run", "-job=provided-but-not-defined", "nginx"})
+	must.Eq(t, 1, result.exitCode)
+}
+
+func TestCLI_Cleanup(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+
+		result := runTestPackCmd(t, s, []string{"cleanup"})
+		must.Zero(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "No stale pack jobs found.")
+
+		// Stop the pack job outside nomad-pack, leaving its metadata behind.
+		expectGoodPackDeploy(t, runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)}))
+		_, _, err = client.Jobs().Deregister(testPack, false, nil)
+		must.NoError(t, err)
+
+		result = runTestPackCmd(t, s, []string{"cleanup"})
+		must.Zero(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), testPack)
+		must.StrContains(t, result.cmdOut.String(), "1 stale pack job(s) would be purged. Pass --purge to purge them.")
+
+		_, _, err = client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+
+		// Jobs are only purged when asked, even when approved.
+		result = runTestPackCmd(t, s, []string{"cleanup", "--auto-approve"})
+		must.Zero(t, result.exitCode)
+		_, _, err = client.Jobs().Info(testPack, nil)
+		must.NoError(t, err)
+
+		// Purging requires approval when not running interactively.
+		result = runTestPackCmd(t, s, []string{"cleanup", "--purge"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "use --auto-approve")
+
+		result = runTestPackCmd(t, s, []string{"cleanup", "--purge", "--auto-approve"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), "Purged 1 stale pack job(s)")
+
+		_, _, err = client.Jobs().Info(testPack, nil)
+		must.ErrorContains(t, err, "404")
+	})
+}
+
+func TestCLI_PackStatus(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)})
+		must.Zero(t, result.exitCode)
+
+		testcases := []struct {
+			name string
+			args []string
+		}{
+			{
+				name: "no-pack-name",
+				args: []string{},
+			},
+			{
+				name: "with-pack-name",
+				args: []string{testPack},
+			},
+			{
+				name: "with-pack-and-registry-name",
+				args: []string{testPack, "--registry=default"},
+			},
+			{
+				name: "with-pack-and-ref",
+				args: []string{testPack, "--ref=latest"},
+			},
+		}
+
+		for _, tc := range testcases {
+			t.Run(tc.name, func(t *testing.T) {
+				args := append([]string{"status"}, tc.args...)
+				result := runTestPackCmd(t, s, args)
+				must.Zero(t, result.exitCode)
+				must.StrContains(t, result.cmdOut.String(), "simple_raw_exec | "+cache.DevRegistryName+" ")
+			})
+		}
+
+		// Only the selected columns are displayed, in the order selected.
+		result = runTestPackCmd(t, s, []string{"status", testPack, "--columns=job,pack,healthy"})
+		must.Zero(t, result.exitCode)
+		must.RegexMatch(t, regexp.MustCompile(`JOB NAME\s+\|\s+PACK NAME\s+\|\s+HEALTHY`), result.cmdOut.String())
+		must.StrNotContains(t, result.cmdOut.String(), cache.DevRegistryName)
+	})
+}
+
+func TestCLI_PackStatus_Watch(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)})
+		must.Zero(t, result.exitCode)
+
+		// The table is redrawn until the context is cancelled, which stands in
+		// for an interrupt.
+		ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+		defer cancel()
+		args := append([]string{"status", testPack, "--watch", "--interval=1"}, AddressFromTestServer(s)...)
+		result = runPackCmdContext(t, ctx, args)
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.GreaterEq(t, 2, strings.Count(result.cmdOut.String(), "Every 1s"))
+		must.GreaterEq(t, 2, strings.Count(result.cmdOut.String(), "simple_raw_exec"))
+
+		result = runTestPackCmd(t, s, []string{"status", testPack, "--watch", "--interval=0"})
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--interval must be at least 1 second")
+	})
+}
+
+func TestCLI_PackStatus_Fails(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		// test for status on missing pack
+		result := runTestPackCmd(t, s, []string{"status", getTestPackPath(t, testPack)})
+		must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
+		must.StrContains(t, result.cmdOut.String(), "no jobs found for pack \""+getTestPackPath(t, testPack)+"\"")
+		// FIXME: Should this have a non-success exit-code?
+		must.Zero(t, result.exitCode)
+
+		// test flag validation for name flag without pack
+		result = runTestPackCmd(t, s, []string{"status", "--name=foo"})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--name can only be used if pack name is provided")
+
+		// test an unknown column lists the valid columns
+		result = runTestPackCmd(t, s, []string{"status", testPack, "--columns=pack,bogus"})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `unknown column "bogus", must be one of: pack, registry, deployment, job, namespace, status, healthy`)
+	})
+}
+
+func TestCLI_PackRender_RootVar(t *testing.T) {
+	t.Parallel()
+	// This test has to do some extra shenanigans because dependent pack template
+	// output is not guaranteed to be ordered. This requires that the test handle
+	// either order.
+	expected := []string{
+		"deps_test/child1/child1.nomad=child1",
+		"deps_test/child2/child2.nomad=child2",
+		"deps_test/deps_test.nomad=deps_test",
+	}
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--no-format=true",
+		getTestPackPath(t, "my_alias_test"),
+	})
+	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
+
+	// Performing a little clever string manipulation on the render output to
+	// prepare it for splitting into a slice of string enables us to use
+	// require.ElementsMatch to validate goodness.
+	outStr := strings.TrimSpace(result.cmdOut.String())
+	outStr = strings.ReplaceAll(outStr, ":\n\n", "=")
+	elems := strings.Split(outStr, "\n")
+
+	must.SliceContainsAll(t, expected, elems)
+}
+
+func TestCLI_PackRender_MetadataJSON(t *testing.T) {
+	t.Parallel()
+
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+
+	expected := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, expected.exitCode, must.Sprintf("cmdOut:\n%v", expected.cmdOut.String()))
+
+	// Replace the metadata with its equivalent in the JSON syntax of HCL.
+	must.NoError(t, os.Remove(path.Join(packPath, "metadata.hcl")))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "metadata.json"), []byte(`{
+  "app": {"url": ""},
+  "pack": {
+    "name": "simple_raw_exec",
+    "description": "This is a test fixture pack used because all platforms support raw_exec",
+    "version": "0.0.1"
+  }
+}`), 0o644))
+
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.Eq(t, expected.cmdOut.String(), result.cmdOut.String())
+	must.StrContains(t, result.cmdOut.String(), `job "simple_raw_exec"`)
+
+	// A pack with both metadata files is ambiguous.
+	b, err := os.ReadFile(path.Join(getTestPackPath(t, testPack), "metadata.hcl"))
+	must.NoError(t, err)
+	must.NoError(t, os.WriteFile(path.Join(packPath, "metadata.hcl"), b, 0o644))
+
+	result = runPackCmd(t, []string{"render", packPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "pack contains both metadata.hcl and metadata.json")
+}
+
+func TestCLI_PackRender_EnvVarPrefix(t *testing.T) {
+	// t.Setenv prevents this test from running in parallel.
+	t.Setenv("PACK_VAR_JOB_NAME", "from_env")
+
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--env-var-prefix=PACK_VAR_"})
+	must.Eq(t, "", result.cmdErr.String(), must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `job "from_env"`)
+
+	// Values are validated against the declared type of the variable.
+	t.Setenv("PACK_VAR_COUNT", "not_a_number")
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--env-var-prefix=PACK_VAR_"})
+	must.Eq(t, 1, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "a number is required")
+}
+
+func TestCLI_PackRender_RequiredVar(t *testing.T) {
+	t.Parallel()
+
+	// Declare a variable without a default on a copy of the test pack.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	varsPath := path.Join(packPath, "variables.hcl")
+	b, err := os.ReadFile(varsPath)
+	must.NoError(t, err)
+	b = append(b, []byte("\nvariable \"owner\" {\n  type = string\n}\n")...)
+	must.NoError(t, os.WriteFile(varsPath, b, 0o644))
+
+	result := runPackCmd(t, []string{"render", packPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `The variable "owner" has no default value`)
+
+	result = runPackCmd(t, []string{"render", packPath, "--var-type-check-only"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `The variable "owner" has no default value`)
+
+	// A variable file setting only the required variable keeps the defaults
+	// of the others.
+	varFile := path.Join(t.TempDir(), "partial.hcl")
+	must.NoError(t, os.WriteFile(varFile, []byte(`owner = "platform"`), 0o644))
+	result = runPackCmd(t, []string{"render", packPath, "-f", varFile})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "count = 1")
+}
+
+func TestCLI_PackRender_VarSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is a shell script")
+	}
+
+	// The plugin directory is set by env var, so the test can not run in
+	// parallel.
+	pluginDir := t.TempDir()
+	t.Setenv(plugin.EnvDir, pluginDir)
+	must.NoError(t, os.WriteFile(path.Join(pluginDir, "config-service"), []byte(`#!/bin/sh
+case "$2" in
+  count) echo '{"found":true,"value":3}' ;;
+  datacenters) echo '{"found":true,"value":["dc2","dc3"]}' ;;
+  *) echo '{"found":false}' ;;
+esac
+`), 0o755))
+
+	// Variables set by flags are not looked up in the source.
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--var-source=config-service", "--var=count=2"})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "count = 2")
+	must.StrContains(t, result.cmdOut.String(), `datacenters = ["dc2", "dc3"]`)
+
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--var-source=missing"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "failed to resolve plugin")
+}
+
+func TestCLI_PackRender_SensitiveVar(t *testing.T) {
+	t.Parallel()
+
+	// Declare a sensitive variable on a copy of the test pack.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	varsPath := path.Join(packPath, "variables.hcl")
+	b, err := os.ReadFile(varsPath)
+	must.NoError(t, err)
+	b = append(b, []byte("\nvariable \"token\" {\n  type      = string\n  default   = \"s3cr3t\"\n  sensitive = true\n}\n")...)
+	must.NoError(t, os.WriteFile(varsPath, b, 0o644))
+
+	result := runPackCmd(t, []string{"generate", "var-file", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "# token=(sensitive)")
+	must.StrNotContains(t, result.cmdOut.String(), "s3cr3t")
+
+	result = runPackCmd(t, []string{"render", packPath, "--var=token=other", "--assert-var=token=s3cr3t"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `The variable "token" resolved to (sensitive), but was asserted to be (sensitive).`)
+	must.StrNotContains(t, result.cmdOut.String(), "s3cr3t")
+}
+
+func TestCLI_PackRender_RenderOnly(t *testing.T) {
+	t.Parallel()
+
+	// Add an auxiliary file to a copy of the test pack.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "templates", "app.conf.tpl"), []byte("listen = 8080\n"), 0o644))
+
+	for _, glob := range []string{"app.conf.tpl", "*.conf"} {
+		result := runPackCmd(t, []string{"render", packPath, "--render-only=" + glob})
+		must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), testPack+"/app.conf:")
+		must.StrNotContains(t, result.cmdOut.String(), testPack+".nomad:")
+	}
+
+	result := runPackCmd(t, []string{"render", packPath, "--render-only=*.json"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `no templates match "*.json", available templates are: app.conf.tpl, `+testPack+".nomad.tpl")
+
+	result = runPackCmd(t, []string{"render", packPath, "--render-only=["})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `invalid --render-only pattern "["`)
+}
+
+func TestCLI_PackRender_VarUpdates(t *testing.T) {
+	t.Parallel()
+
+	varFile := path.Join(t.TempDir(), "env.hcl")
+	must.NoError(t, os.WriteFile(varFile, []byte(`env = { REGION = "us-east-1" }`), 0o644))
+
+	// Keys are set within, and elements appended to, the values given by
+	// variable files and defaults.
+	result := runPackCmd(t, []string{
+		"render", getTestPackPath(t, testPack), "-f", varFile,
+		"--var=env.TEAM=payments", "--var=datacenters+=dc2",
+	})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `REGION = "us-east-1"`)
+	must.StrContains(t, result.cmdOut.String(), `TEAM   = "payments"`)
+	must.StrContains(t, result.cmdOut.String(), `datacenters = ["dc1", "dc2"]`)
+
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--var=count+=2"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `variable "count" of type number is not a list`)
+}
+
+func TestCLI_PackRender_AssertVar(t *testing.T) {
+	t.Parallel()
+
+	result := runPackCmd(t, []string{
+		"render", getTestPackPath(t, testPack),
+		"--var=count=2", "--var=job_name=foo", "--assert-var=count=2", "--assert-var=job_name=foo",
+	})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+
+	// All failed assertions are reported together.
+	result = runPackCmd(t, []string{
+		"render", getTestPackPath(t, testPack),
+		"--var=count=2", "--assert-var=count=3", "--assert-var=job_name=other",
+	})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `The variable "count" resolved to 2, but was asserted to be 3.`)
+	must.StrContains(t, result.cmdOut.String(), `The variable "job_name" resolved to "", but was asserted to be "other".`)
+}
+
+func TestCLI_PackRender_Quiet(t *testing.T) {
+	t.Parallel()
+
+	// The rendered templates are the primary output, so are kept.
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--quiet"})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "simple_raw_exec/simple_raw_exec.nomad:")
+	must.StrContains(t, result.cmdOut.String(), `job "simple_raw_exec"`)
+	must.Eq(t, "", result.cmdErr.String())
+
+	// Informational output is discarded.
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--quiet", "--var-type-check-only"})
+	must.Zero(t, result.exitCode)
+	must.Eq(t, "", result.cmdOut.String())
+
+	// Errors and warnings are still written, to stderr.
+	var cmdOut, cmdErr bytes.Buffer
+	ui := terminal.QuietUI(testui.NonInteractiveTestUI(context.Background(), &cmdOut, &cmdErr))
+	ui.Header("header")
+	ui.Info("info")
+	ui.Success("success")
+	ui.Output("output")
+	ui.Warning("test warning")
+	ui.ErrorWithContext(fmt.Errorf("test error"), "failed to test")
+	must.Eq(t, "", cmdOut.String())
+	must.StrContains(t, cmdErr.String(), "test warning")
+	must.StrContains(t, cmdErr.String(), "test error")
+
+	// Tables and named values are results, so are kept.
+	tbl := terminal.NewTable("Pack Name", "Ref")
+	tbl.Rich([]string{"simple_raw_exec", "latest"}, nil)
+	ui.Table(tbl)
+	ui.NamedValues([]terminal.NamedValue{{Name: "Registry", Value: "default"}})
+	must.StrContains(t, cmdOut.String(), "simple_raw_exec")
+	must.StrContains(t, cmdOut.String(), "Registry")
+}
+
+func TestCLI_PackRender_SetDepVarWithFlag(t *testing.T) {
+	t.Parallel()
+	// This test has to do some extra shenanigans because dependent pack template
+	// output is not guaranteed to be ordered. This requires that the test handle
+	// either order.
+	expected := []string{
+		"deps_test/child1/child1.nomad=override",
+		"deps_test/child2/child2.nomad=child2",
+		"deps_test/deps_test.nomad=deps_test",
+	}
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--no-format=true",
+		"--var", "child1.job_name=override",
+		getTestPackPath(t, "my_alias_test"),
+	})
+
+	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
+	must.Eq(t, 0, result.exitCode, must.Sprintf("incorrect exit code.\nstdout:\n%v\nstderr:%v\n", result.cmdOut.String(), result.cmdErr.String()))
+
+	// Performing a little clever string manipulation on the render output to
+	// prepare it for splitting into a slice of string enables us to use
+	// require.ElementsMatch to validate goodness.
+	outStr := strings.TrimSpace(result.cmdOut.String())
+	outStr = strings.ReplaceAll(outStr, ":\n\n", "=")
+	elems := strings.Split(outStr, "\n")
+
+	must.SliceContainsAll(t, expected, elems)
+}
+
+func TestCLI_PackRender_VarsInOutputTemplate(t *testing.T) {
+	t.Parallel()
+	// This test has to do some extra shenanigans because dependent pack template
+	// output is not guaranteed to be ordered. This requires that the test handle
+	// either order.
+	expected := []string{
+		"deps_test/child1/child1.nomad=override",
+		"deps_test/child2/child2.nomad=child2",
+		"deps_test/deps_test.nomad=deps_test",
+		"outputs.tpl=deps_test,override,child2",
+	}
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--no-format=true",
+		"--var", "child1.job_name=override",
+		"--render-output-template=true",
+		getTestPackPath(t, "my_alias_test"),
+	})
+
+	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
+	must.Eq(t, 0, result.exitCode, must.Sprintf("incorrect exit code.\ncmdOut:\n%v\ns", result.cmdOut.String()))
+	must.StrNotContains(t, result.cmdOut.String(), "Error")
+
+	// Performing a little clever string manipulation on the render output to
+	// prepare it for splitting into a slice of string enables us to use
+	// require.ElementsMatch to validate goodness.
+	outStr := strings.TrimSpace(result.cmdOut.String())
+	outStr = strings.ReplaceAll(outStr, ":\n\n", "=")
+	elems := strings.Split(outStr, "\n")
+
+	must.SliceContainsAll(t, expected, elems, must.Sprintf("unexpected returned value.\nexpected: %v\nelems: %v\nstdout:\n%v\n", expected, elems, result.cmdOut.String()))
+}
+
+func TestCLI_PackRender_OutputName(t *testing.T) {
+	t.Parallel()
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--var", "job_name=foo",
+		"--output-name={{.JobName}}.hcl",
+		getTestPackPath(t, testPack),
+	})
+
+	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
+	must.Eq(t, 0, result.exitCode, must.Sprintf("incorrect exit code.\ncmdOut:\n%v\n", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), testPack+"/foo.hcl:")
+	must.StrNotContains(t, result.cmdOut.String(), testPack+"/"+testPack+".nomad:")
+}
+
+func TestCLI_PackRender_OutputNameInvalid(t *testing.T) {
+	t.Parallel()
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--output-name=../{{.JobName}}",
+		getTestPackPath(t, testPack),
+	})
+
+	must.Eq(t, 1, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "invalid file name")
+}
+
+func TestCLI_PackRender_OutputNameVars(t *testing.T) {
+	t.Parallel()
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--var", "job_name=foo",
+		"--output-name={{.Vars.job_name}}-web.nomad",
+		getTestPackPath(t, testPack),
+	})
+
+	must.Eq(t, 0, result.exitCode, must.Sprintf("incorrect exit code.\ncmdOut:\n%v\n", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), testPack+"/foo-web.nomad:")
+
+	result = runPackCmd(t, []string{
+		"render",
+		"--output-name={{.Vars.tenant}}.nomad",
+		getTestPackPath(t, testPack),
+	})
+
+	must.Eq(t, 1, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `map has no entry for key "tenant"`)
+}
+
+func TestCLI_PackRender_OutputNameCollision(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add a second job, so both jobs are given the
+	// same name by a template which only uses the variables.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", "other.nomad.tpl"),
+		[]byte(`job "other" {}`),
+		0644,
+	))
+
+	result := runPackCmd(t, []string{
+		"render",
+		"--var", "job_name=foo",
+		"--output-name={{.Vars.job_name}}.nomad",
+		packPath,
+	})
+
+	must.Eq(t, 1, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `--output-name produced "`+testPack+`/foo.nomad" for both`)
+}
+
+func TestCLI_PackRender_KeepGoing(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add a template which always fails to render.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", "broken.nomad.tpl"),
+		[]byte(`[[ fail "broken template" ]]`),
+		0644,
+	))
+
+	// Without keep-going the failure stops all output.
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Eq(t, 1, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "broken template")
+	must.StrNotContains(t, result.cmdOut.String(), testPack+".nomad:")
+
+	// With keep-going the good template is output and the failure reported.
+	result = runPackCmd(t, []string{"render", "--keep-going", packPath})
+	must.Eq(t, 1, result.exitCode)
+	out := result.cmdOut.String()
+	must.StrContains(t, out, testPack+"/"+testPack+".nomad:")
+	must.StrContains(t, out, "broken template")
+	must.Greater(t, strings.Index(out, testPack+".nomad:"), strings.Index(out, "broken template"))
+}
+
+func TestCLI_PackRender_Outputs(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and replace the output template with one which
+	// defines named outputs.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "outputs.tpl"),
+		[]byte(`[[ output "count" (var "count" .) ]][[ output "address" (printf "%s.service.consul" (var "job_name" .)) ]]`),
+		0644,
+	))
+
+	result := runPackCmd(t, []string{"render", "--outputs", "--var=count=2", "--var=job_name=web", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	out := result.cmdOut.String()
+	must.StrContains(t, out, "address: web.service.consul")
+	must.StrContains(t, out, "count: 2")
+	must.StrNotContains(t, out, testPack+".nomad")
+
+	result = runPackCmd(t, []string{"render", "--outputs", "--format=json", "--var=count=2", "--var=job_name=web", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+
+	var outputs map[string]any
+	must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &outputs))
+	must.Eq(t, map[string]any{"address": "web.service.consul", "count": float64(2)}, outputs)
+
+	// Outputs can only be defined within the output template.
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", "output.nomad.tpl"),
+		[]byte(`[[ output "other" "value" ]]`),
+		0644,
+	))
+	result = runPackCmd(t, []string{"render", "--outputs", packPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "can only be defined within outputs.tpl")
+}
+
+func TestCLI_PackRender_RenderSeed(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add a template which renders random values.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", "random.nomad.tpl"),
+		[]byte(`# [[ uuidv4 ]] [[ randAlphaNum 16 ]]`),
+		0644,
+	))
+
+	// The same seed renders identical output.
+	result := runPackCmd(t, []string{"render", "--render-seed=42", packPath})
+	must.Zero(t, result.exitCode)
+	again := runPackCmd(t, []string{"render", "--render-seed=42", packPath})
+	must.Eq(t, result.cmdOut.String(), again.cmdOut.String())
+
+	other := runPackCmd(t, []string{"render", "--render-seed=43", packPath})
+	must.NotEq(t, result.cmdOut.String(), other.cmdOut.String())
+
+	// Zero is a valid seed.
+	result = runPackCmd(t, []string{"render", "--render-seed=0", packPath})
+	must.Zero(t, result.exitCode)
+	again = runPackCmd(t, []string{"render", "--render-seed=0", packPath})
+	must.Eq(t, result.cmdOut.String(), again.cmdOut.String())
+
+	// Without a seed, every render differs.
+	result = runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode)
+	again = runPackCmd(t, []string{"render", packPath})
+	must.NotEq(t, result.cmdOut.String(), again.cmdOut.String())
+}
+
+func TestCLI_PackRender_MaxDepth(t *testing.T) {
+	t.Parallel()
+
+	// The test pack has a child, which itself has a grandchild dependency.
+	result := runPackCmd(t, []string{"render", "--max-depth=2", getTestPackPath(t, "deps_test_1")})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+
+	result = runPackCmd(t, []string{"render", "--max-depth=1", getTestPackPath(t, "deps_test_1")})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "dependency tree exceeds the maximum depth of 1: deps_test_1 -> child -> grandchild")
+}
+
+func TestCLI_GenerateVarFile_Dependencies(t *testing.T) {
+	t.Parallel()
+
+	// generate var-file does not take --max-depth, so loads the dependencies
+	// of the pack with the default maximum depth.
+	result := runPackCmd(t, []string{"generate", "var-file", getTestPackPath(t, "deps_test_1")})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrNotContains(t, result.cmdOut.String(), "maximum depth")
+}
+
+func TestCLI_PackRender_FailOnEmptyRender(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack, disable its job, and add an auxiliary file so the
+	// render still produces output.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	jobTpl := path.Join(packPath, "templates", testPack+".nomad.tpl")
+	b, err := os.ReadFile(jobTpl)
+	must.NoError(t, err)
+	must.NoError(t, os.WriteFile(jobTpl, []byte("[[ if false ]]"+string(b)+"[[ end ]]\n"), 0644))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "templates", "config.txt.tpl"), []byte("greeting = hello\n"), 0644))
+
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+	result = runPackCmd(t, []string{"render", "--fail-on-empty-render", packPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), errors.ErrEmptyRender.Error())
+	must.StrContains(t, result.cmdOut.String(), testPack+".nomad")
+
+	result = runPackCmd(t, []string{"render", "--fail-on-empty-render", getTestPackPath(t, testPack)})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+}
+
+func TestCLI_PackRender_Zip(t *testing.T) {
+	t.Parallel()
+
+	// Zip the contents of the test pack, so its metadata is at the root.
+	zipPath := path.Join(t.TempDir(), testPack+".zip")
+	f, err := os.Create(zipPath)
+	must.NoError(t, err)
+	zw := zip.NewWriter(f)
+	must.NoError(t, zw.AddFS(os.DirFS(getTestPackPath(t, testPack))))
+	must.NoError(t, zw.Close())
+	must.NoError(t, f.Close())
+
+	result := runPackCmd(t, []string{"render", zipPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), testPack+"/"+testPack+".nomad")
+
+	// A zip without a pack at its root is rejected.
+	badPath := path.Join(t.TempDir(), "empty.zip")
+	f, err = os.Create(badPath)
+	must.NoError(t, err)
+	zw = zip.NewWriter(f)
+	_, err = zw.Create("README.md")
+	must.NoError(t, err)
+	must.NoError(t, zw.Close())
+	must.NoError(t, f.Close())
+
+	result = runPackCmd(t, []string{"render", badPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "Failed To Extract Pack")
+	must.StrContains(t, result.cmdOut.String(), "metadata.hcl or metadata.json must be at the root of the zip file")
+}
+
+func TestCLI_PackRender_VarTypeCheckOnly(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and replace its job with a template which fails
+	// whenever it is executed.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", testPack+".nomad.tpl"),
+		[]byte(`[[ fail "template executed" ]]`),
+		0644,
+	))
+
+	result := runPackCmd(t, []string{"render", packPath, "--var-type-check-only", "--var=count=3"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "Pack variables are valid")
+	must.StrNotContains(t, result.cmdOut.String(), "template executed")
+
+	// Every type violation is reported.
+	result = runPackCmd(t, []string{"render", packPath, "--var-type-check-only", "--var=count=many", "--var=datacenters=[[]]"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "<value for var count from arguments>")
+	must.StrContains(t, result.cmdOut.String(), "<value for var datacenters from arguments>")
+	must.StrNotContains(t, result.cmdOut.String(), "template executed")
+}
+
+func TestCLI_PackRender_ShowEmpty(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add a template which renders to only whitespace.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", "empty.nomad.tpl"),
+		[]byte("[[ if false ]]job \"empty\" {}[[ end ]]\n"),
+		0644,
+	))
+
+	result := runPackCmd(t, []string{"render", "--show-empty", packPath})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), testPack+"/empty.nomad:")
+	must.StrNotContains(t, result.cmdOut.String(), "rendered to an empty file")
+
+	// Empty templates are omitted by default.
+	result = runPackCmd(t, []string{"render", "--warn-empty", packPath})
+	must.Zero(t, result.exitCode)
+	must.StrNotContains(t, result.cmdOut.String(), testPack+"/empty.nomad:")
+	must.StrContains(t, result.cmdOut.String(), "Template "+testPack+"/empty.nomad rendered to an empty file")
+	must.StrContains(t, result.cmdOut.String(), testPack+"/"+testPack+".nomad:")
+}
+
+func TestCLI_PackRender_CompareToRef(t *testing.T) {
+	reg, _, regPath := createTestRegistries(t)
+	defer cleanTestRegistry(t, regPath)
+
+	testRegFlag := "--registry=" + reg.Name
+	compareFlag := "--compare-to-ref=" + testRef
+
+	result := runPackCmd(t, []string{"render", testPack, testRegFlag, compareFlag})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "No differences between the renders at "+testRef+" and latest.")
+
+	// Change the template at the test ref, so the renders differ.
+	tplPath := path.Join(regPath, testRef, testPack+"@"+testRef, "templates", testPack+".nomad.tpl")
+	tpl, err := os.ReadFile(tplPath)
+	must.NoError(t, err)
+	must.NoError(t, os.WriteFile(tplPath, bytes.Replace(tpl, []byte("attempts = 2"), []byte("attempts = 5"), 1), 0644))
+
+	result = runPackCmd(t, []string{"render", testPack, testRegFlag, compareFlag})
+	must.Zero(t, result.exitCode)
+	out := result.cmdOut.String()
+	must.StrContains(t, out, "--- "+testPack+"/"+testPack+".nomad ("+testRef+")")
+	must.StrContains(t, out, "+++ "+testPack+"/"+testPack+".nomad (latest)")
+	must.StrContains(t, out, "-      attempts = 5")
+	must.StrContains(t, out, "+      attempts = 2")
+	must.StrNotContains(t, out, "No differences")
+
+	// Packs which are not from a registry have no refs to compare.
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), compareFlag})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "--compare-to-ref can only be used with packs from a registry")
+}
+
+func TestCLI_PackRender_VarFileDirectory(t *testing.T) {
+	t.Parallel()
+
+	// Copy the override files for the test pack, alongside a file which is
+	// not a variable file.
+	varDir := path.Join(t.TempDir(), "overrides")
+	must.NoError(t, filesystem.CopyDir(testfixture.AbsPath(t, "v2/override_files/"+testPack), varDir, false, logging.Default()))
+	must.NoError(t, os.WriteFile(path.Join(varDir, "README.md"), []byte("# Overrides\n"), 0644))
+
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--var-file=" + varDir})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `job "sre"`)
+	must.StrContains(t, result.cmdOut.String(), "some awesome token")
+	must.StrNotContains(t, result.cmdOut.String(), "Skipping")
+
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--var-file=" + varDir, "--verbose"})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "Skipping "+path.Join(varDir, "README.md"))
+}
+
+func TestCLI_PackRender_FromRef(t *testing.T) {
+	t.Parallel()
+
+	// Commit a copy of the test pack to a new repository.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	r, err := git.PlainInit(packPath, false)
+	must.NoError(t, err)
+	w, err := r.Worktree()
+	must.NoError(t, err)
+	_, err = w.Add(".")
+	must.NoError(t, err)
+	_, err = w.Commit("Initial Commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "Github Action Test User",
+		Email: "test@example.com",
+		When:  time.Now(),
+	}})
+	must.NoError(t, err)
+
+	// Change the job name default in the working tree only.
+	varsPath := path.Join(packPath, "variables.hcl")
+	b, err := os.ReadFile(varsPath)
+	must.NoError(t, err)
+	b = bytes.Replace(b, []byte(`default     = "" // If "", the pack name will be used`), []byte(`default     = "working"`), 1)
+	must.NoError(t, os.WriteFile(varsPath, b, 0o644))
+
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `job "working"`)
+
+	// The committed pack is rendered, ignoring the working tree.
+	result = runPackCmd(t, []string{"render", packPath, "--from-ref=HEAD"})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `job "simple_raw_exec"`)
+
+	result = runPackCmd(t, []string{"render", packPath, "--from-ref=v9.9.9"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `could not resolve ref "v9.9.9"`)
+}
+
+func TestCLI_PackRender_Clean(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add a second job, which is later removed.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	extraTpl := path.Join(packPath, "templates", "extra.nomad.tpl")
+	must.NoError(t, os.WriteFile(extraTpl, []byte(`job "extra" {}`), 0644))
+
+	// The manifest is only written when cleaning.
+	outDir := path.Join(t.TempDir(), "out")
+	result := runPackCmd(t, []string{"render", packPath, "--to-dir=" + outDir})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.FileNotExists(t, path.Join(outDir, renderManifestName))
+
+	result = runPackCmd(t, []string{"render", packPath, "--to-dir=" + outDir, "--clean", "--auto-approve"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	extraOut := path.Join(outDir, testPack, "extra.nomad")
+	must.FileExists(t, extraOut)
+
+	// Rendering another pack to the same directory keeps the files of the
+	// first.
+	otherOut := path.Join(outDir, "deps_test_1")
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, "deps_test_1"), "--to-dir=" + outDir, "--clean"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.FileExists(t, extraOut)
+	must.DirExists(t, otherOut)
+
+	// Files not written by nomad-pack are never removed.
+	userFile := path.Join(outDir, testPack, "README.md")
+	must.NoError(t, os.WriteFile(userFile, []byte("# Rendered jobs\n"), 0644))
+
+	must.NoError(t, os.Remove(extraTpl))
+
+	// Files skipped by --render-only are not stale.
+	result = runPackCmd(t, []string{"render", packPath, "--to-dir=" + outDir, "--clean", "--auto-approve", "--render-only=" + testPack + ".nomad.tpl"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.FileExists(t, extraOut)
+
+	// Re-rendering must be approved when running non-interactively, and the
+	// stale files are kept when it is not.
+	result = runPackCmd(t, []string{"render", packPath, "--to-dir=" + outDir, "--clean"})
+	must.One(t, result.exitCode)
+	must.FileExists(t, extraOut)
+
+	result = runPackCmd(t, []string{"render", packPath, "--to-dir=" + outDir, "--clean", "--auto-approve"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "Removed stale rendered file "+extraOut)
+	must.FileNotExists(t, extraOut)
+	must.FileExists(t, userFile)
+	must.FileExists(t, path.Join(outDir, testPack, testPack+".nomad"))
+	must.DirExists(t, otherOut)
+
+	// Manifest entries outside the directory are ignored.
+	outside := path.Join(path.Dir(outDir), "outside.nomad")
+	must.NoError(t, os.WriteFile(outside, []byte(`job "outside" {}`), 0644))
+	manifestPath := path.Join(outDir, renderManifestName)
+	manifest, err := readRenderManifest(manifestPath)
+	must.NoError(t, err)
+	manifest[testPack] = append(manifest[testPack], "../outside.nomad")
+	b, err := json.Marshal(manifest)
+	must.NoError(t, err)
+	must.NoError(t, os.WriteFile(manifestPath, b, 0644))
+	result = runPackCmd(t, []string{"render", packPath, "--to-dir=" + outDir, "--clean", "--auto-approve"})
+	must.Zero(t, result.exitCode)
+	must.FileExists(t, outside)
+
+	result = runPackCmd(t, []string{"render", packPath, "--clean"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "--clean can only be used with --to-dir")
+}
+
+func TestCLI_PackRender_Index(t *testing.T) {
+	t.Parallel()
+
+	// Files of the user in the directory are left untouched.
+	outDir := t.TempDir()
+	userManifest := path.Join(outDir, "manifest.json")
+	must.NoError(t, os.WriteFile(userManifest, []byte("{}"), 0644))
+
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--to-dir=" + outDir})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	userContent, err := os.ReadFile(userManifest)
+	must.NoError(t, err)
+	must.Eq(t, "{}", string(userContent))
+
+	indexPath := path.Join(outDir, renderIndexName)
+	b, err := os.ReadFile(indexPath)
+	must.NoError(t, err)
+
+	var index struct {
+		Files []renderIndexEntry `json:"files"`
+	}
+	must.NoError(t, json.Unmarshal(b, &index))
+	must.Len(t, 1, index.Files)
+
+	entry := index.Files[0]
+	must.Eq(t, testPack, entry.Job)
+	must.Eq(t, path.Join(testPack, testPack+".nomad"), entry.Path)
+
+	rendered, err := os.ReadFile(path.Join(outDir, entry.Path))
+	must.NoError(t, err)
+	must.Eq(t, len(rendered), entry.Size)
+	must.Eq(t, fmt.Sprintf("%x", sha256.Sum256(rendered)), entry.SHA256)
+
+	// Rendering the same content produces an identical index.
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--to-dir=" + outDir, "--auto-approve"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	again, err := os.ReadFile(indexPath)
+	must.NoError(t, err)
+	must.Eq(t, string(b), string(again))
+}
+
+func TestCLI_PackRender_FormatComments(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and replace its job with a comment-heavy template.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	tpl := `# The job is named after the pack.
+job [[ meta "pack.name" . | quote ]] {
+// Only run in the configured datacenters.
+datacenters = [[ var "datacenters" . | toJson ]]
+type = "service" # long-running
+
+  /* The app group runs a single
+     task. */
+  group "app" {
+      # Scale with the count variable.
+      count = [[ var "count" . ]]
+
+    task "server" {
+      driver = "raw_exec" # no isolation
+      config {
+        command = "/bin/bash"
+        args = [
+          "-c", # run a script
+          # The command to run.
+          [[ var "command" . | quote ]],
+        ]
+      }
+    }
+  }
+}
+`
+	must.NoError(t, os.WriteFile(path.Join(packPath, "templates", testPack+".nomad.tpl"), []byte(tpl), 0644))
+
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	out := result.cmdOut.String()
+
+	// Each comment is kept, indented with the line following it.
+	for _, expect := range []string{
+		"# The job is named after the pack.\njob \"" + testPack + "\" {",
+		"  // Only run in the configured datacenters.\n  datacenters = [\"dc1\"]",
+		`  type        = "service" # long-running`,
+		"  /* The app group runs a single\n     task. */\n  group \"app\" {",
+		"    # Scale with the count variable.\n    count = 1",
+		`      driver = "raw_exec" # no isolation`,
+		"          \"-c\", # run a script\n          # The command to run.\n          \"",
+	} {
+		must.StrContains(t, out, expect)
+	}
+}
+
+func TestCLI_PackRender_Input(t *testing.T) {
+	t.Parallel()
+
+	inputPath := path.Join(t.TempDir(), "input.json")
+	input := fmt.Sprintf(`{
+  "pack": %q,
+  "vars": {"job_name": "from_input", "count": 3, "datacenters": ["dc2"]}
+}`, getTestPackPath(t, testPack))
+	must.NoError(t, os.WriteFile(inputPath, []byte(input), 0644))
+
+	result := runPackCmd(t, []string{"render", "--input=" + inputPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `job "from_input"`)
+	must.StrContains(t, result.cmdOut.String(), "count = 3")
+	must.StrContains(t, result.cmdOut.String(), `["dc2"]`)
+
+	// Flags override the options in the input file.
+	result = runPackCmd(t, []string{"render", "--input=" + inputPath, "--var=job_name=from_flag"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `job "from_flag"`)
+	must.StrContains(t, result.cmdOut.String(), "count = 3")
+
+	// Keys which are unknown, or not supported by the command, are rejected.
+	must.NoError(t, os.WriteFile(inputPath, []byte(`{"pack": "x", "varz": {}}`), 0644))
+	result = runPackCmd(t, []string{"render", "--input=" + inputPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `unknown key "varz"`)
+
+	must.NoError(t, os.WriteFile(inputPath, []byte(`{"pack": "x", "detach": true}`), 0644))
+	result = runPackCmd(t, []string{"render", "--input=" + inputPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `key "detach" is not supported by the render command`)
+
+	// Keys are applied through the flag of the same name, with dashes in
+	// place of underscores.
+	must.NoError(t, os.WriteFile(inputPath, []byte(`{"wait_timeout": "30s"}`), 0644))
+	c := &RunCommand{baseCommand: &baseCommand{cmdKey: "run"}}
+	flags := c.Flags()
+	must.NoError(t, flags.Parse([]string{"--input=" + inputPath}))
+	must.NoError(t, c.applyInput(flags))
+	must.Eq(t, 30*time.Second, c.jobConfig.RunConfig.WaitTimeout)
+
+	must.NoError(t, os.WriteFile(inputPath, []byte(`{"pack": "x", "wait_timeout": "30s"}`), 0644))
+	result = runPackCmd(t, []string{"render", "--input=" + inputPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `key "wait_timeout" is not supported by the render command`)
+}
+
+func TestCLI_PackRender_ShowVars(t *testing.T) {
+	t.Parallel()
+
+	// Without the flag, the output has no annotations.
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack)})
+	must.Zero(t, result.exitCode)
+	must.StrNotContains(t, result.cmdOut.String(), "# from")
+
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--show-vars", "--var=count=3"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "    # from var.count\n    count = 3\n")
+	must.StrContains(t, result.cmdOut.String(),
+		"  # from [[ var \"datacenters\" . | toJson ]]\n  datacenters = [\"dc1\"]\n")
+}
+
+func TestCLI_PackRender_Parallelism(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add more job templates, so several render at
+	// once.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	tpl, err := os.ReadFile(path.Join(packPath, "templates", testPack+".nomad.tpl"))
+	must.NoError(t, err)
+	for i := range 4 {
+		must.NoError(t, os.WriteFile(path.Join(packPath, "templates", fmt.Sprintf("copy%d.nomad.tpl", i)), tpl, 0644))
+	}
+
+	expected := runPackCmd(t, []string{"render", packPath, "--render-seed=1"})
+	must.Zero(t, expected.exitCode, must.Sprintf("cmdOut:\n%v", expected.cmdOut.String()))
+
+	// A memory limit lower than the output of a single template still
+	// renders every template.
+	result := runPackCmd(t, []string{"render", packPath, "--render-seed=1", "--parallelism=3", "--render-mem-limit=1KB"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.Eq(t, expected.cmdOut.String(), result.cmdOut.String())
+
+	result = runPackCmd(t, []string{"render", packPath, "--parallelism=0"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "--parallelism must be at least 1")
+
+	result = runPackCmd(t, []string{"render", packPath, "--render-mem-limit=lots"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `invalid --render-mem-limit "lots"`)
+}
+
+func TestCLI_PackRender_MaxRenderBytes(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add a template whose loop produces far more
+	// output than any job.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "templates", "runaway.nomad.tpl"),
+		[]byte(`[[ range $i := until 100000 ]]# line [[ $i ]] of a runaway loop
+[[ end ]]`), 0644))
+
+	result := runPackCmd(t, []string{"render", packPath, "--max-render-bytes=64KB"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "failed to render "+testPack+"/templates/runaway.nomad.tpl: rendered output exceeds the maximum of 64000 bytes")
+
+	// The default limit is generous enough for the output.
+	result = runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "# line 99999 of a runaway loop")
+
+	result = runPackCmd(t, []string{"render", packPath, "--max-render-bytes=lots"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `invalid --max-render-bytes "lots"`)
+}
+
+func TestCLI_PackRender_Trace(t *testing.T) {
+	t.Parallel()
+
+	tracePath := path.Join(t.TempDir(), "render.trace")
+	result := runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--trace=" + tracePath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+	// The trace is complete once the command has finished, and marks the
+	// stages of the render.
+	b, err := os.ReadFile(tracePath)
+	must.NoError(t, err)
+	must.True(t, bytes.HasPrefix(b, []byte("go 1.")))
+	for _, region := range []string{"load packs", "resolve variables", "render " + testPack + "/templates/" + testPack + ".nomad.tpl"} {
+		must.True(t, bytes.Contains(b, []byte(region)), must.Sprintf("trace missing region %q", region))
+	}
+
+	result = runPackCmd(t, []string{"render", getTestPackPath(t, testPack), "--trace=" + path.Join(t.TempDir(), "missing", "render.trace")})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "failed to create trace file")
+}
+
+func TestCLI_PackRender_DeferVars(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and add HCL2 variable interpolations to its job.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	tplPath := path.Join(packPath, "templates", testPack+".nomad.tpl")
+	tpl, err := os.ReadFile(tplPath)
+	must.NoError(t, err)
+	tpl = bytes.Replace(tpl, []byte(`type = "service"`),
+		[]byte("type = \"service\"\n  meta {\n    count = \"${var.count}\"\n    region = \"${var.deploy_region}\"\n  }"), 1)
+	must.NoError(t, os.WriteFile(tplPath, tpl, 0644))
+
+	// Without the flag, the interpolations are left for Nomad.
+	result := runPackCmd(t, []string{"render", packPath, "--var=count=3"})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `count  = "${var.count}"`)
 
-		result := runTestPackCmd(t, s, []string{"destroy", getTestPackPath(t, testPack)})
-		must.StrContains(t, result.cmdOut.String(), `Pack "`+testPack+`" destroyed`)
-		must.Zero(t, result.exitCode)
+	result = runPackCmd(t, []string{"render", packPath, "--var=count=3", "--defer-vars=^deploy_"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), `count  = "3"`)
+	must.StrContains(t, result.cmdOut.String(), `region = "${var.deploy_region}"`)
 
-		// Assert job no longer queryable
-		c, err := ct.NewTestClient(s)
-		must.NoError(t, err)
+	// Interpolations which are neither deferred nor pack variables fail.
+	result = runPackCmd(t, []string{"render", packPath, "--defer-vars=^nomad_"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "${var.deploy_region} does not reference a pack variable")
 
-		r, _, err := c.Jobs().Info(testPack, &api.QueryOptions{})
-		must.Nil(t, r)
-		must.EqError(t, err, "Unexpected response code: 404 (job not found)")
-	})
+	result = runPackCmd(t, []string{"render", packPath, "--defer-vars=("})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "invalid --defer-vars pattern")
 }
 
-// Test that destroy properly uses var overrides to target the job
-func TestCLI_PackDestroy_WithOverrides(t *testing.T) {
+func TestCLI_PackRender_KeepVariablesBlock(t *testing.T) {
+	t.Parallel()
+
+	// Copy the test pack and reference pack variables from the job as HCL2
+	// variables.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	tplPath := path.Join(packPath, "templates", testPack+".nomad.tpl")
+	tpl, err := os.ReadFile(tplPath)
+	must.NoError(t, err)
+	tpl = bytes.Replace(tpl, []byte(`type = "service"`),
+		[]byte("type = \"service\"\n  meta {\n    count = \"${var.count}\"\n    command = var.command\n  }"), 1)
+	must.NoError(t, os.WriteFile(tplPath, tpl, 0644))
+
+	result := runPackCmd(t, []string{"render", packPath, "--var=count=3", "--keep-variables-block"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.RegexMatch(t, regexp.MustCompile(`variables \{\n\s+command = ".*"\n\s+count\s+= 3\n\}`), result.cmdOut.String())
+	must.StrContains(t, result.cmdOut.String(), `"${var.count}"`)
+	must.StrContains(t, result.cmdOut.String(), `= var.command`)
+
+	result = runPackCmd(t, []string{"render", packPath, "--keep-variables-block", "--defer-vars=^deploy_"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "--keep-variables-block can not be used with --defer-vars")
+}
+
+func TestCLI_PackRender_JSONWithMeta(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
-		c, err := ct.NewTestClient(s)
-		must.NoError(t, err)
-		// Because this test uses ref, it requires a populated pack cache.
+		args := []string{"render", getTestPackPath(t, testPack), "--out-format=nomad-json-with-meta", "--var=count=2"}
+		result := runTestPackCmd(t, s, args)
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+
+		var jobs []renderedJob
+		must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &jobs))
+		must.Len(t, 1, jobs)
+		must.Eq(t, testPack, jobs[0].Pack.Name)
+		must.NotEq(t, "", jobs[0].Pack.Version)
+		must.Eq(t, testPack+"/"+testPack+".nomad", jobs[0].Template)
+		must.Eq(t, "", jobs[0].Pack.LocalRef)
+		must.Eq(t, 64, len(jobs[0].RenderSHA256))
+		must.Eq(t, testPack, *jobs[0].Job.ID)
+		must.Eq(t, 2, *jobs[0].Job.TaskGroups[0].Count)
+
+		// The output is identical for identical renders.
+		must.Eq(t, result.cmdOut.String(), runTestPackCmd(t, s, args).cmdOut.String())
+
+		result = runTestPackCmd(t, s, append(args, "--to-dir="+t.TempDir()))
+		must.One(t, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "--out-format=nomad-json-with-meta can not be used with --to-dir")
+
+		// Registry packs carry the SHA the registry was cloned at.
 		reg, _, regPath := createTestRegistries(t)
 		defer cleanTestRegistry(t, regPath)
 
-		// 	TODO: Table Testing
-		// Create multiple jobs in the same pack deployment
-
-		jobNames := []string{"foo", "bar"}
-		for _, j := range jobNames {
-			expectGoodPackDeploy(t, runTestPackCmd(
-				t, s, []string{"run", testPack, "--var=job_name=" + j, "--registry=" + reg.Name}))
-		}
-
-		// Stop nonexistent job
-		result := runTestPackCmd(t, s, []string{"destroy", testPack, "--var=job_name=baz", "--registry=" + reg.Name})
-		must.Eq(t, 1, result.exitCode, must.Sprintf(
-			"expected exitcode 1; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
+		result = runTestPackCmd(t, s, []string{"render", testPack, "--registry=" + reg.Name, "--out-format=nomad-json-with-meta"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.NoError(t, json.Unmarshal(result.cmdOut.Bytes(), &jobs))
+		must.Len(t, 1, jobs)
+		must.Eq(t, reg.Name, jobs[0].Pack.Registry)
+		must.Eq(t, testRef, jobs[0].Pack.LocalRef)
+	})
+}
 
-		// Stop job with var override
-		result = runTestPackCmd(t, s, []string{"destroy", testPack, "--var=job_name=foo", "--registry=" + reg.Name})
-		must.Zero(t, result.exitCode, must.Sprintf(
-			"expected exitcode 0; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
+func TestCLI_PackRender_Hooks(t *testing.T) {
+	t.Parallel()
 
-		// Assert job "bar" still exists
-		job, _, err := c.Jobs().Info("bar", &api.QueryOptions{WaitTime: 5 * time.Second})
-		must.NoError(t, err)
-		must.NotNil(t, job)
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.Mkdir(path.Join(packPath, "hooks"), 0755))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "hooks", "pre-deploy.tpl"),
+		[]byte(`./migrate --count=[[ var "count" . ]]`), 0644))
+
+	result := runPackCmd(t, []string{"render", packPath, "--var=count=4"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), testPack+"/hooks/pre-deploy:")
+	must.StrContains(t, result.cmdOut.String(), "./migrate --count=4")
+	must.StrNotContains(t, result.cmdOut.String(), "post-deploy")
+}
 
-		// Stop job with no overrides passed
-		result = runTestPackCmd(t, s, []string{"destroy", testPack, "--registry=" + reg.Name})
-		must.Zero(t, result.exitCode, must.Sprintf(
-			"expected exitcode 0; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
+// writeDependentJobsPack copies the test pack, adding the app and db jobs, with
+// the app job depending on the jobs listed by appDependsOn and the db job on
+// those listed by dbDependsOn.
+func writeDependentJobsPack(t *testing.T, appDependsOn, dbDependsOn string) string {
+	t.Helper()
 
-		// Assert job bar is gone
-		job, _, err = c.Jobs().Info("bar", &api.QueryOptions{WaitTime: 5 * time.Second})
-		must.Error(t, err)
-		must.Eq(t, "Unexpected response code: 404 (job not found)", err.Error())
-		must.Nil(t, job)
-	})
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	for name, dependsOn := range map[string]string{"app": appDependsOn, "db": dbDependsOn} {
+		tpl := fmt.Sprintf(`job %q {
+  datacenters = ["*"]
+  meta {
+    depends_on = %q
+  }
+  group "app" {
+    task "server" {
+      driver = "raw_exec"
+      config {
+        command = "/bin/sleep"
+        args    = ["300"]
+      }
+    }
+  }
+}
+`, name, dependsOn)
+		must.NoError(t, os.WriteFile(path.Join(packPath, "templates", name+".nomad.tpl"), []byte(tpl), 0644))
+	}
+	return packPath
 }
 
-func TestCLI_CLIFlag_NotDefined(t *testing.T) {
-	t.Parallel() // nomad not required
+func TestCLI_PackRender_DependencyOrder(t *testing.T) {
+	t.Parallel()
 
-	// There is no job flag. This tests that adding an unspecified flag does not
-	// create an invalid memory address error
-	// Posix case
-	result := runPackCmd(t, []string{"run", "nginx", "--job=provided-but-not-defined"})
-	must.Eq(t, 1, result.exitCode)
+	packPath := writeDependentJobsPack(t, "db", "")
 
-	// std go case
-	result = runPackCmd(t, []string{"run", "-job=provided-but-not-defined", "nginx"})
-	must.Eq(t, 1, result.exitCode)
-}
+	// By default, the renders are in template name order.
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	out := result.cmdOut.String()
+	must.True(t, strings.Index(out, testPack+"/app.nomad:") < strings.Index(out, testPack+"/db.nomad:"))
 
-func TestCLI_PackStatus(t *testing.T) {
-	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
-		result := runTestPackCmd(t, s, []string{"run", getTestPackPath(t, testPack)})
-		must.Zero(t, result.exitCode)
+	result = runPackCmd(t, []string{"render", packPath, "--dependency-order"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	out = result.cmdOut.String()
+	must.True(t, strings.Index(out, testPack+"/db.nomad:") < strings.Index(out, testPack+"/app.nomad:"))
 
-		testcases := []struct {
-			name string
-			args []string
-		}{
-			{
-				name: "no-pack-name",
-				args: []string{},
-			},
-			{
-				name: "with-pack-name",
-				args: []string{testPack},
-			},
-			{
-				name: "with-pack-and-registry-name",
-				args: []string{testPack, "--registry=default"},
-			},
-			{
-				name: "with-pack-and-ref",
-				args: []string{testPack, "--ref=latest"},
-			},
-		}
+	result = runPackCmd(t, []string{"render", writeDependentJobsPack(t, "db", "app"), "--dependency-order"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "jobs have a dependency cycle: app -> db -> app")
 
-		for _, tc := range testcases {
-			t.Run(tc.name, func(t *testing.T) {
-				args := append([]string{"status"}, tc.args...)
-				result := runTestPackCmd(t, s, args)
-				must.Zero(t, result.exitCode)
-				must.StrContains(t, result.cmdOut.String(), "simple_raw_exec | "+cache.DevRegistryName+" ")
-			})
-		}
-	})
+	result = runPackCmd(t, []string{"render", writeDependentJobsPack(t, "queue", ""), "--dependency-order"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `job "app" depends on unknown job "queue"`)
 }
 
-func TestCLI_PackStatus_Fails(t *testing.T) {
-	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
-		// test for status on missing pack
-		result := runTestPackCmd(t, s, []string{"status", getTestPackPath(t, testPack)})
-		must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
-		must.StrContains(t, result.cmdOut.String(), "no jobs found for pack \""+getTestPackPath(t, testPack)+"\"")
-		// FIXME: Should this have a non-success exit-code?
-		must.Zero(t, result.exitCode)
+func TestCLI_PackRender_SensitiveFile(t *testing.T) {
+	t.Parallel()
 
-		// test flag validation for name flag without pack
-		result = runTestPackCmd(t, s, []string{"status", "--name=foo"})
-		must.Eq(t, 1, result.exitCode)
-		must.StrContains(t, result.cmdOut.String(), "--name can only be used if pack name is provided")
-	})
+	// Copy the test pack and write the command variable, base64 encoded, to
+	// a sensitive file.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	tplPath := path.Join(packPath, "templates", testPack+".nomad.tpl")
+	tpl, err := os.ReadFile(tplPath)
+	must.NoError(t, err)
+	tpl = append([]byte(`[[ fileFromBase64 "tls/server.key" "0600" (var "command" .) ]]`), tpl...)
+	must.NoError(t, os.WriteFile(tplPath, tpl, 0644))
+
+	secret := base64.StdEncoding.EncodeToString([]byte("top-secret-key"))
+	outDir := t.TempDir()
+	result := runPackCmd(t, []string{"render", packPath, "--var=command=" + secret, "--to-dir=" + outDir})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), testPack+"/tls/server.key:")
+	must.StrContains(t, result.cmdOut.String(), "(sensitive, 14 bytes, mode 0600)")
+	must.StrNotContains(t, result.cmdOut.String(), "top-secret-key")
+
+	keyPath := path.Join(outDir, testPack, "tls", "server.key")
+	content, err := os.ReadFile(keyPath)
+	must.NoError(t, err)
+	must.Eq(t, "top-secret-key", string(content))
+	info, err := os.Stat(keyPath)
+	must.NoError(t, err)
+	must.Eq(t, os.FileMode(0600), info.Mode().Perm())
+
+	index, err := os.ReadFile(path.Join(outDir, renderIndexName))
+	must.NoError(t, err)
+	must.StrContains(t, string(index), `"sensitive": true`)
+
+	result = runPackCmd(t, []string{"render", packPath, "--var=command=not base64"})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "illegal base64 data")
 }
 
-func TestCLI_PackRender_RootVar(t *testing.T) {
+func TestCLI_PackRender_SplitVariableFiles(t *testing.T) {
 	t.Parallel()
-	// This test has to do some extra shenanigans because dependent pack template
-	// output is not guaranteed to be ordered. This requires that the test handle
-	// either order.
-	expected := []string{
-		"deps_test/child1/child1.nomad=child1",
-		"deps_test/child2/child2.nomad=child2",
-		"deps_test/deps_test.nomad=deps_test",
-	}
-
-	result := runPackCmd(t, []string{
-		"render",
-		"--no-format=true",
-		getTestPackPath(t, "my_alias_test"),
-	})
-	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
 
-	// Performing a little clever string manipulation on the render output to
-	// prepare it for splitting into a slice of string enables us to use
-	// require.ElementsMatch to validate goodness.
-	outStr := strings.TrimSpace(result.cmdOut.String())
-	outStr = strings.ReplaceAll(outStr, ":\n\n", "=")
-	elems := strings.Split(outStr, "\n")
+	// Copy the test pack and move the count variable into its own file.
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	varsPath := path.Join(packPath, "variables.hcl")
+	vars, err := os.ReadFile(varsPath)
+	must.NoError(t, err)
 
-	must.SliceContainsAll(t, expected, elems)
+	countDecl := regexp.MustCompile(`(?s)variable "count" \{.*?\n\}\n`)
+	must.RegexMatch(t, countDecl, string(vars))
+	must.NoError(t, os.WriteFile(varsPath, countDecl.ReplaceAll(vars, nil), 0644))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "count.variables.hcl"),
+		[]byte("variable \"count\" {\n  type    = number\n  default = 3\n}\n"), 0644))
+
+	result := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("stdout:\n%s", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "count = 3")
+
+	// Declaring the variable again in another file is an error.
+	must.NoError(t, os.MkdirAll(path.Join(packPath, "variables"), 0755))
+	must.NoError(t, os.WriteFile(path.Join(packPath, "variables", "count.hcl"),
+		[]byte("variable \"count\" {\n  default = 4\n}\n"), 0644))
+
+	result = runPackCmd(t, []string{"render", packPath})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), `The variable "count" is declared in both`)
+	must.StrContains(t, result.cmdOut.String(), "count.variables.hcl")
+	must.StrContains(t, result.cmdOut.String(), "variables/count.hcl")
 }
 
-func TestCLI_PackRender_SetDepVarWithFlag(t *testing.T) {
+func TestCLI_PackRender_CheckFormat(t *testing.T) {
 	t.Parallel()
-	// This test has to do some extra shenanigans because dependent pack template
-	// output is not guaranteed to be ordered. This requires that the test handle
-	// either order.
-	expected := []string{
-		"deps_test/child1/child1.nomad=override",
-		"deps_test/child2/child2.nomad=child2",
-		"deps_test/deps_test.nomad=deps_test",
-	}
 
-	result := runPackCmd(t, []string{
-		"render",
-		"--no-format=true",
-		"--var", "child1.job_name=override",
-		getTestPackPath(t, "my_alias_test"),
-	})
+	// The test pack template is not canonically formatted.
+	result := runPackCmd(t, []string{"render", "--check-format", getTestPackPath(t, testPack)})
+	must.Eq(t, 1, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "+++ "+testPack+"/"+testPack+".nomad (formatted)")
+	must.StrContains(t, result.cmdOut.String(), "not canonically formatted")
+
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+	must.NoError(t, os.WriteFile(
+		path.Join(packPath, "templates", testPack+".nomad.tpl"),
+		[]byte("job \"formatted\" {\n  type        = \"service\"\n  datacenters = [\"dc1\"]\n}\n"),
+		0644,
+	))
+
+	result = runPackCmd(t, []string{"render", "--check-format", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "All rendered templates are canonically formatted.")
+}
 
-	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
-	must.Eq(t, 0, result.exitCode, must.Sprintf("incorrect exit code.\nstdout:\n%v\nstderr:%v\n", result.cmdOut.String(), result.cmdErr.String()))
+func TestCLI_PackRender_NomadVar(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
+		_, _, err = client.Variables().Create(&api.Variable{
+			Path:  "config/app",
+			Items: api.VariableItems{"greeting": "hello"},
+		}, nil)
+		must.NoError(t, err)
 
-	// Performing a little clever string manipulation on the render output to
-	// prepare it for splitting into a slice of string enables us to use
-	// require.ElementsMatch to validate goodness.
-	outStr := strings.TrimSpace(result.cmdOut.String())
-	outStr = strings.ReplaceAll(outStr, ":\n\n", "=")
-	elems := strings.Split(outStr, "\n")
+		packPath := path.Join(t.TempDir(), testPack)
+		must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+		must.NoError(t, os.WriteFile(
+			path.Join(packPath, "templates", "greeting.nomad.tpl"),
+			[]byte(`greeting = "[[ nomadVar "config/app" "greeting" ]]"`),
+			0644,
+		))
 
-	must.SliceContainsAll(t, expected, elems)
+		// Lookups are refused unless explicitly allowed.
+		result := runTestPackCmd(t, s, []string{"render", packPath})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), "requires external lookups")
+
+		result = runTestPackCmd(t, s, []string{"render", "--allow-external-lookups", packPath})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		must.StrContains(t, result.cmdOut.String(), `greeting = "hello"`)
+
+		// Missing variables fail clearly.
+		must.NoError(t, os.WriteFile(
+			path.Join(packPath, "templates", "greeting.nomad.tpl"),
+			[]byte(`greeting = "[[ nomadVar "config/missing" "greeting" ]]"`),
+			0644,
+		))
+		result = runTestPackCmd(t, s, []string{"render", "--allow-external-lookups", packPath})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `Nomad variable "config/missing" not found`)
+	})
 }
 
-func TestCLI_PackRender_VarsInOutputTemplate(t *testing.T) {
-	t.Parallel()
-	// This test has to do some extra shenanigans because dependent pack template
-	// output is not guaranteed to be ordered. This requires that the test handle
-	// either order.
-	expected := []string{
-		"deps_test/child1/child1.nomad=override",
-		"deps_test/child2/child2.nomad=child2",
-		"deps_test/deps_test.nomad=deps_test",
-		"outputs.tpl=deps_test,override,child2",
-	}
+func TestCLI_PackRender_NomadVarACL(t *testing.T) {
+	ct.HTTPTestWithACLParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		packPath := path.Join(t.TempDir(), testPack)
+		must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+		must.NoError(t, os.WriteFile(
+			path.Join(packPath, "templates", "greeting.nomad.tpl"),
+			[]byte(`greeting = "[[ nomadVar "config/app" "greeting" ]]"`),
+			0644,
+		))
 
-	result := runPackCmd(t, []string{
-		"render",
-		"--no-format=true",
-		"--var", "child1.job_name=override",
-		"--render-output-template=true",
-		getTestPackPath(t, "my_alias_test"),
+		result := runTestPackCmd(t, s, []string{"render", "--allow-external-lookups", "--token=" + badACLToken, packPath})
+		must.Eq(t, 1, result.exitCode)
+		must.StrContains(t, result.cmdOut.String(), `permission denied reading Nomad variable "config/app"`)
 	})
+}
 
-	must.Eq(t, result.cmdErr.String(), "", must.Sprintf("cmdErr should be empty, but was %q", result.cmdErr.String()))
-	must.Eq(t, 0, result.exitCode, must.Sprintf("incorrect exit code.\ncmdOut:\n%v\ns", result.cmdOut.String()))
-	must.StrNotContains(t, result.cmdOut.String(), "Error")
+func TestCLI_PackRun_ACLCapabilities(t *testing.T) {
+	ct.HTTPTestWithACLParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+		client, err := ct.NewTestClient(s)
+		must.NoError(t, err)
 
-	// Performing a little clever string manipulation on the render output to
-	// prepare it for splitting into a slice of string enables us to use
-	// require.ElementsMatch to validate goodness.
-	outStr := strings.TrimSpace(result.cmdOut.String())
-	outStr = strings.ReplaceAll(outStr, ":\n\n", "=")
-	elems := strings.Split(outStr, "\n")
+		// Create a token which can read, but not submit, jobs.
+		_, err = client.ACLPolicies().Upsert(&api.ACLPolicy{
+			Name:  "read-default",
+			Rules: `namespace "default" { policy = "read" }`,
+		}, nil)
+		must.NoError(t, err)
+		token, _, err := client.ACLTokens().Create(&api.ACLToken{Type: "client", Policies: []string{"read-default"}}, nil)
+		must.NoError(t, err)
 
-	must.SliceContainsAll(t, expected, elems, must.Sprintf("unexpected returned value.\nexpected: %v\nelems: %v\nstdout:\n%v\n", expected, elems, result.cmdOut.String()))
+		// Declare the capabilities required by a copy of the test pack.
+		packPath := path.Join(t.TempDir(), testPack)
+		must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+		metaPath := path.Join(packPath, "metadata.hcl")
+		b, err := os.ReadFile(metaPath)
+		must.NoError(t, err)
+		b = append(b, []byte("\nacl {\n  capabilities = [\"read-job\", \"submit-job\"]\n}\n")...)
+		must.NoError(t, os.WriteFile(metaPath, b, 0o644))
+
+		for _, cmd := range []string{"run", "plan"} {
+			result := runTestPackCmd(t, s, []string{cmd, packPath, "--token=" + token.SecretID})
+			must.NonZero(t, result.exitCode)
+			must.StrContains(t, result.cmdOut.String(), "the ACL token is missing capabilities required by the pack:")
+			must.StrContains(t, result.cmdOut.String(), `namespace "default": submit-job`)
+		}
+
+		result := runTestPackCmd(t, s, []string{"run", packPath, "--token=" + s.Config.Client.Meta["token"]})
+		expectGoodPackDeploy(t, result)
+	})
 }
 
 func TestCLI_CLIFlag_Namespace(t *testing.T) {
@@ -657,6 +2846,60 @@ func TestCLI_CLIFlag_Namespace(t *testing.T) {
 	}
 }
 
+func TestCLI_PerJobNamespace(t *testing.T) {
+	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(srv *agent.TestAgent) {
+		c, err := ct.NewTestClient(srv)
+		must.NoError(t, err)
+		ct.MakeTestNamespaces(t, c)
+
+		// Copy the test pack and add a job which does not set a namespace,
+		// alongside the job whose namespace is set by a variable.
+		packPath := path.Join(t.TempDir(), testPack)
+		must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+		must.NoError(t, os.WriteFile(
+			path.Join(packPath, "templates", "other.nomad.tpl"),
+			[]byte(`job "other" {
+  group "app" {
+    task "server" {
+      driver = "raw_exec"
+      config {
+        command = "/bin/sleep"
+        args    = ["300"]
+      }
+    }
+  }
+}
+`),
+			0644,
+		))
+
+		result := runTestPackCmd(t, srv, []string{"run", packPath, "--var=namespace=job", "--namespace=flag"})
+		expectGoodPackDeploy(t, result)
+
+		for ns, jobID := range map[string]string{"job": testPack, "flag": "other"} {
+			tJobs, _, err := c.Jobs().List(&api.QueryOptions{Namespace: ns})
+			must.NoError(t, err)
+			must.Len(t, 1, tJobs, must.Sprintf("expected one job in %q namespace", ns))
+			must.Eq(t, jobID, tJobs[0].ID)
+		}
+
+		// The jobs are found in both namespaces, whichever namespace the
+		// client is configured with.
+		result = runTestPackCmd(t, srv, []string{"status", testPack, "--columns=job,namespace"})
+		must.Zero(t, result.exitCode)
+		must.RegexMatch(t, regexp.MustCompile(testPack+`\s+\|\s+job`), result.cmdOut.String())
+		must.RegexMatch(t, regexp.MustCompile(`other\s+\|\s+flag`), result.cmdOut.String())
+
+		result = runTestPackCmd(t, srv, []string{"destroy", packPath, "--auto-approve"})
+		must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+		for _, ns := range []string{"job", "flag"} {
+			tJobs, _, err := c.Jobs().List(&api.QueryOptions{Namespace: ns})
+			must.NoError(t, err)
+			must.Len(t, 0, tJobs, must.Sprintf("expected no jobs in %q namespace", ns))
+		}
+	})
+}
+
 func TestCLI_CLIFlag_Token(t *testing.T) {
 	ct.HTTPTestWithACLParallel(t, ct.WithDefaultConfig(), func(srv *agent.TestAgent) {
 		c, err := ct.NewTestClient(srv)
@@ -817,13 +3060,21 @@ func runTestPackCmd(t *testing.T, srv *agent.TestAgent, args []string) PackComma
 
 func runPackCmd(t *testing.T, args []string) PackCommandResult {
 	t.Helper()
-	cmdOut := bytes.NewBuffer(make([]byte, 0))
-	cmdErr := bytes.NewBuffer(make([]byte, 0))
 
 	// Build our cancellation context
 	ctx, closer := helper.WithInterrupt(context.Background())
 	defer closer()
 
+	return runPackCmdContext(t, ctx, args)
+}
+
+// runPackCmdContext runs the command using the passed context, which can be
+// cancelled to stop long running commands.
+func runPackCmdContext(t *testing.T, ctx context.Context, args []string) PackCommandResult {
+	t.Helper()
+	cmdOut := bytes.NewBuffer(make([]byte, 0))
+	cmdErr := bytes.NewBuffer(make([]byte, 0))
+
 	// Make a test UI
 	ui := testui.NonInteractiveTestUI(ctx, cmdOut, cmdErr)
 
@@ -909,11 +3160,17 @@ func expectGoodPackPlan(t *testing.T, r PackCommandResult) {
 // points to the root where the two refs are on the filesystem.
 func createTestRegistries(t *testing.T) (*cache.Registry, *cache.Registry, string) {
 	t.Helper()
+	return createTestRegistriesIn(t, cache.DefaultCachePath())
+}
+
+// createTestRegistriesIn is createTestRegistries for the cache at cachePath.
+func createTestRegistriesIn(t *testing.T, cachePath string) (*cache.Registry, *cache.Registry, string) {
+	t.Helper()
 
 	// Fake a clone
 	registryName := fmt.Sprintf("test-%v", time.Now().UnixMilli())
 
-	regDir := path.Join(cache.DefaultCachePath(), registryName)
+	regDir := path.Join(cachePath, registryName)
 	err := filesystem.MaybeCreateDestinationDir(regDir)
 	must.NoError(t, err)
 
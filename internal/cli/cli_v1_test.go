@@ -225,7 +225,7 @@ func TestCLI_V1_PackStop(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
 		expectGoodPackDeploy(t, runTestPackV1Cmd(t, s, []string{"run", getTestPackV1Path(t, testPack)}))
 
-		result := runTestPackV1Cmd(t, s, []string{"stop", getTestPackV1Path(t, testPack), "--purge=true"})
+		result := runTestPackV1Cmd(t, s, []string{"stop", getTestPackV1Path(t, testPack), "--purge=true", "--auto-approve"})
 		must.Zero(t, result.exitCode)
 		expectNoStdErrOutput(t, result)
 		must.StrContains(t, result.cmdOut.String(), `Pack "`+testPack+`" destroyed`)
@@ -307,7 +307,7 @@ func TestCLI_V1_PackDestroy(t *testing.T) {
 	ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
 		expectGoodPackDeploy(t, runTestPackV1Cmd(t, s, []string{"run", getTestPackV1Path(t, testPack)}))
 
-		result := runTestPackV1Cmd(t, s, []string{"destroy", getTestPackV1Path(t, testPack)})
+		result := runTestPackV1Cmd(t, s, []string{"destroy", getTestPackV1Path(t, testPack), "--auto-approve"})
 		must.Eq(t, 0, result.exitCode)
 		expectNoStdErrOutput(t, result)
 		must.StrContains(t, result.cmdOut.String(), `Pack "`+testPack+`" destroyed`)
@@ -348,11 +348,11 @@ func TestCLI_V1_PackDestroy_WithOverrides(t *testing.T) {
 		}
 
 		// Stop nonexistent job
-		result := runTestPackV1Cmd(t, s, []string{"destroy", testPack, "--var=job_name=baz", "--registry=" + reg.Name})
+		result := runTestPackV1Cmd(t, s, []string{"destroy", testPack, "--var=job_name=baz", "--registry=" + reg.Name, "--auto-approve"})
 		must.Eq(t, 1, result.exitCode, must.Sprintf("expected exitcode 1; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
 
 		// Stop job with var override
-		result = runTestPackV1Cmd(t, s, []string{"destroy", testPack, "--var=job_name=foo", "--registry=" + reg.Name})
+		result = runTestPackV1Cmd(t, s, []string{"destroy", testPack, "--var=job_name=foo", "--registry=" + reg.Name, "--auto-approve"})
 		must.Zero(t, result.exitCode, must.Sprintf("expected exitcode 0; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
 
 		q := api.QueryOptions{}
@@ -365,7 +365,7 @@ func TestCLI_V1_PackDestroy_WithOverrides(t *testing.T) {
 		must.NotNil(t, j)
 
 		// Stop job with no overrides passed
-		result = runTestPackV1Cmd(t, s, []string{"destroy", testPack, "--registry=" + reg.Name})
+		result = runTestPackV1Cmd(t, s, []string{"destroy", testPack, "--registry=" + reg.Name, "--auto-approve"})
 		must.Zero(t, result.exitCode, must.Sprintf("expected exitcode 0; got %v\ncmdOut:%v", result.exitCode, result.cmdOut.String()))
 
 		// Assert job bar is gone
@@ -476,6 +476,48 @@ func TestCLI_V1_PackRender_MyAlias(t *testing.T) {
 	must.SliceContainsAll(t, expected, elems, must.Sprintf("expected: %v\n got: %v", expected, elems))
 }
 
+func TestCLI_V1_Migrate(t *testing.T) {
+	t.Parallel()
+
+	packPath := path.Join(t.TempDir(), testPack)
+	must.NoError(t, filesystem.CopyDir(getTestPackV1Path(t, testPack), packPath, false, logging.Default()))
+	tplPath := path.Join(packPath, "templates", testPack+".nomad.tpl")
+	original, err := os.ReadFile(tplPath)
+	must.NoError(t, err)
+
+	expected := runPackV1Cmd(t, []string{"render", packPath})
+	must.Zero(t, expected.exitCode, must.Sprintf("cmdOut:\n%v", expected.cmdOut.String()))
+
+	// A dry run shows the changes without writing them.
+	result := runPackCmd(t, []string{"migrate", packPath, "--dry-run"})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "from format version 1 to 2")
+	must.StrContains(t, result.cmdOut.String(), `+    count = [[ var "count" . ]]`)
+	must.StrContains(t, result.cmdOut.String(), "would be changed")
+	content, err := os.ReadFile(tplPath)
+	must.NoError(t, err)
+	must.Eq(t, string(original), string(content))
+
+	result = runPackCmd(t, []string{"migrate", packPath})
+	must.Zero(t, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+	must.StrContains(t, result.cmdOut.String(), "Updated templates/"+testPack+".nomad.tpl")
+	must.StrContains(t, result.cmdOut.String(), "migrated to format version 2")
+
+	// The migrated pack renders the same jobs with the current parser.
+	migrated := runPackCmd(t, []string{"render", packPath})
+	must.Zero(t, migrated.exitCode, must.Sprintf("cmdOut:\n%v", migrated.cmdOut.String()))
+	must.Eq(t, expected.cmdOut.String(), migrated.cmdOut.String())
+
+	result = runPackCmd(t, []string{"migrate", packPath})
+	must.Zero(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "is already at format version 2")
+
+	// Packs from a registry are not migrated in place.
+	result = runPackCmd(t, []string{"migrate", testPack})
+	must.One(t, result.exitCode)
+	must.StrContains(t, result.cmdOut.String(), "only packs from a local directory can be migrated")
+}
+
 func TestCLI_V1_CLIFlag_Namespace(t *testing.T) {
 	testCases := []struct {
 		desc   string
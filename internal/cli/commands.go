@@ -8,15 +8,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"runtime/trace"
 
+	"github.com/dustin/go-humanize"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad/api"
 	"github.com/posener/complete"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	flag "github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/envloader"
+	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
 
@@ -49,12 +58,38 @@ type baseCommand struct {
 	// flagPlain is whether the output should be in plain mode.
 	flagPlain bool
 
+	// flagQuiet is whether informational output is suppressed, leaving only
+	// the primary output of the command and errors.
+	flagQuiet bool
+
+	// flagVerbose is whether additional detail is output.
+	flagVerbose bool
+
+	// flagColor is the color mode, one of the terminal.ColorModes.
+	flagColor string
+
+	// cacheDir is the path of the cache holding registries, which defaults to
+	// cache.DefaultCachePath once Init is called.
+	cacheDir string
+
+	// inputFile is the path to a JSON file supplying command options, which
+	// are overridden by any options set by flag
+	inputFile string
+
 	// vars sets values for defined input variables
 	vars map[string]string
 
+	// varAsserts are the values variables must resolve to once all overrides
+	// have been applied
+	varAsserts map[string]string
+
 	// envVars sets values for defined input variables from the environment
 	envVars map[string]string
 
+	// envVarPrefix is an additional env var prefix which is stripped to map
+	// env vars into pack variables
+	envVarPrefix string
+
 	// varFiles is an HCL file(s) setting one or more values
 	// for defined input variables
 	varFiles []string
@@ -71,9 +106,76 @@ type baseCommand struct {
 	// one instance of a pack within the same cluster
 	deploymentName string
 
+	// alias prefixes the names of the deployed jobs and the pack name stored
+	// in their metadata, so a pack can be deployed more than once
+	alias string
+
 	// useParserV1 is true when the user supplies the --parser-v1 flag
 	useParserV1 bool
 
+	// templatePlugins are the paths to template plugins whose functions
+	// should be made available when rendering
+	templatePlugins []string
+
+	// varSources are the paths to variable source plugins which are consulted
+	// for the values of variables not otherwise set
+	varSources []string
+
+	// allowExternalLookups is true when the user supplies the
+	// --allow-external-lookups flag, permitting templates to read values
+	// from the Nomad cluster
+	allowExternalLookups bool
+
+	// maxDepth is the maximum depth of the pack dependency tree
+	maxDepth int
+
+	// renderSeed seeds the template functions which produce random output,
+	// such as randAlphaNum and uuidv4
+	renderSeed int64
+
+	// renderSeedSet is true when --render-seed was passed, as zero is a
+	// valid seed
+	renderSeedSet bool
+
+	// deferVars is the pattern matching the names of the HCL2 variable
+	// interpolations left for Nomad to resolve, compiled into deferVarsRe.
+	deferVars   string
+	deferVarsRe *regexp.Regexp
+
+	// renderParallelism is the number of templates rendered concurrently
+	renderParallelism int
+
+	// renderMemLimit is the soft limit on the output held by the templates
+	// being rendered concurrently, such as "256MB", parsed into
+	// renderMemLimitBytes.
+	renderMemLimit      string
+	renderMemLimitBytes int64
+
+	// maxRenderBytes is the maximum size of the output of a single template,
+	// such as "10MB", parsed into maxRenderBytesValue.
+	maxRenderBytes      string
+	maxRenderBytesValue int64
+
+	// showVars is true when the user supplies the render command's
+	// --show-vars flag, annotating the rendered output with the variables
+	// which produced each value
+	showVars bool
+
+	// keepVariablesBlock is true when the user supplies the render command's
+	// --keep-variables-block flag, leaving the HCL2 variable interpolations
+	// in job templates and declaring them in a variables block
+	keepVariablesBlock bool
+
+	// dumpAST is true when the user supplies the hidden render command
+	// --dump-ast flag, writing the parse tree of each template to stderr
+	dumpAST bool
+
+	// tracePath is the path of the file the Go execution trace of the
+	// command is written to, when the user supplies the --trace flag. The
+	// trace is written to traceFile until the command is closed.
+	tracePath string
+	traceFile *os.File
+
 	// args that were present after parsing flags
 	args []string
 
@@ -107,7 +209,7 @@ func (c *baseCommand) Close() error {
 		closer.Close()
 	}
 
-	return nil
+	return c.stopTrace()
 }
 
 func (c *baseCommand) IsWindows() bool {
@@ -188,7 +290,16 @@ func (c *baseCommand) Init(opts ...Option) error {
 	}
 	c.args = baseCfg.Flags.Args()
 
+	if c.inputFile != "" {
+		if err := c.applyInput(baseCfg.Flags); err != nil {
+			return err
+		}
+	}
+
 	c.envVars = envloader.New().GetVarsFromEnv()
+	if c.envVarPrefix != "" {
+		maps.Copy(c.envVars, envloader.NewWithPrefix(c.envVarPrefix).GetVarsFromEnv())
+	}
 
 	// Do any validation after parsing
 	if baseCfg.Validation != nil {
@@ -198,11 +309,68 @@ func (c *baseCommand) Init(opts ...Option) error {
 		}
 	}
 
+	if err := terminal.SetColorMode(c.flagColor); err != nil {
+		return err
+	}
+
 	// Reset the UI to plain if that was set
 	if c.flagPlain {
 		c.ui = terminal.NonInteractiveUI(c.Ctx)
 	}
 
+	// Only output errors when running quietly
+	if c.flagQuiet {
+		c.ui = terminal.QuietUI(c.ui)
+	}
+
+	if c.alias != "" {
+		if err := job.ValidateAlias(c.alias); err != nil {
+			return fmt.Errorf("invalid --alias: %w", err)
+		}
+	}
+
+	if c.deferVars != "" {
+		if c.deferVarsRe, err = regexp.Compile(c.deferVars); err != nil {
+			return fmt.Errorf("invalid --defer-vars pattern: %w", err)
+		}
+		if c.keepVariablesBlock {
+			return errors.New("--keep-variables-block can not be used with --defer-vars")
+		}
+	}
+
+	if baseCfg.Flags.Defined("parallelism") && c.renderParallelism < 1 {
+		return errors.New("--parallelism must be at least 1")
+	}
+	if c.renderMemLimit != "" {
+		limit, err := humanize.ParseBytes(c.renderMemLimit)
+		if err != nil {
+			return fmt.Errorf("invalid --render-mem-limit %q, expected a size such as \"256MB\"", c.renderMemLimit)
+		}
+		c.renderMemLimitBytes = int64(limit)
+	}
+	if c.maxRenderBytes != "" {
+		limit, err := humanize.ParseBytes(c.maxRenderBytes)
+		if err != nil {
+			return fmt.Errorf("invalid --max-render-bytes %q, expected a size such as \"10MB\"", c.maxRenderBytes)
+		}
+		c.maxRenderBytesValue = int64(limit)
+	}
+
+	if c.tracePath != "" {
+		if err := c.startTrace(); err != nil {
+			return err
+		}
+	}
+
+	if c.cacheDir == "" {
+		c.cacheDir = cache.DefaultCachePath()
+	}
+
+	// Expand any variable file directories into the files they contain.
+	if c.varFiles, err = c.expandVarFiles(c.varFiles); err != nil {
+		return err
+	}
+
 	// Perform the cache ensure, but skip if we are running the version
 	// command.
 	if c.cmdKey != "version" {
@@ -212,14 +380,96 @@ func (c *baseCommand) Init(opts ...Option) error {
 	return nil
 }
 
+// expandVarFiles replaces each directory within the passed variable files with
+// the .hcl and .json files it contains, in lexical order. Any other entries in
+// the directory are skipped.
+func (c *baseCommand) expandVarFiles(varFiles []string) ([]string, error) {
+	out := make([]string, 0, len(varFiles))
+	for _, varFile := range varFiles {
+		info, err := os.Stat(varFile)
+		if err != nil || !info.IsDir() {
+			// Missing files are reported when the variables are parsed.
+			out = append(out, varFile)
+			continue
+		}
+
+		entries, err := os.ReadDir(varFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read variable file directory %q: %w", varFile, err)
+		}
+
+		// ReadDir returns the entries sorted by filename.
+		for _, entry := range entries {
+			entryPath := filepath.Join(varFile, entry.Name())
+			switch ext := filepath.Ext(entry.Name()); {
+			case entry.IsDir(), ext != ".hcl" && ext != ".json":
+				if c.flagVerbose {
+					c.ui.Info(fmt.Sprintf("Skipping %s, variable file directories only load .hcl and .json files", entryPath))
+				}
+			default:
+				out = append(out, entryPath)
+			}
+		}
+	}
+	return out, nil
+}
+
+// outputResult outputs part of the primary output of the command, such as a
+// rendered template. When running quietly it is written directly to stdout
+// without styling, as the quiet UI discards all output other than errors.
+func (c *baseCommand) outputResult(msg string, raw ...any) {
+	if c.flagQuiet {
+		if stdout, _, err := c.ui.OutputWriters(); err == nil {
+			msg, _, _ = terminal.Interpret(msg, raw...)
+			fmt.Fprintln(stdout, msg)
+			return
+		}
+	}
+	c.ui.Output(msg, raw...)
+}
+
+// startTrace starts writing the Go execution trace of the command to the file
+// at tracePath, which is created or truncated.
+func (c *baseCommand) startTrace() error {
+	f, err := os.Create(c.tracePath)
+	if err != nil {
+		return fmt.Errorf("failed to create trace file: %w", err)
+	}
+	if err := trace.Start(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start trace: %w", err)
+	}
+	c.traceFile = f
+	return nil
+}
+
+// stopTrace stops the execution trace started by startTrace, if any, and
+// closes the trace file.
+func (c *baseCommand) stopTrace() error {
+	if c.traceFile == nil {
+		return nil
+	}
+	trace.Stop()
+	err := c.traceFile.Close()
+	c.traceFile = nil
+	return err
+}
+
 func (c *baseCommand) ensureCache() error {
+	if info, err := os.Stat(c.cacheDir); err == nil && !info.IsDir() {
+		return fmt.Errorf("cache directory %q is not a directory", c.cacheDir)
+	}
+
 	// Creates global cache
 	_, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
+		Path:   c.cacheDir,
 		Logger: c.ui,
 	})
-	if err != nil {
-		return err
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("permission denied creating cache directory %q, set --cache-dir or %s to a writable directory", c.cacheDir, EnvCacheDir)
+	case err != nil:
+		return fmt.Errorf("failed to create cache directory %q: %w", c.cacheDir, err)
 	}
 
 	return nil
@@ -229,6 +479,53 @@ func (c *baseCommand) ensureCache() error {
 // to configure the set with your own custom options.
 func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 	set := flag.NewSets()
+	{
+		f := set.NewSet("Global Options")
+		f.BoolVarP(&flag.BoolVarP{
+			BoolVar: &flag.BoolVar{
+				Name:    "quiet",
+				Target:  &c.flagQuiet,
+				Default: false,
+				Usage: `Suppress headers, status updates, and informational and
+						success messages, leaving the results of the command,
+						such as rendered templates and tables, along with errors
+						and warnings, which are written to stderr. Exit codes are
+						unaffected.`,
+			},
+			Shorthand: "q",
+		})
+		f.BoolVarP(&flag.BoolVarP{
+			BoolVar: &flag.BoolVar{
+				Name:    "verbose",
+				Target:  &c.flagVerbose,
+				Default: false,
+				Usage: `Output additional detail, such as unchanged fields in plan
+						diffs and the files skipped when loading a directory of
+						variable files.`,
+			},
+			Shorthand: "v",
+		})
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "color",
+			Target:  &c.flagColor,
+			Values:  terminal.ColorModes,
+			Default: terminal.ColorAuto,
+			EnvVar:  EnvColor,
+			Usage: `Controls whether output is styled with color. The auto mode
+					uses color only when writing to a terminal, always uses it
+					even when output is piped, such as to less -R, and never
+					disables it.`,
+		})
+		f.StringVar(&flag.StringVar{
+			Name:    "cache-dir",
+			Target:  &c.cacheDir,
+			Default: "",
+			EnvVar:  EnvCacheDir,
+			Usage: `Path of the directory holding the registries added by the
+					registry add command, which is created if missing. Defaults
+					to the nomad/packs directory within the user cache directory.`,
+		})
+	}
 	if bit&flagSetOperation != 0 {
 		f := set.NewSet("Operation Options")
 		f.StringSliceVarP(&flag.StringSliceVarP{
@@ -238,12 +535,35 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 				Default: make([]string, 0),
 				Usage: `Specifies the path to a variable override file. This can
 						be provided multiple times on a single command to result
-						in a list of files.`,
+						in a list of files. Passing a directory loads each .hcl
+						and .json file within it, in lexical order.`,
 				Completion: complete.PredictOr(complete.PredictFiles("*.var"), complete.PredictFiles("*.hcl")),
 			},
 			Shorthand: "f",
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:    "input",
+			Target:  &c.inputFile,
+			Default: "",
+			Usage: `Specifies the path to a JSON file supplying the pack name,
+					registry, variables, Nomad namespace, and other options of
+					the command in one structured file. Options set by flag
+					override those in the file.`,
+			Completion: complete.PredictFiles("*.json"),
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "env-var-prefix",
+			Target:  &c.envVarPrefix,
+			Default: "",
+			Usage: `Sets pack variables from all env vars starting with the
+					prefix, in addition to those starting with NOMAD_PACK_VAR_.
+					Variable names are the lowercased remainder of the env var
+					name, so with a prefix of PACK_VAR_ the env var
+					PACK_VAR_JOB_NAME sets the job_name variable.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "ignore-missing-vars",
 			Target:  &c.ignoreMissingVars,
@@ -260,6 +580,17 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 					syntax and can be specified multiple times per command.`,
 		})
 
+		f.StringMapVar(&flag.StringMapVar{
+			Name:    "assert-var",
+			Target:  &c.varAsserts,
+			Default: make(map[string]string),
+			Usage: `Fails the command unless the variable resolves to exactly
+					the specified value once all variable files, flags, and env
+					vars are applied. Uses the same form as --var and can be
+					specified multiple times per command; all failed assertions
+					are reported together.`,
+		})
+
 		f.StringVar(&flag.StringVar{
 			Name:    "name",
 			Target:  &c.deploymentName,
@@ -278,6 +609,18 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 					destroy commands.`,
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:    "alias",
+			Target:  &c.alias,
+			Default: "",
+			Usage: `Prefixes the names of the pack's jobs, and the pack name
+					stored in their metadata, with the alias. This allows the
+					same pack to be run multiple times within a cluster, such
+					as once per tenant, with each instance managed by passing
+					the same alias to the plan, status, stop, and destroy
+					commands. The resulting job names must be DNS-safe.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "parser-v1",
 			Target:  &c.useParserV1,
@@ -286,6 +629,113 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 			enables pack to run packs for earlier versions while you are
 			migrating them to the new syntax`,
 		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:    "template-plugin",
+			Target:  &c.templatePlugins,
+			Default: make([]string, 0),
+			Usage: `Specifies the path to an executable template plugin whose
+					advertised functions are made available to templates.
+					Plugins must reside within the directory set by the
+					NOMAD_PACK_PLUGIN_DIR environment variable, or the default
+					plugin directory within the user configuration directory.
+					This can be provided multiple times.`,
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:    "var-source",
+			Target:  &c.varSources,
+			Default: make([]string, 0),
+			Usage: `Specifies the path to an executable variable source plugin,
+					which is asked for the value of each variable not set by a
+					variable file, --var, or env var. Plugins are consulted in
+					order, and must reside within the same directory as
+					template plugins. This can be provided multiple times.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "allow-external-lookups",
+			Target:  &c.allowExternalLookups,
+			Default: false,
+			Usage: `Allows templates to read values from the target Nomad
+					cluster at render time, using functions such as nomadVar.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "max-depth",
+			Target:  &c.maxDepth,
+			Default: manager.DefaultMaxDependencyDepth,
+			Usage: `The maximum depth of the pack dependency tree, where the
+					dependencies of the pack are at a depth of one. Loading a
+					pack whose dependency tree is deeper results in an error.`,
+		})
+
+		f.Int64Var(&flag.Int64Var{
+			Name:    "render-seed",
+			Target:  &c.renderSeed,
+			Default: 0,
+			SetHook: func(int64) { c.renderSeedSet = true },
+			Usage: `Seeds the template functions which produce random output,
+					such as randAlphaNum, randInt, and uuidv4, so that a given
+					seed always renders identical output. If not set, the
+					system's cryptographically secure source of randomness is
+					used.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "parallelism",
+			Target:  &c.renderParallelism,
+			Default: 1,
+			Usage: `The number of templates rendered concurrently. Rendering
+					templates in parallel is quicker for packs with many
+					templates, at the cost of holding more rendered output in
+					memory at once.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "render-mem-limit",
+			Target:  &c.renderMemLimit,
+			Default: "",
+			Usage: `An approximate limit on the memory used by the output of
+					the templates being rendered concurrently, such as
+					"256MB". New templates are not started while the output
+					of those rendering exceeds the limit. This is a soft
+					limit: templates already rendering are never paused, and
+					a single template may exceed it.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "max-render-bytes",
+			Target:  &c.maxRenderBytes,
+			Default: defaultMaxRenderBytes,
+			Usage: `The maximum size of the output of a single template, such
+					as "10MB". A template whose output exceeds it stops
+					rendering and fails with an error naming it, guarding
+					against templates which produce runaway output. Set to 0
+					to disable the limit.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "trace",
+			Target:  &c.tracePath,
+			Default: "",
+			Usage: `Path of a file to write a Go execution trace of the command
+					to, for debugging the performance of large packs. The
+					trace marks fetching registry packs, resolving
+					variables, and rendering each template, and can be
+					viewed with "go tool trace".`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "defer-vars",
+			Target:  &c.deferVars,
+			Default: "",
+			Usage: `A regular expression matching the names of HCL2 variable
+					interpolations, such as ${var.image}, which are left in
+					job templates for Nomad to resolve when the job is
+					submitted. When set, all other interpolations are
+					resolved using the pack variable of the same name.`,
+		})
 	}
 	if bit&flagSetNeedsApproval != 0 {
 		f := set.NewSet("Approval Options")
@@ -399,6 +849,11 @@ func (c *baseCommand) helpUsageMessage() string {
 	return fmt.Sprintf(`See "nomad-pack %s --help"`, c.cmdKey)
 }
 
+// defaultMaxRenderBytes is the default maximum size of the output of a single
+// template. It is far beyond any reasonable job specification, so it only
+// stops templates producing runaway output.
+const defaultMaxRenderBytes = "10MB"
+
 // flagSetBit is used with baseCommand.flagSet
 type flagSetBit uint
 
@@ -39,7 +39,7 @@ func (c *DestroyCommand) Run(args []string) int {
 }
 
 func (c *DestroyCommand) Flags() *flag.Sets {
-	return c.flagSet(flagSetOperation|flagSetNomadClient, func(set *flag.Sets) {
+	return c.flagSet(flagSetOperation|flagSetNeedsApproval|flagSetNomadClient, func(set *flag.Sets) {
 		c.packConfig = &cache.PackConfig{}
 
 		set.HideUnusedFlags("Operation Options", []string{"var", "var-file"})
@@ -90,6 +90,9 @@ func (c *DestroyCommand) Help() string {
 	# Stop an example pack in deployment "dev" and delete it from the cluster
 	nomad-pack destroy example --name=dev
 
+	# Destroy an example pack in deployment "dev" without confirmation prompts
+	nomad-pack destroy example --name=dev --auto-approve
+
 	# Stop and delete an example pack in deployment "dev" that has a job named "test"
 	# If the same pack has been installed in deployment "dev" but overriding the job
 	# name to "hello", only "test" will be deleted
@@ -103,7 +106,9 @@ func (c *DestroyCommand) Help() string {
 	By default, the destroy command will delete ALL jobs in the pack deployment.
 	If a pack was run using var overrides to specify the job name(s), the var
 	overrides MUST be provided when destroying the pack to guarantee nomad-pack
-	targets the correct job(s) in the pack deployment.
+	targets the correct job(s) in the pack deployment. The jobs and allocations
+	to be deleted are summarized, and the pack name must be typed to confirm
+	unless "--auto-approve" is set.
 
 ` + c.GetExample() + c.Flags().Help())
 }
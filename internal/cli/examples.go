@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"strings"
+)
+
+// examplePack is the pack name used by runnable examples, which the tests
+// replace with the path to a copy of their fixture pack.
+const examplePack = "example"
+
+// commandExample is an example invocation of a command, shown in its help
+// text. Runnable examples are executed by the tests, so they cannot drift from
+// the flags and behavior of the command.
+type commandExample struct {
+	// description explains the example, and is shown as a comment above it.
+	// It may span several lines.
+	description string
+
+	// args are the arguments passed to nomad-pack, starting with the command
+	// name, as they are received after shell quoting is removed.
+	args []string
+
+	// runnable marks the examples the tests can execute against a fixture
+	// pack and a Nomad agent. Examples relying on registries, refs, or files
+	// which do not exist in the tests are not runnable.
+	runnable bool
+
+	// exitCode is the exit code the example returns when run by the tests.
+	exitCode int
+}
+
+// exampleCommand is implemented by commands whose examples are structured,
+// rather than written into the Example string of their baseCommand.
+type exampleCommand interface {
+	examples() []commandExample
+}
+
+// formatExamples formats the examples for the Example string of a command.
+func formatExamples(examples []commandExample) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for i, ex := range examples {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range strings.Split(ex.description, "\n") {
+			b.WriteString("\t# " + line + "\n")
+		}
+
+		args := make([]string, len(ex.args))
+		for j, arg := range ex.args {
+			args[j] = quoteExampleArg(arg)
+		}
+		b.WriteString("\tnomad-pack " + strings.Join(args, " ") + "\n")
+	}
+	b.WriteString("\t")
+	return b.String()
+}
+
+// quoteExampleArg quotes the value of an example argument when a shell would
+// otherwise split or expand it. Double quotes are preferred, unless the value
+// contains characters which are special within them.
+func quoteExampleArg(arg string) string {
+	prefix, value := "", arg
+	if name, v, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "-") {
+		prefix, value = name+"=", v
+	}
+
+	if !strings.ContainsAny(value, " \t\"'{}[]*?$`\\;&|<>()#") {
+		return arg
+	}
+	if !strings.ContainsAny(value, "\"$`\\") {
+		return prefix + `"` + value + `"`
+	}
+	return prefix + "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
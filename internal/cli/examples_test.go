@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/nomad/command/agent"
+	"github.com/shoenig/test/must"
+
+	ct "github.com/hashicorp/nomad-pack/internal/cli/testhelper"
+	flag "github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/logging"
+)
+
+// TestCLI_Examples runs each runnable example of the commands against a copy
+// of the test pack, so the examples shown in the help text stay accurate.
+func TestCLI_Examples(t *testing.T) {
+	t.Parallel()
+
+	base, commands := Commands(context.Background())
+	defer base.Close()
+
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		cmd, err := commands[name]()
+		must.NoError(t, err)
+
+		ec, ok := cmd.(exampleCommand)
+		if !ok {
+			continue
+		}
+
+		// Commands which connect to Nomad are run against a test agent.
+		var needsAgent bool
+		if fc, ok := cmd.(interface{ Flags() *flag.Sets }); ok {
+			needsAgent = fc.Flags().Defined("address")
+		}
+
+		for i, ex := range ec.examples() {
+			if !ex.runnable {
+				continue
+			}
+
+			t.Run(fmt.Sprintf("%s/%d", name, i), func(t *testing.T) {
+				packPath := path.Join(t.TempDir(), testPack)
+				must.NoError(t, filesystem.CopyDir(getTestPackPath(t, testPack), packPath, false, logging.Default()))
+
+				args := slices.Clone(ex.args)
+				for j, arg := range args {
+					if arg == examplePack {
+						args[j] = packPath
+					}
+				}
+
+				run := func(result PackCommandResult) {
+					must.Eq(t, ex.exitCode, result.exitCode, must.Sprintf("cmdOut:\n%v", result.cmdOut.String()))
+				}
+				if !needsAgent {
+					t.Parallel()
+					run(runPackCmd(t, args))
+					return
+				}
+				ct.HTTPTestParallel(t, ct.WithDefaultConfig(), func(s *agent.TestAgent) {
+					run(runTestPackCmd(t, s, args))
+				})
+			})
+		}
+	}
+}
+
+func Test_FormatExamples(t *testing.T) {
+	out := formatExamples([]commandExample{
+		{description: "Render a pack", args: []string{"render", examplePack}},
+		{
+			description: "Render a pack with variables\nfrom the command line",
+			args:        []string{"render", examplePack, "--var=count=3", `--var=env={"A": "b"}`, "--render-only=*.conf"},
+		},
+	})
+	must.Eq(t, `
+	# Render a pack
+	nomad-pack render example
+
+	# Render a pack with variables
+	# from the command line
+	nomad-pack render example --var=count=3 --var='env={"A": "b"}' --render-only="*.conf"
+	`, out)
+}
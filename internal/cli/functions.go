@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/plugin"
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// FunctionsCommand lists the template functions available to pack templates.
+type FunctionsCommand struct {
+	*baseCommand
+	format string
+}
+
+const (
+	functionsFormatTable = "table"
+	functionsFormatJSON  = "json"
+)
+
+func (c *FunctionsCommand) Run(args []string) int {
+	c.cmdKey = "functions"
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithNoArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	var plugins []*renderer.TemplatePlugin
+	for _, pluginPath := range c.templatePlugins {
+		plugin, err := renderer.LoadTemplatePlugin(pluginPath, plugin.DefaultDir())
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to load template plugin")
+			return 1
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	funcs := renderer.Functions(plugins)
+
+	if c.format == functionsFormatJSON {
+		// Write directly to stdout, so the output is kept when running quietly.
+		stdout, _, err := c.ui.OutputWriters()
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to get output writers")
+			return 1
+		}
+		b, err := json.MarshalIndent(funcs, "", "  ")
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to encode template functions")
+			return 1
+		}
+		fmt.Fprintln(stdout, string(b))
+		return 0
+	}
+
+	table := terminal.NewTable("NAME", "SOURCE", "DESCRIPTION")
+	for _, fn := range funcs {
+		table.Rows = append(table.Rows, []terminal.TableEntry{
+			{Value: fn.Name},
+			{Value: fn.Source},
+			{Value: fn.Description},
+		})
+	}
+	c.ui.Table(table)
+
+	return 0
+}
+
+func (c *FunctionsCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Functions Options")
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:    "template-plugin",
+			Target:  &c.templatePlugins,
+			Default: make([]string, 0),
+			Usage: `Specifies the path to an executable template plugin whose
+					advertised functions are included in the list. This can be
+					provided multiple times.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.format,
+			Values:  []string{functionsFormatTable, functionsFormatJSON},
+			Default: functionsFormatTable,
+			Usage: `Output format for the list of functions. The json format
+					writes an array of objects with the name, description, and
+					source of each function, for use by editor tooling.`,
+		})
+	})
+}
+
+func (c *FunctionsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *FunctionsCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *FunctionsCommand) Synopsis() string {
+	return "List the functions available to pack templates."
+}
+
+func (c *FunctionsCommand) Help() string {
+	c.Example = `
+	# List the template functions with their descriptions
+	nomad-pack functions
+
+	# Include the functions provided by a template plugin
+	nomad-pack functions --template-plugin=vault_helpers
+
+	# Output the template functions as JSON for use by other tooling
+	nomad-pack functions --format=json
+	`
+	return formatHelp(`
+	Usage: nomad-pack functions [options]
+
+	List the functions available to pack templates, including those provided
+	by template plugins.
+
+` + c.GetExample() + c.Flags().Help())
+}
@@ -131,19 +131,27 @@ func (c *generateVarFileCommand) Run(args []string) int {
 	c.packConfig.Name = c.args[0]
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
+	defer readLockCache(c.packConfig, c.ui)()
 
 	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
 		return 1
 	}
 
+	// Packs passed as zip files are extracted, and used from there.
+	cleanup, err := extractPackZip(c.packConfig, c.ui, errorContext)
+	if err != nil {
+		return 1
+	}
+	defer cleanup()
+
 	packManager := generatePackManager(c.baseCommand, nil, c.packConfig)
 	renderOutput, err := renderVariableOverrideFile(packManager, c.baseCommand.ui, errorContext)
 	if err != nil {
 		return 1
 	}
 
-	c.ui.Output(renderOutput.AsOverrideFile())
+	c.outputResult(renderOutput.AsOverrideFile())
 	if c.renderTo != "" {
 		if err := c.validateOutFile(); err != nil {
 			c.ui.Error(err.Error())
@@ -226,7 +226,7 @@ var helpText = map[string][2]string{
 	},
 	"info": {
 		"Info gets information on a pack",
-		`The "info" command reads from a pack's metadata.hcl and variables.hcl
+		`The "info" command reads from a pack's metadata and variables.hcl
 		files and prints out the details of a pack.`,
 	},
 	"destroy": {
@@ -260,4 +260,10 @@ var helpText = map[string][2]string{
 		`The "registry list" command lists all registries and associated packs
 		that have been downloaded to the local environment.`,
 	},
+	"registry update": {
+		"Updates pack registries from their sources",
+		`The "registry update" command fetches a registry, or every registry,
+		again from the source it was added from, at each of its refs or at a
+		specific ref, and reports what changed.`,
+	},
 }
@@ -4,8 +4,15 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"runtime/trace"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/nomad/api"
@@ -15,13 +22,17 @@ import (
 	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
 	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/source"
 	"github.com/hashicorp/nomad-pack/internal/runner"
 	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
 
 // get an initialized error context for a command that accepts pack args.
-func initPackCommand(cfg *cache.PackConfig) (errorContext *errors.UIErrorContext) {
+func (c *baseCommand) initPackCommand(cfg *cache.PackConfig) (errorContext *errors.UIErrorContext) {
+	if cfg.CachePath == "" {
+		cfg.CachePath = c.cacheDir
+	}
 	cfg.Init()
 
 	// Generate our UI error context.
@@ -35,6 +46,101 @@ func initPackCommand(cfg *cache.PackConfig) (errorContext *errors.UIErrorContext
 	return
 }
 
+// jobFileDeployment reads the rendered Nomad job specification passed with
+// --job-file, which run and plan deploy in place of a rendered pack. The job
+// is returned as the only template of the deployment, keyed by its file name,
+// along with the runner config labelling it with the pack metadata. The file
+// name without its extensions is used as the pack name, and as the default
+// deployment name.
+func (c *baseCommand) jobFileDeployment(jobFile string) (map[string]string, *runner.Config, *errors.UIErrorContext, error) {
+	errorContext := errors.NewUIErrorContext()
+	errorContext.Add(errors.UIContextPrefixJobFile, jobFile)
+
+	absPath, err := filepath.Abs(jobFile)
+	if err != nil {
+		return nil, nil, errorContext, err
+	}
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, nil, errorContext, err
+	}
+
+	fileName := filepath.Base(absPath)
+	name, _, _ := strings.Cut(fileName, ".")
+	if c.deploymentName == "" {
+		c.deploymentName = job.AliasedName(c.alias, name)
+	}
+	errorContext.Add(errors.UIContextPrefixDeploymentName, c.deploymentName)
+
+	runnerCfg := &runner.Config{
+		PackName:       job.AliasedName(c.alias, name),
+		PathPath:       absPath,
+		PackRef:        cache.DevRef,
+		DeploymentName: c.deploymentName,
+		RegistryName:   cache.DevRegistryName,
+		Alias:          c.alias,
+	}
+	return map[string]string{fileName: string(content)}, runnerCfg, errorContext, nil
+}
+
+// warmPack asks the daemon started by "nomad-pack serve", if one is running,
+// to fetch a registry pack into the cache when it is not already there.
+// Failures are only warned about, so the usual error is reported when the
+// pack is not found in the cache.
+func warmPack(cfg *cache.PackConfig, ui terminal.UI) {
+	if cfg.Registry == cache.DevRegistryName {
+		return
+	}
+	defer trace.StartRegion(context.Background(), "fetch registry pack").End()
+	ok, err := cache.RequestWarmPack(cache.DaemonSocketPath(cfg.CachePath), cfg)
+	if ok && err != nil {
+		ui.Warning(fmt.Sprintf("Failed to fetch pack using the daemon: %s", err))
+	}
+}
+
+// readLockCache takes the read lock of the cache holding a registry pack, so
+// the daemon started by "nomad-pack serve" does not swap the registry out while
+// the pack is in use. Failing to take the lock is only warned about. The
+// returned function releases the lock.
+func readLockCache(cfg *cache.PackConfig, ui terminal.UI) func() {
+	if cfg.Registry == cache.DevRegistryName {
+		return func() {}
+	}
+	unlock, err := cache.ReadLock(cfg.CachePath)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Failed to lock the cache: %s", err))
+		return func() {}
+	}
+	return unlock
+}
+
+// extractPackZip extracts a pack passed as a path to a zip file into a
+// temporary directory, and points the pack config at it. The returned function
+// removes the directory, and must be called once the pack is no longer used.
+// Packs which are not zip files are left untouched.
+func extractPackZip(cfg *cache.PackConfig, ui terminal.UI, errCtx *errors.UIErrorContext) (func(), error) {
+	if cfg.Registry != cache.DevRegistryName || !cache.IsPackZip(cfg.Path) {
+		return func() {}, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "nomad-pack-zip-")
+	if err != nil {
+		ui.ErrorWithContext(err, "failed to create temporary directory", errCtx.GetAll()...)
+		return nil, err
+	}
+
+	packPath, err := cache.ExtractPackZip(cfg.Path, tmpDir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		ui.ErrorWithContext(err, "failed to extract pack", errCtx.GetAll()...)
+		return nil, err
+	}
+
+	cfg.Path = packPath
+	cfg.Name = path.Base(packPath)
+	return func() { os.RemoveAll(tmpDir) }, nil
+}
+
 // generatePackManager is used to generate the pack manager for this Nomad Pack run.
 func generatePackManager(c *baseCommand, client *api.Client, packCfg *cache.PackConfig) *manager.PackManager {
 	// TODO: Refactor to have manager use cache.
@@ -43,7 +149,28 @@ func generatePackManager(c *baseCommand, client *api.Client, packCfg *cache.Pack
 		VariableFiles:   c.varFiles,
 		VariableCLIArgs: c.vars,
 		VariableEnvVars: c.envVars,
+		VariableAsserts: c.varAsserts,
 		UseParserV1:     c.useParserV1,
+		TemplatePlugins: c.templatePlugins,
+
+		VariableSourcePlugins: c.varSources,
+
+		AllowExternalLookups: c.allowExternalLookups,
+		MaxDependencyDepth:   c.maxDepth,
+		RenderSeed:           c.renderSeed,
+		RenderSeedSet:        c.renderSeedSet,
+		DeferVars:            c.deferVarsRe,
+		ShowVars:             c.showVars,
+		KeepVariablesBlock:   c.keepVariablesBlock,
+		RenderParallelism:    c.renderParallelism,
+		RenderMemLimit:       c.renderMemLimitBytes,
+		RenderMaxFileBytes:   c.maxRenderBytesValue,
+		SecretReader:         source.NewVaultReader(),
+	}
+	if c.dumpAST {
+		if _, stderr, err := c.ui.OutputWriters(); err == nil {
+			cfg.DumpAST = stderr
+		}
 	}
 	return manager.NewPackManager(&cfg, client)
 }
@@ -149,19 +276,45 @@ func renderPack(
 	ignoreMissingVars bool,
 	errCtx *errors.UIErrorContext,
 ) (*renderer.Rendered, error) {
-	r, err := manager.ProcessTemplates(renderAux, format, ignoreMissingVars)
+	r, err := manager.ProcessTemplates(renderAux, format, ignoreMissingVars, false)
 	if err != nil {
-		packName := manager.PackName()
-		errCtx.Add(errors.UIContextPrefixPackName, packName)
-		for i := range err {
-			err[i].Context.Append(errCtx)
-			ui.ErrorWithContext(err[i].Err, "failed to process pack", err[i].Context.GetAll()...)
-		}
+		reportRenderErrors(manager, ui, err, errCtx)
 		return nil, errors.New("failed to render")
 	}
 	return r, nil
 }
 
+// checkEmptyRender returns ErrEmptyRender unless at least one of the rendered
+// templates is a job specification. The templates which rendered to only
+// whitespace are added to the error context, so it is clear which collapsed.
+func checkEmptyRender(r *renderer.Rendered, renders []string, errCtx *errors.UIErrorContext) error {
+	for _, content := range renders {
+		if _, ok := renderedJobName(content); ok {
+			return nil
+		}
+	}
+	if empty := slices.Sorted(maps.Keys(r.EmptyRenders())); len(empty) > 0 {
+		errCtx.Add("Empty Templates: ", strings.Join(empty, ", "))
+	}
+	return errors.ErrEmptyRender
+}
+
+// reportRenderErrors outputs each of the errors encountered while processing
+// the pack templates.
+func reportRenderErrors(
+	manager *manager.PackManager,
+	ui terminal.UI,
+	errs []*errors.WrappedUIContext,
+	errCtx *errors.UIErrorContext,
+) {
+	packName := manager.PackName()
+	errCtx.Add(errors.UIContextPrefixPackName, packName)
+	for i := range errs {
+		errs[i].Context.Append(errCtx)
+		ui.ErrorWithContext(errs[i].Err, "failed to process pack", errs[i].Context.GetAll()...)
+	}
+}
+
 // TODO: This needs to be on a domain specific pkg rather than a UI helpers file.
 // This will be possible once we create a logger interface that can be passed
 // between layers.
@@ -214,7 +367,7 @@ func parseJob(cmd *baseCommand, hcl string, errCtx *errors.UIErrorContext) (*api
 // Generates a deployment name if not specified. Default is pack@version.
 func getDeploymentName(c *baseCommand, cfg *cache.PackConfig) string {
 	if c.deploymentName == "" {
-		return cache.AppendRef(cfg.Name, cfg.Ref)
+		return cache.AppendRef(job.AliasedName(c.alias, cfg.Name), cfg.Ref)
 	}
 	return c.deploymentName
 }
@@ -222,7 +375,7 @@ func getDeploymentName(c *baseCommand, cfg *cache.PackConfig) string {
 // TODO: Move to a domain specific package.
 func getPackJobsByDeploy(c *api.Client, cfg *cache.PackConfig, deploymentName string) ([]*api.Job, error) {
 	jobsApi := c.Jobs()
-	jobs, _, err := jobsApi.List(&api.QueryOptions{})
+	jobs, _, err := jobsApi.List(&api.QueryOptions{Namespace: api.AllNamespacesNamespace})
 	if err != nil {
 		return nil, fmt.Errorf("error finding jobs for pack %s: %s", cfg.Name, err)
 	}
@@ -233,9 +386,9 @@ func getPackJobsByDeploy(c *api.Client, cfg *cache.PackConfig, deploymentName st
 	var packJobs []*api.Job
 	hasOtherDeploys := false
 	for _, jobStub := range jobs {
-		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{})
+		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{Namespace: jobStub.Namespace})
 		if err != nil {
-			return nil, fmt.Errorf("error retrieving job %s for pack %s: %s", *nomadJob.ID, cfg.Name, err)
+			return nil, fmt.Errorf("error retrieving job %s for pack %s: %s", jobStub.ID, cfg.Name, err)
 		}
 
 		if nomadJob.Meta != nil {
@@ -258,17 +411,54 @@ func getPackJobsByDeploy(c *api.Client, cfg *cache.PackConfig, deploymentName st
 				}
 			}
 		}
+	}
 
-		if len(packJobs) == 0 && hasOtherDeploys {
-			// TODO: the aesthetics here could be better. This error line is very long.
-			return nil, fmt.Errorf(
-				"pack %q running but not in deployment %q. Run \"nomad-pack status %s\" for more information",
-				cfg.Name, deploymentName, cfg.Name)
-		}
+	if len(packJobs) == 0 && hasOtherDeploys {
+		// TODO: the aesthetics here could be better. This error line is very long.
+		return nil, fmt.Errorf(
+			"pack %q running but not in deployment %q. Run \"nomad-pack status %s\" for more information",
+			cfg.Name, deploymentName, cfg.Name)
 	}
 	return packJobs, nil
 }
 
+// getPackDeploymentByID returns the name of the pack deployment whose jobs
+// created the Nomad deployment with the passed ID or ID prefix. An error is
+// returned if the deployment does not belong to a job managed by the pack.
+func getPackDeploymentByID(c *api.Client, cfg *cache.PackConfig, deploymentID string) (string, error) {
+	deployments, _, err := c.Deployments().PrefixList(deploymentID)
+	if err != nil {
+		return "", fmt.Errorf("error finding deployment %s: %s", deploymentID, err)
+	}
+
+	var jobDeploymentName string
+	for _, d := range deployments {
+		nomadJob, _, err := c.Jobs().Info(d.JobID, &api.QueryOptions{Namespace: d.Namespace})
+		if err != nil {
+			return "", fmt.Errorf("error retrieving job %s for deployment %s: %s", d.JobID, d.ID, err)
+		}
+
+		jobPack, packOk := nomadJob.Meta[job.PackNameKey]
+		deploymentName, deployOk := nomadJob.Meta[job.PackDeploymentNameKey]
+		if !packOk || !deployOk || jobPack != cfg.Name {
+			continue
+		}
+		if cfg.Registry != "" && nomadJob.Meta[job.PackRegistryKey] != cfg.Registry {
+			continue
+		}
+
+		if jobDeploymentName != "" && jobDeploymentName != deploymentName {
+			return "", fmt.Errorf("deployment prefix %q matched multiple pack deployments", deploymentID)
+		}
+		jobDeploymentName = deploymentName
+	}
+
+	if jobDeploymentName == "" {
+		return "", fmt.Errorf("deployment %q not found among jobs of pack %q", deploymentID, cfg.Name)
+	}
+	return jobDeploymentName, nil
+}
+
 // TODO: Needs code review. Will likely move if we decide to move client management
 // out of CLI commands.
 func generateRunner(client *api.Client, packType, cliCfg any, runnerCfg *runner.Config) (runner.Runner, error) {
@@ -311,16 +501,16 @@ func hasVarOverrides(c *baseCommand) bool {
 // TODO: Move to a domain specific package.
 func getDeployedPacks(c *api.Client) (map[string]map[string]struct{}, error) {
 	jobsApi := c.Jobs()
-	jobs, _, err := jobsApi.List(&api.QueryOptions{})
+	jobs, _, err := jobsApi.List(&api.QueryOptions{Namespace: api.AllNamespacesNamespace})
 	if err != nil {
 		return nil, fmt.Errorf("error finding jobs: %s", err)
 	}
 
 	packRegistryMap := map[string]map[string]struct{}{}
 	for _, jobStub := range jobs {
-		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{})
+		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{Namespace: jobStub.Namespace})
 		if err != nil {
-			return nil, fmt.Errorf("error retrieving job %s: %s", *nomadJob.ID, err)
+			return nil, fmt.Errorf("error retrieving job %s: %s", jobStub.ID, err)
 		}
 
 		if nomadJob.Meta != nil {
@@ -355,7 +545,9 @@ type JobStatusInfo struct {
 	registryName   string
 	deploymentName string
 	jobID          string
+	namespace      string
 	status         string
+	healthy        bool
 }
 
 // TODO: Move to a domain specific package.
@@ -370,7 +562,7 @@ type JobStatusError struct {
 // TODO: Move to a domain specific package.
 func getDeployedPackJobs(c *api.Client, cfg *cache.PackConfig, deploymentName string) ([]JobStatusInfo, []JobStatusError, error) {
 	jobsApi := c.Jobs()
-	jobs, _, err := jobsApi.List(&api.QueryOptions{})
+	jobs, _, err := jobsApi.List(&api.QueryOptions{Namespace: api.AllNamespacesNamespace})
 	if err != nil {
 		return nil, nil, fmt.Errorf("error finding jobs for pack %s: %s", cfg.Name, err)
 	}
@@ -378,7 +570,7 @@ func getDeployedPackJobs(c *api.Client, cfg *cache.PackConfig, deploymentName st
 	var packJobs []JobStatusInfo
 	var jobErrs []JobStatusError
 	for _, jobStub := range jobs {
-		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{})
+		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{Namespace: jobStub.Namespace})
 		if err != nil {
 			jobErrs = append(jobErrs, JobStatusError{
 				jobID:    jobStub.ID,
@@ -398,12 +590,18 @@ func getDeployedPackJobs(c *api.Client, cfg *cache.PackConfig, deploymentName st
 						continue
 					}
 				}
+				status := *nomadJob.Status
+				if keptStopped(nomadJob) {
+					status = "stopped"
+				}
 				packJobs = append(packJobs, JobStatusInfo{
 					packName:       cfg.Name,
 					registryName:   jobMeta[job.PackRegistryKey],
 					deploymentName: jobMeta[job.PackDeploymentNameKey],
 					jobID:          *nomadJob.ID,
-					status:         *nomadJob.Status,
+					namespace:      jobStub.Namespace,
+					status:         status,
+					healthy:        jobHealthy(*nomadJob.Status, jobStub.JobSummary),
 				})
 			}
 		}
@@ -411,6 +609,64 @@ func getDeployedPackJobs(c *api.Client, cfg *cache.PackConfig, deploymentName st
 	return packJobs, jobErrs, nil
 }
 
+// getStalePackJobs returns the jobs deployed by nomad-pack which have since
+// been stopped, such as by stopping them outside nomad-pack. These keep their
+// pack metadata until they are purged, so continue to be shown by status. Jobs
+// stopped using stop --keep-meta are not stale.
+func getStalePackJobs(c *api.Client) ([]JobStatusInfo, error) {
+	jobsApi := c.Jobs()
+	jobs, _, err := jobsApi.List(&api.QueryOptions{Namespace: api.AllNamespacesNamespace})
+	if err != nil {
+		return nil, fmt.Errorf("error finding jobs: %s", err)
+	}
+
+	var staleJobs []JobStatusInfo
+	for _, jobStub := range jobs {
+		if !jobStub.Stop {
+			continue
+		}
+
+		nomadJob, _, err := jobsApi.Info(jobStub.ID, &api.QueryOptions{Namespace: jobStub.Namespace})
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving job %s: %s", jobStub.ID, err)
+		}
+
+		packName, ok := nomadJob.Meta[job.PackNameKey]
+		if !ok || keptStopped(nomadJob) {
+			continue
+		}
+		staleJobs = append(staleJobs, JobStatusInfo{
+			packName:       packName,
+			registryName:   nomadJob.Meta[job.PackRegistryKey],
+			deploymentName: nomadJob.Meta[job.PackDeploymentNameKey],
+			jobID:          *nomadJob.ID,
+			namespace:      jobStub.Namespace,
+			status:         *nomadJob.Status,
+		})
+	}
+	return staleJobs, nil
+}
+
+// keptStopped reports whether the job was stopped using stop --keep-meta,
+// retaining its pack metadata until it is run again.
+func keptStopped(j *api.Job) bool {
+	return j.Stop != nil && *j.Stop && j.Meta[job.PackStoppedKey] == "true"
+}
+
+// jobHealthy reports whether a job is running, with every task group having
+// running allocations and none waiting to be placed or started.
+func jobHealthy(status string, summary *api.JobSummary) bool {
+	if status != "running" || summary == nil || len(summary.Summary) == 0 {
+		return false
+	}
+	for _, tg := range summary.Summary {
+		if tg.Running == 0 || tg.Queued > 0 || tg.Starting > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // clientOptsFromCLI emits a slice of v1.ClientOptions based on the environment
 // and flag set passed to the command.
 func clientOptsFromCLI(c *baseCommand) *api.Config {
@@ -510,3 +766,45 @@ func clientOptsFromFlags(c *baseCommand, conf *api.Config) {
 		conf.TLSConfig.Insecure = true
 	}
 }
+
+// outputPackOutputs displays the named output values defined by a pack's
+// output template. The json format emits the outputs as a single object,
+// otherwise they are displayed as named values sorted by name.
+func outputPackOutputs(ui terminal.UI, outputs map[string]any, format string) error {
+	if format == "json" {
+		out, err := json.MarshalIndent(outputs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode outputs: %w", err)
+		}
+		stdout, _, err := ui.OutputWriters()
+		if err != nil {
+			return fmt.Errorf("failed to get output writers: %w", err)
+		}
+		_, err = fmt.Fprintln(stdout, string(out))
+		return err
+	}
+
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	values := make([]terminal.NamedValue, 0, len(outputs))
+	for _, name := range slices.Sorted(maps.Keys(outputs)) {
+		value := outputs[name]
+
+		// Display complex values using their JSON encoding, so lists and maps
+		// can be copied into other tooling.
+		switch value.(type) {
+		case string, bool, int, int64, float64:
+		default:
+			out, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode output %q: %w", name, err)
+			}
+			value = string(out)
+		}
+		values = append(values, terminal.NamedValue{Name: name, Value: value})
+	}
+	ui.NamedValues(values)
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// The stages at which the hooks of a pack are run.
+const (
+	hookPreDeploy  = "pre-deploy"
+	hookPostDeploy = "post-deploy"
+)
+
+// The actions taken when a hook fails, which are set by --pre-hook-failure
+// and --post-hook-failure.
+const (
+	hookFailureAbort = "abort"
+	hookFailureWarn  = "warn"
+)
+
+// hookRun describes a rendered hook script and how it is run.
+type hookRun struct {
+	stage  string
+	script string
+
+	// dir is the directory the script is run within, which is the pack
+	// directory.
+	dir string
+
+	// env are the additional environment variables, in key=value form, set
+	// for the script.
+	env []string
+}
+
+// run writes the script to a temporary file and runs it, writing its output
+// to the UI. Scripts which start with a shebang line are executed directly,
+// and any others are run by sh.
+func (h hookRun) run(ctx context.Context, ui terminal.UI) error {
+	f, err := os.CreateTemp("", "nomad-pack-"+h.stage+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s hook script: %w", h.stage, err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(h.script)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o700)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s hook script: %w", h.stage, err)
+	}
+
+	var cmd *exec.Cmd
+	if strings.HasPrefix(h.script, "#!") {
+		cmd = exec.CommandContext(ctx, f.Name())
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", f.Name())
+	}
+	cmd.Dir = h.dir
+	cmd.Env = append(os.Environ(), h.env...)
+
+	if cmd.Stdout, cmd.Stderr, err = ui.OutputWriters(); err != nil {
+		return fmt.Errorf("failed to run %s hook: %w", h.stage, err)
+	}
+
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w", h.stage, err)
+	}
+	return nil
+}
+
+// hookEnv returns the environment variables set for the hooks, which describe
+// the deployment and pass on the Nomad options set by flag.
+func (c *RunCommand) hookEnv() []string {
+	env := []string{
+		"NOMAD_PACK_NAME=" + c.packConfig.Name,
+		"NOMAD_PACK_DEPLOYMENT_NAME=" + c.deploymentName,
+	}
+	for name, value := range map[string]string{
+		"NOMAD_ADDR":      c.nomadConfig.address,
+		"NOMAD_NAMESPACE": c.nomadConfig.namespace,
+		"NOMAD_REGION":    c.nomadConfig.region,
+		"NOMAD_TOKEN":     c.nomadConfig.token,
+	} {
+		if value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// runHook runs the hook when its script is not empty, handling a failure as
+// set by onFailure. It returns false if the run should be aborted.
+func (c *RunCommand) runHook(h hookRun, onFailure string, errCtx *errors.UIErrorContext) bool {
+	if strings.TrimSpace(h.script) == "" {
+		return true
+	}
+
+	c.ui.Info(fmt.Sprintf("Running %s hook", h.stage))
+	err := h.run(c.Ctx, c.ui)
+	if err == nil {
+		return true
+	}
+
+	if onFailure == hookFailureWarn {
+		c.ui.Warning(err.Error())
+		return true
+	}
+	c.ui.ErrorWithContext(err, "hook failed", errCtx.GetAll()...)
+	return false
+}
@@ -4,13 +4,20 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser/config"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
 	"github.com/mitchellh/go-glint"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -18,6 +25,45 @@ import (
 type InfoCommand struct {
 	*baseCommand
 	packConfig *cache.PackConfig
+	varName    string
+	format     string
+}
+
+const (
+	infoFormatText = "text"
+	infoFormatJSON = "json"
+)
+
+// infoPack is the JSON representation of a pack written by info when run with
+// --format=json.
+type infoPack struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	URL         string          `json:"url"`
+	Variables   []*infoVariable `json:"variables"`
+}
+
+// infoVariable is the detailed description of a single variable declaration.
+type infoVariable struct {
+	Pack        string            `json:"pack"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Description string            `json:"description"`
+	HasDefault  bool              `json:"has_default"`
+	Default     any               `json:"default"`
+	Sensitive   bool              `json:"sensitive"`
+	Validations []*infoValidation `json:"validations"`
+	DeclaredAt  string            `json:"declared_at"`
+
+	// defaultString is the default formatted as it is declared, for the text
+	// output.
+	defaultString string
+}
+
+// infoValidation is a single validation rule of a variable.
+type infoValidation struct {
+	Condition    string `json:"condition"`
+	ErrorMessage string `json:"error_message"`
 }
 
 func (c *InfoCommand) Run(args []string) int {
@@ -27,6 +73,7 @@ func (c *InfoCommand) Run(args []string) int {
 		WithExactArgs(1, args),
 		WithFlags(c.Flags()),
 		WithNoConfig(),
+		WithClient(false),
 	); err != nil {
 		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
 		c.ui.Info(c.helpUsageMessage())
@@ -36,13 +83,21 @@ func (c *InfoCommand) Run(args []string) int {
 	c.packConfig.Name = c.args[0]
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
+	defer readLockCache(c.packConfig, c.ui)()
 
 	// verify packs exist before running jobs
 	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
 		return 1
 	}
 
+	// Packs passed as zip files are extracted, and used from there.
+	cleanup, err := extractPackZip(c.packConfig, c.ui, errorContext)
+	if err != nil {
+		return 1
+	}
+	defer cleanup()
+
 	packPath := c.packConfig.Path
 
 	p, err := loader.Load(packPath)
@@ -54,7 +109,8 @@ func (c *InfoCommand) Run(args []string) int {
 	variableParser, err := parser.NewParser(&config.ParserConfig{
 		ParentPack:        p,
 		RootVariableFiles: p.RootVariableFiles(),
-		IgnoreMissingVars: c.baseCommand.ignoreMissingVars,
+
+		AdditionalVariableFiles: p.AdditionalVariableFiles(),
 	})
 	if err != nil {
 		return 1
@@ -66,6 +122,37 @@ func (c *InfoCommand) Run(args []string) int {
 		return 1
 	}
 
+	packVars := parsedVars.GetVars()
+
+	if c.varName != "" {
+		v, err := lookupInfoVariable(p, packVars, c.varName)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to find variable", errorContext.GetAll()...)
+			return 1
+		}
+		info := newInfoVariable(p, v.pack, v.variable)
+		if c.format == infoFormatJSON {
+			return c.outputJSON(info)
+		}
+		c.outputVariable(info)
+		return 0
+	}
+
+	if c.format == infoFormatJSON {
+		info := &infoPack{
+			Name:        p.Metadata.Pack.Name,
+			Description: p.Metadata.Pack.Description,
+			URL:         p.Metadata.App.URL,
+			Variables:   []*infoVariable{},
+		}
+		for _, pName := range slices.Sorted(maps.Keys(packVars)) {
+			for _, vName := range slices.Sorted(maps.Keys(packVars[pName])) {
+				info.Variables = append(info.Variables, newInfoVariable(p, pName, packVars[pName][vName]))
+			}
+		}
+		return c.outputJSON(info)
+	}
+
 	// Create a new glint document to handle the outputting of information.
 	doc := glint.New()
 
@@ -84,13 +171,17 @@ func (c *InfoCommand) Run(args []string) int {
 		glint.Text(p.Metadata.App.URL),
 	).Row())
 
-	for pName, variables := range parsedVars.GetVars() {
+	// The packs and their variables are output in name order, so the output
+	// is the same each time info is run against the same pack.
+	for _, pName := range slices.Sorted(maps.Keys(packVars)) {
+		variables := packVars[pName]
 
 		doc.Append(glint.Layout(
 			glint.Style(glint.Text(fmt.Sprintf("Pack %q Variables:", pName)), glint.Bold()),
 		).Row())
 
-		for _, v := range variables {
+		for _, vName := range slices.Sorted(maps.Keys(variables)) {
+			v := variables[vName]
 
 			varType := "unknown"
 			if !v.Type.Equals(cty.NilType) {
@@ -109,11 +200,173 @@ func (c *InfoCommand) Run(args []string) int {
 	return 0
 }
 
+// outputJSON writes the info as indented JSON directly to stdout, so the
+// output is kept when running quietly.
+func (c *InfoCommand) outputJSON(info any) int {
+	stdout, _, err := c.ui.OutputWriters()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to get output writers")
+		return 1
+	}
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to encode pack info")
+		return 1
+	}
+	fmt.Fprintln(stdout, string(b))
+	return 0
+}
+
+// outputVariable writes the details of a single variable to the UI, which are
+// kept when running quietly.
+func (c *InfoCommand) outputVariable(v *infoVariable) {
+	varType, varDefault := v.Type, v.defaultString
+	if varType == "" {
+		varType = "(not declared)"
+	}
+	if !v.HasDefault {
+		varDefault = "(none)"
+	}
+
+	c.outputResult("%s", fmt.Sprintf("Variable      %s", v.Name))
+	c.outputResult("%s", fmt.Sprintf("Pack          %s", v.Pack))
+	c.outputResult("%s", fmt.Sprintf("Type          %s", varType))
+	c.outputResult("%s", fmt.Sprintf("Default       %s", varDefault))
+	c.outputResult("%s", fmt.Sprintf("Description   %s", v.Description))
+	c.outputResult("%s", fmt.Sprintf("Sensitive     %t", v.Sensitive))
+	c.outputResult("%s", fmt.Sprintf("Declared at   %s", v.DeclaredAt))
+
+	if len(v.Validations) == 0 {
+		c.outputResult("Validations   (none)")
+		return
+	}
+	c.outputResult("Validations")
+	for _, val := range v.Validations {
+		c.outputResult("%s", fmt.Sprintf("  - condition:     %s", val.Condition))
+		c.outputResult("%s", fmt.Sprintf("    error message: %s", val.ErrorMessage))
+	}
+}
+
+// packVariable is a variable along with the ID of the pack declaring it.
+type packVariable struct {
+	pack     pack.ID
+	variable *variables.Variable
+}
+
+// lookupInfoVariable finds the variable called name. Variables of the root
+// pack are referred to by their name, and variables of dependencies by their
+// name prefixed with the dependency path, as with --var, such as
+// "dep_pack.count". If no variable matches, the error lists the variables
+// which are available.
+func lookupInfoVariable(p *pack.Pack, packVars map[pack.ID]map[variables.ID]*variables.Variable, name string) (*packVariable, error) {
+	var available []string
+	for pID, vars := range packVars {
+		for vName, v := range vars {
+			qualified := infoVariableName(p, pID, vName)
+			if qualified == name {
+				return &packVariable{pack: pID, variable: v}, nil
+			}
+			available = append(available, qualified)
+		}
+	}
+	slices.Sort(available)
+
+	if len(available) == 0 {
+		return nil, fmt.Errorf("variable %q is not declared by the pack, which declares no variables", name)
+	}
+	return nil, fmt.Errorf("variable %q is not declared by the pack, available variables: %s",
+		name, strings.Join(available, ", "))
+}
+
+// infoVariableName returns the name used to refer to a variable with --var.
+// Variables of dependencies are prefixed with the path of the dependency below
+// the root pack, so child.count rather than my_pack.child.count.
+func infoVariableName(p *pack.Pack, pID pack.ID, vName variables.ID) string {
+	if pID == p.ID() {
+		return vName.String()
+	}
+	if dep, ok := strings.CutPrefix(pID.String(), p.ID().String()+"."); ok {
+		return pack.ID(dep).Join(pack.ID(vName)).String()
+	}
+	return pID.Join(pack.ID(vName)).String()
+}
+
+// newInfoVariable builds the detailed description of the variable v, which is
+// declared by the pack identified by pID within the pack p.
+func newInfoVariable(p *pack.Pack, pID pack.ID, v *variables.Variable) *infoVariable {
+	sources := infoVariableSources(p)
+
+	info := &infoVariable{
+		Pack:          pID.String(),
+		Name:          v.Name.String(),
+		Type:          v.TypeString(),
+		Description:   v.Description,
+		HasDefault:    v.HasDefault(),
+		Sensitive:     v.Sensitive,
+		Validations:   []*infoValidation{},
+		DeclaredAt:    infoRange(v.DeclRange),
+		defaultString: v.DefaultString(),
+	}
+
+	if v.HasDefault() {
+		if v.Sensitive {
+			info.Default = variables.SensitiveValue
+		} else if val, err := variables.ConvertCtyToInterface(v.Default); err == nil {
+			info.Default = val
+		} else {
+			info.Default = info.defaultString
+		}
+	}
+
+	for _, val := range v.Validations {
+		info.Validations = append(info.Validations, &infoValidation{
+			Condition:    infoSource(sources, val.Condition.Range()),
+			ErrorMessage: val.ErrorMessage,
+		})
+	}
+	return info
+}
+
+// infoVariableSources returns the content of each variable file of the pack,
+// keyed by the file path used in the ranges of the parsed variables.
+func infoVariableSources(p *pack.Pack) map[string][]byte {
+	out := map[string][]byte{}
+	for _, f := range p.RootVariableFiles() {
+		if f != nil {
+			out[f.Path] = f.Content
+		}
+	}
+	for _, files := range p.AdditionalVariableFiles() {
+		for _, f := range files {
+			out[f.Path] = f.Content
+		}
+	}
+	return out
+}
+
+// infoSource returns the source text covered by rng, falling back to the
+// position of the range if the source is not available.
+func infoSource(sources map[string][]byte, rng hcl.Range) string {
+	src, ok := sources[rng.Filename]
+	if !ok || rng.Start.Byte < 0 || rng.End.Byte > len(src) || rng.Start.Byte > rng.End.Byte {
+		return rng.String()
+	}
+	return string(rng.SliceBytes(src))
+}
+
+// infoRange formats rng as the file and line it starts on.
+func infoRange(rng hcl.Range) string {
+	if rng.Filename == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", rng.Filename, rng.Start.Line)
+}
+
 func (c *InfoCommand) Flags() *flag.Sets {
-	return c.flagSet(flagSetOperation, func(set *flag.Sets) {
+	return c.flagSet(0, func(set *flag.Sets) {
 		c.packConfig = &cache.PackConfig{}
 
-		f := set.NewSet("Render Options")
+		f := set.NewSet("Info Options")
 
 		f.StringVar(&flag.StringVar{
 			Name:    "registry",
@@ -133,19 +386,58 @@ func (c *InfoCommand) Flags() *flag.Sets {
 
 					Using ref with a file path is not supported.`,
 		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "var",
+			Target:  &c.varName,
+			Default: "",
+			Usage: `Name of a single variable to show in detail, including its
+					type, default, description, and validation rules.
+					Variables of dependency packs are named by their pack
+					ID, such as "my_pack.dep_pack.count".`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.format,
+			Values:  []string{infoFormatText, infoFormatJSON},
+			Default: infoFormatText,
+			Usage: `Output format of the pack information. The json format
+					writes an object describing the pack and each of its
+					variables, or only the variable named by --var.`,
+		})
 	})
 }
 
+// examples satisfies the examples function of the exampleCommand interface.
+func (c *InfoCommand) examples() []commandExample {
+	return []commandExample{
+		{
+			description: `Get information on the "example" pack`,
+			args:        []string{"info", examplePack},
+			runnable:    true,
+		},
+		{
+			description: `Show the declaration of the "count" variable of the "example" pack`,
+			args:        []string{"info", examplePack, "--var=count"},
+			runnable:    true,
+		},
+		{
+			description: `Get information on the "example" pack as JSON`,
+			args:        []string{"info", examplePack, "--format=json"},
+			runnable:    true,
+		},
+	}
+}
+
 func (c *InfoCommand) Help() string {
-	c.Example = `
-	# Get information on the "hello_world" pack
-	nomad-pack info hello_world
-	`
+	c.Example = formatExamples(c.examples())
 
 	return formatHelp(`
 	Usage: nomad-pack info <pack-name>
 
 	Returns information on the given pack including name, description, and variable details.
+	Use --var to show the declaration of a single variable in detail.
 
 ` + c.GetExample() + c.Flags().Help())
 }
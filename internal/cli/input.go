@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+)
+
+// commandInput is the structure of the file passed by --input. Each option has
+// the same meaning as the flag of the same name, and the pack is used as the
+// command's argument when none is passed.
+type commandInput struct {
+	Pack      string         `json:"pack"`
+	Registry  string         `json:"registry"`
+	Ref       string         `json:"ref"`
+	Name      string         `json:"name"`
+	Vars      map[string]any `json:"vars"`
+	VarFiles  []string       `json:"var_files"`
+	Address   string         `json:"address"`
+	Namespace string         `json:"namespace"`
+	Region    string         `json:"region"`
+	Detach    *bool          `json:"detach"`
+
+	// WaitTimeout is a duration, such as "10m".
+	WaitTimeout string `json:"wait_timeout"`
+}
+
+// applyInput reads the file passed by --input and applies its options to the
+// command. Options already set by flag are left unchanged, so flags override
+// the file.
+func (c *baseCommand) applyInput(flags *flag.Sets) error {
+	b, err := os.ReadFile(c.inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	var input commandInput
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		// The decoder reports unknown keys as `json: unknown field "key"`.
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return fmt.Errorf("input file %s contains unknown key %s", c.inputFile, field)
+		}
+		return fmt.Errorf("failed to parse input file %s: %w", c.inputFile, err)
+	}
+
+	if input.Pack != "" && len(c.args) == 0 {
+		c.args = []string{input.Pack}
+	}
+
+	var detach string
+	if input.Detach != nil {
+		detach = strconv.FormatBool(*input.Detach)
+	}
+
+	// Each key is applied through the flag of the same name, with dashes in
+	// place of underscores.
+	options := []struct{ key, value string }{
+		{"registry", input.Registry},
+		{"ref", input.Ref},
+		{"name", input.Name},
+		{"address", input.Address},
+		{"namespace", input.Namespace},
+		{"region", input.Region},
+		{"detach", detach},
+		{"wait_timeout", input.WaitTimeout},
+	}
+	for _, opt := range options {
+		if opt.value == "" {
+			continue
+		}
+		name := strings.ReplaceAll(opt.key, "_", "-")
+		if !flags.Defined(name) {
+			return fmt.Errorf("input file key %q is not supported by the %s command", opt.key, c.cmdKey)
+		}
+		if flags.Changed(name) {
+			continue
+		}
+		if err := flags.Set(name, opt.value); err != nil {
+			return fmt.Errorf("invalid value for input file key %q: %w", opt.key, err)
+		}
+	}
+
+	// Variables set by flag override those of the same name in the file.
+	for name, value := range input.Vars {
+		if _, ok := c.vars[name]; ok {
+			continue
+		}
+		if c.vars == nil {
+			c.vars = make(map[string]string)
+		}
+
+		// Strings are passed as is, matching --var, while other values are
+		// passed as JSON, which is valid HCL for numbers, bools, lists, and
+		// objects.
+		if s, ok := value.(string); ok {
+			c.vars[name] = s
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for input file variable %q: %w", name, err)
+		}
+		c.vars[name] = string(encoded)
+	}
+
+	if len(input.VarFiles) > 0 && !flags.Changed("var-file") {
+		c.varFiles = input.VarFiles
+	}
+
+	return nil
+}
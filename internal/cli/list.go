@@ -4,6 +4,9 @@
 package cli
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/posener/complete"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
@@ -16,6 +19,22 @@ type ListCommand struct {
 	*baseCommand
 	registry string
 	ref      string
+	format   string
+}
+
+const (
+	listFormatTable = "table"
+	listFormatJSONL = "jsonl"
+)
+
+// listPackEntry is the JSON representation of a single cached pack emitted
+// when using the jsonl output format.
+type listPackEntry struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Registry    string `json:"registry"`
+	Ref         string `json:"ref"`
 }
 
 func (c *ListCommand) Run(args []string) int {
@@ -32,16 +51,18 @@ func (c *ListCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Get the global cache dir - may be configurable in the future, so using this
-	// helper function rather than a direct reference to the CONST.
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
+		Path:   c.cacheDir,
 		Logger: c.ui,
 	})
 	if err != nil {
 		return 1
 	}
 
+	if c.format == listFormatJSONL {
+		return c.listJSONL(globalCache)
+	}
+
 	// Load the list of registries.
 	err = globalCache.Load()
 	if err != nil {
@@ -54,12 +75,8 @@ func (c *ListCommand) Run(args []string) int {
 	table := packTable()
 	if len(globalCache.Registries()) > 0 {
 		for _, cachedRegistry := range globalCache.Registries() {
-			// filter by registry name if provided...
-			if c.registry != "" && cachedRegistry.Name != c.registry {
-				continue
-			}
-			// ...and filter by registry ref, too
-			if c.ref != "" && cachedRegistry.LocalRef != c.ref {
+			// filter by registry name and ref if provided
+			if !c.includeRegistry(cachedRegistry) {
 				continue
 			}
 			for _, registryPack := range cachedRegistry.Packs {
@@ -79,6 +96,59 @@ func (c *ListCommand) Run(args []string) int {
 	return 0
 }
 
+// listJSONL writes a JSON object for each cached pack on its own line. Each
+// registry is written as soon as it is loaded rather than after the whole
+// cache has been read.
+func (c *ListCommand) listJSONL(globalCache *cache.Cache) int {
+	// Write directly to stdout, so the output is kept when running quietly.
+	stdout, _, err := c.ui.OutputWriters()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to get output writers")
+		return 1
+	}
+
+	err = globalCache.WalkRegistries(func(cachedRegistry *cache.Registry) error {
+		if !c.includeRegistry(cachedRegistry) {
+			return nil
+		}
+		for _, registryPack := range cachedRegistry.Packs {
+			entry := listPackEntry{
+				Name:     registryPack.Name(),
+				Registry: cachedRegistry.Name,
+				Ref:      cachedRegistry.Ref,
+			}
+			if registryPack.Metadata != nil && registryPack.Metadata.Pack != nil {
+				entry.Version = registryPack.Metadata.Pack.Version
+				entry.Description = registryPack.Metadata.Pack.Description
+			}
+
+			b, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, string(b))
+		}
+		return nil
+	})
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to list packs", globalCache.ErrorContext.GetAll()...)
+		return 1
+	}
+	return 0
+}
+
+// includeRegistry returns whether the registry matches the registry name and
+// ref filters.
+func (c *ListCommand) includeRegistry(cachedRegistry *cache.Registry) bool {
+	if c.registry != "" && cachedRegistry.Name != c.registry {
+		return false
+	}
+	if c.ref != "" && cachedRegistry.LocalRef != c.ref {
+		return false
+	}
+	return true
+}
+
 func (c *ListCommand) Flags() *flag.Sets {
 	return c.flagSet(flagSetOperation|flagSetNomadClient, func(set *flag.Sets) {
 		f := set.NewSet("List Options")
@@ -97,6 +167,16 @@ func (c *ListCommand) Flags() *flag.Sets {
 			Usage:   `Registry ref to filter packs by.`,
 		})
 
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.format,
+			Values:  []string{listFormatTable, listFormatJSONL},
+			Default: listFormatTable,
+			Usage: `Output format for the list of packs. The jsonl format writes
+					one JSON object per pack, per line, as each registry is
+					read from the cache.`,
+		})
+
 	})
 }
 
@@ -116,6 +196,9 @@ func (c *ListCommand) Help() string {
 	c.Example = `
 	# List all available packs
 	nomad-pack list
+
+	# List all available packs as JSON Lines
+	nomad-pack list --format=jsonl
 	`
 	return formatHelp(`
 	Usage: nomad-pack list
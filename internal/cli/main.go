@@ -24,6 +24,12 @@ const (
 
 	// EnvPlain is the env var that can be set to force plain output mode.
 	EnvPlain = "NOMAD_PACK_PLAIN"
+
+	// EnvColor is the env var to set with the color mode.
+	EnvColor = "NOMAD_PACK_COLOR"
+
+	// EnvCacheDir is the env var to set with the path of the cache directory.
+	EnvCacheDir = "NOMAD_PACK_CACHE_DIR"
 )
 
 var (
@@ -129,6 +135,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"serve": func() (cli.Command, error) {
+			return &ServeCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &VersionCommand{
 				baseCommand: baseCommand,
@@ -149,6 +160,16 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"functions": func() (cli.Command, error) {
+			return &FunctionsCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"cleanup": func() (cli.Command, error) {
+			return &CleanupCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"stop": func() (cli.Command, error) {
 			return &StopCommand{
 				baseCommand: baseCommand,
@@ -176,6 +197,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"registry changed": func() (cli.Command, error) {
+			return &RegistryChangedCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"registry delete": func() (cli.Command, error) {
 			return &RegistryDeleteCommand{
 				baseCommand: baseCommand,
@@ -186,6 +212,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"registry update": func() (cli.Command, error) {
+			return &RegistryUpdateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"generate": func() (cli.Command, error) {
 			return &GenerateHelpCommand{
 				baseCommand: baseCommand,
@@ -216,6 +247,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"migrate": func() (cli.Command, error) {
+			return &MigrateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 	}
 
 	// register our aliases
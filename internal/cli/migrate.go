@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/migrate"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// MigrateCommand upgrades a pack written for an earlier pack format version
+// to the current version, rewriting its files in place.
+type MigrateCommand struct {
+	*baseCommand
+	packConfig *cache.PackConfig
+
+	// dryRun is a boolean flag to control whether the changes are only
+	// displayed as a diff, rather than written to the pack.
+	dryRun bool
+}
+
+func (c *MigrateCommand) Run(args []string) int {
+	c.cmdKey = "migrate" // Add cmdKey here to print out helpUsageMessage on Init error
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithExactArgs(1, args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	c.packConfig.Name = c.args[0]
+
+	// Set the packConfig defaults if necessary and generate our UI error context.
+	errorContext := c.initPackCommand(c.packConfig)
+
+	// Migrations rewrite the pack in place, so only packs being developed
+	// locally can be migrated.
+	if c.packConfig.Registry != cache.DevRegistryName || cache.IsPackZip(c.packConfig.Path) {
+		c.ui.ErrorWithContext(
+			errors.New("only packs from a local directory can be migrated"),
+			"failed to migrate pack", errorContext.GetAll()...)
+		return 1
+	}
+
+	result, err := migrate.Migrate(c.packConfig.Path)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to migrate pack", errorContext.GetAll()...)
+		return 1
+	}
+
+	if len(result.Changes) == 0 {
+		c.ui.Success(fmt.Sprintf("Pack %s is already at format version %d", c.packConfig.Name, migrate.CurrentVersion))
+		c.outputWarnings(result)
+		return 0
+	}
+
+	c.ui.Info(fmt.Sprintf("Migrating pack %s from format version %d to %d:", c.packConfig.Name, result.From, migrate.CurrentVersion))
+	for _, desc := range result.Applied {
+		c.ui.Info("  - " + desc)
+	}
+
+	if c.dryRun {
+		for _, change := range result.Changes {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(change.Before)),
+				B:        difflib.SplitLines(string(change.After)),
+				FromFile: change.Path,
+				ToFile:   change.Path + " (migrated)",
+				Context:  3,
+			})
+			if err != nil {
+				c.ui.ErrorWithContext(err, "failed to compare migrated files", "File: "+change.Path)
+				return 1
+			}
+			c.outputResult("%s", diff)
+		}
+		c.outputWarnings(result)
+		c.ui.Info(fmt.Sprintf("Dry run: %d file(s) would be changed", len(result.Changes)))
+		return 0
+	}
+
+	if err := result.Write(c.packConfig.Path); err != nil {
+		c.ui.ErrorWithContext(err, "failed to write migrated pack", errorContext.GetAll()...)
+		return 1
+	}
+	for _, change := range result.Changes {
+		c.ui.Info("Updated " + change.Path)
+	}
+	c.outputWarnings(result)
+	c.ui.Success(fmt.Sprintf("Pack %s migrated to format version %d", c.packConfig.Name, migrate.CurrentVersion))
+	return 0
+}
+
+// outputWarnings outputs the parts of the pack which must be migrated by
+// hand.
+func (c *MigrateCommand) outputWarnings(result *migrate.Result) {
+	// The warnings quote template source, so they are passed as arguments
+	// rather than within the format string.
+	for _, warning := range result.Warnings {
+		c.ui.Output("Migrate by hand: %s", warning, terminal.WithWarningStyle())
+	}
+}
+
+func (c *MigrateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		c.packConfig = &cache.PackConfig{}
+
+		f := set.NewSet("Migrate Options")
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "dry-run",
+			Target:  &c.dryRun,
+			Default: false,
+			Usage: `Displays a diff of the changes the migrations would make to
+					each file, without writing them to the pack.`,
+		})
+	})
+}
+
+func (c *MigrateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictDirs("")
+}
+
+func (c *MigrateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+// examples satisfies the examples function of the exampleCommand interface.
+func (c *MigrateCommand) examples() []commandExample {
+	return []commandExample{
+		{
+			description: "Preview the changes needed to migrate a pack, without writing them.",
+			args:        []string{"migrate", examplePack, "--dry-run"},
+			runnable:    true,
+		},
+		{
+			description: "Migrate a pack in the current directory to the current format version.",
+			args:        []string{"migrate", "."},
+		},
+	}
+}
+
+// Help satisfies the Help function of the cli.Command interface.
+func (c *MigrateCommand) Help() string {
+	c.Example = formatExamples(c.examples())
+
+	return formatHelp(`
+	Usage: nomad-pack migrate <pack-path> [options]
+
+	Upgrade a pack written for an earlier pack format version to the current
+	version, rewriting its files in place. Packs do not declare their format
+	version, so it is detected from the features the pack uses. The templates
+	of version 1 packs reference variables using the template data, such as
+	.my.count, which are rewritten to use the var and meta functions.
+
+	Vendored dependencies within the pack's deps directory are not migrated,
+	and must be migrated separately. Anything which cannot be migrated
+	automatically is listed, so it can be updated by hand.
+
+` + c.GetExample() + c.Flags().Help())
+}
+
+// Synopsis satisfies the Synopsis function of the cli.Command interface.
+func (c *MigrateCommand) Synopsis() string {
+	return "Upgrade a pack to the current pack format version"
+}
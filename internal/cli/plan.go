@@ -4,6 +4,11 @@
 package cli
 
 import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/nomad/api"
+
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
@@ -19,6 +24,18 @@ type PlanCommand struct {
 	exitCodeNoChanges int
 	exitCodeChanges   int
 	exitCodeError     int
+
+	// ignoreWarnings is the list of raw patterns supplied by the user which
+	// are compiled and passed to the job runner.
+	ignoreWarnings []string
+
+	// diffIgnore is the list of raw JSONPaths supplied by the user which are
+	// parsed and passed to the job runner.
+	diffIgnore []string
+
+	// jobFile is the path to an already rendered job specification, which is
+	// planned in place of a pack.
+	jobFile string
 }
 
 func (c *PlanCommand) Run(args []string) int {
@@ -29,7 +46,7 @@ func (c *PlanCommand) Run(args []string) int {
 	c.cmdKey = "plan" // Add cmdKey here to print out helpUsageMessage on Init error
 	// Initialize. If we fail, we just exit since Init handles the UI.
 	if err := c.Init(
-		WithExactArgs(1, args),
+		WithCustomArgs(args, PackOrJobFileArgs(&c.jobFile)),
 		WithFlags(c.Flags()),
 		WithNoConfig(),
 	); err != nil {
@@ -38,65 +55,119 @@ func (c *PlanCommand) Run(args []string) int {
 		return c.exitCodeError
 	}
 
-	c.packConfig.Name = c.args[0]
+	// The global verbose flag also increases the verbosity of the diff.
+	c.jobConfig.PlanConfig.Verbose = c.flagVerbose
 
-	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	for _, pattern := range c.ignoreWarnings {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			c.ui.ErrorWithContext(err, fmt.Sprintf("failed to compile ignore-warning pattern %q", pattern))
+			return c.exitCodeError
+		}
+		c.jobConfig.PlanConfig.IgnoreWarnings = append(c.jobConfig.PlanConfig.IgnoreWarnings, re)
+	}
 
-	// verify packs exist before planning jobs
-	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
-		return c.exitCodeError
+	for _, raw := range c.diffIgnore {
+		path, err := job.ParseDiffIgnorePath(raw)
+		if err != nil {
+			c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+			return c.exitCodeError
+		}
+		c.jobConfig.PlanConfig.DiffIgnore = append(c.jobConfig.PlanConfig.DiffIgnore, path)
 	}
 
-	// If no deploymentName set default to pack@ref
-	c.deploymentName = getDeploymentName(c.baseCommand, c.packConfig)
-	errorContext.Add(errors.UIContextPrefixDeploymentName, c.deploymentName)
+	var (
+		errorContext *errors.UIErrorContext
+		client       *api.Client
+		depConfig    *runner.Config
+		templates    map[string]string
+		err          error
+	)
 
-	client, err := c.getAPIClient()
-	if err != nil {
-		c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
-		return c.exitCodeError
-	}
+	if c.jobFile != "" {
+		// The job file is already rendered, so it is planned as it is.
+		templates, depConfig, errorContext, err = c.jobFileDeployment(c.jobFile)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to read job file", errorContext.GetAll()...)
+			return c.exitCodeError
+		}
 
-	packManager := generatePackManager(c.baseCommand, client, c.packConfig)
+		if client, err = c.getAPIClient(); err != nil {
+			c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
+			return c.exitCodeError
+		}
+	} else {
+		c.packConfig.Name = c.args[0]
 
-	// load pack
-	r, err := renderPack(
-		packManager,
-		c.baseCommand.ui,
-		false,
-		false,
-		c.baseCommand.ignoreMissingVars,
-		errorContext,
-	)
-	if err != nil {
-		return c.exitCodeError
-	}
+		// Set the packConfig defaults if necessary and generate our UI error context.
+		errorContext = c.initPackCommand(c.packConfig)
+		defer readLockCache(c.packConfig, c.ui)()
 
-	// Commands that render templates are required to render at least one
-	// parent template.
-	if r.LenParentRenders() < 1 {
-		c.ui.ErrorWithContext(errors.ErrNoTemplatesRendered, "no templates rendered", errorContext.GetAll()...)
-		return c.exitCodeError
-	}
+		// verify packs exist before planning jobs
+		if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
+			return c.exitCodeError
+		}
+
+		// Packs passed as zip files are extracted, and used from there.
+		cleanup, err := extractPackZip(c.packConfig, c.ui, errorContext)
+		if err != nil {
+			return c.exitCodeError
+		}
+		defer cleanup()
+
+		// If no deploymentName set default to pack@ref
+		c.deploymentName = getDeploymentName(c.baseCommand, c.packConfig)
+		errorContext.Add(errors.UIContextPrefixDeploymentName, c.deploymentName)
+
+		if client, err = c.getAPIClient(); err != nil {
+			c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
+			return c.exitCodeError
+		}
+
+		packManager := generatePackManager(c.baseCommand, client, c.packConfig)
+
+		// load pack
+		r, err := renderPack(
+			packManager,
+			c.baseCommand.ui,
+			false,
+			false,
+			c.baseCommand.ignoreMissingVars,
+			errorContext,
+		)
+		if err != nil {
+			return c.exitCodeError
+		}
 
-	depConfig := runner.Config{
-		PackName:       c.packConfig.Name,
-		PathPath:       c.packConfig.Path,
-		PackRef:        c.packConfig.Ref,
-		DeploymentName: c.deploymentName,
-		RegistryName:   c.packConfig.Registry,
+		// Commands that render templates are required to render at least one
+		// parent template.
+		if r.LenParentRenders() < 1 {
+			c.ui.ErrorWithContext(errors.ErrNoTemplatesRendered, "no templates rendered", errorContext.GetAll()...)
+			return c.exitCodeError
+		}
+
+		depConfig = &runner.Config{
+			PackName:       job.AliasedName(c.alias, c.packConfig.Name),
+			PathPath:       c.packConfig.Path,
+			PackRef:        c.packConfig.Ref,
+			DeploymentName: c.deploymentName,
+			RegistryName:   c.packConfig.Registry,
+			Alias:          c.alias,
+
+			ACLCapabilities: packManager.RequiredACLCapabilities(),
+		}
+		templates = r.ParentRenders()
 	}
 
 	// TODO(jrasell) come up with a better way to pass the appropriate config.
-	jobRunner, err := generateRunner(client, "job", c.jobConfig, &depConfig)
+	jobRunner, err := generateRunner(client, "job", c.jobConfig, depConfig)
 	if err != nil {
 		c.ui.ErrorWithContext(err, "failed to generate deployer", errorContext.GetAll()...)
 		return c.exitCodeError
 	}
 
 	// Set the rendered templates on the job deployer.
-	jobRunner.SetTemplates(r.ParentRenders())
+	jobRunner.SetTemplates(templates)
 
 	// Parse the templates. If we have any error, output this and exit.
 	if validateErrs := jobRunner.ParseTemplates(); validateErrs != nil {
@@ -115,6 +186,13 @@ func (c *PlanCommand) Run(args []string) int {
 		return c.exitCodeError
 	}
 
+	if aclErrs := jobRunner.CheckACLCapabilities(errorContext); aclErrs != nil {
+		for _, aclErr := range aclErrs {
+			c.ui.ErrorWithContext(aclErr.Err, aclErr.Subject, aclErr.Context.GetAll()...)
+		}
+		return c.exitCodeError
+	}
+
 	if conflictErrs := jobRunner.CheckForConflicts(errorContext); conflictErrs != nil {
 		for _, conflictErr := range conflictErrs {
 			c.ui.ErrorWithContext(conflictErr.Err, conflictErr.Subject, conflictErr.Context.GetAll()...)
@@ -127,7 +205,8 @@ func (c *PlanCommand) Run(args []string) int {
 		c.ui.ErrorWithContext(planErrs.Err, planErrs.Subject, planErrs.Context.GetAll()...)
 	}
 
-	if planExitCode < 2 {
+	// Keep the patch and json output machine readable.
+	if planExitCode < 2 && !c.jobConfig.PlanConfig.MachineReadable() {
 		c.ui.Success("Plan succeeded")
 	}
 
@@ -173,6 +252,16 @@ func (c *PlanCommand) Flags() *flag.Sets {
 					Using ref with a file path is not supported.`,
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:    "job-file",
+			Target:  &c.jobFile,
+			Default: "",
+			Usage: `Path to an already rendered Nomad job specification to plan
+					in place of a pack, which can not be passed as well. The
+					job is not rendered, but is labelled with the pack
+					metadata as run --job-file would deploy it.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "diff",
 			Target:  &c.jobConfig.PlanConfig.Diff,
@@ -181,6 +270,27 @@ func (c *PlanCommand) Flags() *flag.Sets {
 					planned job is shown. Defaults to true.`,
 		})
 
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.jobConfig.PlanConfig.Format,
+			Values:  []string{job.PlanFormatDiff, job.PlanFormatPatch, job.PlanFormatJSON},
+			Default: job.PlanFormatDiff,
+			Usage: `Output format of the plan. The patch format replaces the
+					diff and scheduler dry-run output with a JSON Patch
+					(RFC 6902) per job, describing the change from the deployed
+					job to the planned job. The json format replaces them with
+					a JSON summary per job, including the change in CPU and
+					memory requested by each task group.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "priority",
+			Target:  &c.jobConfig.RunConfig.Priority,
+			Default: 0,
+			Usage: `If set, the scheduling priority of each job is set to the
+					passed value before it is planned, as run --priority does.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "policy-override",
 			Target:  &c.jobConfig.PlanConfig.PolicyOverride,
@@ -189,15 +299,35 @@ func (c *PlanCommand) Flags() *flag.Sets {
 					Sentinel policies.`,
 		})
 
-		f.BoolVarP(&flag.BoolVarP{
-			BoolVar: &flag.BoolVar{
-				Name:    "verbose",
-				Target:  &c.jobConfig.PlanConfig.Verbose,
-				Default: false,
-				Usage:   `Increase diff verbosity.`,
-			},
-			Shorthand: "v",
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:    "ignore-warning",
+			Target:  &c.ignoreWarnings,
+			Default: make([]string, 0),
+			Usage: `A regular expression matched against each job warning
+					returned by the plan. Matching warnings are not displayed.
+					Warnings which are not ignored return the same exit code
+					as changes. This can be provided multiple times to ignore
+					several warnings.`,
 		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:    "diff-ignore",
+			Target:  &c.diffIgnore,
+			Default: make([]string, 0),
+			Usage: `A JSONPath to fields of the job, such as
+					$.TaskGroups[*].Meta.deployed_at, which are removed from
+					both the deployed and planned job before they are compared.
+					Differences only within ignored fields do not count as
+					changes, and are left out of the patch and json formats.
+					The human-readable diff of the default diff format is NOT
+					filtered: it is computed by the Nomad servers and still
+					shows changes to ignored fields, which then only affect
+					the exit code. The fields managed by the Nomad servers,
+					such as the modify indexes, version, status, and submit
+					time, are always ignored. This can be provided multiple
+					times.`,
+		})
+
 		f.IntVar(&flag.IntVar{
 			Name:    "exit-code-no-changes",
 			Target:  &c.exitCodeNoChanges,
@@ -229,33 +359,79 @@ func (c *PlanCommand) AutocompleteFlags() complete.Flags {
 	return c.Flags().Completions()
 }
 
-func (c *PlanCommand) Help() string {
-	c.Example = `
-	# Plan an example pack with the default deployment name
-	nomad-pack plan example
-
-	# Plan an example pack at a specific ref
-	nomad-pack plan example --ref=v0.0.1
-
-	# Plan a pack from a registry other than the default registry
-	nomad-pack plan traefik --registry=community --ref=v0.0.1
-
-	# Plan an example pack without showing the diff
-	nomad-pack plan example --diff=false
+// examples satisfies the examples function of the exampleCommand interface.
+// Planning a pack which is not yet deployed creates objects, so the runnable
+// examples exit with code 1.
+func (c *PlanCommand) examples() []commandExample {
+	return []commandExample{
+		{
+			description: "Plan an example pack with the default deployment name",
+			args:        []string{"plan", examplePack},
+			runnable:    true,
+			exitCode:    1,
+		},
+		{
+			description: "Plan an example pack at a specific ref",
+			args:        []string{"plan", examplePack, "--ref=v0.0.1"},
+		},
+		{
+			description: "Plan a pack from a registry other than the default registry",
+			args:        []string{"plan", "traefik", "--registry=community", "--ref=v0.0.1"},
+		},
+		{
+			description: "Plan an example pack without showing the diff",
+			args:        []string{"plan", examplePack, "--diff=false"},
+			runnable:    true,
+			exitCode:    1,
+		},
+		{
+			description: "Plan an example pack, hiding any warnings about deprecated fields",
+			args:        []string{"plan", examplePack, "--ignore-warning=deprecated"},
+			runnable:    true,
+			exitCode:    1,
+		},
+		{
+			description: "Plan an example pack, writing a JSON Patch of the change to each job",
+			args:        []string{"plan", examplePack, "--format=patch"},
+			runnable:    true,
+			exitCode:    1,
+		},
+		{
+			description: "Plan an example pack, writing a JSON summary of the resource changes",
+			args:        []string{"plan", examplePack, "--format=json"},
+			runnable:    true,
+			exitCode:    1,
+		},
+		{
+			description: "Plan an example pack, ignoring changes to a timestamp set in the job metadata",
+			args:        []string{"plan", examplePack, "--format=patch", "--diff-ignore=$.Meta.deployed_at"},
+			runnable:    true,
+			exitCode:    1,
+		},
+		{
+			description: "Plan an already rendered job specification as a pack deployment",
+			args:        []string{"plan", "--job-file=./example.nomad.hcl"},
+		},
+		{
+			description: "Plan a pack under development from the filesystem - supports current\n" +
+				"working directory or relative path",
+			args: []string{"plan", "."},
+		},
+	}
+}
 
-	# Plan a pack under development from the filesystem - supports current
-	# working directory or relative path
-	nomad-pack plan .
-	`
+func (c *PlanCommand) Help() string {
+	c.Example = formatExamples(c.examples())
 
 	return formatHelp(`
-	Usage: nomad-pack plan <pack-name> [options]
+	Usage: nomad-pack plan [<pack-name> | --job-file=<path>] [options]
 
 	Determine the effects of submitting a new or updated Nomad Pack
 
 	Plan will return one of the following exit codes:
 		* code 0:   No objects will be created or destroyed.
-		* code 1:   Objects will be created or destroyed.
+		* code 1:   Objects will be created or destroyed, or the plan
+		            returned warnings which were not ignored.
 		* code 255: An error occurred determining the plan.
 
 ` + c.GetExample() + c.Flags().Help())
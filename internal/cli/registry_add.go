@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/dustin/go-humanize"
 	"github.com/posener/complete"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
@@ -22,6 +23,24 @@ type RegistryAddCommand struct {
 	name   string
 	target string
 	ref    string
+	auth   string
+	caCert string
+
+	// packsDir is the directory within the registry which contains the
+	// packs, for registries which do not use the default layout.
+	packsDir string
+
+	// failIfExists controls whether adding a registry which already exists
+	// in the cache errors, rather than refreshing the registry.
+	failIfExists bool
+
+	// shallow controls whether a registry added at a ref is cloned with a
+	// depth of 1.
+	shallow bool
+
+	// mirror is the URL of a mirror of the registry, cloned when the source
+	// cannot be.
+	mirror string
 }
 
 func (c *RegistryAddCommand) Run(args []string) int {
@@ -55,19 +74,45 @@ func (c *RegistryAddCommand) Run(args []string) int {
 
 	// Add the registry or registry target to the global cache
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
-		Logger: c.ui,
+		Path:       c.cacheDir,
+		Logger:     c.ui,
+		CACertPath: c.caCert,
 	})
 	if err != nil {
 		return 1
 	}
 
-	newRegistry, err := globalCache.Add(&cache.AddOpts{
+	username, password, err := parseRegistryAuth(c.auth)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to parse registry auth", errorContext.GetAll()...)
+		return 1
+	}
+
+	addOpts := &cache.AddOpts{
 		RegistryName: c.name,
 		Source:       c.source,
 		PackName:     c.target,
 		Ref:          c.ref,
-	})
+		Username:     username,
+		Password:     password,
+		PacksDir:     c.packsDir,
+		FailIfExists: c.failIfExists,
+		Shallow:      c.shallow,
+		Mirror:       c.mirror,
+	}
+
+	// Show the fetch progress when attached to a terminal, so slow clones do
+	// not appear to have hung.
+	if c.ui.Interactive() {
+		status := c.ui.Status()
+		status.Update(fmt.Sprintf("Fetching registry %s", c.name))
+		addOpts.Progress = func(received int64) {
+			status.Update(fmt.Sprintf("Fetching registry %s (%s received)", c.name, humanize.Bytes(uint64(received))))
+		}
+		defer status.Close()
+	}
+
+	newRegistry, err := globalCache.Add(addOpts)
 	if err != nil {
 		return 1
 	}
@@ -136,9 +181,103 @@ func (c *RegistryAddCommand) Flags() *flag.Sets {
 
 					Using ref with a file path is not supported.`,
 		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "pack-dir",
+			Target:  &c.packsDir,
+			Default: "",
+			Usage: `The directory within the registry which contains the packs.
+					Defaults to "packs", use "." for registries which keep packs
+					at their root. The directory is remembered when the
+					registry is added again.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "fail-if-exists",
+			Target:  &c.failIfExists,
+			Default: false,
+			Usage: `Return an error if a registry with the same name already
+					exists in the cache. By default, adding an existing
+					registry fetches it again at the specified ref.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-auth",
+			Target:  &c.auth,
+			Default: "",
+			EnvVar:  "NOMAD_PACK_REGISTRY_AUTH",
+			Usage: `Credentials used when cloning a registry over HTTPS. Supports
+					"token:<token>" for access tokens and
+					"basic:<username>:<password>" for basic authentication.
+					Credentials are redacted from all output, and are sent as
+					an HTTP header rather than stored in the cloned registry.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "registry-shallow",
+			Target:  &c.shallow,
+			Default: false,
+			EnvVar:  "NOMAD_PACK_REGISTRY_SHALLOW",
+			Usage: `Clone the registry with a depth of 1 when adding it at a
+					branch or tag with --ref, which greatly reduces the
+					download size of large registries. Refs which cannot be
+					cloned shallowly, such as a SHA, fall back to a full
+					clone. Registries added at latest are always cloned
+					shallowly.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-mirror",
+			Target:  &c.mirror,
+			Default: "",
+			Usage: `URL of a mirror of the registry, which is cloned when the
+					source cannot be, such as during an outage. The mirror
+					must carry the ref being added, and is cloned with the
+					same credentials. The mirror is remembered when the
+					registry is added again, including by "nomad-pack serve".`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-ca-cert",
+			Target:  &c.caCert,
+			Default: "",
+			EnvVar:  "NOMAD_PACK_REGISTRY_CA_CERT",
+			Usage: `Path to a PEM encoded CA certificate bundle used to verify
+					registries cloned over HTTPS, for registries served with
+					certificates from a private CA. Defaults to the system
+					trust store. This is independent of the Nomad TLS
+					configuration.`,
+		})
 	})
 }
 
+// parseRegistryAuth parses the value of the --registry-auth flag into the
+// username and password used for the HTTPS clone. Tokens are sent as the
+// password with a placeholder username, which is accepted by the common git
+// hosting providers.
+func parseRegistryAuth(auth string) (string, string, error) {
+	if auth == "" {
+		return "", "", nil
+	}
+
+	kind, value, _ := strings.Cut(auth, ":")
+	switch kind {
+	case "token":
+		if value == "" {
+			return "", "", errors.New(`registry auth token must not be empty`)
+		}
+		return "oauth2", value, nil
+	case "basic":
+		username, password, ok := strings.Cut(value, ":")
+		if !ok || username == "" {
+			return "", "", errors.New(`registry basic auth must be in the form "basic:<username>:<password>"`)
+		}
+		return username, password, nil
+	default:
+		return "", "", fmt.Errorf(`unsupported registry auth type %q, must be one of "token" or "basic"`, kind)
+	}
+}
+
 func (c *RegistryAddCommand) AutocompleteArgs() complete.Predictor {
 	return complete.PredictNothing
 }
@@ -161,6 +300,24 @@ func (c *RegistryAddCommand) Help() string {
 
 	# Download packs from a registry at a specific tag/release/SHA.
 	nomad-pack registry add community github.com/hashicorp/nomad-pack-community-registry  --ref=v0.1.0
+
+	# Download packs from a registry at a specific tag, fetching only that commit.
+	nomad-pack registry add community github.com/hashicorp/nomad-pack-community-registry --ref=v0.1.0 --registry-shallow
+
+	# Download packs from a registry which keeps them in a non-standard directory.
+	nomad-pack registry add monorepo github.com/example/monorepo --pack-dir=deploy/packs
+
+	# Add a registry only if it has not already been added to the global cache.
+	nomad-pack registry add community github.com/hashicorp/nomad-pack-community-registry --fail-if-exists
+
+	# Fall back to a mirror of the registry when the source cannot be cloned.
+	nomad-pack registry add community github.com/hashicorp/nomad-pack-community-registry --registry-mirror=https://git.example.com/mirrors/community.git
+
+	# Download packs from a registry served with a certificate from a private CA.
+	nomad-pack registry add internal https://git.example.com/packs.git --registry-ca-cert=/etc/ssl/internal-ca.pem
+
+	# Download packs from a private registry over HTTPS using an access token.
+	nomad-pack registry add private github.com/example/private-registry --registry-auth="token:$GIT_TOKEN"
 	`
 	return formatHelp(`
 	Usage: nomad-pack registry add <name> <source> [options]
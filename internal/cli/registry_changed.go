@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+)
+
+const (
+	registryChangedFormatList = "list"
+	registryChangedFormatJSON = "json"
+)
+
+// RegistryChangedCommand lists the packs of a registry in the global cache
+// which changed since an older ref.
+type RegistryChangedCommand struct {
+	*baseCommand
+	name     string
+	sinceRef string
+	format   string
+	auth     string
+	caCert   string
+}
+
+func (c *RegistryChangedCommand) Run(args []string) int {
+	c.cmdKey = "registry changed"
+
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithExactArgs(1, args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	c.name = args[0]
+
+	errorContext := errors.NewUIErrorContext()
+	errorContext.Add(errors.UIContextPrefixRegistryName, c.name)
+
+	if c.sinceRef == "" {
+		c.ui.ErrorWithContext(errors.New("--since-ref is required"), ErrParsingArgsOrFlags, errorContext.GetAll()...)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+	errorContext.Add("Since Ref: ", c.sinceRef)
+
+	username, password, err := parseRegistryAuth(c.auth)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to parse registry auth", errorContext.GetAll()...)
+		return 1
+	}
+
+	globalCache, err := cache.NewCache(&cache.CacheConfig{
+		Path:       c.cacheDir,
+		Logger:     c.ui,
+		CACertPath: c.caCert,
+	})
+	if err != nil {
+		return 1
+	}
+
+	packs, err := globalCache.ChangedPacks(&cache.ChangedOpts{
+		RegistryName: c.name,
+		SinceRef:     c.sinceRef,
+		Username:     username,
+		Password:     password,
+	})
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to find changed packs", errorContext.GetAll()...)
+		return 1
+	}
+
+	// Write directly to stdout, so the output can be consumed by pipelines
+	// even when running quietly.
+	stdout, _, err := c.ui.OutputWriters()
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to get output writers")
+		return 1
+	}
+
+	if c.format == registryChangedFormatJSON {
+		if packs == nil {
+			packs = []string{}
+		}
+		b, err := json.Marshal(packs)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to encode changed packs", errorContext.GetAll()...)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(b))
+		return 0
+	}
+
+	for _, pack := range packs {
+		fmt.Fprintln(stdout, pack)
+	}
+	return 0
+}
+
+func (c *RegistryChangedCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Registry Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "since-ref",
+			Target:  &c.sinceRef,
+			Default: "",
+			Usage: `The older git ref of the registry to compare the latest ref
+					in the cache against. Supports tags and SHAs. Required.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.format,
+			Values:  []string{registryChangedFormatList, registryChangedFormatJSON},
+			Default: registryChangedFormatList,
+			Usage: `Output format for the changed packs. The list format writes
+					the name of each pack on its own line, and the json format
+					writes an array of pack names.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-auth",
+			Target:  &c.auth,
+			Default: "",
+			EnvVar:  "NOMAD_PACK_REGISTRY_AUTH",
+			Usage: `Credentials used when cloning the registry over HTTPS, in
+					the same form as for "nomad-pack registry add".`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-ca-cert",
+			Target:  &c.caCert,
+			Default: "",
+			EnvVar:  "NOMAD_PACK_REGISTRY_CA_CERT",
+			Usage: `Path to a PEM encoded CA certificate bundle used to verify
+					the registry when cloning over HTTPS. Defaults to the
+					system trust store.`,
+		})
+	})
+}
+
+func (c *RegistryChangedCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RegistryChangedCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RegistryChangedCommand) Synopsis() string {
+	return "List the packs of a registry which changed since a ref."
+}
+
+func (c *RegistryChangedCommand) Help() string {
+	c.Example = `
+	# List the packs of the community registry which changed since v0.1.0.
+	nomad-pack registry changed community --since-ref=v0.1.0
+
+	# Output the changed packs as a JSON array.
+	nomad-pack registry changed community --since-ref=v0.1.0 --format=json
+	`
+	return formatHelp(`
+	Usage: nomad-pack registry changed <name> --since-ref=<ref> [options]
+
+	List the packs whose files differ between the latest ref of a registry in
+	the global cache and an older ref. The history of the registry is fetched
+	from the source it was added from to compare the refs, so the registry
+	must have been added at the latest ref.
+
+` + c.GetExample() + c.Flags().Help())
+}
@@ -43,10 +43,8 @@ func (c *RegistryDeleteCommand) Run(args []string) int {
 	errorContext.Add(errors.UIContextPrefixRegistryName, c.name)
 	errorContext.Add(errors.UIContextPrefixRegistryTarget, c.target)
 
-	// Get the global cache dir - may be configurable in the future, so using this
-	// helper function rather than a direct reference to the CONST.
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
+		Path:   c.cacheDir,
 		Logger: c.ui,
 	})
 	if err != nil {
@@ -23,11 +23,11 @@ func (c *RegistryHelpCommand) Run(args []string) int {
 		WithNoConfig(),
 		WithClient(false),
 	); err != nil {
-		c.ui.Info("The registry command requires one of the following subcommands: add, delete, list.")
+		c.ui.Info("The registry command requires one of the following subcommands: add, changed, delete, list.")
 		return 1
 	}
 
-	c.ui.Info("The registry command requires one of the following subcommands: add, delete, list.")
+	c.ui.Info("The registry command requires one of the following subcommands: add, changed, delete, list.")
 	return 0
 }
 
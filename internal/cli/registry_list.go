@@ -21,6 +21,7 @@ func (c *RegistryListCommand) Run(args []string) int {
 
 	if err := c.Init(
 		WithNoArgs(args),
+		WithFlags(c.Flags()),
 		WithNoConfig(),
 		WithClient(false),
 	); err != nil {
@@ -29,10 +30,8 @@ func (c *RegistryListCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Get the global cache dir - may be configurable in the future, so using this
-	// helper function rather than a direct reference to the CONST.
 	globalCache, err := cache.NewCache(&cache.CacheConfig{
-		Path:   cache.DefaultCachePath(),
+		Path:   c.cacheDir,
 		Logger: c.ui,
 	})
 	if err != nil {
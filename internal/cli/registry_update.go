@@ -0,0 +1,222 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// RegistryUpdateCommand fetches registries in the global cache again from the
+// sources they were added from.
+type RegistryUpdateCommand struct {
+	*baseCommand
+	ref    string
+	all    bool
+	auth   string
+	caCert string
+}
+
+func (c *RegistryUpdateCommand) Run(args []string) int {
+	c.cmdKey = "registry update"
+
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithCustomArgs(args, c.validateArgs),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	username, password, err := parseRegistryAuth(c.auth)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to parse registry auth")
+		return 1
+	}
+
+	globalCache, err := cache.NewCache(&cache.CacheConfig{
+		Path:       c.cacheDir,
+		Logger:     c.ui,
+		CACertPath: c.caCert,
+	})
+	if err != nil {
+		return 1
+	}
+
+	names := c.args
+	if c.all {
+		if names, err = globalCache.RegistryNames(); err != nil {
+			c.ui.ErrorWithContext(err, "failed to list registries")
+			return 1
+		}
+		if len(names) == 0 {
+			c.ui.Info("No registries have been added to the cache.")
+			return 0
+		}
+	}
+
+	// Update each registry in turn, so one failing to update does not stop
+	// the others when updating them all.
+	exitCode := 0
+	for _, name := range names {
+		errorContext := errors.NewUIErrorContext()
+		errorContext.Add(errors.UIContextPrefixRegistryName, name)
+		if c.ref != "" {
+			errorContext.Add("Registry Ref: ", c.ref)
+		}
+
+		updateOpts := &cache.UpdateOpts{
+			RegistryName: name,
+			Ref:          c.ref,
+			Username:     username,
+			Password:     password,
+		}
+
+		// Show the fetch progress when attached to a terminal, so slow clones
+		// do not appear to have hung.
+		var status terminal.Status
+		if c.ui.Interactive() {
+			status = c.ui.Status()
+			status.Update(fmt.Sprintf("Updating registry %s", name))
+			updateOpts.Progress = func(received int64) {
+				status.Update(fmt.Sprintf("Updating registry %s (%s received)", name, humanize.Bytes(uint64(received))))
+			}
+		}
+
+		updates, err := globalCache.Update(updateOpts)
+		if status != nil {
+			_ = status.Close()
+		}
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to update registry", errorContext.GetAll()...)
+			exitCode = 1
+			continue
+		}
+
+		for _, update := range updates {
+			c.ui.Info(formatRefUpdate(name, update))
+		}
+	}
+
+	return exitCode
+}
+
+// validateArgs requires the name of a single registry, unless every registry
+// is updated with --all.
+func (c *RegistryUpdateCommand) validateArgs(b *baseCommand, args []string) error {
+	if !c.all {
+		return ExactArgs(1)(b, args)
+	}
+	if len(args) != 0 {
+		return errors.New("--all can not be used with a registry name")
+	}
+	return nil
+}
+
+// formatRefUpdate describes what changed when the ref of the registry was
+// updated.
+func formatRefUpdate(name string, update *cache.RefUpdate) string {
+	var out strings.Builder
+	switch {
+	case update.PreviousSHA == "":
+		fmt.Fprintf(&out, "Registry %s added at ref %s (%s).", name, update.Ref, update.SHA)
+	case update.Changed():
+		fmt.Fprintf(&out, "Registry %s at ref %s updated from %s to %s.", name, update.Ref, update.PreviousSHA, update.SHA)
+	default:
+		fmt.Fprintf(&out, "Registry %s at ref %s is up to date (%s).", name, update.Ref, update.SHA)
+	}
+	if update.PreviousSHA != "" && len(update.AddedPacks) > 0 {
+		fmt.Fprintf(&out, " New packs: %s.", strings.Join(update.AddedPacks, ", "))
+	}
+	return out.String()
+}
+
+func (c *RegistryUpdateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Registry Options")
+
+		f.StringVar(&flag.StringVar{
+			Name:    "ref",
+			Target:  &c.ref,
+			Default: "",
+			Usage: `Specific git ref of the registry to update, which is added
+					to the cache if the registry does not have it yet. By
+					default, each ref of the registry in the cache is
+					updated.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "all",
+			Target:  &c.all,
+			Default: false,
+			Usage: `Update every registry in the cache. Registries which fail to
+					update are reported, and do not stop the others from
+					being updated.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-auth",
+			Target:  &c.auth,
+			Default: "",
+			EnvVar:  "NOMAD_PACK_REGISTRY_AUTH",
+			Usage: `Credentials used when cloning the registry over HTTPS, in
+					the same form as for "nomad-pack registry add".`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "registry-ca-cert",
+			Target:  &c.caCert,
+			Default: "",
+			EnvVar:  "NOMAD_PACK_REGISTRY_CA_CERT",
+			Usage: `Path to a PEM encoded CA certificate bundle used to verify
+					the registry when cloning over HTTPS. Defaults to the
+					system trust store.`,
+		})
+	})
+}
+
+func (c *RegistryUpdateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RegistryUpdateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RegistryUpdateCommand) Synopsis() string {
+	return "Update registries in the local environment."
+}
+
+func (c *RegistryUpdateCommand) Help() string {
+	c.Example = `
+	# Fetch the latest commit of each ref of the community registry.
+	nomad-pack registry update community
+
+	# Fetch the latest commit of the main branch of the community registry.
+	nomad-pack registry update community --ref=main
+
+	# Update every registry in the global cache.
+	nomad-pack registry update --all
+	`
+	return formatHelp(`
+	Usage: nomad-pack registry update [<name> | --all] [options]
+
+	Update registries in the global cache from the sources they were added
+	from, reporting the commit each ref moved to and any new packs. The packs
+	directory and mirror the registry was added with are kept.
+
+` + c.GetExample() + c.Flags().Help())
+}
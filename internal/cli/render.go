@@ -5,13 +5,22 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"slices"
 	"strings"
+	"text/template"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/nomad/api"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/posener/complete"
 	"golang.org/x/exp/maps"
 
@@ -19,6 +28,9 @@ import (
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
+	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
 
@@ -47,17 +59,145 @@ type RenderCommand struct {
 
 	// overwriteAll is set to true when someone specifies "a" to the y/n/a
 	overwriteAll bool
+
+	// checkFormat is a boolean flag to control whether the rendered job
+	// specifications are checked against their canonical HCL formatting
+	// instead of being output.
+	checkFormat bool
+
+	// keepGoing is a boolean flag to control whether templates which render
+	// successfully are still output when another template fails.
+	keepGoing bool
+
+	// outputName is a template used to name rendered job specifications,
+	// which is evaluated with the name of the job.
+	outputName string
+
+	// renderOutputs is a boolean flag to control whether only the named
+	// output values defined by the output template are displayed.
+	renderOutputs bool
+
+	// outputsFormat is the format used to display the named output values.
+	outputsFormat string
+
+	// outFormat is the format the rendered templates are output in, one of
+	// renderFormatHCL or renderFormatJSONWithMeta.
+	outFormat string
+
+	// showEmpty is a boolean flag to control whether templates which render
+	// to only whitespace are included in the output.
+	showEmpty bool
+
+	// warnEmpty is a boolean flag to control whether a warning is logged for
+	// each template which renders to only whitespace.
+	warnEmpty bool
+
+	// compareToRef is the ref of the pack to render alongside the requested
+	// ref, outputting a diff of the renders rather than the renders.
+	compareToRef string
+
+	// clean is a boolean flag to control whether files written to --to-dir
+	// by a previous render of the pack deployment, but not by this one, are
+	// removed.
+	clean bool
+
+	// fromRef is the git ref of the repository containing a local pack at
+	// which the pack is rendered, instead of the working tree.
+	fromRef string
+
+	// renderOnly is a glob which restricts the output to the templates whose
+	// path within the templates directory matches.
+	renderOnly string
+
+	// failOnEmptyRender is a boolean flag to control whether the command
+	// fails when the pack renders no non-empty job specifications.
+	failOnEmptyRender bool
+
+	// varTypeCheckOnly is a boolean flag to control whether only the types
+	// and presence of the pack variables are checked, without rendering.
+	varTypeCheckOnly bool
+
+	// dependencyOrder is a boolean flag to control whether the rendered jobs
+	// are output after the jobs they depend on.
+	dependencyOrder bool
+}
+
+// renderManifestName is the name of the file within --to-dir recording the
+// files written by the last render of each pack deployment using --clean, so
+// only those files are removed.
+const renderManifestName = ".nomad-pack-render"
+
+// renderIndexName is the name of the file within --to-dir listing the files
+// written by the last render, for consumption by other tooling. It is dot
+// prefixed, like renderManifestName, so it does not collide with the files
+// of users or the templates of packs.
+const renderIndexName = ".nomad-pack-index.json"
+
+// renderIndexEntry describes a single file written to --to-dir.
+type renderIndexEntry struct {
+	Job    string `json:"job,omitempty"`
+	Path   string `json:"path"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Sensitive files, written by the fileFromBase64 template function, are
+	// not hashed so the index reveals nothing about their content.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// The formats the rendered templates can be output in, set by --out-format.
+const (
+	renderFormatHCL          = "hcl"
+	renderFormatJSONWithMeta = "nomad-json-with-meta"
+)
+
+// renderedJob is the JSON representation of a single rendered job output by
+// --out-format=nomad-json-with-meta. It wraps the Nomad job with details of
+// the pack it was rendered from. RenderSHA256 is the SHA256 of the rendered
+// template, rather than of the pack source.
+type renderedJob struct {
+	Pack         renderedJobPack `json:"pack"`
+	Template     string          `json:"template"`
+	RenderSHA256 string          `json:"render_sha256"`
+	Job          *api.Job        `json:"job"`
+}
+
+// renderedJobPack describes the pack a renderedJob was rendered from.
+type renderedJobPack struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Ref      string `json:"ref"`
+	LocalRef string `json:"local_ref,omitempty"`
+	Registry string `json:"registry"`
+}
+
+// outputNameData is the data made available to the --output-name template.
+type outputNameData struct {
+	JobName      string
+	TemplateName string
+	Vars         map[string]any
 }
 
 type Render struct {
 	Name    string
 	Content string
+
+	// Mode is the permission the file is written with. If zero, 0644 is used.
+	Mode fs.FileMode
+
+	// Sensitive is true for the files written by the fileFromBase64 template
+	// function, whose content is redacted from the terminal.
+	Sensitive bool
 }
 
 func (r Render) toTerminal(c *RenderCommand) {
-	c.ui.Output(r.Name+":", terminal.WithStyle(terminal.BoldStyle))
-	c.ui.Output("")
-	c.ui.Output(r.Content)
+	c.outputResult(r.Name+":", terminal.WithStyle(terminal.BoldStyle))
+	c.outputResult("")
+	if r.Sensitive {
+		c.outputResult(fmt.Sprintf("(sensitive, %d bytes, mode %04o)", len(r.Content), r.Mode))
+		return
+	}
+	c.outputResult(r.Content)
 }
 
 func (r Render) toFile(c *RenderCommand, ec *errors.UIErrorContext) error {
@@ -74,7 +214,7 @@ func (r Render) toFile(c *RenderCommand, ec *errors.UIErrorContext) error {
 
 	filesystem.MaybeCreateDestinationDir(outDir)
 
-	err = writeFile(c, outFile, r.Content)
+	err = writeFile(c, outFile, r.Content, r.Mode)
 	if err != nil {
 		ec.Add("Destination File: ", outFile)
 		return err
@@ -83,6 +223,205 @@ func (r Render) toFile(c *RenderCommand, ec *errors.UIErrorContext) error {
 	return nil
 }
 
+// updateRenderManifest records the files rendered to --to-dir under the pack
+// deployment in the manifest, keeping the entries of other deployments which
+// render to the same directory. The files listed for the deployment by the
+// previous manifest which were not rendered this time are removed first, along
+// with any directories they leave empty. Files which were not written by
+// nomad-pack, or which are also recorded for another deployment, are never
+// removed.
+//
+// When --render-only restricts the output, the files which were skipped are
+// kept in the manifest rather than treated as stale.
+func (c *RenderCommand) updateRenderManifest(renders []Render) error {
+	renderToDir := path.Clean(c.renderToDir)
+	manifestPath := path.Join(renderToDir, renderManifestName)
+
+	manifest, err := readRenderManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	key := c.renderManifestKey()
+	previous := manifest[key]
+
+	rendered := make([]string, 0, len(renders))
+	for _, r := range renders {
+		rendered = append(rendered, r.Name)
+	}
+	if c.renderOnly != "" {
+		rendered = append(rendered, previous...)
+	}
+	slices.Sort(rendered)
+	rendered = slices.Compact(rendered)
+
+	var stale []string
+	for _, name := range previous {
+		if slices.Contains(rendered, name) {
+			continue
+		}
+		shared := false
+		for other, names := range manifest {
+			if other != key && slices.Contains(names, name) {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			stale = append(stale, name)
+		}
+	}
+
+	if len(stale) > 0 {
+		confirmed, err := c.confirmClean(stale)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return errors.New("stale rendered files exist and removal was not confirmed")
+		}
+		for _, name := range stale {
+			if err := removeRenderedFile(renderToDir, name); err != nil {
+				return err
+			}
+			c.ui.Info(fmt.Sprintf("Removed stale rendered file %s", path.Join(renderToDir, name)))
+		}
+	}
+
+	manifest[key] = rendered
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode render manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write render manifest: %w", err)
+	}
+	return nil
+}
+
+// renderManifestKey returns the key the files rendered for the pack deployment
+// are recorded under in the render manifest. The ref is not included, so the
+// files of a previous version of the pack are cleaned when rendering another.
+func (c *RenderCommand) renderManifestKey() string {
+	if c.deploymentName != "" {
+		return c.deploymentName
+	}
+	return job.AliasedName(c.alias, c.packConfig.Name)
+}
+
+// writeRenderIndex writes the index of the files rendered to --to-dir. The
+// entries are sorted by path and hold nothing which varies between renders of
+// the same content, so the index is identical when the renders are.
+func (c *RenderCommand) writeRenderIndex(renders []Render) error {
+	entries := make([]renderIndexEntry, 0, len(renders))
+	for _, r := range renders {
+		if r.Sensitive {
+			entries = append(entries, renderIndexEntry{
+				Path:      r.Name,
+				Size:      len(r.Content),
+				Sensitive: true,
+			})
+			continue
+		}
+		sum := sha256.Sum256([]byte(r.Content))
+		jobName, _ := renderedJobName(r.Content)
+		entries = append(entries, renderIndexEntry{
+			Job:    jobName,
+			Path:   r.Name,
+			Size:   len(r.Content),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	slices.SortFunc(entries, func(a, b renderIndexEntry) int { return strings.Compare(a.Path, b.Path) })
+
+	b, err := json.MarshalIndent(map[string][]renderIndexEntry{"files": entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode render index: %w", err)
+	}
+	b = append(b, '\n')
+
+	if err := os.WriteFile(path.Join(path.Clean(c.renderToDir), renderIndexName), b, 0644); err != nil {
+		return fmt.Errorf("failed to write render index: %w", err)
+	}
+	return nil
+}
+
+// readRenderManifest returns the names of the files recorded by the manifest,
+// keyed by pack deployment. Names which would resolve outside the directory
+// are skipped, so a modified manifest cannot cause other files to be removed.
+func readRenderManifest(manifestPath string) (map[string][]string, error) {
+	manifest := make(map[string][]string)
+
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return manifest, nil
+		}
+		return nil, fmt.Errorf("failed to read render manifest: %w", err)
+	}
+
+	var recorded map[string][]string
+	if err := json.Unmarshal(b, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to decode render manifest: %w", err)
+	}
+	for key, names := range recorded {
+		for _, name := range names {
+			if filepath.IsLocal(name) && name != renderManifestName && name != renderIndexName {
+				manifest[key] = append(manifest[key], name)
+			}
+		}
+	}
+	return manifest, nil
+}
+
+// removeRenderedFile removes the named file from the directory, then removes
+// each parent directory it leaves empty, up to the directory itself.
+func removeRenderedFile(dir, name string) error {
+	if err := os.Remove(path.Join(dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale rendered file: %w", err)
+	}
+	for parent := path.Dir(name); parent != "."; parent = path.Dir(parent) {
+		// Removing a directory fails if it is not empty, which is expected
+		// when it holds other files.
+		if os.Remove(path.Join(dir, parent)) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// confirmClean asks the user to confirm the removal of the stale rendered
+// files, which is required when running non-interactively unless approved by
+// flag.
+func (c *RenderCommand) confirmClean(stale []string) (bool, error) {
+	if c.autoApproved {
+		return true, nil
+	}
+
+	// For non-interactive UIs, the value must be passed by flag.
+	if !c.ui.Interactive() {
+		return false, errors.New("removing stale rendered files requires confirmation; use --auto-approve when running non-interactively")
+	}
+
+	for {
+		remove, err := c.ui.Input(&terminal.Input{
+			Prompt: fmt.Sprintf("%d stale rendered file(s) will be removed (%s), continue? [y/n] ",
+				len(stale), strings.Join(stale, ", ")),
+			Style: terminal.WarningBoldStyle,
+		})
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(remove) {
+		case "y":
+			return true, nil
+		case "n":
+			return false, nil
+		default:
+			c.ui.Output("Please select a valid option.\n", terminal.WithStyle(terminal.ErrorBoldStyle))
+		}
+	}
+}
+
 func confirmOverwrite(c *RenderCommand, path string) (bool, error) {
 	// For non-interactive UIs, the value must be passed by flag.
 	if !c.ui.Interactive() {
@@ -138,7 +477,11 @@ func validateOutDir(path string) error {
 	return nil
 }
 
-func writeFile(c *RenderCommand, path string, content string) error {
+func writeFile(c *RenderCommand, path string, content string, mode fs.FileMode) error {
+	if mode == 0 {
+		mode = 0644
+	}
+
 	// Check to see if the file already exists and validate against the value
 	// of overwrite.
 	_, err := os.Stat(path)
@@ -153,11 +496,17 @@ func writeFile(c *RenderCommand, path string, content string) error {
 		}
 	}
 
-	err = os.WriteFile(path, []byte(content), 0644)
+	err = os.WriteFile(path, []byte(content), mode)
 	if err != nil {
 		return fmt.Errorf("failed to write rendered template to file: %s", err)
 	}
 
+	// The mode is only applied by WriteFile when creating the file, so set it
+	// again in case an existing file was overwritten.
+	if err = os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set mode of rendered file: %s", err)
+	}
+
 	return nil
 }
 
@@ -245,6 +594,139 @@ func rangeRenders(subj map[string]string, target *[]Render) {
 	}
 }
 
+// rangeSensitiveFiles appends the sensitive files to the target renders,
+// sorted by name. Files are named like the templates, without the templates
+// directory, and must not share a name with any render.
+func rangeSensitiveFiles(files map[string]renderer.SensitiveFile, target *[]Render) error {
+	keys := maps.Keys(files)
+	slices.Sort(keys)
+	for _, key := range keys {
+		packKey, name, _ := strings.Cut(key, "/templates/")
+		render := Render{
+			Name:      packKey + "/" + name,
+			Content:   files[key].Content,
+			Mode:      files[key].Mode,
+			Sensitive: true,
+		}
+		for _, existing := range *target {
+			if existing.Name == render.Name {
+				return fmt.Errorf("file %s has the same name as a rendered template", render.Name)
+			}
+		}
+		*target = append(*target, render)
+	}
+	return nil
+}
+
+// filterRenderOnly returns the rendered templates whose path within the
+// templates directory of their pack matches the --render-only glob. The glob
+// matches the template file name either with or without its .tpl extension.
+func filterRenderOnly(glob string, rendered map[string]string) map[string]string {
+	out := make(map[string]string)
+	for name, content := range rendered {
+		_, tplPath, _ := strings.Cut(name, "/templates/")
+		if ok, _ := path.Match(glob, tplPath); ok {
+			out[name] = content
+			continue
+		}
+		if ok, _ := path.Match(glob, strings.TrimSuffix(tplPath, ".tpl")); ok {
+			out[name] = content
+		}
+	}
+	return out
+}
+
+// applyOutputName renames each render containing a job specification using
+// the passed name template. The pack-relative directory of the render is kept
+// so that dependent packs still write into their own directories. The vars are
+// the values of the parent pack's variables, so renders of the same pack with
+// different variables can be given distinct names.
+func applyOutputName(nameTpl string, renders []Render, vars map[string]any) error {
+	tpl, err := template.New("output-name").Option("missingkey=error").Parse(nameTpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse --output-name template: %w", err)
+	}
+
+	seen := make(map[string]string, len(renders))
+	for i, r := range renders {
+		jobName, ok := renderedJobName(r.Content)
+		if !ok {
+			continue
+		}
+
+		var buf strings.Builder
+		dir, file := path.Split(r.Name)
+		err = tpl.Execute(&buf, outputNameData{
+			JobName:      jobName,
+			TemplateName: file,
+			Vars:         vars,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to execute --output-name template for %s: %w", r.Name, err)
+		}
+
+		name := buf.String()
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("--output-name produced invalid file name %q for %s", name, r.Name)
+		}
+
+		newName := path.Join(dir, name)
+		if prev, ok := seen[newName]; ok {
+			return fmt.Errorf("--output-name produced %q for both %s and %s", newName, prev, r.Name)
+		}
+		seen[newName] = r.Name
+		renders[i].Name = newName
+	}
+	return nil
+}
+
+// sortRendersByDependencies sorts the renders so that each job follows the
+// jobs listed by the job.DependsOnKey meta of its job block. Renders which are
+// not jobs keep their place where the dependencies allow.
+func sortRendersByDependencies(renders []Render) ([]Render, error) {
+	byName := make(map[string]Render, len(renders))
+	names := make([]string, 0, len(renders))
+	jobIDs := map[string]string{}
+	dependsOn := map[string][]string{}
+	for _, r := range renders {
+		byName[r.Name] = r
+		names = append(names, r.Name)
+		if jobID, ok := renderedJobName(r.Content); ok {
+			jobIDs[r.Name] = jobID
+			dependsOn[r.Name] = job.TemplateDependsOn(r.Content)
+		}
+	}
+
+	order, err := job.DependencyOrder(names, jobIDs, dependsOn)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]Render, 0, len(renders))
+	for _, name := range order {
+		sorted = append(sorted, byName[name])
+	}
+	return sorted, nil
+}
+
+// renderedJobName returns the label of the job block in the rendered
+// template, if the template is a parseable job specification.
+func renderedJobName(content string) (string, bool) {
+	file, diags := hclsyntax.ParseConfig([]byte(content), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", false
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return "", false
+	}
+	for _, block := range body.Blocks {
+		if block.Type == "job" && len(block.Labels) == 1 {
+			return block.Labels[0], true
+		}
+	}
+	return "", false
+}
+
 // Run satisfies the Run function of the cli.Command interface.
 func (c *RenderCommand) Run(args []string) int {
 	c.cmdKey = "render" // Add cmdKey here to print out helpUsageMessage on Init error
@@ -262,12 +744,45 @@ func (c *RenderCommand) Run(args []string) int {
 	c.packConfig.Name = c.args[0]
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
+
+	warmPack(c.packConfig, c.ui)
+	defer readLockCache(c.packConfig, c.ui)()
 
 	if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
 		return 1
 	}
 
+	// Packs passed as zip files are extracted, and used from there.
+	cleanup, err := extractPackZip(c.packConfig, c.ui, errorContext)
+	if err != nil {
+		return 1
+	}
+	defer cleanup()
+
+	// Render a copy of the pack as committed at the ref, rather than the
+	// working tree.
+	if c.fromRef != "" {
+		if c.packConfig.Registry != cache.DevRegistryName {
+			c.ui.ErrorWithContext(errors.New("--from-ref can only be used with packs from a local path"),
+				"failed to render pack", errorContext.GetAll()...)
+			return 1
+		}
+		errorContext.Add("Git Ref: ", c.fromRef)
+
+		tmpDir, err := os.MkdirTemp("", "nomad-pack-ref-")
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to create temporary directory", errorContext.GetAll()...)
+			return 1
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if c.packConfig.Path, err = cache.CheckoutPackRef(c.packConfig.Path, c.fromRef, tmpDir); err != nil {
+			c.ui.ErrorWithContext(err, "failed to check out pack ref", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
 	client, err := c.getAPIClient()
 	if err != nil {
 		c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
@@ -278,34 +793,193 @@ func (c *RenderCommand) Run(args []string) int {
 		c.ui.Error(err.Error())
 		return 1
 	}
+	if c.outFormat == renderFormatJSONWithMeta {
+		for name, set := range map[string]bool{
+			"--to-dir":         c.renderToDir != "",
+			"--compare-to-ref": c.compareToRef != "",
+			"--check-format":   c.checkFormat,
+			"--outputs":        c.renderOutputs,
+		} {
+			if set {
+				c.ui.Error(fmt.Sprintf("--out-format=%s can not be used with %s", renderFormatJSONWithMeta, name))
+				return 1
+			}
+		}
+	}
+	if c.clean && c.renderToDir == "" {
+		c.ui.Error("--clean can only be used with --to-dir")
+		return 1
+	}
+	if _, err = path.Match(c.renderOnly, ""); err != nil {
+		c.ui.Error(fmt.Sprintf("invalid --render-only pattern %q: %s", c.renderOnly, err))
+		return 1
+	}
 	packManager := generatePackManager(c.baseCommand, client, c.packConfig)
 
-	renderOutput, err := renderPack(
-		packManager,
-		c.baseCommand.ui,
+	// Check the variables without executing any templates, which is much
+	// quicker than a full render.
+	if c.varTypeCheckOnly {
+		if varErrs := packManager.CheckVariables(); varErrs != nil {
+			reportRenderErrors(packManager, c.ui, varErrs, errorContext)
+			return 1
+		}
+		c.ui.Success("Pack variables are valid")
+		return 0
+	}
+
+	// Render the pack directly rather than with renderPack, so any templates
+	// which failed when keeping going can be reported after the output.
+	renderOutput, renderErrs := packManager.ProcessTemplates(
 		!c.noRenderAuxFiles,
-		!c.noFormat,
+		!c.noFormat && !c.checkFormat,
 		c.baseCommand.ignoreMissingVars,
-		errorContext,
+		c.keepGoing,
 	)
-	if err != nil {
+	if renderOutput == nil {
+		reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
 		return 1
 	}
 
 	// The render command should at least render one parent, or one dependant
 	// pack template.
 	if renderOutput.LenParentRenders() < 1 && renderOutput.LenDependentRenders() < 1 {
+		reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
 		c.ui.ErrorWithContext(errors.ErrNoTemplatesRendered, "no templates rendered", errorContext.GetAll()...)
 		return 1
 	}
 
+	dependentRenders := renderOutput.DependentRenders()
+	parentRenders := renderOutput.ParentRenders()
+	emptyTemplates := renderOutput.EmptyRenders()
+
+	// Restrict the output to the templates matching --render-only, erroring
+	// with the available templates when none match.
+	if c.renderOnly != "" {
+		var available []string
+		for _, rendered := range []map[string]string{dependentRenders, parentRenders, emptyTemplates} {
+			for name := range rendered {
+				_, tplPath, _ := strings.Cut(name, "/templates/")
+				available = append(available, tplPath)
+			}
+		}
+
+		dependentRenders = filterRenderOnly(c.renderOnly, dependentRenders)
+		parentRenders = filterRenderOnly(c.renderOnly, parentRenders)
+		emptyTemplates = filterRenderOnly(c.renderOnly, emptyTemplates)
+
+		if len(dependentRenders)+len(parentRenders)+len(emptyTemplates) == 0 {
+			slices.Sort(available)
+			c.ui.ErrorWithContext(
+				fmt.Errorf("no templates match %q, available templates are: %s",
+					c.renderOnly, strings.Join(slices.Compact(available), ", ")),
+				"no templates rendered", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
 	var renders []Render
 
 	// Iterate the rendered files and add these to the list of renders to
 	// output. This allows errors to surface and end things without emitting
 	// partial output and then erroring out.
-	rangeRenders(renderOutput.DependentRenders(), &renders)
-	rangeRenders(renderOutput.ParentRenders(), &renders)
+	rangeRenders(dependentRenders, &renders)
+	rangeRenders(parentRenders, &renders)
+
+	// Templates which rendered to only whitespace are not deployed, so are
+	// only shown when asked, to make clear which conditional templates
+	// collapsed to nothing.
+	var emptyRenders []Render
+	rangeRenders(emptyTemplates, &emptyRenders)
+	if c.warnEmpty {
+		for _, render := range emptyRenders {
+			c.ui.Warning(fmt.Sprintf("Template %s rendered to an empty file", render.Name))
+		}
+	}
+	if c.failOnEmptyRender {
+		rendered := make([]string, 0, len(renders))
+		for _, render := range renders {
+			rendered = append(rendered, render.Content)
+		}
+		if err = checkEmptyRender(renderOutput, rendered, errorContext); err != nil {
+			reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
+			c.ui.ErrorWithContext(err, "empty render", errorContext.GetAll()...)
+			return 1
+		}
+	}
+	if c.showEmpty {
+		renders = append(renders, emptyRenders...)
+	}
+
+	// Output the jobs after the jobs they depend on, so they can be applied
+	// in the order they are output.
+	if c.dependencyOrder {
+		if renders, err = sortRendersByDependencies(renders); err != nil {
+			c.ui.ErrorWithContext(err, "failed to order jobs by dependencies", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// When checking the format, report the unformatted job specifications
+	// rather than outputting the renders.
+	if c.checkFormat {
+		exitCode := c.checkRendersFormat(renders)
+		if len(renderErrs) > 0 {
+			reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
+			return 1
+		}
+		return exitCode
+	}
+
+	// Rename the rendered job specifications if the user has asked for a
+	// consistent naming scheme.
+	if c.outputName != "" {
+		if err = applyOutputName(c.outputName, renders, packManager.Vars()); err != nil {
+			c.ui.ErrorWithContext(err, "failed to apply output name", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// Output the jobs as JSON, for ingestion by other tooling, rather than
+	// the rendered templates.
+	if c.outFormat == renderFormatJSONWithMeta {
+		if len(renderErrs) > 0 {
+			reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
+			return 1
+		}
+		if err = c.outputRenderedJobs(client, packManager, renders); err != nil {
+			c.ui.ErrorWithContext(err, "failed to output jobs", errorContext.GetAll()...)
+			return 1
+		}
+		return 0
+	}
+
+	// When comparing against another ref, output the differences between the
+	// renders instead of the renders themselves.
+	if c.compareToRef != "" {
+		if len(renderErrs) > 0 {
+			reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
+			return 1
+		}
+		return c.compareRenders(client, renders, errorContext)
+	}
+
+	// When only the outputs are wanted, render the output template and display
+	// the named values it defines instead of the job specifications.
+	if c.renderOutputs {
+		if len(renderErrs) > 0 {
+			reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
+			return 1
+		}
+		if _, err = packManager.ProcessOutputTemplate(); err != nil {
+			c.ui.ErrorWithContext(err, "failed to render output template", errorContext.GetAll()...)
+			return 1
+		}
+		if err = outputPackOutputs(c.ui, packManager.ProcessedOutputs(), c.outputsFormat); err != nil {
+			c.ui.ErrorWithContext(err, "failed to display outputs", errorContext.GetAll()...)
+			return 1
+		}
+		return 0
+	}
 
 	// If the user wants to render and display the outputs template file then
 	// render this. In the event the render returns an error, print this but do
@@ -322,6 +996,31 @@ func (c *RenderCommand) Run(args []string) int {
 		}
 	}
 
+	// Add the hooks the pack defines, so they can be reviewed before they are
+	// run by the run command.
+	if c.renderOnly == "" {
+		preHook, postHook, err := packManager.ProcessHookTemplates()
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to render hooks", errorContext.GetAll()...)
+			return 1
+		}
+		for _, hook := range []Render{
+			{Name: packManager.PackName() + "/hooks/" + hookPreDeploy, Content: preHook},
+			{Name: packManager.PackName() + "/hooks/" + hookPostDeploy, Content: postHook},
+		} {
+			if strings.TrimSpace(hook.Content) != "" {
+				renders = append(renders, hook)
+			}
+		}
+	}
+
+	// Add the files written by the fileFromBase64 template function, which
+	// are never shown, so they are written alongside the templates.
+	if err = rangeSensitiveFiles(renderOutput.SensitiveFiles(), &renders); err != nil {
+		c.ui.ErrorWithContext(err, "failed to render sensitive files", errorContext.GetAll()...)
+		return 1
+	}
+
 	// Output the renders. Output the files first if enabled so that any renders
 	// that display will also have been written to disk.
 	for _, render := range renders {
@@ -338,11 +1037,226 @@ func (c *RenderCommand) Run(args []string) int {
 		render.toTerminal(c)
 	}
 
+	// Record the files written when cleaning, removing those left by the
+	// previous render. Failed templates have no render, so their files are
+	// kept by skipping this until every template renders.
+	if c.renderToDir != "" && len(renderErrs) == 0 {
+		if c.clean {
+			if err = c.updateRenderManifest(renders); err != nil {
+				c.ui.ErrorWithContext(err, "failed to clean rendered files", errorContext.GetAll()...)
+				return 1
+			}
+		}
+		if err = c.writeRenderIndex(renders); err != nil {
+			c.ui.ErrorWithContext(err, "failed to index rendered files", errorContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// Report any templates which failed to render now the successful renders
+	// have been output.
+	if len(renderErrs) > 0 {
+		reportRenderErrors(packManager, c.ui, renderErrs, errorContext)
+		return 1
+	}
+
+	return 0
+}
+
+// outputRenderedJobs outputs the rendered job specifications as a JSON array,
+// each parsed into its Nomad JSON form and wrapped with the details of the
+// pack. Renders which are not job specifications are skipped. The renders are
+// already sorted, so the output is identical for identical renders.
+func (c *RenderCommand) outputRenderedJobs(client *api.Client, pm *manager.PackManager, renders []Render) error {
+	packDetails := renderedJobPack{
+		Name:     pm.PackName(),
+		Ref:      c.packConfig.Ref,
+		Registry: c.packConfig.Registry,
+	}
+	localRef, err := c.packConfig.LocalRef()
+	if err != nil {
+		return fmt.Errorf("failed to read registry ref: %w", err)
+	}
+	packDetails.LocalRef = localRef
+	if meta := pm.Metadata(); meta != nil && meta.Pack != nil {
+		packDetails.Version = meta.Pack.Version
+	}
+
+	jobs := make([]renderedJob, 0, len(renders))
+	for _, r := range renders {
+		if _, ok := renderedJobName(r.Content); !ok {
+			continue
+		}
+
+		job, err := client.Jobs().ParseHCLOpts(&api.JobsParseRequest{
+			JobHCL:       r.Content,
+			Canonicalize: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", r.Name, err)
+		}
+
+		sum := sha256.Sum256([]byte(r.Content))
+		jobs = append(jobs, renderedJob{
+			Pack:         packDetails,
+			Template:     r.Name,
+			RenderSHA256: hex.EncodeToString(sum[:]),
+			Job:          job,
+		})
+	}
+
+	out, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode jobs: %w", err)
+	}
+	stdout, _, err := c.ui.OutputWriters()
+	if err != nil {
+		return fmt.Errorf("failed to get output writers: %w", err)
+	}
+	_, err = fmt.Fprintln(stdout, string(out))
+	return err
+}
+
+// compareRenders renders the pack at the ref passed to --compare-to-ref, using
+// the same variables, and outputs a diff against the passed renders for each
+// rendered file which differs.
+func (c *RenderCommand) compareRenders(client *api.Client, renders []Render, errorContext *errors.UIErrorContext) int {
+	if c.packConfig.Registry == cache.DevRegistryName {
+		c.ui.ErrorWithContext(errors.New("--compare-to-ref can only be used with packs from a registry"),
+			"failed to compare renders", errorContext.GetAll()...)
+		return 1
+	}
+
+	compareConfig := &cache.PackConfig{
+		Registry: c.packConfig.Registry,
+		Name:     c.packConfig.Name,
+		Ref:      c.compareToRef,
+	}
+	compareContext := c.initPackCommand(compareConfig)
+	if err := cache.VerifyPackExists(compareConfig, compareContext, c.ui); err != nil {
+		return 1
+	}
+
+	compareManager := generatePackManager(c.baseCommand, client, compareConfig)
+	compareOutput, err := renderPack(
+		compareManager,
+		c.ui,
+		!c.noRenderAuxFiles,
+		!c.noFormat,
+		c.baseCommand.ignoreMissingVars,
+		compareContext,
+	)
+	if err != nil {
+		return 1
+	}
+
+	var compareRenders []Render
+	rangeRenders(compareOutput.DependentRenders(), &compareRenders)
+	rangeRenders(compareOutput.ParentRenders(), &compareRenders)
+	if c.outputName != "" {
+		if err = applyOutputName(c.outputName, compareRenders, compareManager.Vars()); err != nil {
+			c.ui.ErrorWithContext(err, "failed to apply output name", compareContext.GetAll()...)
+			return 1
+		}
+	}
+
+	// Index the renders of both refs by name, so files which only exist at one
+	// of the refs are shown as added or removed.
+	before := make(map[string]string, len(compareRenders))
+	for _, r := range compareRenders {
+		before[r.Name] = r.Content
+	}
+	after := make(map[string]string, len(renders))
+	for _, r := range renders {
+		after[r.Name] = r.Content
+	}
+
+	names := maps.Keys(before)
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changed int
+	for _, name := range names {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(before[name]),
+			B:        difflib.SplitLines(after[name]),
+			FromFile: fmt.Sprintf("%s (%s)", name, compareConfig.Ref),
+			ToFile:   fmt.Sprintf("%s (%s)", name, c.packConfig.Ref),
+			Context:  3,
+		})
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to compare renders", "Template Name: "+name)
+			return 1
+		}
+		if diff == "" {
+			continue
+		}
+		changed++
+		c.outputResult(diff)
+	}
+
+	if changed == 0 {
+		c.ui.Success(fmt.Sprintf("No differences between the renders at %s and %s.", compareConfig.Ref, c.packConfig.Ref))
+	}
 	return 0
 }
 
+// checkRendersFormat outputs a diff for each rendered job specification which
+// differs from its canonical HCL formatting. It returns the exit code for the
+// command, which is non-zero when any render is not formatted.
+func (c *RenderCommand) checkRendersFormat(renders []Render) int {
+	var unformatted []string
+	for _, r := range renders {
+		if !strings.HasSuffix(r.Name, ".nomad") && !strings.HasSuffix(r.Name, ".hcl") {
+			continue
+		}
+
+		diff, err := formatDiff(r)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to check format", "Template Name: "+r.Name)
+			return 1
+		}
+		if diff == "" {
+			continue
+		}
+
+		unformatted = append(unformatted, r.Name)
+		c.outputResult(diff)
+	}
+
+	if len(unformatted) > 0 {
+		c.ui.Error(fmt.Sprintf("The following rendered templates are not canonically formatted:\n  %s",
+			strings.Join(unformatted, "\n  ")))
+		return 1
+	}
+
+	c.ui.Success("All rendered templates are canonically formatted.")
+	return 0
+}
+
+// formatDiff returns a unified diff between the render and its canonical HCL
+// formatting. An empty string is returned if the render is already formatted.
+func formatDiff(r Render) (string, error) {
+	formatted := string(renderer.FormatHCL([]byte(r.Content)))
+	if formatted == r.Content {
+		return "", nil
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(r.Content),
+		B:        difflib.SplitLines(formatted),
+		FromFile: r.Name,
+		ToFile:   r.Name + " (formatted)",
+		Context:  3,
+	})
+}
+
 func (c *RenderCommand) Flags() *flag.Sets {
-	return c.flagSet(flagSetOperation|flagSetNeedsApproval, func(set *flag.Sets) {
+	return c.flagSet(flagSetOperation|flagSetNeedsApproval|flagSetNomadClient, func(set *flag.Sets) {
 		c.packConfig = &cache.PackConfig{}
 
 		f := set.NewSet("Render Options")
@@ -374,6 +1288,36 @@ func (c *RenderCommand) Flags() *flag.Sets {
 					pack is rendered and displayed.`,
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:    "outputs",
+			Target:  &c.renderOutputs,
+			Default: false,
+			Usage: `Display only the named output values defined by the output
+					template within the pack, instead of the rendered templates.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "out-format",
+			Target:  &c.outFormat,
+			Values:  []string{renderFormatHCL, renderFormatJSONWithMeta},
+			Default: renderFormatHCL,
+			Usage: `The format the rendered templates are output in. The
+					nomad-json-with-meta format outputs a JSON array holding
+					each rendered job in its Nomad JSON form, along with the
+					pack name, version, ref, registry, and the git SHA the
+					registry was cloned at, and the SHA256 of the rendered
+					template. Jobs are parsed by the Nomad API.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "format",
+			Target:  &c.outputsFormat,
+			Values:  []string{"text", "json"},
+			Default: "text",
+			Usage: `The format used to display the outputs when using --outputs.
+					The json format emits the outputs as a single object.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "skip-aux-files",
 			Target:  &c.noRenderAuxFiles,
@@ -389,15 +1333,175 @@ func (c *RenderCommand) Flags() *flag.Sets {
 			Usage:   `Controls whether or not to format templates before outputting.`,
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:    "keep-variables-block",
+			Target:  &c.keepVariablesBlock,
+			Default: false,
+			Usage: `Leaves the HCL2 variable interpolations, such as
+					${var.image}, in the rendered job specifications and
+					declares the variables they reference in a variables
+					block, with the values of the pack variables of the same
+					name as their defaults. The job is then self-contained,
+					but its variables can still be set when it is submitted
+					to Nomad. Can not be used with --defer-vars.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "dump-ast",
+			Target:  &c.dumpAST,
+			Default: false,
+			Hidden:  true,
+			Usage: `Debugging aid which writes the parse tree of each template
+					to stderr before the templates are executed. The render
+					itself is unchanged.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "show-vars",
+			Target:  &c.showVars,
+			Default: false,
+			Usage: `Annotates the rendered job specifications with comments
+					naming the pack variable which produced each interpolated
+					value, or the template expression when the value is
+					derived from variables by other means. This is useful
+					when reviewing rendered output.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "show-empty",
+			Target:  &c.showEmpty,
+			Default: false,
+			Usage: `Includes the templates which render to only whitespace in
+					the output, as empty files.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "warn-empty",
+			Target:  &c.warnEmpty,
+			Default: false,
+			Usage: `Logs a warning for each template which renders to only
+					whitespace, such as when a condition is always false.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "var-type-check-only",
+			Target:  &c.varTypeCheckOnly,
+			Default: false,
+			Usage: `Checks the supplied variable values against the types of
+					the pack's variable declarations, that each required
+					variable is set, and any --assert-var values, without
+					rendering any templates. All violations are reported
+					together.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "fail-on-empty-render",
+			Target:  &c.failOnEmptyRender,
+			Default: false,
+			Usage: `If set, the command fails when the pack renders no
+					non-empty job specifications, so CI catches a pack whose
+					templates have all collapsed to nothing.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "compare-to-ref",
+			Target:  &c.compareToRef,
+			Default: "",
+			Usage: `Renders the pack at this ref in addition to the requested
+					ref, using the same variables, and outputs a diff of the
+					rendered files instead of the renders. Only supported for
+					packs from a registry.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "from-ref",
+			Target:  &c.fromRef,
+			Default: "",
+			Usage: `Renders a pack from a local path as it exists at this git
+					ref of the repository containing it, such as a tag, branch,
+					or SHA. The working tree, including any uncommitted
+					changes, is not used. Only supported for packs from a
+					local path.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "render-only",
+			Target:  &c.renderOnly,
+			Default: "",
+			Usage: `A glob which restricts the output to the templates whose
+					path within the templates directory matches, such as
+					"app.nomad.tpl" or "*.conf". The .tpl extension may be
+					omitted. Errors with the available templates if none
+					match.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "check-format",
+			Target:  &c.checkFormat,
+			Default: false,
+			Usage: `Checks the rendered job specifications are canonically HCL
+					formatted instead of outputting them. A diff is shown for
+					each unformatted template and the command exits non-zero.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "keep-going",
+			Target:  &c.keepGoing,
+			Default: false,
+			Usage: `Controls whether the remaining templates are rendered and
+					output when a template fails to render. Any failures are
+					reported after the output and the command exits non-zero.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "output-name",
+			Target:  &c.outputName,
+			Default: "",
+			Usage: `A template used to name rendered job specifications, in
+					the output and when writing to --to-dir. The template is
+					evaluated with the job name as {{.JobName}}, the
+					template file name, without the .tpl extension, as
+					{{.TemplateName}}, and the values of the pack's variables
+					as {{.Vars}}. For example "{{.Vars.tenant}}-{{.JobName}}.nomad".
+					Templates which do not contain a job are not renamed, and
+					it is an error for two templates to be given the same name.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "dependency-order",
+			Target:  &c.dependencyOrder,
+			Default: false,
+			Usage: `Output each job after the jobs it depends on, rather than in
+					template name order. A job lists the IDs of the jobs it
+					depends on, separated by commas, in the depends_on key of
+					its meta block. It is an error for a job to depend on a
+					job which is not rendered, or for the dependencies to
+					form a cycle.`,
+		})
+
 		f.StringVarP(&flag.StringVarP{
 			StringVar: &flag.StringVar{
 				Name:   "to-dir",
 				Target: &c.renderToDir,
 				Usage: `Path to write rendered job files to in addition to
-						standard output.`,
+						standard output. A ` + renderIndexName + ` file listing the
+						job, path, size, and sha256 of each file written is
+						also written to the directory.`,
 			},
 			Shorthand: "o",
 		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "clean",
+			Target:  &c.clean,
+			Default: false,
+			Usage: `Records the files written to --to-dir in the directory's
+					` + renderManifestName + ` file, and removes those recorded
+					by the previous --clean render of the same pack deployment
+					which were not produced by this one, such as those of a
+					removed job. Files skipped by --render-only are kept.
+					Requires confirmation unless --auto-approve is set.`,
+		})
 	})
 }
 
@@ -409,29 +1513,89 @@ func (c *RenderCommand) AutocompleteFlags() complete.Flags {
 	return c.Flags().Completions()
 }
 
+// examples satisfies the examples function of the exampleCommand interface.
+func (c *RenderCommand) examples() []commandExample {
+	return []commandExample{
+		{
+			description: "Render an example pack with override variables in a variable file.",
+			args:        []string{"render", examplePack, "--var-file=./overrides.hcl"},
+		},
+		{
+			description: "Render an example pack with cli variable overrides.",
+			args:        []string{"render", examplePack, "--var=count=3", `--var=datacenters=["dc1", "dc2"]`},
+			runnable:    true,
+		},
+		{
+			description: "Render an example pack including the outputs template file.",
+			args:        []string{"render", examplePack, "--render-output-template"},
+			runnable:    true,
+		},
+		{
+			description: "Display the named outputs of an example pack as a JSON object.",
+			args:        []string{"render", examplePack, "--outputs", "--format=json"},
+			runnable:    true,
+		},
+		{
+			description: "Render an example pack, outputting the rendered templates to file in\n" +
+				"addition to the terminal. Setting auto-approve allows the command to\n" +
+				"overwrite existing files.",
+			args: []string{"render", examplePack, "--to-dir", "~/out", "--auto-approve"},
+		},
+		{
+			description: "Render an example pack, naming each job specification after its job.",
+			args:        []string{"render", examplePack, "--to-dir", "~/out", "--output-name={{.JobName}}.nomad"},
+		},
+		{
+			description: "Render an example pack, warning about templates which render to nothing.",
+			args:        []string{"render", examplePack, "--warn-empty"},
+			runnable:    true,
+		},
+		{
+			description: "Show how the rendered output of a registry pack changes between refs.",
+			args:        []string{"render", examplePack, "--registry=community", "--ref=v0.0.2", "--compare-to-ref=v0.0.1"},
+		},
+		{
+			description: "Render a local pack as committed at a git tag, ignoring the working tree.",
+			args:        []string{"render", "./" + examplePack, "--from-ref=v0.0.1"},
+		},
+		{
+			description: "Render only the templates of an example pack matching a glob.",
+			args:        []string{"render", examplePack, "--render-only=*.nomad"},
+			runnable:    true,
+		},
+		{
+			description: "Check the variable values supplied to an example pack have the types\n" +
+				"it declares, without rendering any templates.",
+			args:     []string{"render", examplePack, "--var=count=3", "--var-type-check-only"},
+			runnable: true,
+		},
+		{
+			description: "Render an example pack, annotating each value with the variable which\n" +
+				"produced it.",
+			args:     []string{"render", examplePack, "--show-vars"},
+			runnable: true,
+		},
+		{
+			description: "Check the rendered job specifications of a pack are formatted.",
+			args:        []string{"render", examplePack, "--check-format"},
+		},
+		{
+			description: "Render an example pack, outputting the templates which render even if\n" +
+				"another template fails.",
+			args:     []string{"render", examplePack, "--keep-going"},
+			runnable: true,
+		},
+		{
+			description: "Render a pack under development from the filesystem - supports current\n" +
+				"working directory or relative path",
+			args: []string{"render", "."},
+		},
+	}
+}
+
 // Help satisfies the Help function of the cli.Command interface.
 func (c *RenderCommand) Help() string {
-
-	c.Example = `
-	# Render an example pack with override variables in a variable file.
-	nomad-pack render example --var-file="./overrides.hcl"
-
-	# Render an example pack with cli variable overrides.
-	nomad-pack render example --var="redis_image_version=latest" \
-		--var="redis_resources={"cpu": "1000", "memory": "512"}"
-
-	# Render an example pack including the outputs template file.
-	nomad-pack render example --render-output-template
-
-	# Render an example pack, outputting the rendered templates to file in
-	# addition to the terminal. Setting auto-approve allows the command to
-	# overwrite existing files.
-	nomad-pack render example --to-dir ~/out --auto-approve
-
-	# Render a pack under development from the filesystem - supports current
-	# working directory or relative path
-	nomad-pack render .
-	`
+	c.Example = formatExamples(c.examples())
 
 	return formatHelp(`
 	Usage: nomad-pack render <pack-name> [options]
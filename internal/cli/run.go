@@ -5,12 +5,18 @@ package cli
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/posener/complete"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/internal/pkg/manager"
 	"github.com/hashicorp/nomad-pack/internal/runner"
 	"github.com/hashicorp/nomad-pack/internal/runner/job"
 )
@@ -20,6 +26,22 @@ type RunCommand struct {
 	packConfig *cache.PackConfig
 	jobConfig  *job.CLIConfig
 	Validation ValidationFn
+
+	// failOnEmptyRender is a boolean flag to control whether the command
+	// fails when the pack renders no non-empty job specifications.
+	failOnEmptyRender bool
+
+	// jobFile is the path to an already rendered job specification, which is
+	// deployed in place of a pack.
+	jobFile string
+
+	// runHooks is whether the pre and post deploy hooks of the pack are run.
+	runHooks bool
+
+	// preHookFailure and postHookFailure are the actions taken when the
+	// respective hook fails, either hookFailureAbort or hookFailureWarn.
+	preHookFailure  string
+	postHookFailure string
 }
 
 func (c *RunCommand) Run(args []string) int {
@@ -27,7 +49,7 @@ func (c *RunCommand) Run(args []string) int {
 
 	// Initialize. If we fail, we just exit since Init handles the UI.
 	if err := c.Init(
-		WithExactArgs(1, args),
+		WithCustomArgs(args, PackOrJobFileArgs(&c.jobFile)),
 		WithFlags(c.Flags()),
 		WithNoConfig(),
 	); err != nil {
@@ -42,73 +64,143 @@ func (c *RunCommand) Run(args []string) int {
 // pulled from the RunCommand as these are parsed with the Run.
 func (c *RunCommand) run() int {
 
-	c.packConfig.Name = c.args[0]
-
-	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
-
-	// verify packs exist before running jobs
-	err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui)
-	if err != nil {
+	if c.jobConfig.RunConfig.CheckCurrentIndex && c.jobConfig.RunConfig.CheckIndexSet {
+		c.ui.ErrorWithContext(errors.New("--check-current-index can not be used with --check-index"), ErrParsingArgsOrFlags)
 		return 1
 	}
 
-	// If no deploymentName set default to pack@ref
-	c.deploymentName = getDeploymentName(c.baseCommand, c.packConfig)
-	errorContext.Add(errors.UIContextPrefixDeploymentName, c.deploymentName)
-
-	// create the http client
-	client, err := c.getAPIClient()
-	if err != nil {
-		c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
+	if c.jobConfig.RunConfig.FollowLogs && c.jobConfig.RunConfig.Detach {
+		c.ui.ErrorWithContext(errors.New("--follow-logs can not be used with --detach"), ErrParsingArgsOrFlags)
 		return 1
 	}
 
-	packManager := generatePackManager(c.baseCommand, client, c.packConfig)
-
-	// Render the pack now, before creating the deployer. If we get an error
-	// we won't make it to the deployer.
-	r, err := renderPack(
-		packManager,
-		c.baseCommand.ui,
-		false,
-		false,
-		c.baseCommand.ignoreMissingVars,
-		errorContext,
+	var (
+		errorContext *errors.UIErrorContext
+		client       *api.Client
+		packManager  *manager.PackManager
+		depConfig    *runner.Config
+		templates    map[string]string
+		preHook      hookRun
+		postHook     hookRun
+		err          error
 	)
-	if err != nil {
-		return 255
-	}
 
-	renderedParents := r.ParentRenders()
-	renderedDeps := r.DependentRenders()
-
-	// TODO: Refactor to use PackConfig. Maybe PackConfig should be in a more common
-	// pkg than cache, or maybe it's ok for runner to depend on the cache.
-	// Need to discuss with jrasell.
-	depConfig := runner.Config{
-		PackName:       c.packConfig.Name,
-		PathPath:       c.packConfig.Path,
-		PackRef:        c.packConfig.Ref,
-		DeploymentName: c.deploymentName,
-		RegistryName:   c.packConfig.Registry,
+	if c.jobFile != "" {
+		// The job file is already rendered, so it is deployed as it is.
+		templates, depConfig, errorContext, err = c.jobFileDeployment(c.jobFile)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to read job file", errorContext.GetAll()...)
+			return 1
+		}
+
+		if client, err = c.getAPIClient(); err != nil {
+			c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
+			return 1
+		}
+	} else {
+		c.packConfig.Name = c.args[0]
+
+		// Set the packConfig defaults if necessary and generate our UI error context.
+		errorContext = c.initPackCommand(c.packConfig)
+
+		warmPack(c.packConfig, c.ui)
+		defer readLockCache(c.packConfig, c.ui)()
+
+		// verify packs exist before running jobs
+		if err := cache.VerifyPackExists(c.packConfig, errorContext, c.ui); err != nil {
+			return 1
+		}
+
+		// Packs passed as zip files are extracted, and used from there.
+		cleanup, err := extractPackZip(c.packConfig, c.ui, errorContext)
+		if err != nil {
+			return 1
+		}
+		defer cleanup()
+
+		// If no deploymentName set default to pack@ref
+		c.deploymentName = getDeploymentName(c.baseCommand, c.packConfig)
+		errorContext.Add(errors.UIContextPrefixDeploymentName, c.deploymentName)
+
+		// create the http client
+		if client, err = c.getAPIClient(); err != nil {
+			c.ui.ErrorWithContext(err, "failed to initialize client", errorContext.GetAll()...)
+			return 1
+		}
+
+		packManager = generatePackManager(c.baseCommand, client, c.packConfig)
+
+		// Render the pack now, before creating the deployer. If we get an error
+		// we won't make it to the deployer.
+		r, err := renderPack(
+			packManager,
+			c.baseCommand.ui,
+			false,
+			false,
+			c.baseCommand.ignoreMissingVars,
+			errorContext,
+		)
+		if err != nil {
+			return 255
+		}
+
+		renderedParents := r.ParentRenders()
+		renderedDeps := r.DependentRenders()
+
+		// Render the hooks along with the templates, so they are run with
+		// the same variables.
+		preScript, postScript, err := packManager.ProcessHookTemplates()
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to render hooks", errorContext.GetAll()...)
+			return 1
+		}
+		if c.runHooks {
+			env := c.hookEnv()
+			preHook = hookRun{stage: hookPreDeploy, script: preScript, dir: c.packConfig.Path, env: env}
+			postHook = hookRun{stage: hookPostDeploy, script: postScript, dir: c.packConfig.Path, env: env}
+		} else if strings.TrimSpace(preScript+postScript) != "" {
+			c.ui.Info("The pack defines deploy hooks, which are only run when --run-hooks is set")
+		}
+
+		if c.failOnEmptyRender {
+			rendered := slices.Concat(slices.Collect(maps.Values(renderedParents)), slices.Collect(maps.Values(renderedDeps)))
+			if err := checkEmptyRender(r, rendered, errorContext); err != nil {
+				c.ui.ErrorWithContext(err, "empty render", errorContext.GetAll()...)
+				return 1
+			}
+		}
+
+		// TODO: Refactor to use PackConfig. Maybe PackConfig should be in a more common
+		// pkg than cache, or maybe it's ok for runner to depend on the cache.
+		// Need to discuss with jrasell.
+		depConfig = &runner.Config{
+			PackName:       job.AliasedName(c.alias, c.packConfig.Name),
+			PathPath:       c.packConfig.Path,
+			PackRef:        c.packConfig.Ref,
+			DeploymentName: c.deploymentName,
+			RegistryName:   c.packConfig.Registry,
+			Alias:          c.alias,
+
+			ACLCapabilities: packManager.RequiredACLCapabilities(),
+		}
+
+		templates = make(map[string]string, r.LenDependentRenders()+r.LenParentRenders())
+		for dn, ds := range renderedDeps {
+			templates[dn] = ds
+		}
+		for pn, ps := range renderedParents {
+			templates[pn] = ps
+		}
 	}
 
 	// TODO(jrasell) come up with a better way to pass the appropriate config.
-	runDeployer, err := generateRunner(client, "job", c.jobConfig, &depConfig)
+	runDeployer, err := generateRunner(client, "job", c.jobConfig, depConfig)
 	if err != nil {
 		c.ui.ErrorWithContext(err, "failed to generate deployer", errorContext.GetAll()...)
 		return 1
 	}
 
 	// Set the rendered templates on the job deployer.
-	templates := make(map[string]string, r.LenDependentRenders()+r.LenParentRenders())
-	for dn, ds := range renderedDeps {
-		templates[dn] = ds
-	}
-	for pn, ps := range renderedParents {
-		templates[pn] = ps
-	}
 	runDeployer.SetTemplates(templates)
 
 	// Parse the templates. If we have any error, output this and exit.
@@ -129,6 +221,24 @@ func (c *RunCommand) run() int {
 		return 1
 	}
 
+	// Patch the jobs before they are checked, so the checks apply to the jobs
+	// which are submitted.
+	if c.jobConfig.RunConfig.PatchFile != "" {
+		if patchErrs := runDeployer.ApplyPatch(errorContext); patchErrs != nil {
+			for _, patchErr := range patchErrs {
+				c.ui.ErrorWithContext(patchErr.Err, patchErr.Subject, patchErr.Context.GetAll()...)
+			}
+			return 1
+		}
+	}
+
+	if aclErrs := runDeployer.CheckACLCapabilities(errorContext); aclErrs != nil {
+		for _, aclErr := range aclErrs {
+			c.ui.ErrorWithContext(aclErr.Err, aclErr.Subject, aclErr.Context.GetAll()...)
+		}
+		return 1
+	}
+
 	if conflictErrs := runDeployer.CheckForConflicts(errorContext); conflictErrs != nil {
 		for _, conflictErr := range conflictErrs {
 			c.ui.ErrorWithContext(conflictErr.Err, conflictErr.Subject, conflictErr.Context.GetAll()...)
@@ -136,27 +246,77 @@ func (c *RunCommand) run() int {
 		return 1
 	}
 
+	if c.jobConfig.RunConfig.PolicyDir != "" {
+		if policyErrs := runDeployer.CheckPolicies(c.ui, errorContext); policyErrs != nil {
+			for _, policyErr := range policyErrs {
+				c.ui.ErrorWithContext(policyErr.Err, policyErr.Subject, policyErr.Context.GetAll()...)
+			}
+			return 1
+		}
+	}
+
+	// Run the pre-deploy hook once the jobs have passed every check, so it is
+	// only run when the jobs are about to be deployed.
+	if !c.runHook(preHook, c.preHookFailure, errorContext) {
+		return 1
+	}
+
 	// Deploy the rendered template. If we have any error, output this and
 	// exit.
-	if deployErr := runDeployer.Deploy(c.ui, errorContext); deployErr != nil {
+	if deployErr := runDeployer.Deploy(c.Ctx, c.ui, errorContext); deployErr != nil {
 		c.ui.ErrorWithContext(deployErr.Err, deployErr.Subject, deployErr.Context.GetAll()...)
 		return 1
 	}
 
-	if c.packConfig.Registry == cache.DevRegistryName {
-		c.ui.Success(fmt.Sprintf("Pack successfully deployed. Use %s to manage this deployed instance with plan, stop, destroy, or info", c.packConfig.SourcePath))
+	if !c.runHook(postHook, c.postHookFailure, errorContext) {
+		return 1
+	}
+
+	if c.jobFile != "" {
+		c.ui.Success(fmt.Sprintf("Job file successfully deployed as pack deployment %q", c.deploymentName))
+	} else if c.packConfig.Registry == cache.DevRegistryName {
+		target := c.packConfig.SourcePath
+		if c.alias != "" {
+			target += " with --alias=" + c.alias
+		}
+		c.ui.Success(fmt.Sprintf("Pack successfully deployed. Use %s to manage this deployed instance with plan, stop, destroy, or info", target))
 	} else {
-		c.ui.Success(fmt.Sprintf("Pack successfully deployed. Use %s with --ref=%s to manage this deployed instance with plan, stop, destroy, or info", c.packConfig.Name, c.packConfig.Ref))
+		target := fmt.Sprintf("%s with --ref=%s", c.packConfig.Name, c.packConfig.Ref)
+		if c.alias != "" {
+			target += " --alias=" + c.alias
+		}
+		c.ui.Success(fmt.Sprintf("Pack successfully deployed. Use %s to manage this deployed instance with plan, stop, destroy, or info", target))
 	}
 
-	output, err := packManager.ProcessOutputTemplate()
-	if err != nil {
-		c.ui.ErrorWithContext(err, "failed to render output template", "Pack Name: "+c.packConfig.Name)
-		return 1
+	// Job files have no pack to provide an output template or outputs.
+	if packManager != nil {
+		output, err := packManager.ProcessOutputTemplate()
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to render output template", "Pack Name: "+c.packConfig.Name)
+			return 1
+		}
+
+		if strings.TrimSpace(output) != "" {
+			c.ui.Output(fmt.Sprintf("\n%s", output))
+		}
+
+		if outputs := packManager.ProcessedOutputs(); len(outputs) > 0 {
+			c.ui.Header("Outputs")
+			if err := outputPackOutputs(c.ui, outputs, "text"); err != nil {
+				c.ui.ErrorWithContext(err, "failed to display outputs", "Pack Name: "+c.packConfig.Name)
+				return 1
+			}
+		}
 	}
 
-	if output != "" {
-		c.ui.Output(fmt.Sprintf("\n%s", output))
+	// Follow the logs of the deployed jobs once the outputs are displayed, so
+	// they are not lost among the log lines.
+	if c.jobConfig.RunConfig.FollowLogs {
+		c.ui.Info("Following task logs, press Ctrl-C to stop")
+		if logsErr := runDeployer.FollowLogs(c.Ctx, c.ui); logsErr != nil {
+			c.ui.ErrorWithContext(logsErr.Err, logsErr.Subject, errorContext.GetAll()...)
+			return 1
+		}
 	}
 	return 0
 }
@@ -190,6 +350,17 @@ func (c *RunCommand) Flags() *flag.Sets {
 					Using ref with a file path is not supported.`,
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:    "job-file",
+			Target:  &c.jobFile,
+			Default: "",
+			Usage: `Path to an already rendered Nomad job specification to run
+					in place of a pack, which can not be passed as well. The
+					job is not rendered, but is labelled with the pack
+					metadata, using the file name without its extensions as
+					the pack name, and is otherwise run as a rendered pack.`,
+		})
+
 		f.Uint64Var(&flag.Uint64Var{
 			Name:    "check-index",
 			Target:  &c.jobConfig.RunConfig.CheckIndex,
@@ -201,6 +372,18 @@ func (c *RunCommand) Flags() *flag.Sets {
 					passed, it ensures that the job is being updated from a
 					known state. The use of this flag is most common in
 					conjunction with job plan command.`,
+			SetHook: func(uint64) { c.jobConfig.RunConfig.CheckIndexSet = true },
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "check-current-index",
+			Target:  &c.jobConfig.RunConfig.CheckCurrentIndex,
+			Default: false,
+			Usage: `If set, each job is only registered if its job modify index
+					is unchanged since the pack was rendered, so a concurrent
+					update by another deployment is not overwritten. Jobs which
+					did not exist when rendered are only registered if they
+					still do not exist.`,
 		})
 
 		f.StringVar(&flag.StringVar{
@@ -211,7 +394,8 @@ func (c *RunCommand) Flags() *flag.Sets {
 					sending to the Nomad servers. This allows passing the Consul
 					token without storing it in the job file. This overrides the
 					token found in the $CONSUL_HTTP_TOKEN environment variable
-					and that found in the job.`,
+					and that found in the job. The token is not included in the
+					job source stored by Nomad or in the input to policies.`,
 		})
 
 		f.StringVar(&flag.StringVar{
@@ -235,7 +419,8 @@ func (c *RunCommand) Flags() *flag.Sets {
 					sending to the Nomad servers. This allows passing the Vault
 					token without storing it in the job file. This overrides the
 					token found in the $VAULT_TOKEN environment variable and
-					that found in the job.`,
+					that found in the job. The token is not included in the job
+					source stored by Nomad or in the input to policies.`,
 		})
 
 		f.StringVar(&flag.StringVar{
@@ -254,6 +439,25 @@ func (c *RunCommand) Flags() *flag.Sets {
 					policies.`,
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:    "policy-dir",
+			Target:  &c.jobConfig.RunConfig.PolicyDir,
+			Default: "",
+			Usage: `If set, each rendered job is evaluated against the OPA
+					policies within the directory before it is submitted, using
+					the opa executable. Policies add messages to the
+					data.nomad_pack.deny set, and any job which is denied is
+					not submitted.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "policy-warn-only",
+			Target:  &c.jobConfig.RunConfig.PolicyWarnOnly,
+			Default: false,
+			Usage: `If set, jobs denied by the policies passed by --policy-dir
+					are submitted, and the denies are output as warnings.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "preserve-counts",
 			Target:  &c.jobConfig.RunConfig.PreserveCounts,
@@ -262,6 +466,112 @@ func (c *RunCommand) Flags() *flag.Sets {
 					when updating a job.`,
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:    "only-changed",
+			Target:  &c.jobConfig.RunConfig.OnlyChanged,
+			Default: false,
+			Usage: `If set, each job is planned against the version currently
+					deployed and only jobs with differences are submitted.
+					Unchanged jobs are reported and skipped.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "priority",
+			Target:  &c.jobConfig.RunConfig.Priority,
+			Default: 0,
+			Usage: `If set, the scheduling priority of each job is set to the
+					passed value before it is submitted, overriding the priority
+					set by the pack templates. The accepted range depends on the
+					job_max_priority configuration of the Nomad servers.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "patch",
+			Target:  &c.jobConfig.RunConfig.PatchFile,
+			Default: "",
+			Usage: `If set, the JSON Patch (RFC 6902) within the file is applied
+					to each rendered job before it is submitted. Paths address
+					the JSON representation of the job used by the Nomad API,
+					such as /TaskGroups/0/Count, which is also the format of
+					plan --format=patch. A failing operation fails the run.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "fail-on-empty-render",
+			Target:  &c.failOnEmptyRender,
+			Default: false,
+			Usage: `If set, the command fails without submitting anything when
+					the pack renders no non-empty job specifications, such as
+					when every template is disabled by its variables.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "run-hooks",
+			Target:  &c.runHooks,
+			Default: false,
+			Usage: `If set, the hooks/pre-deploy.tpl and hooks/post-deploy.tpl
+					templates of the pack are rendered into scripts which are
+					run within the pack directory before and after the jobs
+					are deployed.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "pre-hook-failure",
+			Target:  &c.preHookFailure,
+			Values:  []string{hookFailureAbort, hookFailureWarn},
+			Default: hookFailureAbort,
+			Usage: `The action taken when the pre-deploy hook fails. By default
+					the run is aborted before any job is deployed.`,
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "post-hook-failure",
+			Target:  &c.postHookFailure,
+			Values:  []string{hookFailureAbort, hookFailureWarn},
+			Default: hookFailureWarn,
+			Usage: `The action taken when the post-deploy hook fails. By default
+					a warning is output, while abort exits with an error. The
+					deployed jobs are not rolled back either way.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "detach",
+			Target:  &c.jobConfig.RunConfig.Detach,
+			Default: false,
+			Usage: `If set, each job is submitted and its evaluation ID printed
+					without waiting for the evaluation to complete.`,
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "wait-timeout",
+			Target:  &c.jobConfig.RunConfig.WaitTimeout,
+			Default: 5 * time.Minute,
+			Usage: `How long to wait for the evaluation of each job to complete
+					before failing the run. Set to 0 to wait until interrupted.
+					Ignored with --detach.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "follow-logs",
+			Target:  &c.jobConfig.RunConfig.FollowLogs,
+			Default: false,
+			Usage: `If set, once the allocations of each job have started, the
+					stdout and stderr of their tasks are streamed to the
+					terminal until interrupted. Each line is prefixed with its
+					allocation, task, and stream. Can not be used with
+					--detach.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "no-source",
+			Target:  &c.jobConfig.RunConfig.NoSource,
+			Default: false,
+			Usage: `If set, the rendered job specification is not stored in
+					Nomad as the job's submission source. By default the source
+					is stored so the Nomad UI shows the exact specification
+					deployed.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "rollback",
 			Hidden:  true,
@@ -281,28 +591,83 @@ func (c *RunCommand) AutocompleteFlags() complete.Flags {
 	return c.Flags().Completions()
 }
 
+// examples satisfies the examples function of the exampleCommand interface.
+func (c *RunCommand) examples() []commandExample {
+	return []commandExample{
+		{
+			description: `Run an example pack with the default deployment name "example".`,
+			args:        []string{"run", examplePack},
+			runnable:    true,
+		},
+		{
+			description: `Run an example pack with the specified deployment name "dev"`,
+			args:        []string{"run", examplePack, "--name=dev"},
+			runnable:    true,
+		},
+		{
+			description: "Run an example pack with override variables in a variable file",
+			args:        []string{"run", examplePack, "--var-file=./overrides.hcl"},
+		},
+		{
+			description: "Run an example pack with cli variable overrides",
+			args:        []string{"run", examplePack, "--var=count=2", `--var=env={"LOG_LEVEL": "debug"}`},
+			runnable:    true,
+		},
+		{
+			description: "Run the pack and options described by a JSON input file",
+			args:        []string{"run", "--input=./example.json"},
+		},
+		{
+			description: "Run an example pack, only submitting jobs which have changed",
+			args:        []string{"run", examplePack, "--only-changed"},
+			runnable:    true,
+		},
+		{
+			description: "Run an example pack, failing if its jobs change while it is being rendered",
+			args:        []string{"run", examplePack, "--check-current-index"},
+			runnable:    true,
+		},
+		{
+			description: "Run an example pack with a higher scheduling priority than its templates set",
+			args:        []string{"run", examplePack, "--priority=80"},
+			runnable:    true,
+		},
+		{
+			description: "Run an example pack without waiting for the evaluations to complete",
+			args:        []string{"run", examplePack, "--detach"},
+			runnable:    true,
+		},
+		{
+			description: "Run an example pack, blocking jobs denied by the OPA policies in a directory",
+			args:        []string{"run", examplePack, "--policy-dir=./policies"},
+		},
+		{
+			description: "Run an example pack, adjusting each job with a JSON Patch before it is submitted",
+			args:        []string{"run", examplePack, "--patch=./constraints.json"},
+		},
+		{
+			description: "Run an example pack without storing the rendered job source in Nomad",
+			args:        []string{"run", examplePack, "--no-source"},
+			runnable:    true,
+		},
+		{
+			description: "Run an already rendered job specification as a pack deployment",
+			args:        []string{"run", "--job-file=./example.nomad.hcl"},
+		},
+		{
+			description: "Run a pack under development from the filesystem - supports current\n" +
+				"working directory or relative path",
+			args: []string{"run", "."},
+		},
+	}
+}
+
 func (c *RunCommand) Help() string {
 	// TODO: do we want to ref example?
-	c.Example = `
-	# Run an example pack with the default deployment name "example".
-	nomad-pack run example
-
-	# Run an example pack with the specified deployment name "dev"
-	nomad-pack run example --name=dev
-
-	# Run an example pack with override variables in a variable file
-	nomad-pack run example --var-file="./overrides.hcl"
-
-	# Run an example pack with cli variable overrides
-	nomad-pack run example --var="redis_image_version=latest" --var="redis_resources={"cpu": "1000", "memory": "512"}"
-
-	# Run a pack under development from the filesystem - supports current
-	# working directory or relative path
-	nomad-pack run .
-	`
+	c.Example = formatExamples(c.examples())
 
 	return formatHelp(`
-	Usage: nomad-pack run <pack-name> [options]
+	Usage: nomad-pack run [<pack-name> | --job-file=<path>] [options]
 
 	Install the specified Nomad Pack to a configured Nomad cluster.
 
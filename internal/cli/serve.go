@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
+)
+
+// ServeCommand runs a daemon which keeps the registries of the global cache
+// warm for the run and render commands.
+type ServeCommand struct {
+	*baseCommand
+
+	// interval is how often the latest ref of each registry is fetched.
+	interval time.Duration
+}
+
+func (c *ServeCommand) Run(args []string) int {
+	c.cmdKey = "serve"
+
+	if err := c.Init(
+		WithNoArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	if c.interval <= 0 {
+		c.ui.Error("--interval must be greater than zero")
+		return 1
+	}
+
+	cachePath := c.cacheDir
+	socketPath := cache.DaemonSocketPath(cachePath)
+	errorContext := errors.NewUIErrorContext()
+	errorContext.Add("Socket Path: ", socketPath)
+
+	globalCache, err := cache.NewCache(&cache.CacheConfig{
+		Path:   cachePath,
+		Logger: c.ui,
+	})
+	if err != nil {
+		return 1
+	}
+
+	// A socket left by a daemon which did not shut down cleanly is removed,
+	// but one which is still answering is not replaced.
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		c.ui.ErrorWithContext(errors.New("a daemon is already running"), "failed to start daemon", errorContext.GetAll()...)
+		return 1
+	}
+	_ = os.Remove(socketPath)
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		c.ui.ErrorWithContext(err, "failed to start daemon", errorContext.GetAll()...)
+		return 1
+	}
+	defer os.Remove(socketPath)
+
+	c.ui.Info(fmt.Sprintf("Serving the cache at %s, refreshing registries every %s.", cachePath, c.interval))
+
+	daemon := cache.NewDaemon(globalCache, c.interval)
+	daemon.Refresh()
+	if err = daemon.Serve(c.Ctx, ln); err != nil {
+		c.ui.ErrorWithContext(err, "daemon failed", errorContext.GetAll()...)
+		return 1
+	}
+	return 0
+}
+
+func (c *ServeCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Serve Options")
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "interval",
+			Target:  &c.interval,
+			Default: 5 * time.Minute,
+			Usage: `How often the latest ref of each registry in the cache is
+					fetched from the source it was added from.`,
+		})
+	})
+}
+
+func (c *ServeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServeCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ServeCommand) Synopsis() string {
+	return "Run a daemon which keeps registries warm"
+}
+
+func (c *ServeCommand) Help() string {
+	c.Example = `
+	# Keep the registries in the cache warm, refreshing them every 5 minutes
+	nomad-pack serve
+
+	# Refresh the registries every hour
+	nomad-pack serve --interval=1h
+	`
+	return formatHelp(`
+	Usage: nomad-pack serve [options]
+
+	Run a daemon which keeps the registries in the cache warm. The latest ref
+	of each registry is fetched periodically, and the run and render commands
+	ask the daemon to fetch registry packs at refs which are not yet cached
+	over a unix socket in the cache directory. The commands use the cache
+	directly when no daemon is running.
+
+` + c.GetExample() + c.Flags().Help())
+}
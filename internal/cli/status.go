@@ -5,6 +5,9 @@ package cli
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/api"
 	"github.com/posener/complete"
@@ -12,14 +15,51 @@ import (
 	"github.com/hashicorp/nomad-pack/internal/pkg/cache"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	flag "github.com/hashicorp/nomad-pack/internal/pkg/flag"
+	"github.com/hashicorp/nomad-pack/internal/runner/job"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
 
 type StatusCommand struct {
 	*baseCommand
 	packConfig *cache.PackConfig
+
+	// columns are the names of the columns displayed for the jobs of a pack,
+	// in the order they are displayed.
+	columns []string
+
+	// watch redraws the status every interval seconds until interrupted.
+	watch    bool
+	interval int
+}
+
+// statusColumn is a column which can be displayed in the table of the jobs
+// belonging to a pack.
+type statusColumn struct {
+	name   string
+	header string
+	value  func(JobStatusInfo) terminal.TableEntry
+}
+
+// statusColumns are the columns which can be selected using --columns, in
+// their default order.
+var statusColumns = []statusColumn{
+	{"pack", "Pack Name", func(j JobStatusInfo) terminal.TableEntry { return terminal.TableEntry{Value: j.packName} }},
+	{"registry", "Registry Name", func(j JobStatusInfo) terminal.TableEntry { return terminal.TableEntry{Value: j.registryName} }},
+	{"deployment", "Deployment Name", func(j JobStatusInfo) terminal.TableEntry { return terminal.TableEntry{Value: j.deploymentName} }},
+	{"job", "Job Name", func(j JobStatusInfo) terminal.TableEntry { return terminal.TableEntry{Value: j.jobID} }},
+	{"namespace", "Namespace", func(j JobStatusInfo) terminal.TableEntry { return terminal.TableEntry{Value: j.namespace} }},
+	{"status", "Status", func(j JobStatusInfo) terminal.TableEntry { return terminal.TableEntry{Value: j.status} }},
+	{"healthy", "Healthy", func(j JobStatusInfo) terminal.TableEntry {
+		if j.healthy {
+			return terminal.TableEntry{Value: "true", Color: terminal.Green}
+		}
+		return terminal.TableEntry{Value: "false", Color: terminal.Red}
+	}},
 }
 
+// defaultStatusColumns are the columns displayed when --columns is not set.
+var defaultStatusColumns = []string{"pack", "registry", "deployment", "job", "namespace", "status"}
+
 func (c *StatusCommand) Run(args []string) int {
 	c.cmdKey = "status" // Add cmdKey here to print out helpUsageMessage on Init error
 	// Initialize. If we fail, we just exit since Init handles the UI.
@@ -33,8 +73,23 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The jobs of an aliased pack store the aliased pack name in their
+	// metadata, so it is used to find them.
 	if len(c.args) > 0 {
-		c.packConfig.Name = c.args[0]
+		c.packConfig.Name = job.AliasedName(c.alias, c.args[0])
+	}
+
+	columns, err := parseStatusColumns(c.columns)
+	if err != nil {
+		c.ui.ErrorWithContext(err, ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
+	if c.watch && c.interval < 1 {
+		c.ui.ErrorWithContext(errors.New("--interval must be at least 1 second"), ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
 	}
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
@@ -47,15 +102,48 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 
-	// If pack name isn't specified, return all deployed packs
-	if c.packConfig.Name == "" {
-		return c.renderAllDeployedPacks(client, errorContext)
+	render := func() int {
+		// If pack name isn't specified, return all deployed packs
+		if c.packConfig.Name == "" {
+			return c.renderAllDeployedPacks(client, errorContext)
+		}
+		return c.renderDeployedPackJobs(client, columns, errorContext)
 	}
 
-	return c.renderDeployedPackJobs(client, errorContext)
+	if c.watch {
+		return c.watchStatus(render)
+	}
+	return render()
 }
 
-func (c *StatusCommand) renderDeployedPackJobs(client *api.Client, errorContext *errors.UIErrorContext) int {
+// clearer is implemented by the UIs which write to a terminal, and so can
+// clear their previous output.
+type clearer interface {
+	Clear()
+}
+
+// watchStatus clears the terminal and redraws the status every interval until
+// the command is interrupted. Errors retrieving the status are displayed in
+// place of the table, as they are often transient during a rollout.
+func (c *StatusCommand) watchStatus(render func() int) int {
+	interval := time.Duration(c.interval) * time.Second
+	for {
+		if ui, ok := c.ui.(clearer); ok {
+			ui.Clear()
+		}
+		c.ui.Info(fmt.Sprintf("Every %s, last refreshed at %s. Press Ctrl-C to exit.",
+			interval, time.Now().Format(time.TimeOnly)))
+		render()
+
+		select {
+		case <-c.Ctx.Done():
+			return 0
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *StatusCommand) renderDeployedPackJobs(client *api.Client, columns []statusColumn, errorContext *errors.UIErrorContext) int {
 	var err error
 	packJobs, jobErrs, err := getDeployedPackJobs(client, c.packConfig, c.deploymentName)
 	if err != nil {
@@ -72,7 +160,7 @@ func (c *StatusCommand) renderDeployedPackJobs(client *api.Client, errorContext
 		return 0
 	}
 
-	c.ui.Table(formatDeployedPackJobs(packJobs))
+	c.ui.Table(formatDeployedPackJobs(packJobs, columns))
 
 	if len(jobErrs) > 0 {
 		c.ui.WarningBold("error retrieving job status for the following jobs:")
@@ -123,6 +211,32 @@ func (c *StatusCommand) Flags() *flag.Sets {
 
 					Using ref with a file path is not supported.`,
 		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:    "columns",
+			Target:  &c.columns,
+			Default: defaultStatusColumns,
+			Usage: `Comma separated list of the columns to display for the jobs
+					of a pack, in the order they are displayed. Supports pack,
+					registry, deployment, job, namespace, status, and healthy.
+					A job is healthy when it is running, with every task group
+					having running allocations and none queued or starting.`,
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "watch",
+			Target:  &c.watch,
+			Default: false,
+			Usage: `Refresh the status every interval until interrupted, clearing
+					and redrawing the table, for monitoring a rollout.`,
+		})
+
+		f.IntVar(&flag.IntVar{
+			Name:    "interval",
+			Target:  &c.interval,
+			Default: 2,
+			Usage:   `The number of seconds between refreshes when using --watch.`,
+		})
 	})
 }
 
@@ -150,6 +264,14 @@ func (c *StatusCommand) Help() string {
 	# Get a list of all deployed jobs and their status for an example pack in
 	# the deployment name "dev"
 	nomad-pack status example --name=dev --registry=community
+
+	# Get a list of the deployed jobs of an example pack, displaying only
+	# selected columns
+	nomad-pack status example --columns=pack,job,status,healthy
+
+	# Watch the health of the jobs of an example pack during a rollout,
+	# refreshing every 5 seconds
+	nomad-pack status example --columns=job,status,healthy --watch --interval=5
 	`
 
 	return formatHelp(`
@@ -181,6 +303,29 @@ func validateStatusArgs(b *baseCommand, args []string) error {
 	return nil
 }
 
+// parseStatusColumns returns the status columns with the passed names, in the
+// order they were passed.
+func parseStatusColumns(names []string) ([]statusColumn, error) {
+	columns := make([]statusColumn, 0, len(names))
+	for _, name := range names {
+		idx := slices.IndexFunc(statusColumns, func(col statusColumn) bool {
+			return col.name == strings.ToLower(strings.TrimSpace(name))
+		})
+		if idx < 0 {
+			valid := make([]string, len(statusColumns))
+			for i, col := range statusColumns {
+				valid[i] = col.name
+			}
+			return nil, fmt.Errorf("unknown column %q, must be one of: %s", name, strings.Join(valid, ", "))
+		}
+		columns = append(columns, statusColumns[idx])
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("at least one column must be specified")
+	}
+	return columns, nil
+}
+
 func formatDeployedPacks(packRegistryMap map[string]map[string]struct{}) *terminal.Table {
 	tbl := terminal.NewTable("Pack Name", "Registry Name")
 	for packName, registryMap := range packRegistryMap {
@@ -194,15 +339,18 @@ func formatDeployedPacks(packRegistryMap map[string]map[string]struct{}) *termin
 	return tbl
 }
 
-func formatDeployedPackJobs(packJobs []JobStatusInfo) *terminal.Table {
-	tbl := terminal.NewTable("Pack Name", "Registry Name", "Deployment Name", "Job Name", "Status")
+func formatDeployedPackJobs(packJobs []JobStatusInfo, columns []statusColumn) *terminal.Table {
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.header
+	}
+
+	tbl := terminal.NewTable(headers...)
 	for _, jobInfo := range packJobs {
 		row := []terminal.TableEntry{}
-		row = append(row, terminal.TableEntry{Value: jobInfo.packName})
-		row = append(row, terminal.TableEntry{Value: jobInfo.registryName})
-		row = append(row, terminal.TableEntry{Value: jobInfo.deploymentName})
-		row = append(row, terminal.TableEntry{Value: jobInfo.jobID})
-		row = append(row, terminal.TableEntry{Value: jobInfo.status})
+		for _, col := range columns {
+			row = append(row, col.value(jobInfo))
+		}
 		tbl.Rows = append(tbl.Rows, row)
 	}
 	return tbl
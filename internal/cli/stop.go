@@ -6,6 +6,8 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/nomad/api"
@@ -15,15 +17,20 @@ import (
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
 	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
+	"github.com/hashicorp/nomad-pack/internal/runner/job"
+	"github.com/hashicorp/nomad-pack/terminal"
 )
 
 type StopCommand struct {
 	*baseCommand
-	packConfig *cache.PackConfig
-	purge      bool
-	global     bool
-	Validation ValidationFn
+	packConfig   *cache.PackConfig
+	deploymentID string
+	purge        bool
+	global       bool
+	keepMeta     bool
+	Validation   ValidationFn
 }
 
 func (c *StopCommand) Run(args []string) int {
@@ -54,10 +61,16 @@ func (c *StopCommand) Run(args []string) int {
 		stoppedOrDestroyed = "destroyed"
 	}
 
+	if c.keepMeta && c.purge {
+		c.ui.ErrorWithContext(errors.New("--keep-meta can not be used with --purge"), ErrParsingArgsOrFlags)
+		c.ui.Info(c.helpUsageMessage())
+		return 1
+	}
+
 	c.packConfig.Name = c.args[0]
 
 	// Set the packConfig defaults if necessary and generate our UI error context.
-	errorContext := initPackCommand(c.packConfig)
+	errorContext := c.initPackCommand(c.packConfig)
 
 	client, err := c.getAPIClient()
 	if err != nil {
@@ -65,6 +78,31 @@ func (c *StopCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The jobs of an aliased pack store the aliased pack name in their
+	// metadata, so it is used to find them.
+	deployedConfig := *c.packConfig
+	deployedConfig.Name = job.AliasedName(c.alias, c.packConfig.Name)
+
+	// Resolve the Nomad deployment to the pack deployment which created it, so
+	// that only the jobs sharing its metadata are targeted.
+	if c.deploymentID != "" {
+		errorContext.Add(errors.UIContextPrefixDeploymentID, c.deploymentID)
+
+		var deploymentName string
+		deploymentName, err = getPackDeploymentByID(client, &deployedConfig, c.deploymentID)
+		if err != nil {
+			c.ui.ErrorWithContext(err, "failed to find deployment", errorContext.GetAll()...)
+			return 1
+		}
+		if c.deploymentName != "" && c.deploymentName != deploymentName {
+			c.ui.ErrorWithContext(
+				fmt.Errorf("deployment %q belongs to pack deployment %q, not %q", c.deploymentID, deploymentName, c.deploymentName),
+				"conflicting deployment flags", errorContext.GetAll()...)
+			return 1
+		}
+		c.deploymentName = deploymentName
+	}
+
 	if c.deploymentName == "" {
 		// Add the path to the pack on the error context.
 		errorContext.Add(errors.UIContextPrefixPackPath, c.packConfig.Path)
@@ -113,20 +151,28 @@ func (c *StopCommand) Run(args []string) int {
 			tplErrorContext.Add(errors.UIContextPrefixTemplateName, tplName)
 
 			// get job struct from template
-			var job *api.Job
-			job, err = parseJob(c.baseCommand, tpl, tplErrorContext)
+			var tplJob *api.Job
+			tplJob, err = parseJob(c.baseCommand, tpl, tplErrorContext)
 			if err != nil {
 				// err output is handled by parseJob
 				return 1
 			}
 
+			// Target the aliased job deployed from the template.
+			if c.alias != "" {
+				if err = job.ApplyAlias(tplJob, c.alias); err != nil {
+					c.ui.ErrorWithContext(err, "failed to alias job", tplErrorContext.GetAll()...)
+					return 1
+				}
+			}
+
 			// Add the jobID to the error context.
-			tplErrorContext.Add(errors.UIContextPrefixJobName, *job.Name)
-			jobs = append(jobs, job)
+			tplErrorContext.Add(errors.UIContextPrefixJobName, *tplJob.Name)
+			jobs = append(jobs, tplJob)
 		}
 	} else {
 		// If no job names are specified, get all jobs belonging to the pack and deployment
-		jobs, err = getPackJobsByDeploy(client, c.packConfig, c.deploymentName)
+		jobs, err = getPackJobsByDeploy(client, &deployedConfig, c.deploymentName)
 		if err != nil {
 			c.ui.ErrorWithContext(err, "failed to find jobs for pack", errorContext.GetAll()...)
 			return 1
@@ -139,6 +185,7 @@ func (c *StopCommand) Run(args []string) int {
 	}
 
 	var errs []error
+	var targets []*api.Job
 	for _, job := range jobs {
 		err = c.checkForConflicts(client, job)
 
@@ -147,18 +194,36 @@ func (c *StopCommand) Run(args []string) int {
 			c.ui.Warning(fmt.Sprintf("skipping job %q - conflict check failed with err: %s", *job.ID, err))
 			continue
 		}
+		targets = append(targets, job)
+	}
 
-		// TODO: add interactive support
-		if !c.confirmStop() {
-			c.ui.Info(fmt.Sprintf("%s job %q aborted by user", helper.Title(stopOrDestroy), *job.ID))
-			continue
+	if len(targets) > 0 {
+		confirmed, err := c.confirmPurge(client, targets)
+		if err != nil {
+			c.ui.ErrorWithContext(err, fmt.Sprintf("error confirming %s of pack", stopOrDestroy), errorContext.GetAll()...)
+			return 1
 		}
+		if !confirmed {
+			c.ui.Info(fmt.Sprintf("%s of pack %q aborted by user", helper.Title(stopOrDestroy), c.packConfig.Name))
+			return 1
+		}
+	}
 
-		// Invoke the stop
-		_, _, err := client.Jobs().DeregisterOpts(*job.ID, &api.DeregisterOptions{
-			Purge:  c.purge,
-			Global: c.global,
-		}, &api.WriteOptions{})
+	for _, job := range targets {
+		// Invoke the stop, in the namespace of the job as the jobs of a pack
+		// may be spread across namespaces.
+		writeOpts := &api.WriteOptions{}
+		if job.Namespace != nil {
+			writeOpts.Namespace = *job.Namespace
+		}
+		if c.keepMeta {
+			err = stopKeepMeta(client, *job.ID, writeOpts)
+		} else {
+			_, _, err = client.Jobs().DeregisterOpts(*job.ID, &api.DeregisterOptions{
+				Purge:  c.purge,
+				Global: c.global,
+			}, writeOpts)
+		}
 		if err != nil {
 			errs = append(errs, err)
 			c.ui.ErrorWithContext(err, fmt.Sprintf("error deregistering job: %q", *job.ID))
@@ -206,15 +271,153 @@ func (c *StopCommand) checkForConflicts(client *api.Client, job *api.Job) error
 	return nil
 }
 
-// TODO: Add interactive support
-func (c *StopCommand) confirmStop() bool {
+// stopKeepMeta stops the deployed job by registering it as stopped, marking
+// it with job.PackStoppedKey. Unlike deregistering it, the job is then listed
+// by status as stopped and is not purged by cleanup. The job modify index is
+// enforced, so that a job changed since it was read is not overwritten.
+func stopKeepMeta(client *api.Client, jobID string, writeOpts *api.WriteOptions) error {
+	deployed, _, err := client.Jobs().Info(jobID, &api.QueryOptions{Namespace: writeOpts.Namespace})
+	if err != nil {
+		return fmt.Errorf("error retrieving job %q: %s", jobID, err)
+	}
+
+	if deployed.Meta == nil {
+		deployed.Meta = map[string]string{}
+	}
+	deployed.Meta[job.PackStoppedKey] = "true"
+	deployed.Stop = pointer.Of(true)
+
+	_, _, err = client.Jobs().RegisterOpts(deployed, &api.RegisterOptions{
+		EnforceIndex: true,
+		ModifyIndex:  *deployed.JobModifyIndex,
+	}, writeOpts)
+	return err
+}
+
+// purgeImpact summarizes what purging a job removes from the cluster.
+type purgeImpact struct {
+	jobID         string
+	namespace     string
+	jobType       string
+	status        string
+	taskGroups    int
+	allocs        int
+	runningAllocs int
+}
+
+// getPurgeImpact looks up each of the deployed jobs and their allocations, to
+// summarize what purging them removes.
+func getPurgeImpact(client *api.Client, jobs []*api.Job) ([]purgeImpact, error) {
+	impact := make([]purgeImpact, 0, len(jobs))
+	for _, job := range jobs {
+		namespace := api.DefaultNamespace
+		if job.Namespace != nil {
+			namespace = *job.Namespace
+		}
+
+		// The jobs may have been rendered from the pack, so the deployed job
+		// is read for its current status.
+		opts := &api.QueryOptions{Namespace: namespace}
+		deployed, _, err := client.Jobs().Info(*job.ID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving job %q: %s", *job.ID, err)
+		}
+		allocs, _, err := client.Jobs().Allocations(*job.ID, false, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving allocations for job %q: %s", *job.ID, err)
+		}
+
+		ji := purgeImpact{
+			jobID:      *deployed.ID,
+			namespace:  namespace,
+			jobType:    *deployed.Type,
+			status:     *deployed.Status,
+			taskGroups: len(deployed.TaskGroups),
+			allocs:     len(allocs),
+		}
+		for _, alloc := range allocs {
+			if alloc.ClientStatus == api.AllocClientStatusRunning {
+				ji.runningAllocs++
+			}
+		}
+		impact = append(impact, ji)
+	}
+	return impact, nil
+}
+
+// confirmPurge asks the user to confirm the purge of the passed jobs, as this
+// permanently removes them from the Nomad server state. The jobs and their
+// allocations are summarized, and the pack name must be typed to confirm, so
+// that the wrong pack is not destroyed by accident. Soft stops do not require
+// confirmation.
+func (c *StopCommand) confirmPurge(client *api.Client, jobs []*api.Job) (bool, error) {
 	// TODO: Confirm the stop if the job was a prefix match
 	// TODO: Confirm we want to stop only a single region of a multiregion job
-	return true
+	if !c.purge || c.autoApproved {
+		return true, nil
+	}
+
+	// For non-interactive UIs, the value must be passed by flag.
+	if !c.ui.Interactive() {
+		return false, errors.New("purging jobs requires confirmation; use --auto-approve when running non-interactively")
+	}
+
+	impact, err := getPurgeImpact(client, jobs)
+	if err != nil {
+		return false, err
+	}
+	return c.confirmPurgeImpact(impact)
+}
+
+// confirmPurgeImpact displays the summary of the jobs to be purged, and
+// prompts for the pack name until it is typed correctly or left empty.
+func (c *StopCommand) confirmPurgeImpact(impact []purgeImpact) (bool, error) {
+	c.ui.WarningBold(fmt.Sprintf("The following jobs of pack %q will be purged from the cluster:", c.packConfig.Name))
+	c.ui.Table(formatPurgeImpact(impact))
+
+	var allocs, running int
+	for _, ji := range impact {
+		allocs += ji.allocs
+		running += ji.runningAllocs
+	}
+	c.ui.Warning(fmt.Sprintf("%d job(s) and %d allocation(s), %d of them running, will be removed.", len(impact), allocs, running))
+
+	for {
+		name, err := c.ui.Input(&terminal.Input{
+			Prompt: fmt.Sprintf("Type the pack name %q to confirm, or leave empty to abort: ", c.packConfig.Name),
+			Style:  terminal.WarningBoldStyle,
+		})
+		if err != nil {
+			return false, err
+		}
+		switch strings.TrimSpace(name) {
+		case c.packConfig.Name:
+			return true, nil
+		case "":
+			return false, nil
+		default:
+			c.ui.Output("The pack name does not match.\n", terminal.WithStyle(terminal.ErrorBoldStyle))
+		}
+	}
+}
+
+func formatPurgeImpact(impact []purgeImpact) *terminal.Table {
+	tbl := terminal.NewTable("Job Name", "Namespace", "Type", "Status", "Task Groups", "Allocations")
+	for _, ji := range impact {
+		tbl.Rows = append(tbl.Rows, []terminal.TableEntry{
+			{Value: ji.jobID},
+			{Value: ji.namespace},
+			{Value: ji.jobType},
+			{Value: ji.status},
+			{Value: strconv.Itoa(ji.taskGroups)},
+			{Value: fmt.Sprintf("%d (%d running)", ji.allocs, ji.runningAllocs)},
+		})
+	}
+	return tbl
 }
 
 func (c *StopCommand) Flags() *flag.Sets {
-	return c.flagSet(flagSetOperation|flagSetNomadClient, func(set *flag.Sets) {
+	return c.flagSet(flagSetOperation|flagSetNeedsApproval|flagSetNomadClient, func(set *flag.Sets) {
 		c.packConfig = &cache.PackConfig{}
 
 		f := set.NewSet("Stop Options")
@@ -236,13 +439,24 @@ func (c *StopCommand) Flags() *flag.Sets {
 					Using ref with a file path is not supported.`,
 		})
 
+		f.StringVar(&flag.StringVar{
+			Name:    "deployment",
+			Target:  &c.deploymentID,
+			Default: "",
+			Usage: `ID of a Nomad deployment created by the pack. Only the jobs
+					of the pack deployment which created it will be stopped.
+					Useful when the same pack has been deployed multiple
+					times within a namespace.`,
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "purge",
 			Target:  &c.purge,
 			Default: false,
 			Usage: `Purge is used to stop packs and purge them from the system.
 					If not set, packs will still be queryable and will be purged
-					by the garbage collector.`,
+					by the garbage collector. Purging must be confirmed unless
+					--auto-approve is set.`,
 		})
 
 		f.BoolVar(&flag.BoolVar{
@@ -253,6 +467,16 @@ func (c *StopCommand) Flags() *flag.Sets {
 					stop will stop only a single region at a time. Ignored for
 					single-region jobs.`,
 		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "keep-meta",
+			Target:  &c.keepMeta,
+			Default: false,
+			Usage: `Keep the pack metadata of the stopped jobs, marking them as
+					intentionally stopped. The pack remains listed by status
+					as stopped, and its jobs are not purged by cleanup, until
+					it is run again. Can not be used with --purge.`,
+		})
 	})
 }
 
@@ -272,10 +496,21 @@ func (c *StopCommand) Help() string {
 	# Stop an example pack in deployment "dev" and purge it from the system
 	nomad-pack stop example --name=dev --purge
 
+	# Purge an example pack in deployment "dev" without confirmation prompts
+	nomad-pack stop example --name=dev --purge --auto-approve
+
 	# Stop an example pack in deployment "dev" that has a job named "test"
 	# If the same pack has been installed in deployment "dev" but overriding the
 	# job name to "hello", only "test" will be stopped
 	nomad-pack stop example --name=dev --var=job_name=test
+
+	# Stop an example pack in deployment "dev", keeping it listed by status
+	# as stopped until it is run again
+	nomad-pack stop example --name=dev --keep-meta
+
+	# Stop the jobs of the example pack deployment which created the Nomad
+	# deployment "d0a3b5c2"
+	nomad-pack stop example --deployment=d0a3b5c2
 	`
 	return formatHelp(`
 	Usage: nomad-pack stop <pack name> [options]
@@ -4,13 +4,28 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/hashicorp/nomad-pack/internal/pkg/flag"
 	"github.com/hashicorp/nomad-pack/internal/pkg/version"
 	"github.com/posener/complete"
 )
 
+// releaseCheckTimeout bounds how long --check-updates waits for the release
+// endpoint, so an unreachable endpoint does not hang scripts.
+const releaseCheckTimeout = 5 * time.Second
+
 type VersionCommand struct {
 	*baseCommand
+
+	// checkUpdates is a boolean flag to control whether the release endpoint
+	// is queried to report whether a newer version is available.
+	checkUpdates bool
+
+	// releaseURL is the endpoint queried for the latest release.
+	releaseURL string
 }
 
 func (c *VersionCommand) Run(args []string) int {
@@ -29,14 +44,66 @@ func (c *VersionCommand) Run(args []string) int {
 		return 1
 	}
 
-	c.ui.Output("Nomad Pack %s\n", version.HumanVersion())
+	c.outputResult("Nomad Pack %s\n", version.HumanVersion())
+
+	if c.checkUpdates {
+		c.checkForUpdates()
+	}
 
 	// Exit zero since we have completed successfully.
 	return 0
 }
 
+// checkForUpdates reports whether a newer release than this binary is
+// available. Failing to check is only warned about, as the local version has
+// already been output.
+func (c *VersionCommand) checkForUpdates() {
+	ctx, cancel := context.WithTimeout(c.Ctx, releaseCheckTimeout)
+	defer cancel()
+
+	latest, err := version.LatestRelease(ctx, c.releaseURL)
+	if err != nil {
+		c.ui.Warning(fmt.Sprintf("Unable to check for updates: %s", err))
+		return
+	}
+
+	outdated, err := version.IsOutdated(latest)
+	if err != nil {
+		c.ui.Warning(fmt.Sprintf("Unable to check for updates: %s", err))
+		return
+	}
+
+	if outdated {
+		c.ui.Warning(fmt.Sprintf("A newer version of Nomad Pack is available: v%s", latest))
+		return
+	}
+	c.ui.Success("Nomad Pack is up to date")
+}
+
 func (c *VersionCommand) Flags() *flag.Sets {
-	return c.flagSet(0, nil)
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Version Options")
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "check-updates",
+			Target:  &c.checkUpdates,
+			Default: false,
+			Usage: `Queries the release endpoint for the latest version of
+					Nomad Pack and reports whether this binary is up to date.
+					If the endpoint cannot be reached, only the local version
+					is printed, along with a warning.`,
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "release-url",
+			Target:  &c.releaseURL,
+			Default: version.DefaultReleaseURL,
+			EnvVar:  "NOMAD_PACK_RELEASE_URL",
+			Usage: `The endpoint queried by --check-updates for the latest
+					release, which must return a JSON object with a version
+					field.`,
+		})
+	})
 }
 
 func (c *VersionCommand) AutocompleteArgs() complete.Predictor {
@@ -52,9 +119,17 @@ func (c *VersionCommand) Synopsis() string {
 }
 
 func (c *VersionCommand) Help() string {
+	c.Example = `
+	# Print the version of Nomad Pack.
+	nomad-pack version
+
+	# Print the version and report whether a newer release is available.
+	nomad-pack version --check-updates
+	`
 	return formatHelp(`
-	Usage: nomad-pack version
+	Usage: nomad-pack version [options]
 
 	Prints the version information for Nomad Pack.
-`)
+
+` + c.GetExample() + c.Flags().Help())
 }
@@ -4,16 +4,22 @@
 package cache
 
 import (
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
-	gg "github.com/hashicorp/go-getter"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
@@ -50,9 +56,95 @@ func (c *Cache) Add(opts *AddOpts) (*Registry, error) {
 		return cachedRegistry, errors.ErrRegistrySourceRequired
 	}
 
+	if opts.PacksDir != "" && !filepath.IsLocal(opts.PacksDir) {
+		return cachedRegistry, errors.ErrInvalidPacksDir
+	}
+
+	if opts.CACertPath == "" {
+		opts.CACertPath = c.cfg.CACertPath
+	}
+
+	// Adding a registry which already exists refreshes it rather than
+	// erroring, so automation can add registries unconditionally.
+	existing, err := c.existingRegistry(opts.RegistryName)
+	if err != nil {
+		c.cfg.Logger.ErrorWithContext(err, "error checking for existing registry", c.ErrorContext.GetAll()...)
+		return cachedRegistry, err
+	}
+	if existing != nil {
+		if opts.FailIfExists {
+			err = errors.ErrRegistryExists
+			c.cfg.Logger.ErrorWithContext(err, "registry already exists in the cache", c.ErrorContext.GetAll()...)
+			return cachedRegistry, err
+		}
+		if existing.Source != "" && existing.Source != opts.Source {
+			c.cfg.Logger.Warning(fmt.Sprintf("registry %s was previously added from %s, replacing its packs with those from %s",
+				opts.RegistryName, opts.redact(existing.Source), opts.redact(opts.Source)))
+			opts.replace = true
+		} else {
+			// Keep using the packs directory and mirror the registry was
+			// added with.
+			if opts.PacksDir == "" {
+				opts.PacksDir = existing.PacksDir
+			}
+			if opts.Mirror == "" {
+				opts.Mirror = existing.Mirror
+			}
+			if opts.CACertPath == "" {
+				opts.CACertPath = existing.CACertPath
+			}
+		}
+	}
+
+	// The CA is recorded with the registry, so is stored as an absolute path
+	// for use from other directories.
+	if opts.CACertPath != "" {
+		if opts.CACertPath, err = filepath.Abs(opts.CACertPath); err != nil {
+			return cachedRegistry, err
+		}
+		if _, err = caCertConfig(opts.CACertPath); err != nil {
+			c.cfg.Logger.ErrorWithContext(err, "could not configure registry CA certificate", c.ErrorContext.GetAll()...)
+			return cachedRegistry, err
+		}
+	}
+
 	return c.addFromURI(opts)
 }
 
+// existingRegistry returns the metadata of the named registry if it already
+// exists in the cache, or nil if it does not. The source may be empty for
+// registries which were added before the source was recorded.
+func (c *Cache) existingRegistry(name string) (*Registry, error) {
+	refEntries, err := os.ReadDir(filepath.Join(c.cfg.Path, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *Registry
+	for _, refEntry := range refEntries {
+		if !refEntry.IsDir() {
+			continue
+		}
+		if existing == nil {
+			existing = &Registry{Name: name}
+		}
+
+		b, err := os.ReadFile(filepath.Join(c.cfg.Path, name, refEntry.Name(), "metadata.json"))
+		if err != nil {
+			continue
+		}
+		registry := &Registry{}
+		if err := json.Unmarshal(b, registry); err == nil && registry.Source != "" {
+			return registry, nil
+		}
+	}
+
+	return existing, nil
+}
+
 // addFromURI loads a registry from a remote git repository. If addToCache is
 // true, the registry will also be added to the global cache. The cache directory
 // must be specified to allow user customization of cache location. If a name is
@@ -90,7 +182,7 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 	logger.Debug(fmt.Sprintf("Processing pack entries at %s", c.clonePath()))
 
 	// Move the cloned registry packs to the global cache.
-	packEntries, err := os.ReadDir(c.clonedPacksPath())
+	packEntries, err := os.ReadDir(c.clonedPacksPath(opts.packsDir()))
 	for _, packEntry := range packEntries {
 		// Don't process the .git folder or any files
 		// TODO: Handle symlinks
@@ -107,6 +199,8 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 			RegistryName: opts.RegistryName,
 			PackName:     packEntry.Name(),
 			Ref:          opts.Ref,
+			PacksDir:     opts.PacksDir,
+			replace:      opts.replace,
 		}
 
 		err = c.processPackEntry(packOpts, packEntry)
@@ -123,6 +217,9 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 	})
 	cachedRegistry.LocalRef = c.latestSHA
 	cachedRegistry.Source = opts.Source
+	cachedRegistry.PacksDir = opts.PacksDir
+	cachedRegistry.Mirror = opts.Mirror
+	cachedRegistry.CACertPath = opts.CACertPath
 	if err != nil {
 		logger.ErrorWithContext(err, "error getting registry after add", c.ErrorContext.GetAll()...)
 		return
@@ -139,34 +236,27 @@ func (c *Cache) addFromURI(opts *AddOpts) (cachedRegistry *Registry, err error)
 	return
 }
 
-// cloneRemoteGitRegistry clones a remote git repository to the cache. Returns
-// the SHA of the HEAD of the cloned repository.
+// cloneRemoteGitRegistry clones a remote git repository to the cache. If the
+// source cannot be cloned and the registry has a mirror, the mirror is cloned
+// instead, once it is verified to carry the same ref. Returns the SHA of the
+// HEAD of the cloned repository.
 func (c *Cache) cloneRemoteGitRegistry(opts *AddOpts) (string, error) {
 	logger := c.cfg.Logger
-	url := opts.Source
-
-	// Append the pack name to the go-getter url if a pack name was specified
-	if opts.PackName != "" {
-		src := strings.TrimSuffix(opts.Source, ".git") // to make the next command work consistently
-		url = fmt.Sprintf("%s.git//packs/%s", src, opts.PackName)
-	}
 
-	// If ref is set, add query string variable
-	if !opts.IsLatest() {
-		url = fmt.Sprintf("%s?ref=%s", url, opts.Ref)
-	} else {
-		// Attempt to shallow clone the constructed url
-		url = fmt.Sprintf("%s?depth=1", url)
-	}
+	clonePath := c.clonePath()
 
-	logger.Debug(fmt.Sprintf("go-getter URL is %s", url))
+	err := c.cloneGitSource(opts, opts.Source, clonePath)
+	if err != nil && opts.Mirror != "" {
+		logger.Warning(fmt.Sprintf("failed to fetch registry %s from %s, falling back to mirror %s: %s",
+			opts.RegistryName, opts.redact(opts.Source), opts.redact(opts.Mirror), opts.redact(err.Error())))
+		_ = os.RemoveAll(c.clonePath())
 
-	clonePath := c.clonePath()
-	// If pack name is set, add an intermediary "packs" and pack dir manually.
-	if opts.PackName != "" {
-		clonePath = path.Join(clonePath, "packs", opts.PackName)
+		if err = c.verifyMirrorRef(opts); err == nil {
+			err = c.cloneGitSource(opts, opts.Mirror, clonePath)
+		}
 	}
-	if err := gg.Get(clonePath, fmt.Sprintf("git::%s", url)); err != nil {
+	if err != nil {
+		err = errors.New(opts.redact(err.Error()))
 		logger.ErrorWithContext(err, "could not install registry", c.ErrorContext.GetAll()...)
 		return "n/a", err
 	}
@@ -182,6 +272,179 @@ func (c *Cache) cloneRemoteGitRegistry(opts *AddOpts) (string, error) {
 	return sha, nil
 }
 
+// cloneGitSource clones the registry from the git source, which is either the
+// registry source or its mirror, into clonePath at the ref of the opts. The
+// returned error may contain credentials, so must be redacted.
+func (c *Cache) cloneGitSource(opts *AddOpts, source, clonePath string) error {
+	logger := c.cfg.Logger
+
+	// Pass any credentials as config of the git commands, rather than in the
+	// source URL, so they are not stored in the config of the clone.
+	config, err := opts.gitAuthConfig(source)
+	if err != nil {
+		return fmt.Errorf("could not configure registry credentials: %w", err)
+	}
+	caConfig, err := caCertConfig(opts.CACertPath)
+	if err != nil {
+		return err
+	}
+	config = append(config, caConfig...)
+
+	logger.Debug(fmt.Sprintf("cloning registry from %s at ref %s", opts.redact(source), opts.Ref))
+
+	stopProgress := watchCloneProgress(clonePath, opts.Progress)
+	defer stopProgress()
+
+	// Registries at latest are always cloned shallowly.
+	if opts.IsLatest() {
+		return gitClone(source, clonePath, "", 1, config)
+	}
+	if opts.Shallow {
+		// Shallow clones can only fetch branches and tags, so refs such as
+		// a SHA fall back to a full clone.
+		err = gitClone(source, clonePath, opts.Ref, 1, config)
+		if err == nil {
+			return nil
+		}
+		logger.Debug(fmt.Sprintf("shallow clone at ref %s failed, falling back to a full clone: %s", opts.Ref, opts.redact(err.Error())))
+		_ = os.RemoveAll(clonePath)
+	}
+	return gitClone(source, clonePath, opts.Ref, 0, config)
+}
+
+// verifyMirrorRef returns an error unless the registry mirror carries the ref
+// the registry is being added at, so falling back to the mirror never fetches
+// the packs at a different ref. Branches and tags must be advertised by the
+// mirror. Other refs, such as a SHA, can only be checked out if the mirror has
+// them, so are verified by the clone itself.
+func (c *Cache) verifyMirrorRef(opts *AddOpts) error {
+	if opts.IsLatest() {
+		return nil
+	}
+
+	listOpts := &git.ListOptions{}
+	if opts.hasAuth() {
+		listOpts.Auth = &githttp.BasicAuth{Username: opts.Username, Password: opts.Password}
+	}
+	if opts.CACertPath != "" {
+		b, err := os.ReadFile(opts.CACertPath)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		listOpts.CABundle = b
+	}
+
+	// Sources without a scheme which are not local paths are assumed to be
+	// HTTPS, as they are by the git getter.
+	mirror := opts.Mirror
+	if !strings.Contains(mirror, "://") {
+		if _, err := os.Stat(mirror); err != nil {
+			mirror = "https://" + mirror
+		}
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "mirror", URLs: []string{mirror}})
+	refs, err := remote.List(listOpts)
+	if err != nil {
+		return fmt.Errorf("could not list the refs of registry mirror: %w", err)
+	}
+	for _, ref := range refs {
+		if name := ref.Name(); (name.IsBranch() || name.IsTag()) && name.Short() == opts.Ref {
+			return nil
+		}
+	}
+	if isCommitHash(opts.Ref) {
+		return nil
+	}
+	return fmt.Errorf("registry mirror does not have ref %q", opts.Ref)
+}
+
+// isCommitHash reports whether the ref could be a commit SHA, which may be
+// abbreviated.
+func isCommitHash(ref string) bool {
+	if len(ref) < 7 || len(ref) > 40 {
+		return false
+	}
+	for _, r := range ref {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// caCertConfig returns the git config which makes git trust the CA
+// certificates in the PEM bundle at caPath when fetching a registry over
+// HTTPS, instead of the system trust store. The config only applies to the
+// git commands it is passed to, so registries fetched concurrently can each
+// trust their own CA.
+func caCertConfig(caPath string) ([]gitConfig, error) {
+	if caPath == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", caPath)
+	}
+	return []gitConfig{{key: "http.sslCAInfo", value: caPath}}, nil
+}
+
+// cloneProgressInterval is how often the clone directory size is reported
+// while fetching a registry.
+const cloneProgressInterval = 250 * time.Millisecond
+
+// watchCloneProgress periodically passes the size of the clone directory to fn
+// until the returned stop function is called. The git getter does not expose
+// the transport progress, so the bytes written to disk are used instead.
+func watchCloneProgress(dir string, fn func(int64)) (stop func()) {
+	if fn == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(cloneProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fn(dirSize(dir))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// dirSize returns the total size of the regular files within dir. Files which
+// disappear or cannot be read while walking are ignored.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
 func (c *Cache) processPackEntry(opts *AddOpts, packEntry os.DirEntry) error {
 	logger := c.cfg.Logger
 	logger.Debug(fmt.Sprintf("Processing pack %s@%s", packEntry.Name(), opts.Ref))
@@ -195,7 +458,7 @@ func (c *Cache) processPackEntry(opts *AddOpts, packEntry os.DirEntry) error {
 
 	// Here we could have err=fs.ErrNotExist or err=nil
 	// Only look for latest when the pack path is found.
-	if err == nil && !opts.IsLatest() {
+	if err == nil && !opts.IsLatest() && !opts.replace {
 		// If ref target is not latest, continue to next entry because ref already exists
 		logger.Debug("Pack already exists at specified ref - skipping")
 		return nil
@@ -209,6 +472,13 @@ func (c *Cache) processPackEntry(opts *AddOpts, packEntry os.DirEntry) error {
 		if err != nil {
 			return err
 		}
+	} else if err == nil {
+		// The registry source has changed, so the pack at this ref must be
+		// replaced with the one from the new source.
+		if err := os.RemoveAll(opts.PackPath()); err != nil {
+			logger.ErrorWithContext(err, "error removing previous pack directory", c.ErrorContext.GetAll()...)
+			return err
+		}
 	}
 
 	logger.Debug(fmt.Sprintf("Writing pack to %s", opts.PackPath()))
@@ -330,6 +600,88 @@ type AddOpts struct {
 	Username string
 	// Optional password for basic auth to a registry that requires authentication.
 	Password string
+	// Optional callback which is periodically passed the number of bytes
+	// fetched while cloning a git registry.
+	Progress func(received int64)
+	// Optional directory within the registry which contains the packs.
+	// Defaults to DefaultPacksDir.
+	PacksDir string
+	// Optional mirror of the registry source, which is cloned when the source
+	// cannot be. The mirror must carry the same ref, and uses the same
+	// credentials as the source.
+	Mirror string
+	// Optional path to a PEM bundle of CA certificates trusted when cloning
+	// the registry over HTTPS. Defaults to the CA of the cache config, and
+	// then to the CA the registry was previously added with.
+	CACertPath string
+	// Optional flag to return an error rather than refreshing the registry
+	// when a registry with the same name already exists in the cache.
+	FailIfExists bool
+	// Optional flag to clone the registry with a depth of 1 when adding it at
+	// a ref, which must then be a branch or tag. Other refs, such as a SHA,
+	// fall back to a full clone. Registries added at latest are always
+	// cloned shallowly.
+	Shallow bool
+	// replace is set when the registry source has changed, so packs which
+	// already exist at the ref are replaced rather than skipped.
+	replace bool
+}
+
+// hasAuth returns whether credentials have been supplied for the registry.
+func (opts *AddOpts) hasAuth() bool {
+	return opts.Username != "" || opts.Password != ""
+}
+
+// gitAuthConfig returns the git config which authenticates fetches of the
+// passed registry source or mirror with the registry credentials, as an HTTP
+// Authorization header scoped to the host of the source. Credentials are only
+// supported for HTTP(S) sources; sources without a scheme are assumed to be
+// HTTPS.
+func (opts *AddOpts) gitAuthConfig(src string) ([]gitConfig, error) {
+	if !opts.hasAuth() {
+		return nil, nil
+	}
+
+	if !strings.Contains(src, "://") {
+		src = "https://" + src
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry source: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("registry credentials are only supported for HTTPS sources, got %q", u.Scheme)
+	}
+
+	return []gitConfig{{
+		key:   fmt.Sprintf("http.%s://%s/.extraHeader", u.Scheme, u.Host),
+		value: "Authorization: Basic " + opts.basicAuth(),
+	}}, nil
+}
+
+// basicAuth returns the registry credentials encoded for HTTP basic auth.
+func (opts *AddOpts) basicAuth() string {
+	return base64.StdEncoding.EncodeToString([]byte(opts.Username + ":" + opts.Password))
+}
+
+// redact removes any registry credentials from the passed string so that it
+// can be safely logged or returned to the user.
+func (opts *AddOpts) redact(s string) string {
+	if !opts.hasAuth() {
+		return s
+	}
+	for _, secret := range []string{
+		opts.basicAuth(),
+		url.UserPassword(opts.Username, opts.Password).String(),
+		url.QueryEscape(opts.Password),
+		opts.Password,
+	} {
+		if secret != "" && secret != ":" {
+			s = strings.ReplaceAll(s, secret, "redacted")
+		}
+	}
+	return s
 }
 
 // RegistryPath fulfills the cacheOperationProvider interface for AddOpts
@@ -386,5 +738,13 @@ func (opts *AddOpts) IsTarget(dirEntry os.DirEntry) bool {
 func (opts *AddOpts) clonedPackPath(c *Cache) string {
 	// Don't use PackDir here because we won't have the Revision on the cloned
 	// directory name, thought we will append it to the registry entry if it is set.
-	return path.Join(c.clonedPacksPath(), opts.PackName)
+	return path.Join(c.clonedPacksPath(opts.packsDir()), opts.PackName)
+}
+
+// packsDir returns the directory within the registry which contains the packs.
+func (opts *AddOpts) packsDir() string {
+	if opts.PacksDir == "" {
+		return DefaultPacksDir
+	}
+	return opts.PacksDir
 }
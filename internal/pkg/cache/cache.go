@@ -19,6 +19,7 @@ import (
 const (
 	DefaultRegistryName = "default"
 	DefaultRef          = "latest"
+	DefaultPacksDir     = "packs"
 	DevRegistryName     = "<<local folder>>"
 	DevRef              = "<<none>>"
 	DefaultDirPerms     = 0700
@@ -90,6 +91,11 @@ type CacheConfig struct {
 	Path   string
 	Eager  bool
 	Logger logging.Logger
+
+	// CACertPath is the path to a PEM bundle of CA certificates which are
+	// trusted when fetching registries over HTTPS, instead of the system
+	// trust store.
+	CACertPath string
 }
 
 // cacheOperationProvider provides an interface for the Opts family of structs
@@ -112,10 +118,10 @@ func (c *Cache) clonePath() string {
 }
 
 // clonedPacksPath returns the path where remote repository packs have been cloned
-// to during download processing. This enforces the hard convention that there
-// must be a packs directory in the registry.
-func (c *Cache) clonedPacksPath() string {
-	return path.Join(c.cfg.Path, tmpDir, "packs")
+// to during download processing. The packs are expected within packsDir of the
+// registry, which is usually DefaultPacksDir.
+func (c *Cache) clonedPacksPath(packsDir string) string {
+	return path.Join(c.cfg.Path, tmpDir, packsDir)
 }
 
 // Registries is an accessor for the cached registries contain within the cache instance.
@@ -142,7 +148,17 @@ func (c *Cache) Packs() (packs []*Pack) {
 
 // Load loads a list of registries from a cache path. It assumes each
 // directory in the specified path cache is a registry.
-func (c *Cache) Load() (err error) {
+func (c *Cache) Load() error {
+	return c.WalkRegistries(func(registry *Registry) error {
+		c.registries = append(c.registries, registry)
+		return nil
+	})
+}
+
+// WalkRegistries loads each registry ref from the cache path in turn and
+// passes it to fn without retaining it, so callers can process large caches
+// incrementally. Walking stops at the first error returned by fn.
+func (c *Cache) WalkRegistries(fn func(*Registry) error) (err error) {
 	c.ErrorContext.Add(errors.RegistryContextPrefixCachePath, c.cfg.Path)
 
 	if c.cfg.Path == "" {
@@ -163,8 +179,9 @@ func (c *Cache) Load() (err error) {
 
 	// Iterate over the registries and build a registry/pack for each entry at each ref.
 	for _, registryEntry := range registryEntries {
-		// ignore the .git folder which will be present since these are all git repos
-		if registryEntry.Name() == ".git" {
+		// ignore the .git folder which will be present since these are all git
+		// repos, and the staging directories of registries being refreshed
+		if strings.HasPrefix(registryEntry.Name(), ".") {
 			continue
 		}
 
@@ -189,7 +206,9 @@ func (c *Cache) Load() (err error) {
 				return
 			}
 
-			c.registries = append(c.registries, registry)
+			if err = fn(registry); err != nil {
+				return
+			}
 		}
 	}
 
@@ -4,10 +4,25 @@
 package cache
 
 import (
+	"archive/zip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io/fs"
+	"maps"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path"
 	"slices"
 	"strings"
@@ -16,6 +31,7 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/shoenig/test/must"
 
@@ -114,6 +130,123 @@ func TestAddRegistryPacksAtMultipleRefs(t *testing.T) {
 	must.Eq(t, 2, len(pts.RefsUnique()))
 }
 
+func TestAddRegistryExisting(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   cacheDir,
+		Logger: NewTestLogger(t),
+	})
+	must.NoError(t, err)
+
+	registry, err := cache.Add(testAddOpts("existing"))
+	must.NoError(t, err)
+	must.NotNil(t, registry)
+
+	// Adding the same registry again refreshes it.
+	registry, err = cache.Add(testAddOpts("existing"))
+	must.NoError(t, err)
+	must.NotNil(t, registry)
+	must.Eq(t, len(listAllTestPacks(t, cacheDir)), len(registry.Packs))
+
+	// Adding the registry at another ref adds the ref alongside latest.
+	opts := testAddOpts("existing")
+	opts.Ref = tReg.Ref1()
+	_, err = cache.Add(opts)
+	must.NoError(t, err)
+	must.Eq(t, 2, len(listAllTestPacks(t, cacheDir).RefsUnique()))
+
+	// Unless asked to fail if the registry exists.
+	opts = testAddOpts("existing")
+	opts.FailIfExists = true
+	registry, err = cache.Add(opts)
+	must.ErrorIs(t, err, errors.ErrRegistryExists)
+	must.Nil(t, registry)
+
+	// A changed source replaces the packs at an existing ref.
+	source := path.Join(t.TempDir(), "test_registry.git")
+	must.NoError(t, filesystem.CopyDir(tReg.SourceURL(), source, false, NoopLogger{}))
+	opts = testAddOpts("existing")
+	opts.Source = source
+	opts.Ref = tReg.Ref1()
+	registry, err = cache.Add(opts)
+	must.NoError(t, err)
+	must.Eq(t, source, registry.Source)
+}
+
+func TestAddRegistryMirror(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   t.TempDir(),
+		Logger: NewTestLogger(t),
+	})
+	must.NoError(t, err)
+
+	unavailable := path.Join(t.TempDir(), "unavailable.git")
+
+	// The mirror is cloned when the source cannot be, and is recorded.
+	registry, err := cache.Add(&AddOpts{RegistryName: "mirrored", Source: unavailable, Mirror: tReg.SourceURL()})
+	must.NoError(t, err)
+	must.Eq(t, tReg.SourceURL(), registry.Mirror)
+	must.Eq(t, unavailable, registry.Source)
+	must.Positive(t, len(registry.Packs))
+
+	// Adding the registry again keeps using the mirror, which carries the
+	// branch.
+	registry, err = cache.Add(&AddOpts{RegistryName: "mirrored", Source: unavailable, Ref: "master"})
+	must.NoError(t, err)
+	must.Eq(t, tReg.SourceURL(), registry.Mirror)
+	must.Eq(t, tReg.ref2, registry.LocalRef)
+
+	// A mirror which does not carry the ref is not used.
+	_, err = cache.Add(&AddOpts{RegistryName: "mirrored", Source: unavailable, Ref: "missing"})
+	must.ErrorContains(t, err, `registry mirror does not have ref "missing"`)
+}
+
+func TestAddRegistryWithPacksDir(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+
+	// Build a registry which keeps its packs under a nested directory.
+	source := path.Join(t.TempDir(), "monorepo.git")
+	must.NoError(t, filesystem.CopyDir(testfixture.MustAbsPath("v2/test_registry/packs"), path.Join(source, "deploy", "packs"), false, NoopLogger{}))
+	r, err := git.PlainInit(source, false)
+	must.NoError(t, err)
+	w, err := r.Worktree()
+	must.NoError(t, err)
+	_, err = w.Add(".")
+	must.NoError(t, err)
+	_, err = w.Commit("Initial Commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "Github Action Test User",
+		Email: "test@example.com",
+		When:  time.Now(),
+	}})
+	must.NoError(t, err)
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   cacheDir,
+		Logger: NewTestLogger(t),
+	})
+	must.NoError(t, err)
+
+	opts := &AddOpts{RegistryName: "monorepo", Source: source, PacksDir: "deploy/packs"}
+	registry, err := cache.Add(opts)
+	must.NoError(t, err)
+	must.Eq(t, len(dirEntries(t, path.Join(source, "deploy", "packs"))), len(registry.Packs))
+	must.Eq(t, "deploy/packs", registry.PacksDir)
+
+	// The packs directory is remembered when adding the registry again.
+	registry, err = cache.Add(&AddOpts{RegistryName: "monorepo", Source: source, PackName: "simple_raw_exec"})
+	must.NoError(t, err)
+	must.Len(t, 1, registry.Packs)
+
+	// The packs directory must be within the registry.
+	_, err = cache.Add(&AddOpts{RegistryName: "escape", Source: source, PacksDir: "../packs"})
+	must.ErrorIs(t, err, errors.ErrInvalidPacksDir)
+}
+
 func TestAddRegistryWithTarget(t *testing.T) {
 	t.Parallel()
 	cacheDir := t.TempDir()
@@ -181,6 +314,42 @@ func TestAddRegistryWithSHA(t *testing.T) {
 	must.Eq(t, expectedRegistryMetadata, r)
 }
 
+func TestAddRegistryShallow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		ref      string
+		localRef string
+	}{
+		{name: "branch", ref: "master", localRef: tReg.ref2},
+		// A SHA cannot be cloned shallowly, so falls back to a full clone.
+		{name: "sha", ref: tReg.Ref1(), localRef: tReg.Ref1()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			cache, err := NewCache(&CacheConfig{
+				Path:   cacheDir,
+				Logger: NewTestLogger(t),
+			})
+			must.NoError(t, err)
+
+			// Local clones ignore the depth, so the source is passed as a
+			// file URL.
+			registry, err := cache.Add(&AddOpts{
+				RegistryName: "shallow",
+				Source:       "file://" + tReg.SourceURL(),
+				Ref:          tc.ref,
+				Shallow:      true,
+			})
+			must.NoError(t, err)
+			must.Eq(t, tc.localRef, registry.LocalRef)
+			must.Eq(t, len(listAllTestPacks(t, cacheDir)), len(registry.Packs))
+		})
+	}
+}
+
 func TestAddRegistryWithRefAndPackName(t *testing.T) {
 	t.Parallel()
 	cacheDir := t.TempDir()
@@ -418,6 +587,589 @@ func TestParsePackURL(t *testing.T) {
 	}
 }
 
+func TestCheckoutPackRef(t *testing.T) {
+	t.Parallel()
+
+	// Build a repository which keeps a pack under a nested directory, with a
+	// tagged commit followed by an untagged change.
+	source := t.TempDir()
+	packPath := path.Join(source, "deploy", "packs", "simple_raw_exec")
+	must.NoError(t, filesystem.CopyDir(testfixture.MustAbsPath("v2/test_registry/packs/simple_raw_exec"), packPath, false, NoopLogger{}))
+	r, err := git.PlainInit(source, false)
+	must.NoError(t, err)
+	w, err := r.Worktree()
+	must.NoError(t, err)
+
+	commit := func(msg string) plumbing.Hash {
+		_, err := w.Add(".")
+		must.NoError(t, err)
+		hash, err := w.Commit(msg, &git.CommitOptions{Author: &object.Signature{
+			Name:  "Github Action Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		}})
+		must.NoError(t, err)
+		return hash
+	}
+	_, err = r.CreateTag("v0.0.1", commit("Initial Commit"), nil)
+	must.NoError(t, err)
+
+	varsFile := path.Join(packPath, "variables.hcl")
+	original, err := os.ReadFile(varsFile)
+	must.NoError(t, err)
+	must.NoError(t, os.WriteFile(varsFile, []byte("committed change"), 0o644))
+	commit("Update variables")
+
+	// Uncommitted changes are never included.
+	must.NoError(t, os.WriteFile(varsFile, []byte("uncommitted change"), 0o644))
+
+	out, err := CheckoutPackRef(packPath, "v0.0.1", t.TempDir())
+	must.NoError(t, err)
+	must.Eq(t, "simple_raw_exec", path.Base(out))
+	b, err := os.ReadFile(path.Join(out, "variables.hcl"))
+	must.NoError(t, err)
+	must.Eq(t, string(original), string(b))
+	must.FileExists(t, path.Join(out, "metadata.hcl"))
+
+	out, err = CheckoutPackRef(packPath, "HEAD", t.TempDir())
+	must.NoError(t, err)
+	b, err = os.ReadFile(path.Join(out, "variables.hcl"))
+	must.NoError(t, err)
+	must.Eq(t, "committed change", string(b))
+
+	_, err = CheckoutPackRef(packPath, "v9.9.9", t.TempDir())
+	must.ErrorContains(t, err, `could not resolve ref "v9.9.9"`)
+}
+
+func TestExtractPackZip(t *testing.T) {
+	t.Parallel()
+
+	writeZip := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		zipPath := path.Join(t.TempDir(), "example.zip")
+		f, err := os.Create(zipPath)
+		must.NoError(t, err)
+		zw := zip.NewWriter(f)
+		for _, name := range slices.Sorted(maps.Keys(files)) {
+			w, err := zw.Create(name)
+			must.NoError(t, err)
+			_, err = w.Write([]byte(files[name]))
+			must.NoError(t, err)
+		}
+		must.NoError(t, zw.Close())
+		must.NoError(t, f.Close())
+		return zipPath
+	}
+
+	testCases := []struct {
+		name      string
+		files     map[string]string
+		expectDir string
+		expectErr string
+	}{
+		{
+			name:      "root",
+			files:     map[string]string{"metadata.hcl": "app {}", "templates/job.nomad.tpl": "job"},
+			expectDir: "example",
+		},
+		{
+			name: "top-level directory",
+			files: map[string]string{
+				"my_pack/metadata.hcl":            "app {}",
+				"my_pack/templates/job.nomad.tpl": "job",
+				"__MACOSX/my_pack/._metadata.hcl": "",
+			},
+			expectDir: "my_pack",
+		},
+		{
+			name:      "missing metadata",
+			files:     map[string]string{"templates/job.nomad.tpl": "job"},
+			expectErr: "metadata.hcl or metadata.json must be at the root of the zip file",
+		},
+		{
+			name:      "several top-level directories",
+			files:     map[string]string{"a/metadata.hcl": "app {}", "b/metadata.hcl": "app {}"},
+			expectErr: "does not contain a pack",
+		},
+		{
+			name:      "path traversal",
+			files:     map[string]string{"metadata.hcl": "app {}", "../escape.txt": "x"},
+			expectErr: `invalid path "../escape.txt"`,
+		},
+		{
+			name:      "parent top-level directory",
+			files:     map[string]string{"../metadata.hcl": "app {}", "../templates/job.nomad.tpl": "job"},
+			expectErr: `invalid path "../`,
+		},
+		{
+			name:      "absolute path",
+			files:     map[string]string{"/metadata.hcl": "app {}", "/templates/job.nomad.tpl": "job"},
+			expectErr: `invalid path "/`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := ExtractPackZip(writeZip(t, tc.files), t.TempDir())
+			if tc.expectErr != "" {
+				must.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			must.NoError(t, err)
+			must.Eq(t, tc.expectDir, path.Base(out))
+			must.FileExists(t, path.Join(out, "metadata.hcl"))
+			b, err := os.ReadFile(path.Join(out, "templates", "job.nomad.tpl"))
+			must.NoError(t, err)
+			must.Eq(t, "job", string(b))
+			must.FileNotExists(t, path.Join(out, "__MACOSX"))
+		})
+	}
+
+	must.True(t, IsPackZip("/tmp/pack.ZIP"))
+	must.False(t, IsPackZip("/tmp/pack"))
+}
+
+func TestChangedPacks(t *testing.T) {
+	t.Parallel()
+
+	// Build a registry whose second commit changes a single pack.
+	sourcePath := path.Join(t.TempDir(), "changed_registry")
+	must.NoError(t, filesystem.CopyDir(testfixture.MustAbsPath("v2/test_registry"), sourcePath, false, NoopLogger{}))
+	r, err := git.PlainInit(sourcePath, false)
+	must.NoError(t, err)
+	w, err := r.Worktree()
+	must.NoError(t, err)
+	commitOptions := &git.CommitOptions{Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}}
+
+	_, err = w.Add(".")
+	must.NoError(t, err)
+	first, err := w.Commit("Initial Commit", commitOptions)
+	must.NoError(t, err)
+
+	must.NoError(t, os.WriteFile(path.Join(sourcePath, "packs", "simple_raw_exec", "README.md"), []byte("changed"), 0644))
+	must.NoError(t, os.WriteFile(path.Join(sourcePath, "README.md"), []byte("changed"), 0644))
+	_, err = w.Add(".")
+	must.NoError(t, err)
+	_, err = w.Commit("Change a pack", commitOptions)
+	must.NoError(t, err)
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   t.TempDir(),
+		Logger: NewTestLogger(t),
+	})
+	must.NoError(t, err)
+	opts := testAddOpts("changed")
+	opts.Source = sourcePath
+	_, err = cache.Add(opts)
+	must.NoError(t, err)
+
+	packs, err := cache.ChangedPacks(&ChangedOpts{RegistryName: "changed", SinceRef: first.String()})
+	must.NoError(t, err)
+	must.Eq(t, []string{"simple_raw_exec"}, packs)
+
+	packs, err = cache.ChangedPacks(&ChangedOpts{RegistryName: "changed", SinceRef: "HEAD"})
+	must.NoError(t, err)
+	must.SliceEmpty(t, packs)
+
+	_, err = cache.ChangedPacks(&ChangedOpts{RegistryName: "changed", SinceRef: "v9.9.9"})
+	must.ErrorContains(t, err, `could not resolve ref "v9.9.9"`)
+
+	_, err = cache.ChangedPacks(&ChangedOpts{RegistryName: "unknown", SinceRef: first.String()})
+	must.EqError(t, err, `registry "unknown" has not been added to the cache at ref "latest"`)
+}
+
+func TestUpdateRegistry(t *testing.T) {
+	t.Parallel()
+
+	sourcePath := path.Join(t.TempDir(), "update_registry")
+	must.NoError(t, filesystem.CopyDir(testfixture.MustAbsPath("v2/test_registry"), sourcePath, false, NoopLogger{}))
+	r, err := git.PlainInit(sourcePath, false)
+	must.NoError(t, err)
+	w, err := r.Worktree()
+	must.NoError(t, err)
+	commitOptions := &git.CommitOptions{Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}}
+
+	_, err = w.Add(".")
+	must.NoError(t, err)
+	first, err := w.Commit("Initial Commit", commitOptions)
+	must.NoError(t, err)
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   t.TempDir(),
+		Logger: NewTestLogger(t),
+	})
+	must.NoError(t, err)
+	opts := testAddOpts("update")
+	opts.Source = sourcePath
+	_, err = cache.Add(opts)
+	must.NoError(t, err)
+	opts.Ref = "master"
+	_, err = cache.Add(opts)
+	must.NoError(t, err)
+
+	// Without changes to the source, the refs are unchanged.
+	updates, err := cache.Update(&UpdateOpts{RegistryName: "update"})
+	must.NoError(t, err)
+	must.Len(t, 2, updates)
+	for _, update := range updates {
+		must.False(t, update.Changed())
+		must.Eq(t, first.String(), update.SHA)
+		must.SliceEmpty(t, update.AddedPacks)
+	}
+
+	// Add a pack to the source, which is fetched at each ref.
+	must.NoError(t, filesystem.CopyDir(path.Join(sourcePath, "packs", "simple_raw_exec"), path.Join(sourcePath, "packs", "another_raw_exec"), false, NoopLogger{}))
+	metadataPath := path.Join(sourcePath, "packs", "another_raw_exec", "metadata.hcl")
+	b, err := os.ReadFile(metadataPath)
+	must.NoError(t, err)
+	must.NoError(t, os.WriteFile(metadataPath, []byte(strings.ReplaceAll(string(b), `"simple_raw_exec"`, `"another_raw_exec"`)), 0644))
+	_, err = w.Add(".")
+	must.NoError(t, err)
+	second, err := w.Commit("Add a pack", commitOptions)
+	must.NoError(t, err)
+
+	updates, err = cache.Update(&UpdateOpts{RegistryName: "update"})
+	must.NoError(t, err)
+	must.Eq(t, []string{"latest", "master"}, []string{updates[0].Ref, updates[1].Ref})
+	for _, update := range updates {
+		must.True(t, update.Changed())
+		must.Eq(t, first.String(), update.PreviousSHA)
+		must.Eq(t, second.String(), update.SHA)
+		must.Eq(t, []string{"another_raw_exec"}, update.AddedPacks)
+	}
+	must.DirExists(t, path.Join(cache.cfg.Path, "update", "master", "another_raw_exec@master"))
+
+	// A ref can be updated on its own, and need not be in the cache yet.
+	updates, err = cache.Update(&UpdateOpts{RegistryName: "update", Ref: first.String()})
+	must.NoError(t, err)
+	must.Len(t, 1, updates)
+	must.Eq(t, "", updates[0].PreviousSHA)
+	must.Eq(t, first.String(), updates[0].SHA)
+	must.Len(t, 4, updates[0].AddedPacks)
+
+	names, err := cache.RegistryNames()
+	must.NoError(t, err)
+	must.Eq(t, []string{"update"}, names)
+
+	_, err = cache.Update(&UpdateOpts{RegistryName: "unknown"})
+	must.EqError(t, err, `registry "unknown" has not been added to the cache`)
+}
+
+func TestPackOfFile(t *testing.T) {
+	ci.Parallel(t)
+
+	testCases := []struct {
+		packsDir string
+		name     string
+		expected string
+	}{
+		{packsDir: "packs", name: "packs/nginx/metadata.hcl", expected: "nginx"},
+		{packsDir: "packs", name: "packs/nginx/templates/nginx.nomad.tpl", expected: "nginx"},
+		{packsDir: "packs", name: "packs/README.md", expected: ""},
+		{packsDir: "packs", name: "README.md", expected: ""},
+		{packsDir: "deploy/packs/", name: "deploy/packs/nginx/metadata.hcl", expected: "nginx"},
+		{packsDir: ".", name: "nginx/metadata.hcl", expected: "nginx"},
+		{packsDir: ".", name: "README.md", expected: ""},
+		{packsDir: "packs", name: "", expected: ""},
+	}
+	for _, tc := range testCases {
+		must.Eq(t, tc.expected, packOfFile(tc.packsDir, tc.name), must.Sprintf("packsDir %q name %q", tc.packsDir, tc.name))
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	t.Parallel()
+	cacheDir := t.TempDir()
+
+	cache, err := NewCache(&CacheConfig{
+		Path:   cacheDir,
+		Logger: NewTestLogger(t),
+	})
+	must.NoError(t, err)
+	_, err = cache.Add(testAddOpts("daemon"))
+	must.NoError(t, err)
+
+	socketPath := DaemonSocketPath(cacheDir)
+	cfg := &PackConfig{Registry: "daemon", Name: "simple_raw_exec", Ref: tReg.Ref1()}
+
+	// Clients fall back to the cache when no daemon is running.
+	ok, err := RequestWarmPack(socketPath, cfg)
+	must.False(t, ok)
+	must.NoError(t, err)
+
+	ln, err := net.Listen("unix", socketPath)
+	must.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- NewDaemon(cache, time.Hour).Serve(ctx, ln) }()
+
+	// Packs at refs which are not cached are fetched from the registry
+	// source.
+	ok, err = RequestWarmPack(socketPath, cfg)
+	must.True(t, ok)
+	must.NoError(t, err)
+	must.DirExists(t, path.Join(cacheDir, "daemon", tReg.Ref1(), "simple_raw_exec@"+tReg.Ref1()))
+
+	_, err = RequestWarmPack(socketPath, &PackConfig{Registry: "unknown", Name: "simple_raw_exec", Ref: DefaultRef})
+	must.EqError(t, err, `registry "unknown" has not been added to the cache`)
+
+	_, err = RequestWarmPack(socketPath, &PackConfig{Registry: "daemon", Name: "missing", Ref: tReg.Ref1()})
+	must.ErrorContains(t, err, `pack "missing@`+tReg.Ref1()+`" not found in registry "daemon"`)
+
+	// Refreshing swaps in the latest ref fetched in a staging directory.
+	latestLog := path.Join(cacheDir, "daemon", DefaultRef, "simple_raw_exec@"+DefaultRef, "latest.log")
+	before, err := os.ReadFile(latestLog)
+	must.NoError(t, err)
+	// The swap waits for commands holding the read lock of the cache.
+	unlock, err := ReadLock(cacheDir)
+	must.NoError(t, err)
+	refreshed := make(chan struct{})
+	go func() {
+		NewDaemon(cache, time.Hour).Refresh()
+		close(refreshed)
+	}()
+	select {
+	case <-refreshed:
+		t.Fatal("registry refreshed while the cache was read locked")
+	case <-time.After(500 * time.Millisecond):
+	}
+	unchanged, err := os.ReadFile(latestLog)
+	must.NoError(t, err)
+	must.Eq(t, string(before), string(unchanged))
+	unlock()
+	<-refreshed
+
+	after, err := os.ReadFile(latestLog)
+	must.NoError(t, err)
+	must.NotEq(t, string(before), string(after))
+	for _, entry := range dirEntries(t, cacheDir) {
+		must.False(t, strings.HasPrefix(entry.Name(), refreshDirPrefix))
+	}
+
+	cancel()
+	must.NoError(t, <-served)
+}
+
+func TestAddOpts_GitAuthConfig(t *testing.T) {
+	ci.Parallel(t)
+
+	testCases := []struct {
+		name        string
+		opts        *AddOpts
+		expectedKey string
+		expectErr   bool
+	}{
+		{
+			name: "no auth",
+			opts: &AddOpts{Source: "github.com/hashicorp/nomad-pack-community-registry"},
+		},
+		{
+			name: "token without scheme",
+			opts: &AddOpts{
+				Source:   "github.com/example/private-registry",
+				Username: "oauth2",
+				Password: "s3cr3t",
+			},
+			expectedKey: "http.https://github.com/.extraHeader",
+		},
+		{
+			name: "basic with scheme",
+			opts: &AddOpts{
+				Source:   "https://gitlab.com/example/private-registry.git",
+				Username: "user",
+				Password: "p@ss",
+			},
+			expectedKey: "http.https://gitlab.com/.extraHeader",
+		},
+		{
+			name: "non-https source",
+			opts: &AddOpts{
+				Source:   "ssh://git@github.com/example/private-registry",
+				Username: "oauth2",
+				Password: "s3cr3t",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ci.Parallel(t)
+			config, err := tc.opts.gitAuthConfig(tc.opts.Source)
+			if tc.expectErr {
+				must.Error(t, err)
+				return
+			}
+			must.NoError(t, err)
+			if !tc.opts.hasAuth() {
+				must.SliceEmpty(t, config)
+				return
+			}
+			must.Len(t, 1, config)
+			must.Eq(t, tc.expectedKey, config[0].key)
+			must.Eq(t, "Authorization: Basic "+tc.opts.basicAuth(), config[0].value)
+
+			// The credentials must never survive redaction.
+			redacted := tc.opts.redact("error downloading: " + config[0].value + " " + tc.opts.Password)
+			must.StrNotContains(t, redacted, tc.opts.Password)
+			must.StrNotContains(t, redacted, tc.opts.basicAuth())
+		})
+	}
+}
+
+func TestGitClone_Credentials(t *testing.T) {
+	ci.Parallel(t)
+
+	gitPath, err := exec.Command("git", "--exec-path").Output()
+	must.NoError(t, err)
+
+	// Serve the test registry over HTTP, requiring basic auth.
+	backend := &cgi.Handler{
+		Path: path.Join(strings.TrimSpace(string(gitPath)), "git-http-backend"),
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + path.Dir(tReg.SourceURL()),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "oauth2" || pass != "s3cr3t" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	source := srv.URL + "/" + path.Base(tReg.SourceURL())
+	opts := &AddOpts{Source: source, Username: "oauth2", Password: "s3cr3t"}
+	config, err := opts.gitAuthConfig(source)
+	must.NoError(t, err)
+
+	dst := path.Join(t.TempDir(), "clone")
+	must.NoError(t, gitClone(source, dst, "", 1, config))
+	must.DirExists(t, path.Join(dst, "packs"))
+
+	// The credentials are not stored in the config of the clone.
+	b, err := os.ReadFile(path.Join(dst, ".git", "config"))
+	must.NoError(t, err)
+	must.StrContains(t, string(b), source)
+	must.StrNotContains(t, string(b), "s3cr3t")
+	must.StrNotContains(t, string(b), opts.basicAuth())
+
+	// Without the credentials the clone is refused.
+	err = gitClone(source, path.Join(t.TempDir(), "clone"), "", 1, nil)
+	must.ErrorContains(t, err, "git clone failed")
+}
+
+func TestCACertConfig(t *testing.T) {
+	ci.Parallel(t)
+
+	dir := t.TempDir()
+	caPath := path.Join(dir, "ca.pem")
+	must.NoError(t, os.WriteFile(caPath, testCACertPEM(t), 0644))
+
+	config, err := caCertConfig(caPath)
+	must.NoError(t, err)
+	must.Eq(t, []gitConfig{{key: "http.sslCAInfo", value: caPath}}, config)
+
+	config, err = caCertConfig("")
+	must.NoError(t, err)
+	must.SliceEmpty(t, config)
+
+	invalidPath := path.Join(dir, "invalid.pem")
+	must.NoError(t, os.WriteFile(invalidPath, []byte("not a certificate"), 0644))
+	_, err = caCertConfig(invalidPath)
+	must.EqError(t, err, "no PEM certificates found in "+invalidPath)
+
+	_, err = caCertConfig(path.Join(dir, "missing.pem"))
+	must.ErrorContains(t, err, "failed to read CA certificate")
+}
+
+func TestAddRegistryCACert(t *testing.T) {
+	ci.Parallel(t)
+	cacheDir := t.TempDir()
+
+	caPath := path.Join(t.TempDir(), "ca.pem")
+	must.NoError(t, os.WriteFile(caPath, testCACertPEM(t), 0644))
+
+	cache, err := NewCache(&CacheConfig{
+		Path:       cacheDir,
+		Logger:     NewTestLogger(t),
+		CACertPath: caPath,
+	})
+	must.NoError(t, err)
+	_, err = cache.Add(testAddOpts("private"))
+	must.NoError(t, err)
+
+	// The CA is recorded with the registry, and used when it is added again
+	// without one.
+	registry, err := cache.existingRegistry("private")
+	must.NoError(t, err)
+	must.Eq(t, caPath, registry.CACertPath)
+
+	cache, err = NewCache(&CacheConfig{Path: cacheDir, Logger: NewTestLogger(t)})
+	must.NoError(t, err)
+	_, err = cache.Add(testAddOpts("private"))
+	must.NoError(t, err)
+	registry, err = cache.existingRegistry("private")
+	must.NoError(t, err)
+	must.Eq(t, caPath, registry.CACertPath)
+
+	// Registries added without a CA trust the system store.
+	_, err = cache.Add(testAddOpts("public"))
+	must.NoError(t, err)
+	registry, err = cache.existingRegistry("public")
+	must.NoError(t, err)
+	must.Eq(t, "", registry.CACertPath)
+}
+
+// testCACertPEM returns a PEM encoded self-signed CA certificate.
+func testCACertPEM(t *testing.T) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	must.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "nomad-pack test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	must.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestWatchCloneProgress(t *testing.T) {
+	ci.Parallel(t)
+
+	dir := t.TempDir()
+	must.NoError(t, os.WriteFile(path.Join(dir, "a"), make([]byte, 1024), 0644))
+
+	received := make(chan int64, 10)
+	stop := watchCloneProgress(dir, func(n int64) {
+		select {
+		case received <- n:
+		default:
+		}
+	})
+
+	select {
+	case n := <-received:
+		must.Eq(t, 1024, n)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for progress")
+	}
+	stop()
+
+	// A nil callback disables progress reporting.
+	watchCloneProgress(dir, nil)()
+}
+
 type TestLogger struct {
 	t *testing.T
 }
@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+)
+
+// ChangedOpts are the options for listing the packs of a registry which
+// changed since a ref.
+type ChangedOpts struct {
+	// RegistryName is the name of the registry in the cache.
+	RegistryName string
+	// SinceRef is the older git ref the registry is compared against.
+	SinceRef string
+	// Username and Password are the credentials used to clone the registry
+	// source over HTTPS, if it requires them.
+	Username string
+	Password string
+}
+
+// ChangedPacks returns the names of the packs whose files differ between the
+// latest ref of the registry in the cache and the passed older ref, in name
+// order. The history of the registry is cloned from the source it was added
+// from, as the cache only holds the files of each ref.
+func (c *Cache) ChangedPacks(opts *ChangedOpts) ([]string, error) {
+	registry, err := c.latestRegistry(opts.RegistryName)
+	if err != nil {
+		return nil, err
+	}
+
+	addOpts := &AddOpts{
+		Source:   registry.Source,
+		Username: opts.Username,
+		Password: opts.Password,
+		PacksDir: registry.PacksDir,
+	}
+	config, err := addOpts.gitAuthConfig(registry.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	caPath := c.cfg.CACertPath
+	if caPath == "" {
+		caPath = registry.CACertPath
+	}
+	caConfig, err := caCertConfig(caPath)
+	if err != nil {
+		return nil, err
+	}
+	config = append(config, caConfig...)
+
+	tmpPath, err := os.MkdirTemp("", "nomad-pack-changed-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpPath)
+
+	// The whole history is needed to find the older ref, so the clone is not
+	// shallow.
+	clonePath := path.Join(tmpPath, opts.RegistryName)
+	if err = gitClone(registry.Source, clonePath, "", 0, config); err != nil {
+		return nil, errors.New(addOpts.redact(err.Error()))
+	}
+
+	r, err := git.PlainOpen(clonePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cloned repository: %w", err)
+	}
+
+	// The registry is compared as it is in the cache, which may be behind the
+	// source if it has not been added again recently.
+	currentRef := registry.LocalRef
+	if currentRef == "" || currentRef == "n/a" {
+		currentRef = plumbing.HEAD.String()
+	}
+	current, err := resolveTree(r, currentRef)
+	if err != nil {
+		return nil, err
+	}
+	since, err := resolveTree(r, opts.SinceRef)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(since, current)
+	if err != nil {
+		return nil, fmt.Errorf("could not compare refs: %w", err)
+	}
+
+	var packs []string
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if pack := packOfFile(addOpts.packsDir(), name); pack != "" && !slices.Contains(packs, pack) {
+				packs = append(packs, pack)
+			}
+		}
+	}
+	slices.Sort(packs)
+	return packs, nil
+}
+
+// latestRegistry returns the metadata of the latest ref of the named registry
+// in the cache.
+func (c *Cache) latestRegistry(name string) (*Registry, error) {
+	b, err := os.ReadFile(filepath.Join(c.cfg.Path, name, DefaultRef, "metadata.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("registry %q has not been added to the cache at ref %q", name, DefaultRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &Registry{}
+	if err = json.Unmarshal(b, registry); err != nil {
+		return nil, fmt.Errorf("could not read registry metadata: %w", err)
+	}
+	if registry.Source == "" {
+		return nil, fmt.Errorf("registry %q has no recorded source, add it again to compare refs", name)
+	}
+	return registry, nil
+}
+
+// packOfFile returns the name of the pack containing the file at the path,
+// relative to the registry root, or an empty string if the file is not within
+// a pack.
+func packOfFile(packsDir, name string) string {
+	if name == "" {
+		return ""
+	}
+	if packsDir != "." {
+		var ok bool
+		if name, ok = strings.CutPrefix(name, path.Clean(packsDir)+"/"); !ok {
+			return ""
+		}
+	}
+	pack, _, ok := strings.Cut(name, "/")
+	if !ok || pack == ".git" {
+		return ""
+	}
+	return pack
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CheckoutPackRef writes the pack at packPath, as it exists at the passed ref
+// of the git repository containing it, into a directory of the same name
+// within dst. The path to the written pack is returned. Only the committed
+// tree is read, so changes in the working tree are not included.
+func CheckoutPackRef(packPath, ref, dst string) (string, error) {
+	r, err := git.PlainOpenWithOptions(packPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("could not open git repository containing pack: %w", err)
+	}
+
+	tree, err := resolveTree(r, ref)
+	if err != nil {
+		return "", err
+	}
+
+	// Find the pack directory within the tree, relative to the repository
+	// root. Symlinks are resolved, as the root is reported without them.
+	wt, err := r.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("could not read git worktree: %w", err)
+	}
+	root, err := filepath.EvalSymlinks(wt.Filesystem.Root())
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.EvalSymlinks(packPath)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	if rel != "." {
+		if tree, err = tree.Tree(filepath.ToSlash(rel)); err != nil {
+			return "", fmt.Errorf("pack directory %s does not exist at ref %q: %w", rel, ref, err)
+		}
+	}
+
+	out := path.Join(dst, path.Base(packPath))
+	err = tree.Files().ForEach(func(f *object.File) error {
+		return writeTreeFile(path.Join(out, f.Name), f)
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not write pack at ref %q: %w", ref, err)
+	}
+	return out, nil
+}
+
+// resolveTree returns the tree of the commit the ref resolves to.
+func resolveTree(r *git.Repository, ref string) (*object.Tree, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve ref %q: %w", ref, err)
+	}
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("could not read commit for ref %q: %w", ref, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("could not read tree for ref %q: %w", ref, err)
+	}
+	return tree, nil
+}
+
+// writeTreeFile writes the git tree file to the destination path, keeping
+// executable permissions and symlinks.
+func writeTreeFile(dst string, f *object.File) error {
+	if err := os.MkdirAll(path.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	contents, err := f.Contents()
+	if err != nil {
+		return err
+	}
+
+	switch f.Mode {
+	case filemode.Symlink:
+		return os.Symlink(contents, dst)
+	case filemode.Executable:
+		return os.WriteFile(dst, []byte(contents), 0o755)
+	default:
+		return os.WriteFile(dst, []byte(contents), 0o644)
+	}
+}
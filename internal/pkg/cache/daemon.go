@@ -0,0 +1,271 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/filesystem"
+)
+
+// DaemonSocketName is the name of the unix socket within the cache directory
+// which the daemon started by "nomad-pack serve" listens on.
+const DaemonSocketName = "serve.sock"
+
+// refreshDirPrefix prefixes the staging directories within the cache which
+// registries are refreshed in. Directories with a dot prefix are not read as
+// registries.
+const refreshDirPrefix = ".refresh-"
+
+// daemonDialTimeout is how long clients wait to connect to the daemon before
+// assuming it is not running.
+const daemonDialTimeout = time.Second
+
+// DaemonSocketPath returns the path of the daemon socket for the cache at
+// cachePath.
+func DaemonSocketPath(cachePath string) string {
+	return path.Join(cachePath, DaemonSocketName)
+}
+
+// WarmRequest asks the daemon to ensure a registry pack is in the cache.
+type WarmRequest struct {
+	Registry string `json:"registry"`
+	Pack     string `json:"pack"`
+	Ref      string `json:"ref"`
+}
+
+// WarmResponse is the reply of the daemon to a WarmRequest. An empty error
+// means the pack is in the cache.
+type WarmResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Daemon keeps the registries of a cache warm, by periodically fetching the
+// latest ref of each registry and fetching packs at other refs on request.
+// Cache operations are serialized, as the cache is not safe for concurrent
+// modification.
+type Daemon struct {
+	cache    *Cache
+	interval time.Duration
+	mu       sync.Mutex
+}
+
+// NewDaemon returns a daemon for the cache which refreshes the registries
+// every interval.
+func NewDaemon(cache *Cache, interval time.Duration) *Daemon {
+	return &Daemon{cache: cache, interval: interval}
+}
+
+// Serve refreshes the registries and answers the requests of clients on the
+// listener until the context is canceled.
+func (d *Daemon) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Refresh()
+			}
+		}
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go d.handle(conn)
+	}
+}
+
+// Refresh fetches the latest ref of each registry in the cache from the
+// source it was added from. Refs other than latest are left alone, as tags
+// and SHAs do not change.
+func (d *Daemon) Refresh() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries, err := os.ReadDir(d.cache.cfg.Path)
+	if err != nil {
+		d.cache.cfg.Logger.ErrorWithContext(err, "failed to read cache", d.cache.ErrorContext.GetAll()...)
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == tmpDir {
+			continue
+		}
+		if _, err := os.Stat(path.Join(d.cache.cfg.Path, entry.Name(), DefaultRef)); err != nil {
+			continue
+		}
+
+		existing, err := d.cache.existingRegistry(entry.Name())
+		if err != nil || existing == nil || existing.Source == "" {
+			continue
+		}
+
+		d.cache.cfg.Logger.Debug(fmt.Sprintf("refreshing registry %s", entry.Name()))
+		if err := d.refreshRegistry(entry.Name(), existing); err != nil {
+			d.cache.cfg.Logger.ErrorWithContext(err, fmt.Sprintf("failed to refresh registry %s", entry.Name()),
+				d.cache.ErrorContext.GetAll()...)
+		}
+	}
+}
+
+// refreshRegistry fetches the latest ref of the named registry into a staging
+// directory, and then swaps it with the latest ref in the cache, so commands
+// reading the cache while the registry is fetched keep seeing the previous
+// packs rather than a partial copy. The swap waits for commands holding the
+// read lock of the cache, as the latest ref is briefly missing during it.
+func (d *Daemon) refreshRegistry(name string, existing *Registry) error {
+	staging, err := os.MkdirTemp(d.cache.cfg.Path, refreshDirPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	livePath := path.Join(d.cache.cfg.Path, name, DefaultRef)
+	stagedPath := path.Join(staging, name, DefaultRef)
+
+	// Start from a copy of the latest ref, so the result matches adding the
+	// registry again in place.
+	if err = filesystem.CopyDir(livePath, stagedPath, false, d.cache.cfg.Logger); err != nil {
+		return err
+	}
+
+	stagingCfg := *d.cache.cfg
+	stagingCfg.Path = staging
+	stagingCfg.Eager = false
+	stagingCache, err := NewCache(&stagingCfg)
+	if err != nil {
+		return err
+	}
+	if _, err = stagingCache.Add(&AddOpts{
+		RegistryName: name,
+		Source:       existing.Source,
+		PacksDir:     existing.PacksDir,
+		CACertPath:   existing.CACertPath,
+	}); err != nil {
+		return err
+	}
+
+	unlock, err := writeLock(d.cache.cfg.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// A directory can not be renamed over one which is not empty, so the
+	// previous ref is moved aside first.
+	previousPath := path.Join(staging, DefaultRef)
+	if err = os.Rename(livePath, previousPath); err != nil {
+		return err
+	}
+	if err = os.Rename(stagedPath, livePath); err != nil {
+		_ = os.Rename(previousPath, livePath)
+		return err
+	}
+	return nil
+}
+
+// Warm ensures the requested pack is in the cache, fetching the registry at
+// the requested ref from the source it was added from if the pack is not.
+func (d *Daemon) Warm(req WarmRequest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if req.Ref == "" {
+		req.Ref = DefaultRef
+	}
+	packPath := path.Join(d.cache.cfg.Path, req.Registry, req.Ref, AppendRef(req.Pack, req.Ref))
+	if _, err := os.Stat(packPath); err == nil {
+		return nil
+	}
+
+	existing, err := d.cache.existingRegistry(req.Registry)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.Source == "" {
+		return fmt.Errorf("registry %q has not been added to the cache", req.Registry)
+	}
+
+	d.cache.cfg.Logger.Debug(fmt.Sprintf("fetching pack %s from registry %s", AppendRef(req.Pack, req.Ref), req.Registry))
+	if _, err = d.cache.Add(&AddOpts{
+		RegistryName: req.Registry,
+		Source:       existing.Source,
+		PackName:     req.Pack,
+		Ref:          req.Ref,
+		PacksDir:     existing.PacksDir,
+		CACertPath:   existing.CACertPath,
+	}); err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(packPath); err != nil {
+		return fmt.Errorf("pack %q not found in registry %q", AppendRef(req.Pack, req.Ref), req.Registry)
+	}
+	return nil
+}
+
+// handle answers the single request sent over the connection.
+func (d *Daemon) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var req WarmRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		d.cache.cfg.Logger.Debug(fmt.Sprintf("failed to decode daemon request: %s", err))
+		return
+	}
+
+	var resp WarmResponse
+	if err := d.Warm(req); err != nil {
+		resp.Error = err.Error()
+	}
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// RequestWarmPack asks the daemon listening on the socket to ensure the pack
+// described by cfg is in the cache. The returned bool is false when no daemon
+// is running, in which case the caller uses the cache directly.
+func RequestWarmPack(socketPath string, cfg *PackConfig) (bool, error) {
+	conn, err := net.DialTimeout("unix", socketPath, daemonDialTimeout)
+	if err != nil {
+		return false, nil
+	}
+	defer conn.Close()
+
+	req := WarmRequest{Registry: cfg.Registry, Pack: cfg.Name, Ref: cfg.Ref}
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return true, fmt.Errorf("failed to send request to daemon: %w", err)
+	}
+
+	var resp WarmResponse
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return true, fmt.Errorf("failed to read response from daemon: %w", err)
+	}
+	if resp.Error != "" {
+		return true, errors.New(resp.Error)
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// gitConfig is a git config key and value which applies to a single git
+// command.
+type gitConfig struct {
+	key   string
+	value string
+}
+
+// gitSourceURL returns the URL git fetches the registry source or mirror
+// from. Sources without a scheme which are not local paths or SCP-like SSH
+// addresses are assumed to be HTTPS.
+func gitSourceURL(src string) string {
+	if strings.Contains(src, "://") || strings.HasPrefix(src, "git@") {
+		return src
+	}
+	if _, err := os.Stat(src); err == nil {
+		return src
+	}
+	return "https://" + src
+}
+
+// gitClone clones the git repository at src into dst, which must not exist or
+// be empty. When depth is set, only that many commits of the ref are fetched,
+// so the ref must be a branch or tag. Otherwise, the whole repository is
+// cloned and any ref is checked out.
+func gitClone(src, dst, ref string, depth int, config []gitConfig) error {
+	args := []string{"clone"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+	}
+	args = append(args, "--", gitSourceURL(src), dst)
+
+	if err := runGit("", config, args...); err != nil {
+		return err
+	}
+	if ref != "" && depth == 0 {
+		return runGit(dst, config, "checkout", ref)
+	}
+	return nil
+}
+
+// runGit runs git with the args within dir. The config is passed through the
+// environment of the command, so it applies to this command only, and is
+// neither stored in the config of the repository nor visible in the arguments
+// of the process.
+func runGit(dir string, config []gitConfig, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT="+strconv.Itoa(len(config)),
+	)
+	for i, c := range config {
+		cmd.Env = append(cmd.Env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, c.key),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, c.value),
+		)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
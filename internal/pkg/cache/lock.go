@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"os"
+	"path"
+)
+
+// lockFileName is the name of the file within the cache directory which is
+// locked while registries are read or swapped. The dot prefix keeps it from
+// being read as a registry.
+const lockFileName = ".lock"
+
+// ReadLock takes a shared lock on the cache at cachePath, which keeps the
+// daemon started by "nomad-pack serve" from swapping in a refreshed registry
+// while packs are read from the cache. Any number of readers may hold the lock
+// at once. The returned function releases it.
+func ReadLock(cachePath string) (func(), error) {
+	return lockCache(cachePath, false)
+}
+
+// writeLock takes an exclusive lock on the cache at cachePath, waiting for
+// readers holding the lock to release it.
+func writeLock(cachePath string) (func(), error) {
+	return lockCache(cachePath, true)
+}
+
+func lockCache(cachePath string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path.Join(cachePath, lockFileName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -4,6 +4,9 @@
 package cache
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -21,6 +24,10 @@ type PackConfig struct {
 	Ref        string
 	Path       string
 	SourcePath string
+
+	// CachePath is the path of the cache holding registry packs. The
+	// default cache path is used when it is not set.
+	CachePath string
 }
 
 func (cfg *PackConfig) Init() {
@@ -33,6 +40,10 @@ func (cfg *PackConfig) Init() {
 		cfg.Ref = DefaultRef
 	}
 
+	if cfg.CachePath == "" {
+		cfg.CachePath = DefaultCachePath()
+	}
+
 	// If the passed source is a directory path, then set directory based defaults.
 	packPath, pathErr := filepath.Abs(cfg.Name)
 	if pathErr == nil {
@@ -61,12 +72,35 @@ func (cfg *PackConfig) initFromDirectory(packPath string) {
 // initFromArgs is a utility function to build a pack path for registry added
 // packs. Not for use with file system based packs.
 func (cfg *PackConfig) initFromArgs() {
-	cfg.Path = path.Join(DefaultCachePath(), cfg.Registry, cfg.Ref, cfg.Name)
+	cfg.Path = path.Join(cfg.CachePath, cfg.Registry, cfg.Ref, cfg.Name)
 	if cfg.Ref != "" {
 		cfg.Path = AppendRef(cfg.Path, cfg.Ref)
 	}
 }
 
+// LocalRef returns the git SHA the registry of the pack was cloned at, as
+// recorded in the registry metadata. It is empty for packs from a local path
+// and for registries which have no recorded SHA.
+func (cfg *PackConfig) LocalRef() (string, error) {
+	if cfg.Registry == DevRegistryName {
+		return "", nil
+	}
+
+	b, err := os.ReadFile(filepath.Join(cfg.CachePath, cfg.Registry, cfg.Ref, "metadata.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	registry := &Registry{}
+	if err = json.Unmarshal(b, registry); err != nil {
+		return "", fmt.Errorf("could not read registry metadata: %w", err)
+	}
+	return registry.LocalRef, nil
+}
+
 // Pack wraps a pack.Pack add adds the local cache ref. Useful for
 // showing the registry in the global cache differentiated from the pack metadata.
 type Pack struct {
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
 	"path"
@@ -26,13 +27,22 @@ type Registry struct {
 	// or an actual git ref)
 	Ref string `json:"ref,omitempty"`
 	// LocalRef is a reference to the git SHA that we have available locally
-	LocalRef string  `json:"local_ref,omitempty"`
-	Packs    []*Pack `json:"-"`
+	LocalRef string `json:"local_ref,omitempty"`
+	// PacksDir is the directory within the registry which contains the packs,
+	// if it is not the default
+	PacksDir string `json:"packs_dir,omitempty"`
+	// Mirror is the URL of a mirror of the source, which is cloned when the
+	// source cannot be, if it is set
+	Mirror string `json:"mirror,omitempty"`
+	// CACertPath is the path of the PEM bundle of CA certificates trusted
+	// when fetching the registry, if it is set
+	CACertPath string  `json:"ca_cert_path,omitempty"`
+	Packs      []*Pack `json:"-"`
 }
 
 // get will attempt to load the specified packs from a path, and then append them
 // to the registry's Packs slice. If no packs specified, it will get them all.
-// If the root of the path does not contain a metadata.hcl file, it is not
+// If the root of the path does not contain a metadata file, it is not
 // considered a valid pack, and will return an invalid cached pack.
 // If the loader is unable to load the pack, likewise an invalid cached pack is
 // returned. This function is not exported, to enforce clients using the cache functions.
@@ -61,6 +71,7 @@ func (r *Registry) get(opts *GetOpts, cache *Cache) error {
 		r.LocalRef = cachedRegistry.LocalRef
 		r.Source = cachedRegistry.Source
 		r.Ref = cachedRegistry.Ref
+		r.PacksDir = cachedRegistry.PacksDir
 	}
 
 	// Iterate over the packs in the registry and load each pack so that
@@ -80,11 +91,11 @@ func (r *Registry) get(opts *GetOpts, cache *Cache) error {
 		var loadedPack *pack.Pack
 		var cachedPack *Pack
 
-		if _, err = os.Stat(path.Join(opts.RegistryPath(), packEntry.Name(), "metadata.hcl")); os.IsNotExist(err) {
+		if _, err = loader.MetadataFile(path.Join(opts.RegistryPath(), packEntry.Name())); errors.Is(err, fs.ErrNotExist) {
 			cache.cfg.Logger.ErrorWithContext(errors.New("error loading pack"),
-				fmt.Sprintf("no metadata.hcl found in pack %s", packEntry.Name()), cache.ErrorContext.GetAll()...)
+				fmt.Sprintf("no metadata.hcl or metadata.json found in pack %s", packEntry.Name()), cache.ErrorContext.GetAll()...)
 
-			// Add an invalid pack if no metadata.hcl exists
+			// Add an invalid pack if no metadata file exists
 			invalidOpts := &GetOpts{
 				cachePath:    opts.cachePath,
 				RegistryName: opts.RegistryName,
@@ -98,7 +109,7 @@ func (r *Registry) get(opts *GetOpts, cache *Cache) error {
 		} else if err != nil {
 			// If some other error, log and continue to next pack.
 			cache.cfg.Logger.ErrorWithContext(err,
-				fmt.Sprintf("error checking metadata.hcl for pack %s", packEntry.Name()), cache.ErrorContext.GetAll()...)
+				fmt.Sprintf("error checking metadata file for pack %s", packEntry.Name()), cache.ErrorContext.GetAll()...)
 			continue
 		}
 
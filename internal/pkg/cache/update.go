@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+)
+
+// UpdateOpts are the options for updating a registry in the cache from the
+// source it was added from.
+type UpdateOpts struct {
+	// RegistryName is the name of the registry in the cache.
+	RegistryName string
+	// Ref is the git ref of the registry to update. If not set, each ref of
+	// the registry in the cache is updated.
+	Ref string
+	// Username and Password are the credentials used to clone the registry
+	// source over HTTPS, if it requires them.
+	Username string
+	Password string
+	// Optional callback which is periodically passed the number of bytes
+	// fetched while cloning the registry.
+	Progress func(received int64)
+}
+
+// RefUpdate describes the update of a single ref of a registry.
+type RefUpdate struct {
+	// Ref is the git ref of the registry which was updated.
+	Ref string
+	// PreviousSHA is the SHA the ref was at before the update, which is empty
+	// if the ref was not in the cache.
+	PreviousSHA string
+	// SHA is the SHA the ref is at after the update.
+	SHA string
+	// AddedPacks are the names of the packs which were not in the cache at
+	// the ref before the update, in name order.
+	AddedPacks []string
+}
+
+// Changed returns whether the update moved the ref to another SHA.
+func (u *RefUpdate) Changed() bool {
+	return u.PreviousSHA != u.SHA
+}
+
+// Update fetches the refs of the registry again from the source it was added
+// from, replacing the packs in the cache, and returns what changed for each
+// ref in ref order. The packs directory and mirror the registry was added
+// with are kept. Packs which were added to the cache on their own with a
+// target are replaced by the whole registry.
+func (c *Cache) Update(opts *UpdateOpts) ([]*RefUpdate, error) {
+	if c.cfg.Path == "" {
+		return nil, errors.ErrCachePathRequired
+	}
+	if opts.RegistryName == "" {
+		return nil, errors.ErrRegistryNameRequired
+	}
+
+	existing, err := c.existingRegistry(opts.RegistryName)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("registry %q has not been added to the cache", opts.RegistryName)
+	}
+	if existing.Source == "" {
+		return nil, fmt.Errorf("registry %q has no recorded source, add it again to update it", opts.RegistryName)
+	}
+
+	refs := []string{opts.Ref}
+	if opts.Ref == "" {
+		if refs, err = c.registryRefs(opts.RegistryName); err != nil {
+			return nil, err
+		}
+	}
+
+	updates := make([]*RefUpdate, 0, len(refs))
+	for _, ref := range refs {
+		update := &RefUpdate{Ref: ref}
+		previous, err := c.registryAtRef(opts.RegistryName, ref)
+		if err != nil {
+			return nil, err
+		}
+		var previousPacks []string
+		if previous != nil {
+			update.PreviousSHA = previous.LocalRef
+			previousPacks = registryPackNames(previous)
+		}
+
+		registry, err := c.Add(&AddOpts{
+			RegistryName: opts.RegistryName,
+			Source:       existing.Source,
+			Ref:          ref,
+			Username:     opts.Username,
+			Password:     opts.Password,
+			Progress:     opts.Progress,
+			PacksDir:     existing.PacksDir,
+			Mirror:       existing.Mirror,
+			replace:      true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update registry %q at ref %q: %w", opts.RegistryName, ref, err)
+		}
+
+		update.SHA = registry.LocalRef
+		for _, name := range registryPackNames(registry) {
+			if !slices.Contains(previousPacks, name) {
+				update.AddedPacks = append(update.AddedPacks, name)
+			}
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// RegistryNames returns the names of the registries in the cache, in name
+// order.
+func (c *Cache) RegistryNames() ([]string, error) {
+	if c.cfg.Path == "" {
+		return nil, errors.ErrCachePathRequired
+	}
+	entries, err := os.ReadDir(c.cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != tmpDir && entry.Name() != ".git" {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// registryRefs returns the refs of the named registry in the cache, in ref
+// order.
+func (c *Cache) registryRefs(name string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(c.cfg.Path, name))
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			refs = append(refs, entry.Name())
+		}
+	}
+	return refs, nil
+}
+
+// registryAtRef returns the metadata and packs of the named registry at the
+// ref in the cache, or nil if the registry has not been added at the ref.
+func (c *Cache) registryAtRef(name, ref string) (*Registry, error) {
+	b, err := os.ReadFile(filepath.Join(c.cfg.Path, name, ref, "metadata.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &Registry{}
+	if err = json.Unmarshal(b, metadata); err != nil {
+		return nil, fmt.Errorf("could not read registry metadata: %w", err)
+	}
+	registry, err := c.Get(&GetOpts{RegistryName: name, Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	registry.LocalRef = metadata.LocalRef
+	return registry, nil
+}
+
+// registryPackNames returns the names of the packs of the registry, in name
+// order.
+func registryPackNames(registry *Registry) []string {
+	names := make([]string, 0, len(registry.Packs))
+	for _, p := range registry.Packs {
+		names = append(names, p.Name())
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
+)
+
+// IsPackZip reports whether the pack path refers to a zip file, rather than a
+// pack directory.
+func IsPackZip(packPath string) bool {
+	return strings.EqualFold(path.Ext(packPath), ".zip")
+}
+
+// ExtractPackZip writes the pack within the zip file at zipPath into a
+// directory within dst, returning the path to the written pack. The pack's
+// metadata file must be at the root of the zip file, or within its only
+// top-level directory, as when a pack directory itself is zipped. The pack is
+// named after that directory, or otherwise after the zip file.
+func ExtractPackZip(zipPath, dst string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("could not open zip file: %w", err)
+	}
+	defer r.Close()
+
+	prefix, err := zipPackRoot(r.File)
+	if err != nil {
+		return "", fmt.Errorf("zip file %s does not contain a pack: %w", path.Base(zipPath), err)
+	}
+
+	name := strings.TrimSuffix(prefix, "/")
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(zipPath), path.Ext(zipPath))
+	}
+
+	out := path.Join(dst, name)
+	for _, f := range r.File {
+		// Check the whole name, so a prefix such as ../ can not move the
+		// pack outside of dst.
+		if !filepath.IsLocal(f.Name) {
+			return "", fmt.Errorf("zip file contains invalid path %q", f.Name)
+		}
+		rel, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok || rel == "" {
+			continue
+		}
+		if err := writeZipFile(path.Join(out, rel), f); err != nil {
+			return "", fmt.Errorf("could not extract %q from zip file: %w", f.Name, err)
+		}
+	}
+	return out, nil
+}
+
+// zipPackRoot returns the prefix of the entries of the zip file which make up
+// the pack, which is either empty or the name of its only top-level directory
+// followed by a slash.
+func zipPackRoot(files []*zip.File) (string, error) {
+	names := make(map[string]struct{}, len(files))
+	topLevel := make(map[string]struct{})
+
+	for _, f := range files {
+		names[f.Name] = struct{}{}
+
+		// Archives created on macOS carry resource forks in a directory of
+		// their own, which is not part of the pack.
+		top, _, _ := strings.Cut(f.Name, "/")
+		if top == "" || top == "." || top == ".." {
+			return "", fmt.Errorf("zip file contains invalid path %q", f.Name)
+		}
+		if top != "__MACOSX" {
+			topLevel[top] = struct{}{}
+		}
+	}
+
+	for _, metadataFile := range loader.MetadataFileNames {
+		if _, ok := names[metadataFile]; ok {
+			return "", nil
+		}
+		if len(topLevel) == 1 {
+			for top := range topLevel {
+				if _, ok := names[top+"/"+metadataFile]; ok {
+					return top + "/", nil
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("%s must be at the root of the zip file, or within its only top-level directory",
+		strings.Join(loader.MetadataFileNames, " or "))
+}
+
+// writeZipFile writes the zip file entry to the passed path, creating any
+// parent directories.
+func writeZipFile(dst string, f *zip.File) error {
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return os.MkdirAll(dst, 0o755)
+	case !mode.IsRegular():
+		return fmt.Errorf("unsupported file mode %s", mode.Type())
+	}
+
+	if err := os.MkdirAll(path.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	var perm fs.FileMode = 0o644
+	if mode&0o111 != 0 {
+		perm = 0o755
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -5,29 +5,33 @@ package deps
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"path"
 
 	gg "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 
+	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
 
-// Vendor reads the metadata.hcl from the provided directory and downloads
+// Vendor reads the pack metadata from the provided directory and downloads
 // dependencies
 func Vendor(ctx context.Context, ui terminal.UI, targetPath string) error {
-	// attempt to read metadata.hcl
+	// attempt to read the metadata file
+	metadataFile, err := loader.MetadataFile(targetPath)
+	if err != nil {
+		return err
+	}
 	metadata := &pack.Metadata{}
-	err := hclsimple.DecodeFile(path.Join(targetPath, "metadata.hcl"), nil, metadata)
+	err = hclsimple.DecodeFile(metadataFile, nil, metadata)
 	if err != nil {
 		return err
 	}
 
 	if len(metadata.Dependencies) == 0 {
-		return errors.New("metadata.hcl file does not contain any dependencies")
+		return fmt.Errorf("%s file does not contain any dependencies", path.Base(metadataFile))
 	}
 
 	for _, d := range metadata.Dependencies {
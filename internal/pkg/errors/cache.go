@@ -6,11 +6,13 @@ package errors
 var (
 	ErrCachePathRequired       = newError("cache path is required")
 	ErrInvalidCachePath        = newError("invalid cache path")
+	ErrInvalidPacksDir         = newError("packs directory must be a relative path within the registry")
 	ErrInvalidRegistryRevision = newError("invalid revision")
 	ErrInvalidRegistrySource   = newError("invalid registry source")
 	ErrNoRegistriesAdded       = newError("no registries were added to the cache")
 	ErrPackNameRequired        = newError("pack name is required")
 	ErrPackNotFound            = newError("pack not found")
+	ErrRegistryExists          = newError("registry already exists")
 	ErrRegistryNameRequired    = newError("registry name is required")
 	ErrRegistryNotFound        = newError("registry not found")
 	ErrRegistrySourceRequired  = newError("registry source is required")
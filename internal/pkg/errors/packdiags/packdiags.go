@@ -31,6 +31,17 @@ func DiagMissingRootVar(name string, sub *hcl.Range) *hcl.Diagnostic {
 	}
 }
 
+// DiagMissingRequiredVar is returned when a variable which declares no default
+// is not set by any variable override.
+func DiagMissingRequiredVar(name string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Missing value for required variable",
+		Detail:   fmt.Sprintf(`The variable %q has no default value, so a value must be set using a variable file, the --var flag, or an environment variable.`, name),
+		Subject:  sub,
+	}
+}
+
 // DiagInvalidDefaultValue is returned when the default for a variable does not
 // match the specified variable type.
 func DiagInvalidDefaultValue(detail string, sub *hcl.Range) *hcl.Diagnostic {
@@ -75,6 +86,85 @@ func DiagInvalidVariableName(sub *hcl.Range) *hcl.Diagnostic {
 	}
 }
 
+// DiagFailedVariableValidation is returned when a variable value does not
+// satisfy one of the validation rules declared by the pack author.
+func DiagFailedVariableValidation(msg string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid value for variable",
+		Detail:   msg,
+		Subject:  sub,
+	}
+}
+
+// DiagFailedVariableAssertion is returned when the final value of a variable
+// does not match the value asserted by the pack consumer.
+func DiagFailedVariableAssertion(name, expected, actual string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Variable assertion failed",
+		Detail:   fmt.Sprintf(`The variable %q resolved to %s, but was asserted to be %s.`, name, actual, expected),
+		Subject:  sub,
+	}
+}
+
+// DiagDuplicateVariable is returned when a pack author declares the same
+// variable in more than one of the pack's variable files.
+func DiagDuplicateVariable(name string, first, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Duplicate variable declaration",
+		Detail:   fmt.Sprintf(`The variable %q is declared in both %s and %s.`, name, first.Filename, sub.Filename),
+		Subject:  sub,
+	}
+}
+
+// DiagInvalidVariableExpression is returned when the expression given as the
+// value of a variable cannot be evaluated, or evaluates to a value which does
+// not match the variable type.
+func DiagInvalidVariableExpression(name, expr, detail string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid expression for variable",
+		Detail:   fmt.Sprintf(`The expression %q given for variable %q is invalid: %s`, expr, name, detail),
+		Subject:  sub,
+	}
+}
+
+// DiagVariableExpressionCycle is returned when the expression given as the
+// value of a variable refers back to that variable, either directly or through
+// the expressions of other variables.
+func DiagVariableExpressionCycle(name, expr string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Cycle in variable expressions",
+		Detail:   fmt.Sprintf(`The expression %q given for variable %q refers back to the variable itself.`, expr, name),
+		Subject:  sub,
+	}
+}
+
+// DiagInvalidVariableUpdate is returned when a --var override which sets a key
+// within a map variable, or appends to a list variable, can not be applied.
+func DiagInvalidVariableUpdate(name, detail string, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid variable update",
+		Detail:   fmt.Sprintf(`The value given for %q can not be applied to the variable: %s.`, name, detail),
+		Subject:  sub,
+	}
+}
+
+// DiagFailedVariableLookup is returned when a variable source fails to look up
+// the value of a variable.
+func DiagFailedVariableLookup(name string, err error, sub *hcl.Range) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Failed to look up variable",
+		Detail:   fmt.Sprintf(`A variable source failed to look up the value of %q: %s.`, name, err),
+		Subject:  sub,
+	}
+}
+
 // SafeDiagnosticsAppend prevents a nil Diagnostic from appending to the target
 // Diagnostics, since HasError is not nil-safe.
 func SafeDiagnosticsAppend(base hcl.Diagnostics, in *hcl.Diagnostic) hcl.Diagnostics {
@@ -49,6 +49,37 @@ func TestPackDiag_DiagMissingRootVar(t *testing.T) {
 	must.Eq(t, testRange, *diag.Subject)
 }
 
+func TestPackDiag_DiagFailedVariableValidation(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagFailedVariableValidation("count must be positive", &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Invalid value for variable", diag.Summary)
+	must.Eq(t, `count must be positive`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
+func TestPackDiag_DiagFailedVariableAssertion(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagFailedVariableAssertion("count", "2", "1", &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Variable assertion failed", diag.Summary)
+	must.Eq(t, `The variable "count" resolved to 1, but was asserted to be 2.`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
+func TestPackDiag_DiagDuplicateVariable(t *testing.T) {
+	ci.Parallel(t)
+	first := hcl.Range{Filename: "variables.hcl"}
+	diag := DiagDuplicateVariable("count", &first, &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Duplicate variable declaration", diag.Summary)
+	must.Eq(t, `The variable "count" is declared in both variables.hcl and «filename».`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
 func TestPackDiag_DiagInvalidDefaultValue(t *testing.T) {
 	ci.Parallel(t)
 	diag := DiagInvalidDefaultValue("test detail", &testRange)
@@ -68,6 +99,16 @@ func TestPackDiag_DiagFailedToConvertCty(t *testing.T) {
 	must.Eq(t, `Test Error`, diag.Detail)
 	must.Eq(t, testRange, *diag.Subject)
 }
+func TestPackDiag_DiagMissingRequiredVar(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagMissingRequiredVar("owner", &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Missing value for required variable", diag.Summary)
+	must.StrContains(t, diag.Detail, `The variable "owner" has no default value`)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
 func TestPackDiag_DiagInvalidValueForType(t *testing.T) {
 	ci.Parallel(t)
 	diag := DiagInvalidValueForType(errors.New("test error"), &testRange)
@@ -89,6 +130,46 @@ func TestPackDiag_DiagInvalidVariableName(t *testing.T) {
 	must.Eq(t, testRange, *diag.Subject)
 }
 
+func TestPackDiag_DiagInvalidVariableExpression(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagInvalidVariableExpression("replicas", "var.base * var.factor", "test error", &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Invalid expression for variable", diag.Summary)
+	must.Eq(t, `The expression "var.base * var.factor" given for variable "replicas" is invalid: test error`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
+func TestPackDiag_DiagVariableExpressionCycle(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagVariableExpressionCycle("replicas", "var.replicas + 1", &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Cycle in variable expressions", diag.Summary)
+	must.Eq(t, `The expression "var.replicas + 1" given for variable "replicas" refers back to the variable itself.`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
+func TestPackDiag_DiagInvalidVariableUpdate(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagInvalidVariableUpdate("ports+", "variable \"ports\" is not a list", &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Invalid variable update", diag.Summary)
+	must.Eq(t, `The value given for "ports+" can not be applied to the variable: variable "ports" is not a list.`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
+func TestPackDiag_DiagFailedVariableLookup(t *testing.T) {
+	ci.Parallel(t)
+	diag := DiagFailedVariableLookup("region", errors.New("connection refused"), &testRange)
+
+	must.Eq(t, diag.Severity, hcl.DiagError)
+	must.Eq(t, "Failed to look up variable", diag.Summary)
+	must.Eq(t, `A variable source failed to look up the value of "region": connection refused.`, diag.Detail)
+	must.Eq(t, testRange, *diag.Subject)
+}
+
 func TestPackDiag_SafeDiagnosticsAppend(t *testing.T) {
 	diags := hcl.Diagnostics{}
 	var diag *hcl.Diagnostic
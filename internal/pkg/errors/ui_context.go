@@ -12,6 +12,11 @@ import (
 // indication to the problem, as I have certainly been confused by this.
 var ErrNoTemplatesRendered = newError("no templates were rendered by the renderer process run")
 
+// ErrEmptyRender is an error to be used when the CLI is asked to fail if a
+// render results in no job specifications with content, such as when every
+// template is behind a condition which is false.
+var ErrEmptyRender = newError("no non-empty job specifications were rendered")
+
 // UIContextPrefix* are the prefixes commonly used to create a string used in
 // UI errors outputs. If a prefix is used more than once, it should have a
 // const created.
@@ -27,12 +32,14 @@ const (
 	UIContextPrefixTemplateName   = "Template Name: "
 	UIContextPrefixJobName        = "Job Name: "
 	UIContextPrefixDeploymentName = "Deployment Name: "
+	UIContextPrefixDeploymentID   = "Deployment ID: "
 	UIContextPrefixRegion         = "Region: "
 	UIContextPrefixHCLRange       = "HCL Range: "
 	UIContextPrefixRegistryName   = "Registry Name: "
 	UIContextPrefixRegistryPath   = "Registry Path: "
 	UIContextPrefixRegistryTarget = "Registry Target: "
 	UIContextPrefixOutputPath     = "Output Path: "
+	UIContextPrefixJobFile        = "Job File: "
 )
 
 // UIErrorContext is used to store and manipulate error context strings used
@@ -121,6 +121,32 @@ func (f *Sets) Visit(fn func(*flag.Flag)) {
 	f.unionSet.Visit(fn)
 }
 
+// Defined reports whether a flag with the given name exists in the sets.
+func (f *Sets) Defined(name string) bool {
+	return f.unionSet.Lookup(name) != nil
+}
+
+// Changed reports whether the named flag was set while parsing, using
+// whichever of the posix or std go flag sets was parsed.
+func (f *Sets) Changed(name string) bool {
+	if f.goflagSet.Parsed() {
+		var changed bool
+		f.goflagSet.Visit(func(fl *goflag.Flag) {
+			changed = changed || fl.Name == name
+		})
+		return changed
+	}
+	fl := f.unionSet.Lookup(name)
+	return fl != nil && fl.Changed
+}
+
+// Set sets the value of the named flag as though it had been passed on the
+// command line. Flags which accept multiple values, such as slices and maps,
+// have the value appended.
+func (f *Sets) Set(name, value string) error {
+	return f.unionSet.Set(name, value)
+}
+
 // Help builds custom help for this command, grouping by flag set.
 func (fs *Sets) Help() string {
 	var out bytes.Buffer
@@ -74,3 +74,23 @@ func TestSets(t *testing.T) {
 		})
 	}
 }
+
+func TestSets_Changed(t *testing.T) {
+	for _, args := range [][]string{{"--alpha", "1"}, {"-alpha", "1"}} {
+		var valA, valB int
+		sets := NewSets()
+		set := sets.NewSet("set")
+		set.IntVar(&IntVar{Name: "alpha", Target: &valA})
+		set.IntVar(&IntVar{Name: "beta", Target: &valB})
+
+		must.NoError(t, sets.Parse(args))
+		must.True(t, sets.Changed("alpha"))
+		must.False(t, sets.Changed("beta"))
+		must.False(t, sets.Changed("gamma"))
+
+		must.True(t, sets.Defined("beta"))
+		must.False(t, sets.Defined("gamma"))
+		must.NoError(t, sets.Set("beta", "2"))
+		must.Eq(t, 2, valB)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +17,47 @@ import (
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 )
 
+const (
+	// metadataFileHCL and metadataFileJSON are the names of the pack metadata
+	// file. A pack has exactly one of them, and the JSON file uses the JSON
+	// syntax of HCL, so both decode into the same metadata.
+	metadataFileHCL  = "metadata.hcl"
+	metadataFileJSON = "metadata.json"
+)
+
+// MetadataFileNames are the file names the metadata of a pack may be read
+// from.
+var MetadataFileNames = []string{metadataFileHCL, metadataFileJSON}
+
+// MetadataFile returns the path of the metadata file of the pack within dir.
+// It returns an error wrapping fs.ErrNotExist if the pack has no metadata
+// file, and an error if it has more than one.
+func MetadataFile(dir string) (string, error) {
+	var found []string
+	for _, name := range MetadataFileNames {
+		_, err := os.Stat(filepath.Join(dir, name))
+		switch {
+		case err == nil:
+			found = append(found, name)
+		case !errors.Is(err, fs.ErrNotExist):
+			return "", err
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("%s or %s file not found: %w", metadataFileHCL, metadataFileJSON, fs.ErrNotExist)
+	case 1:
+		return filepath.Join(dir, found[0]), nil
+	default:
+		return "", errAmbiguousMetadata
+	}
+}
+
+// errAmbiguousMetadata is returned when a pack has both metadata files, as it
+// is unclear which is meant to be used.
+var errAmbiguousMetadata = fmt.Errorf("pack contains both %s and %s, only one may be used", metadataFileHCL, metadataFileJSON)
+
 func Load(name string) (*pack.Pack, error) {
 	fi, err := os.Stat(name)
 	if err != nil {
@@ -81,13 +123,14 @@ func loadFiles(files []*pack.File) (*pack.Pack, error) {
 
 	for _, f := range files {
 		switch {
-		case f.Name == "metadata.hcl":
+		case f.Name == metadataFileHCL, f.Name == metadataFileJSON:
 
-			// Decode the metadata file into the pack. There shouldn't be more
-			// than one per pack, but if there is, last found wins.
-			if p.Metadata == nil {
-				p.Metadata = new(pack.Metadata)
+			// Decode the metadata file into the pack. The decoder picks the
+			// native or JSON syntax of HCL from the file extension.
+			if p.Metadata != nil {
+				return p, errAmbiguousMetadata
 			}
+			p.Metadata = new(pack.Metadata)
 			if err := hclsimple.Decode(f.Name, f.Content, nil, p.Metadata); err != nil {
 				return p, fmt.Errorf("failed to decode %s: %v", f.Name, err)
 			}
@@ -95,11 +138,23 @@ func loadFiles(files []*pack.File) (*pack.Pack, error) {
 		case f.Name == "variables.hcl":
 			p.RootVariableFile = f
 
+		case !strings.Contains(f.Name, "/") && strings.HasSuffix(f.Name, ".variables.hcl"),
+			strings.HasPrefix(f.Name, "variables/") && strings.HasSuffix(f.Name, ".hcl"):
+			// Large packs can split their variable declarations across
+			// multiple files, which are merged with the root variable file.
+			p.VariableFiles = append(p.VariableFiles, f)
+
 		case f.Name == "outputs.tpl":
 			// This sets the default output template file. It can be overridden
 			// from the CLI.
 			p.OutputTemplateFile = f
 
+		case f.Name == "hooks/pre-deploy.tpl":
+			p.PreDeployHookFile = f
+
+		case f.Name == "hooks/post-deploy.tpl":
+			p.PostDeployHookFile = f
+
 		case strings.HasPrefix(f.Name, "templates/") &&
 			strings.HasSuffix(f.Name, ".nomad.tpl") ||
 			strings.Contains(f.Name, "templates/_"):
@@ -119,9 +174,15 @@ func loadFiles(files []*pack.File) (*pack.Pack, error) {
 		}
 	}
 
+	// A pack which only declares its variables in additional files uses the
+	// first as its root variable file.
+	if p.RootVariableFile == nil && len(p.VariableFiles) > 0 {
+		p.RootVariableFile, p.VariableFiles = p.VariableFiles[0], p.VariableFiles[1:]
+	}
+
 	// Validate the metadata.
 	if p.Metadata == nil {
-		return p, errors.New("metadata.hcl file not found")
+		return p, fmt.Errorf("%s or %s file not found", metadataFileHCL, metadataFileJSON)
 	}
 	return p, p.Metadata.Validate()
 }
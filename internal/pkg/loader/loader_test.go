@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package loader
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+const (
+	testMetadataHCL = `
+app {
+  url = "https://example.com"
+}
+
+pack {
+  name        = "example"
+  description = "An example pack"
+  version     = "0.1.0"
+}
+
+dependency "child" {
+  alias = "child1"
+}
+
+dependency "child" {
+  alias = "child2"
+}
+`
+	testMetadataJSON = `{
+  "app": {"url": "https://example.com"},
+  "pack": {"name": "example", "description": "An example pack", "version": "0.1.0"},
+  "dependency": {"child": [{"alias": "child1"}, {"alias": "child2"}]}
+}`
+)
+
+// writeTestPack writes the files into a new pack directory, returning its
+// path.
+func writeTestPack(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		must.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoad_Metadata(t *testing.T) {
+	t.Parallel()
+
+	hclPack, err := Load(writeTestPack(t, map[string]string{"metadata.hcl": testMetadataHCL}))
+	must.NoError(t, err)
+	must.Eq(t, "example", hclPack.Name())
+	must.Len(t, 2, hclPack.Metadata.Dependencies)
+
+	jsonPack, err := Load(writeTestPack(t, map[string]string{"metadata.json": testMetadataJSON}))
+	must.NoError(t, err)
+	must.Eq(t, hclPack.Metadata, jsonPack.Metadata)
+
+	_, err = Load(writeTestPack(t, map[string]string{
+		"metadata.hcl":  testMetadataHCL,
+		"metadata.json": testMetadataJSON,
+	}))
+	must.ErrorContains(t, err, "pack contains both metadata.hcl and metadata.json")
+
+	_, err = Load(writeTestPack(t, map[string]string{"variables.hcl": ""}))
+	must.ErrorContains(t, err, "metadata.hcl or metadata.json file not found")
+
+	_, err = Load(writeTestPack(t, map[string]string{"metadata.json": `{"pack": `}))
+	must.ErrorContains(t, err, "failed to decode metadata.json")
+}
+
+func TestLoad_Hooks(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(writeTestPack(t, map[string]string{"metadata.hcl": testMetadataHCL}))
+	must.NoError(t, err)
+	must.Nil(t, p.PreDeployHookFile)
+	must.Nil(t, p.PostDeployHookFile)
+
+	dir := writeTestPack(t, map[string]string{"metadata.hcl": testMetadataHCL})
+	must.NoError(t, os.Mkdir(filepath.Join(dir, "hooks"), 0o755))
+	must.NoError(t, os.WriteFile(filepath.Join(dir, "hooks", "pre-deploy.tpl"), []byte("echo pre"), 0o644))
+	must.NoError(t, os.WriteFile(filepath.Join(dir, "hooks", "post-deploy.tpl"), []byte("echo post"), 0o644))
+
+	p, err = Load(dir)
+	must.NoError(t, err)
+	must.Eq(t, "echo pre", string(p.PreDeployHookFile.Content))
+	must.Eq(t, "echo post", string(p.PostDeployHookFile.Content))
+}
+
+func TestMetadataFile(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestPack(t, map[string]string{"metadata.json": testMetadataJSON})
+	file, err := MetadataFile(dir)
+	must.NoError(t, err)
+	must.Eq(t, filepath.Join(dir, "metadata.json"), file)
+
+	_, err = MetadataFile(writeTestPack(t, nil))
+	must.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = MetadataFile(writeTestPack(t, map[string]string{
+		"metadata.hcl":  testMetadataHCL,
+		"metadata.json": testMetadataJSON,
+	}))
+	must.ErrorContains(t, err, "pack contains both metadata.hcl and metadata.json")
+}
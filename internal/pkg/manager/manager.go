@@ -4,19 +4,31 @@
 package manager
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"path"
+	"regexp"
+	"runtime/trace"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
+	"github.com/hashicorp/nomad-pack/internal/pkg/plugin"
 	"github.com/hashicorp/nomad-pack/internal/pkg/renderer"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser/config"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/source"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
 	"github.com/hashicorp/nomad/api"
 )
 
+// DefaultMaxDependencyDepth is the maximum depth of the pack dependency tree
+// used when the configuration does not set one.
+const DefaultMaxDependencyDepth = 10
+
 // Config contains all the user specified parameters needed to correctly run
 // the pack manager.
 type Config struct {
@@ -25,6 +37,69 @@ type Config struct {
 	VariableCLIArgs map[string]string
 	VariableEnvVars map[string]string
 	UseParserV1     bool
+
+	// VariableAsserts are the values variables are expected to resolve to
+	// once all overrides have been merged.
+	VariableAsserts map[string]string
+
+	// TemplatePlugins are the paths to template plugins which should be
+	// loaded and made available to the renderer.
+	TemplatePlugins []string
+
+	// AllowExternalLookups permits template functions which read from the
+	// Nomad cluster, such as nomadVar.
+	AllowExternalLookups bool
+
+	// MaxDependencyDepth is the maximum depth of the pack dependency tree,
+	// where the dependencies of the parent pack are at a depth of one. If
+	// zero, DefaultMaxDependencyDepth is used.
+	MaxDependencyDepth int
+
+	// RenderSeed seeds the template functions which produce random output
+	// when RenderSeedSet is true.
+	RenderSeed    int64
+	RenderSeedSet bool
+
+	// DeferVars matches the names of the HCL2 variable interpolations left
+	// for Nomad to resolve. When set, all other interpolations are resolved
+	// using the pack variables.
+	DeferVars *regexp.Regexp
+
+	// ShowVars annotates the rendered job templates with comments naming the
+	// pack variable which produced each interpolated value.
+	ShowVars bool
+
+	// KeepVariablesBlock leaves the HCL2 variable interpolations in the
+	// rendered job templates, declaring them in a variables block whose
+	// defaults are the values of the pack variables.
+	KeepVariablesBlock bool
+
+	// RenderParallelism is the number of templates rendered concurrently.
+	RenderParallelism int
+
+	// RenderMemLimit is a soft limit, in bytes, on the output held by the
+	// templates being rendered concurrently. Zero disables the limit.
+	RenderMemLimit int64
+
+	// RenderMaxFileBytes is the maximum number of bytes a single template may
+	// render. Zero disables the limit.
+	RenderMaxFileBytes int64
+
+	// DumpAST receives a summary of the parse tree of each template before
+	// it is rendered, when set.
+	DumpAST io.Writer
+
+	// VariableSources are consulted, in order, for the value of each variable
+	// not set by a variable file, --var flag, or env var.
+	VariableSources []variables.VariableSource
+
+	// VariableSourcePlugins are the paths to variable source plugins, which
+	// are loaded and consulted after the VariableSources.
+	VariableSourcePlugins []string
+
+	// SecretReader reads the secrets referenced by --var values in the form
+	// vault:<path>#<field>.
+	SecretReader source.SecretReader
 }
 
 // PackManager is responsible for loading, parsing, and rendering a Pack and
@@ -36,6 +111,9 @@ type PackManager struct {
 
 	// loadedPack is unavailable until the loadAndValidatePacks func is run.
 	loadedPack *pack.Pack
+
+	// tplCtx is unavailable until the ProcessTemplates func is run.
+	tplCtx parser.PackTemplateContext
 }
 
 func NewPackManager(cfg *Config, client *api.Client) *PackManager {
@@ -49,7 +127,9 @@ func NewPackManager(cfg *Config, client *api.Client) *PackManager {
 // definition files. This is used between the variable override file generator
 // code and the ProcessTemplates logic in this file.
 func (pm *PackManager) ProcessVariableFiles() (*parser.ParsedVariables, []*errors.WrappedUIContext) {
+	region := trace.StartRegion(context.Background(), "load packs")
 	loadedPack, err := pm.loadAndValidatePacks()
+	region.End()
 	if err != nil {
 		return nil, []*errors.WrappedUIContext{{
 			Err:     err,
@@ -64,6 +144,21 @@ func (pm *PackManager) ProcessVariableFiles() (*parser.ParsedVariables, []*error
 	// without the version.
 	parentName, _, _ := strings.Cut(path.Base(pm.cfg.Path), "@")
 
+	// load any variable source plugins, so they are consulted after the
+	// configured variable sources
+	sources := slices.Clone(pm.cfg.VariableSources)
+	for _, pluginPath := range pm.cfg.VariableSourcePlugins {
+		src, err := source.LoadPlugin(pluginPath, plugin.DefaultDir())
+		if err != nil {
+			return nil, []*errors.WrappedUIContext{{
+				Err:     err,
+				Subject: "failed to load variable source plugin",
+				Context: errors.NewUIErrorContext(),
+			}}
+		}
+		sources = append(sources, src)
+	}
+
 	pCfg := &config.ParserConfig{
 		Version:           config.V2,
 		ParentPack:        pm.loadedPack,
@@ -71,6 +166,10 @@ func (pm *PackManager) ProcessVariableFiles() (*parser.ParsedVariables, []*error
 		EnvOverrides:      pm.cfg.VariableEnvVars,
 		FileOverrides:     pm.cfg.VariableFiles,
 		FlagOverrides:     pm.cfg.VariableCLIArgs,
+
+		AdditionalVariableFiles: loadedPack.AdditionalVariableFiles(),
+		VariableSources:         sources,
+		SecretReader:            pm.cfg.SecretReader,
 	}
 
 	if pm.cfg.UseParserV1 {
@@ -87,7 +186,9 @@ func (pm *PackManager) ProcessVariableFiles() (*parser.ParsedVariables, []*error
 		}}
 	}
 
+	region = trace.StartRegion(context.Background(), "resolve variables")
 	parsedVars, diags := variableParser.Parse()
+	region.End()
 	if diags != nil && diags.HasErrors() {
 		return nil, errors.HCLDiagsToWrappedUIContext(diags)
 	}
@@ -95,6 +196,26 @@ func (pm *PackManager) ProcessVariableFiles() (*parser.ParsedVariables, []*error
 	return parsedVars, nil
 }
 
+// CheckVariables parses the variables of the pack and the supplied overrides,
+// checking their types, that each required variable is set, and any value
+// assertions, without rendering any templates. The type errors of every
+// override are reported together.
+func (pm *PackManager) CheckVariables() []*errors.WrappedUIContext {
+	parsedVars, wErr := pm.ProcessVariableFiles()
+	if wErr != nil {
+		return wErr
+	}
+
+	diags := parsedVars.CheckRequired(pm.loadedPack.ID())
+	if len(pm.cfg.VariableAsserts) > 0 {
+		diags = diags.Extend(parsedVars.AssertValues(pm.loadedPack.ID(), pm.cfg.VariableAsserts))
+	}
+	if diags.HasErrors() {
+		return errors.HCLDiagsToWrappedUIContext(diags)
+	}
+	return nil
+}
+
 // ProcessTemplates is responsible for running all backend process for the
 // PackManager returning an error along with the ProcessedPack. This contains
 // all the rendered templates.
@@ -102,22 +223,47 @@ func (pm *PackManager) ProcessVariableFiles() (*parser.ParsedVariables, []*error
 // TODO(jrasell) figure out whether we want an error or hcl.Diagnostics return
 // object. If we stick to an error, then we need to come up with a way of
 // nicely formatting them.
-func (pm *PackManager) ProcessTemplates(renderAux bool, format bool, ignoreMissingVars bool) (*renderer.Rendered, []*errors.WrappedUIContext) {
+func (pm *PackManager) ProcessTemplates(renderAux bool, format bool, ignoreMissingVars bool, keepGoing bool) (*renderer.Rendered, []*errors.WrappedUIContext) {
 
 	parsedVars, wErr := pm.ProcessVariableFiles()
 	if wErr != nil {
 		return nil, wErr
 	}
 
+	// Variables without a default must be set, as there is no value to
+	// render them with.
+	if diags := parsedVars.CheckRequired(pm.loadedPack.ID()); diags.HasErrors() {
+		return nil, errors.HCLDiagsToWrappedUIContext(diags)
+	}
+
+	// Check the resolved variables before rendering, so failed assertions are
+	// all reported together.
+	if len(pm.cfg.VariableAsserts) > 0 {
+		if diags := parsedVars.AssertValues(pm.loadedPack.ID(), pm.cfg.VariableAsserts); diags.HasErrors() {
+			return nil, errors.HCLDiagsToWrappedUIContext(diags)
+		}
+	}
+
 	// Pre-test the parsed variables so that we can trust them
 	// in rendering and to use for errors later
 	tplCtx, diags := parsedVars.ToPackTemplateContext(pm.loadedPack)
 	if diags != nil && diags.HasErrors() {
 		return nil, errors.HCLDiagsToWrappedUIContext(diags)
 	}
+	pm.tplCtx = tplCtx
 
 	r := new(renderer.Renderer)
 	r.Client = pm.client
+	r.AllowExternalLookups = pm.cfg.AllowExternalLookups
+	r.Seed = pm.cfg.RenderSeed
+	r.SeedSet = pm.cfg.RenderSeedSet
+	r.DeferVars = pm.cfg.DeferVars
+	r.ShowVars = pm.cfg.ShowVars
+	r.KeepVariablesBlock = pm.cfg.KeepVariablesBlock
+	r.Parallelism = pm.cfg.RenderParallelism
+	r.MemLimit = pm.cfg.RenderMemLimit
+	r.MaxFileBytes = pm.cfg.RenderMaxFileBytes
+	r.DumpAST = pm.cfg.DumpAST
 	pm.renderer = r
 
 	// should auxiliary files be rendered as well?
@@ -126,13 +272,36 @@ func (pm *PackManager) ProcessTemplates(renderAux bool, format bool, ignoreMissi
 	// should we format before rendering?
 	pm.renderer.Format = format
 
+	// should a failing template stop the remaining templates rendering?
+	pm.renderer.KeepGoing = keepGoing
+
+	// load any template plugins, so their functions are available
+	for _, pluginPath := range pm.cfg.TemplatePlugins {
+		plugin, err := renderer.LoadTemplatePlugin(pluginPath, plugin.DefaultDir())
+		if err != nil {
+			return nil, []*errors.WrappedUIContext{{
+				Err:     err,
+				Subject: "failed to load template plugin",
+				Context: errors.NewUIErrorContext(),
+			}}
+		}
+		pm.renderer.Plugins = append(pm.renderer.Plugins, plugin)
+	}
+
 	rendered, err := r.Render(pm.loadedPack, parsedVars)
 	if err != nil {
 		return nil, []*errors.WrappedUIContext{
 			errors.ParseTemplateError(tplCtx, err).ToWrappedUIContext(),
 		}
 	}
-	return rendered, nil
+
+	// When keeping going, templates which failed are returned alongside the
+	// partial render.
+	var renderErrs []*errors.WrappedUIContext
+	for _, err := range rendered.Errors() {
+		renderErrs = append(renderErrs, errors.ParseTemplateError(tplCtx, err).ToWrappedUIContext())
+	}
+	return rendered, renderErrs
 }
 
 // ProcessOutputTemplate performs the output template rendering.
@@ -140,6 +309,28 @@ func (pm *PackManager) ProcessOutputTemplate() (string, error) {
 	return pm.renderer.RenderOutput()
 }
 
+// ProcessHookTemplates renders the pre and post deploy hook templates of the
+// parent pack. A hook the pack does not define is returned empty.
+// ProcessTemplates must be called first.
+func (pm *PackManager) ProcessHookTemplates() (pre, post string, err error) {
+	return pm.renderer.RenderHooks()
+}
+
+// ProcessedOutputs returns the named output values defined by the output
+// template. ProcessOutputTemplate must be called first.
+func (pm *PackManager) ProcessedOutputs() map[string]any {
+	return pm.renderer.Outputs()
+}
+
+// Vars returns the values of the parent pack's variables the templates were
+// rendered with. ProcessTemplates must be called first.
+func (pm *PackManager) Vars() map[string]any {
+	if pm.tplCtx == nil {
+		return nil
+	}
+	return pm.tplCtx.Vars()
+}
+
 // loadAndValidatePacks triggers the initial parent load and then starts the
 // dependent pack loader. The returned pack will therefore be fully populated.
 func (pm *PackManager) loadAndValidatePacks() (*pack.Pack, error) {
@@ -157,16 +348,28 @@ func (pm *PackManager) loadAndValidatePacks() (*pack.Pack, error) {
 	// dependencies are stored.
 	depsPath := path.Join(pm.cfg.Path, "deps")
 
-	if err := pm.loadAndValidatePack(parentPack, depsPath); err != nil {
+	if err := pm.loadAndValidatePack(parentPack, depsPath, []string{parentPack.Name()}); err != nil {
 		return nil, fmt.Errorf("failed to load pack dependency: %v", err)
 	}
 
 	return parentPack, nil
 }
 
+// maxDependencyDepth returns the configured maximum depth of the pack
+// dependency tree, or the default if none is configured, as is the case for
+// commands without the --max-depth flag.
+func (pm *PackManager) maxDependencyDepth() int {
+	if pm.cfg.MaxDependencyDepth > 0 {
+		return pm.cfg.MaxDependencyDepth
+	}
+	return DefaultMaxDependencyDepth
+}
+
 // loadAndValidatePack recursively loads a pack and its dependencies. Errors
-// result in an immediate return.
-func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, depsPath string) error {
+// result in an immediate return. The chain holds the names of the packs from
+// the parent down to the current pack, and is used to enforce the maximum
+// dependency depth.
+func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, depsPath string, chain []string) error {
 
 	for _, dep := range cur.Metadata.Dependencies {
 
@@ -175,6 +378,13 @@ func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, depsPath string) erro
 			continue
 		}
 
+		// The parent pack is the first entry of the chain, so its length is
+		// the depth of this dependency.
+		if maxDepth := pm.maxDependencyDepth(); len(chain) > maxDepth {
+			return fmt.Errorf("dependency tree exceeds the maximum depth of %d: %s",
+				maxDepth, strings.Join(append(slices.Clone(chain), dep.Name), " -> "))
+		}
+
 		// Load and validate the dependency pack.
 		packPath := path.Join(depsPath, path.Clean(dep.Name))
 		depPack, err := loader.Load(packPath)
@@ -190,7 +400,7 @@ func (pm *PackManager) loadAndValidatePack(cur *pack.Pack, depsPath string) erro
 		cur.AddDependency(dep.ID(), depPack)
 
 		// Recursive call.
-		if err := pm.loadAndValidatePack(depPack, path.Join(packPath, "deps")); err != nil {
+		if err := pm.loadAndValidatePack(depPack, path.Join(packPath, "deps"), append(slices.Clone(chain), dep.Name)); err != nil {
 			return err
 		}
 	}
@@ -217,3 +427,12 @@ func (pm *PackManager) Metadata() *pack.Metadata {
 	}
 	return pm.loadedPack.Metadata
 }
+
+// RequiredACLCapabilities returns the Nomad ACL capabilities declared by the
+// loaded pack and its dependencies.
+func (pm *PackManager) RequiredACLCapabilities() []string {
+	if pm.loadedPack == nil {
+		return nil
+	}
+	return pm.loadedPack.RequiredACLCapabilities()
+}
@@ -0,0 +1,237 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package migrate upgrades packs written for earlier pack format versions to
+// the current format version.
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsimple"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/loader"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+)
+
+// CurrentVersion is the pack format version understood by this release.
+//
+// Version 1 packs reference their variables using the template data, such as
+// .my.count, and may set the app.author and pack.url metadata fields. Version
+// 2 packs use the var and meta template functions, and omit those fields.
+const CurrentVersion = 2
+
+// Pack holds the files of a pack which migrations may change, keyed by their
+// path within the pack directory.
+type Pack struct {
+	// Name is the name of the pack, from its metadata.
+	Name string
+
+	// Dependencies maps each name by which the pack may refer to one of its
+	// dependencies to the dependency.
+	Dependencies map[string]*Dependency
+
+	Files map[string][]byte
+}
+
+// Dependency describes a dependency of a pack being migrated.
+type Dependency struct {
+	// Context is the name of the dependency within the template context.
+	Context string
+
+	// Dependencies are the names of the dependency's own dependencies within
+	// its template context. They are only known when it is vendored.
+	Dependencies []string
+}
+
+// Migration upgrades a pack from one format version to the next. Apply
+// changes the files of the pack in place, and returns warnings describing
+// anything which must be migrated by hand.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(p *Pack) ([]string, error)
+}
+
+// migrations are the registered migrations, in the order they are applied.
+// Each must leave a pack which has already been migrated unchanged, as the
+// format version of a pack is detected by which migrations change it.
+var migrations = []Migration{
+	{
+		From:        1,
+		Description: "remove the deprecated app.author and pack.url metadata fields",
+		Apply:       removeDeprecatedMetadata,
+	},
+	{
+		From:        1,
+		Description: "replace template data variable references, such as .my.count, with the var and meta functions",
+		Apply:       rewriteTemplateRefs,
+	},
+}
+
+// Change is a file whose content was changed by the migrations.
+type Change struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// Result describes the migration of a pack.
+type Result struct {
+	// From is the detected format version of the pack. It is CurrentVersion
+	// when the pack needs no migration.
+	From int
+
+	// Applied are the descriptions of the migrations which changed the pack.
+	Applied []string
+
+	// Changes are the files changed, sorted by path.
+	Changes []Change
+
+	// Warnings describe anything which must be migrated by hand.
+	Warnings []string
+}
+
+// Migrate loads the pack within dir and applies the registered migrations to
+// it, without writing any files. Packs do not declare their format version,
+// so it is detected as the version of the first migration which changes the
+// pack.
+func Migrate(dir string) (*Result, error) {
+	p, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	before := make(map[string][]byte, len(p.Files))
+	for name, content := range p.Files {
+		before[name] = bytes.Clone(content)
+	}
+
+	result := &Result{From: CurrentVersion}
+	for _, m := range migrations {
+		snapshot := maps.Clone(p.Files)
+
+		warnings, err := m.Apply(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s: %w", m.Description, err)
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+
+		for name, content := range p.Files {
+			if !bytes.Equal(snapshot[name], content) {
+				result.Applied = append(result.Applied, m.Description)
+				result.From = min(result.From, m.From)
+				break
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(p.Files)) {
+		if !bytes.Equal(before[name], p.Files[name]) {
+			result.Changes = append(result.Changes, Change{Path: name, Before: before[name], After: p.Files[name]})
+		}
+	}
+	return result, nil
+}
+
+// Write writes the changed files of the migration to the pack within dir,
+// keeping the permissions of the files they replace.
+func (r *Result) Write(dir string) error {
+	for _, c := range r.Changes {
+		filePath := filepath.Join(dir, filepath.FromSlash(c.Path))
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", c.Path, err)
+		}
+		if err := os.WriteFile(filePath, c.After, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", c.Path, err)
+		}
+	}
+	return nil
+}
+
+// Load reads the files of the pack within dir which migrations may change:
+// its metadata, output template, and the templates within its templates
+// directory. Vendored dependencies are not included, and must be migrated
+// separately.
+func Load(dir string) (*Pack, error) {
+	p := &Pack{Files: make(map[string][]byte)}
+
+	metadataFile, err := loader.MetadataFile(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack metadata: %w", err)
+	}
+	metadata, err := os.ReadFile(metadataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack metadata: %w", err)
+	}
+	metadataName := filepath.Base(metadataFile)
+	p.Files[metadataName] = metadata
+
+	var md pack.Metadata
+	if err := hclsimple.Decode(metadataName, metadata, nil, &md); err != nil {
+		return nil, fmt.Errorf("failed to decode pack metadata: %w", err)
+	}
+	if md.Pack != nil {
+		p.Name = md.Pack.Name
+	}
+
+	p.Dependencies = make(map[string]*Dependency, len(md.Dependencies))
+	for _, d := range md.Dependencies {
+		dep := &Dependency{Context: d.AliasOrName()}
+
+		// Vendored dependencies are found by name within the deps directory.
+		var depMD pack.Metadata
+		depFile, err := loader.MetadataFile(filepath.Join(dir, "deps", d.Name))
+		if err == nil {
+			err = hclsimple.DecodeFile(depFile, nil, &depMD)
+		}
+		if err == nil {
+			for _, dd := range depMD.Dependencies {
+				dep.Dependencies = append(dep.Dependencies, dd.AliasOrName())
+			}
+		}
+
+		p.Dependencies[d.Name] = dep
+		p.Dependencies[d.AliasOrName()] = dep
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "outputs.tpl")); err == nil {
+		p.Files["outputs.tpl"] = content
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read outputs.tpl: %w", err)
+	}
+
+	err = filepath.WalkDir(filepath.Join(dir, "templates"), func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".tpl") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		p.Files[path.Clean(filepath.ToSlash(rel))] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack templates: %w", err)
+	}
+	return p, nil
+}
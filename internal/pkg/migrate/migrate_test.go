@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+const testMetadataV1 = `app {
+  url    = "https://example.com"
+  author = "HashiCorp"
+}
+
+pack {
+  name        = "example"
+  description = "An example pack."
+  url         = "https://example.com/pack"
+  version     = "0.1.0"
+}
+`
+
+const testMetadataV2 = `app {
+  url = "https://example.com"
+}
+
+pack {
+  name        = "example"
+  description = "An example pack."
+  version     = "0.1.0"
+}
+`
+
+// writePack writes the files to a new pack directory, returning its path.
+func writePack(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		must.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+		must.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+	}
+	return dir
+}
+
+// migrateTemplate migrates a pack with a single template, returning the
+// migrated template and any warnings.
+func migrateTemplate(t *testing.T, extra map[string]string, tpl string) (string, []string) {
+	t.Helper()
+	files := map[string]string{
+		"metadata.hcl":                testMetadataV2,
+		"templates/example.nomad.tpl": tpl,
+	}
+	for name, content := range extra {
+		files[name] = content
+	}
+	dir := writePack(t, files)
+
+	result, err := Migrate(dir)
+	must.NoError(t, err)
+	for _, c := range result.Changes {
+		if c.Path == "templates/example.nomad.tpl" {
+			return string(c.After), result.Warnings
+		}
+	}
+	return tpl, result.Warnings
+}
+
+func TestMigrate_TemplateRefs(t *testing.T) {
+	testCases := []struct {
+		name string
+		tpl  string
+		want string
+	}{
+		{
+			name: "my",
+			tpl:  `count = [[ .my.count ]]`,
+			want: `count = [[ var "count" . ]]`,
+		},
+		{
+			name: "pack name",
+			tpl:  `count = [[ .example.count ]]`,
+			want: `count = [[ var "count" . ]]`,
+		},
+		{
+			name: "nested",
+			tpl:  `[[ .my.config.port ]]`,
+			want: `[[ var "config.port" . ]]`,
+		},
+		{
+			name: "argument",
+			tpl:  `[[ .my.name | quote ]] [[ quote .my.name ]]`,
+			want: `[[ var "name" . | quote ]] [[ quote (var "name" .) ]]`,
+		},
+		{
+			name: "all variables",
+			tpl:  `[[ toJson .my ]]`,
+			want: `[[ toJson (vars .) ]]`,
+		},
+		{
+			name: "metadata",
+			tpl:  `[[ .nomad_pack.pack.name ]]`,
+			want: `[[ meta "pack.name" . ]]`,
+		},
+		{
+			name: "root within range",
+			tpl:  `[[ range .my.ports ]][[ $.my.prefix ]][[ . ]][[ end ]]`,
+			want: `[[ range var "ports" . ]][[ var "prefix" $ ]][[ . ]][[ end ]]`,
+		},
+		{
+			name: "control structures",
+			tpl:  `[[ if .my.enabled ]][[ template "job" . ]][[ else ]][[ .my.other ]][[ end ]]`,
+			want: `[[ if var "enabled" . ]][[ template "job" . ]][[ else ]][[ var "other" . ]][[ end ]]`,
+		},
+		{
+			name: "defined template",
+			tpl:  `[[ define "job" ]]job [[ .my.job_name | quote ]][[ end ]]`,
+			want: `[[ define "job" ]]job [[ var "job_name" . | quote ]][[ end ]]`,
+		},
+		{
+			name: "unrelated",
+			tpl:  `[[ $x := .other.value ]][[ $x ]] # keep .my.count in text`,
+			want: `[[ $x := .other.value ]][[ $x ]] # keep .my.count in text`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, warnings := migrateTemplate(t, nil, tc.tpl)
+			must.Eq(t, tc.want, got)
+			must.SliceEmpty(t, warnings)
+		})
+	}
+}
+
+func TestMigrate_Warnings(t *testing.T) {
+	t.Run("range", func(t *testing.T) {
+		tpl := "[[ range .my.items ]]\n[[ .my.name ]]\n[[ end ]]"
+		got, warnings := migrateTemplate(t, nil, tpl)
+		must.Eq(t, "[[ range var \"items\" . ]]\n[[ .my.name ]]\n[[ end ]]", got)
+		must.Len(t, 1, warnings)
+		must.StrHasPrefix(t, "templates/example.nomad.tpl:2: .my.name", warnings[0])
+	})
+
+	t.Run("metadata block", func(t *testing.T) {
+		got, warnings := migrateTemplate(t, nil, `[[ .nomad_pack.pack ]]`)
+		must.Eq(t, `[[ .nomad_pack.pack ]]`, got)
+		must.Len(t, 1, warnings)
+		must.StrContains(t, warnings[0], "has no equivalent")
+	})
+}
+
+func TestMigrate_Dependencies(t *testing.T) {
+	metadata := testMetadataV2 + `
+dependency "child" {
+  alias  = "kid"
+  source = "git://example.com/child"
+}
+`
+	childMetadata := `pack {
+  name    = "child"
+  version = "0.1.0"
+}
+
+dependency "grandchild" {}
+`
+	extra := map[string]string{
+		"metadata.hcl":            metadata,
+		"deps/child/metadata.hcl": childMetadata,
+	}
+
+	got, warnings := migrateTemplate(t, extra, `[[ .kid.count ]] [[ .child.count ]] [[ .kid.grandchild.count ]] [[ template "x" .kid ]]`)
+	must.Eq(t, `[[ var "count" .kid ]] [[ var "count" .kid ]] [[ .kid.grandchild.count ]] [[ template "x" .kid ]]`, got)
+	must.SliceEmpty(t, warnings)
+}
+
+func TestMigrate_Version(t *testing.T) {
+	t.Run("v1", func(t *testing.T) {
+		dir := writePack(t, map[string]string{
+			"metadata.hcl":                testMetadataV1,
+			"outputs.tpl":                 `[[ .my.count ]]`,
+			"templates/example.nomad.tpl": `[[ .my.count ]]`,
+			"templates/notes.txt":         `[[ .my.count ]]`,
+		})
+
+		result, err := Migrate(dir)
+		must.NoError(t, err)
+		must.Eq(t, 1, result.From)
+		must.Len(t, 2, result.Applied)
+		must.Len(t, 3, result.Changes)
+		must.Eq(t, "metadata.hcl", result.Changes[0].Path)
+		must.Eq(t, testMetadataV2, string(result.Changes[0].After))
+
+		// Migrate does not write the changes itself.
+		content, err := os.ReadFile(filepath.Join(dir, "outputs.tpl"))
+		must.NoError(t, err)
+		must.Eq(t, `[[ .my.count ]]`, string(content))
+
+		must.NoError(t, result.Write(dir))
+		content, err = os.ReadFile(filepath.Join(dir, "outputs.tpl"))
+		must.NoError(t, err)
+		must.Eq(t, `[[ var "count" . ]]`, string(content))
+
+		// A migrated pack is at the current version.
+		result, err = Migrate(dir)
+		must.NoError(t, err)
+		must.Eq(t, CurrentVersion, result.From)
+		must.SliceEmpty(t, result.Changes)
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := writePack(t, map[string]string{
+			"metadata.hcl":                testMetadataV2,
+			"templates/example.nomad.tpl": `[[ var "count" . ]]`,
+		})
+
+		result, err := Migrate(dir)
+		must.NoError(t, err)
+		must.Eq(t, CurrentVersion, result.From)
+		must.SliceEmpty(t, result.Applied)
+		must.SliceEmpty(t, result.Changes)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		dir := writePack(t, map[string]string{
+			"metadata.hcl":                testMetadataV2,
+			"templates/example.nomad.tpl": `[[ .my.count `,
+		})
+
+		_, err := Migrate(dir)
+		must.ErrorContains(t, err, "failed to parse templates/example.nomad.tpl")
+	})
+
+	t.Run("missing metadata", func(t *testing.T) {
+		_, err := Migrate(t.TempDir())
+		must.ErrorContains(t, err, "failed to read pack metadata")
+	})
+}
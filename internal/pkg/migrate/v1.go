@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package migrate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template/parse"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsimple"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+)
+
+// removeDeprecatedMetadata removes the app.author and pack.url metadata
+// fields, which were deprecated by the version 2 format. A metadata.json file
+// is not rewritten, as that would lose its formatting, so a warning is
+// returned instead if it sets either field.
+func removeDeprecatedMetadata(p *Pack) ([]string, error) {
+	if content, ok := p.Files["metadata.json"]; ok {
+		var md pack.Metadata
+		if err := hclsimple.Decode("metadata.json", content, nil, &md); err != nil {
+			return nil, err
+		}
+		var warnings []string
+		if md.App != nil && md.App.Author != "" {
+			warnings = append(warnings, "metadata.json: app.author is deprecated and should be removed")
+		}
+		if md.Pack != nil && md.Pack.URL != "" {
+			warnings = append(warnings, "metadata.json: pack.url is deprecated and should be removed")
+		}
+		return warnings, nil
+	}
+
+	f, diags := hclwrite.ParseConfig(p.Files["metadata.hcl"], "metadata.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var changed bool
+	for _, block := range f.Body().Blocks() {
+		var name string
+		switch block.Type() {
+		case "app":
+			name = "author"
+		case "pack":
+			name = "url"
+		default:
+			continue
+		}
+		if block.Body().GetAttribute(name) != nil {
+			block.Body().RemoveAttribute(name)
+			changed = true
+		}
+	}
+
+	if changed {
+		p.Files["metadata.hcl"] = hclwrite.Format(f.Bytes())
+	}
+	return nil, nil
+}
+
+// rewriteTemplateRefs replaces the variable and metadata references of the
+// version 1 template data, such as .my.count or .nomad_pack.pack.name, with
+// the var and meta functions of the version 2 template context.
+func rewriteTemplateRefs(p *Pack) ([]string, error) {
+	var warnings []string
+	for _, name := range slices.Sorted(maps.Keys(p.Files)) {
+		if !strings.HasSuffix(name, ".tpl") {
+			continue
+		}
+		r := &refRewriter{pack: p, name: name, src: string(p.Files[name])}
+		out, err := r.rewrite()
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, r.warnings...)
+		p.Files[name] = []byte(out)
+	}
+	return warnings, nil
+}
+
+// refRewriter rewrites the version 1 references within a single template.
+type refRewriter struct {
+	pack *Pack
+	name string
+	src  string
+
+	edits    []refEdit
+	warnings []string
+}
+
+// refEdit replaces the template source between start and end with text.
+type refEdit struct {
+	start, end int
+	text       string
+}
+
+// rewrite parses the template and returns its source with each reference
+// replaced. Only the references are changed, so the rest of the template,
+// including its whitespace and comments, is kept as written.
+func (r *refRewriter) rewrite() (string, error) {
+	tree := parse.New(r.name)
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(r.src, "[[", "]]", treeSet); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", r.name, err)
+	}
+
+	// The templates defined within the file are walked as well. They are
+	// assumed to be passed the template data, as is usual for helpers.
+	r.walk(tree.Root, true)
+	for _, name := range slices.Sorted(maps.Keys(treeSet)) {
+		if t := treeSet[name]; t != tree {
+			r.walk(t.Root, true)
+		}
+	}
+
+	slices.SortFunc(r.edits, func(a, b refEdit) int { return b.start - a.start })
+	out := r.src
+	for _, e := range r.edits {
+		out = out[:e.start] + e.text + out[e.end:]
+	}
+	return out, nil
+}
+
+// walk visits the actions of the list. Dot is the template data only when
+// rootDot is true; the bodies of range and with blocks change it.
+func (r *refRewriter) walk(list *parse.ListNode, rootDot bool) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			r.pipe(n.Pipe, rootDot)
+		case *parse.IfNode:
+			r.pipe(n.Pipe, rootDot)
+			r.walk(n.List, rootDot)
+			r.walk(n.ElseList, rootDot)
+		case *parse.RangeNode:
+			r.pipe(n.Pipe, rootDot)
+			r.walk(n.List, false)
+			r.walk(n.ElseList, rootDot)
+		case *parse.WithNode:
+			r.pipe(n.Pipe, rootDot)
+			r.walk(n.List, false)
+			r.walk(n.ElseList, rootDot)
+		case *parse.TemplateNode:
+			r.pipe(n.Pipe, rootDot)
+		}
+	}
+}
+
+func (r *refRewriter) pipe(pipe *parse.PipeNode, rootDot bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			r.arg(arg, rootDot, len(cmd.Args) == 1)
+		}
+	}
+}
+
+// arg visits a command argument. When the argument is the only one of its
+// command, a replacement function call needs no parentheses.
+func (r *refRewriter) arg(node parse.Node, rootDot, sole bool) {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		r.ref(n, n.Ident, ".", rootDot, sole)
+	case *parse.VariableNode:
+		// References relative to the template data using $ are unaffected by
+		// range and with blocks.
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			r.ref(n, n.Ident[1:], "$", true, sole)
+		}
+	case *parse.PipeNode:
+		r.pipe(n, rootDot)
+	case *parse.ChainNode:
+		r.arg(n.Node, rootDot, false)
+	}
+}
+
+// ref records the replacement of a reference to the template data.
+func (r *refRewriter) ref(node parse.Node, idents []string, dot string, rootDot, sole bool) {
+	text := node.String()
+	repl, isV1 := r.replacement(idents, dot)
+	if !isV1 {
+		return
+	}
+
+	// The position of a field with several identifiers is that of its last
+	// identifier, so find where the whole reference starts.
+	pos := int(node.Position())
+	start := strings.LastIndex(r.src[:min(pos+len(text), len(r.src))], text)
+	line := strings.Count(r.src[:pos], "\n") + 1
+
+	switch {
+	case repl == "":
+		r.warnings = append(r.warnings, fmt.Sprintf("%s:%d: %s has no equivalent in the current format", r.name, line, text))
+	case !rootDot:
+		r.warnings = append(r.warnings, fmt.Sprintf("%s:%d: %s is within a range or with block, so is not relative to the template data", r.name, line, text))
+	case start < 0:
+		r.warnings = append(r.warnings, fmt.Sprintf("%s:%d: %s could not be located", r.name, line, text))
+	default:
+		if !sole {
+			repl = "(" + repl + ")"
+		}
+		r.edits = append(r.edits, refEdit{start: start, end: start + len(text), text: repl})
+	}
+}
+
+// replacement returns the version 2 replacement of a reference to the
+// template data, relative to dot. It returns false if the reference is not a
+// version 1 reference, and an empty replacement if the reference has no
+// equivalent.
+func (r *refRewriter) replacement(idents []string, dot string) (string, bool) {
+	first, rest := idents[0], strings.Join(idents[1:], ".")
+
+	switch {
+	case first == "my" || first == r.pack.Name:
+		if rest == "" {
+			return "vars " + dot, true
+		}
+		return fmt.Sprintf("var %q %s", rest, dot), true
+
+	case first == "nomad_pack":
+		// The metadata is only available as individual values.
+		if len(idents) < 3 {
+			return "", true
+		}
+		return fmt.Sprintf("meta %q %s", rest, dot), true
+	}
+
+	// A dependency alone, or followed by one of its own dependencies, is a
+	// template context in version 2, so only references to its variables
+	// are rewritten.
+	if dep, ok := r.pack.Dependencies[first]; ok && rest != "" && !slices.Contains(dep.Dependencies, idents[1]) {
+		ctx := "." + dep.Context
+		if dot == "$" {
+			ctx = "$" + ctx
+		}
+		return fmt.Sprintf("var %q %s", rest, ctx), true
+	}
+	return "", false
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package plugin locates and runs the external executables which extend
+// nomad-pack, such as template plugins and variable source plugins. Plugins
+// are only loaded from an allowed directory, and are run as a subprocess for
+// each request.
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// EnvDir is the env var which can be set to override the directory that
+// plugins are allowed to be loaded from.
+const EnvDir = "NOMAD_PACK_PLUGIN_DIR"
+
+// DefaultDir returns the default directory that plugins are allowed to be
+// loaded from.
+func DefaultDir() string {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir
+	}
+	cfgDir, err := os.UserConfigDir()
+	if err != nil {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			homeDir = "~"
+		}
+		return filepath.Join(homeDir, ".nomad/pack/plugins")
+	}
+	return filepath.Join(cfgDir, "nomad/pack/plugins")
+}
+
+// Resolve returns the absolute, symlink-free path to the plugin and ensures
+// it sits within the allowed directory. A bare name is looked up within the
+// allowed directory.
+func Resolve(pluginPath, allowedDir string) (string, error) {
+	if allowedDir == "" {
+		return "", fmt.Errorf("no plugin directory configured")
+	}
+
+	dir, err := filepath.EvalSymlinks(allowedDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve plugin directory: %w", err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", fmt.Errorf("failed to resolve plugin directory: %w", err)
+	}
+
+	if !strings.ContainsRune(pluginPath, filepath.Separator) && !strings.ContainsRune(pluginPath, '/') {
+		pluginPath = filepath.Join(dir, pluginPath)
+	}
+
+	resolved, err := filepath.EvalSymlinks(pluginPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve plugin: %w", err)
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return "", fmt.Errorf("failed to resolve plugin: %w", err)
+	}
+
+	rel, err := filepath.Rel(dir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("plugin %s is not within the allowed directory %s", pluginPath, dir)
+	}
+
+	return resolved, nil
+}
+
+// Run runs the plugin at the resolved path with the arguments, passing it
+// stdin and returning its stdout. A non-zero exit code is returned as an
+// error, with stderr used as the message.
+func Run(path string, stdin []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
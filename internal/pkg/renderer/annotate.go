@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// varMarker matches the markers written ahead of the output of each annotated
+// template action. The control characters delimiting them do not occur within
+// job specifications, so they can be found and removed once rendered.
+var varMarker = regexp.MustCompile("\x1e([0-9]+)\x1f")
+
+// hclHeredoc matches a line which opens an HCL heredoc, capturing the
+// delimiter which closes it.
+var hclHeredoc = regexp.MustCompile(`<<-?\s*([A-Za-z_][A-Za-z0-9_]*)\s*$`)
+
+// markVarActions inserts a marker ahead of each action within the tree which
+// outputs a value derived from pack variables, returning the annotation of
+// each marker indexed by its number. Only the actions of the tree itself are
+// marked, as the output of defined templates may be processed further by the
+// template which includes them.
+func markVarActions(tree *parse.Tree) []string {
+	var notes []string
+	var walk func(list *parse.ListNode)
+	walk = func(list *parse.ListNode) {
+		if list == nil {
+			return
+		}
+		nodes := make([]parse.Node, 0, len(list.Nodes))
+		for _, node := range list.Nodes {
+			switch n := node.(type) {
+			case *parse.ActionNode:
+				if note := varActionNote(n.Pipe); note != "" {
+					nodes = append(nodes, &parse.TextNode{
+						NodeType: parse.NodeText,
+						Pos:      n.Pos,
+						Text:     fmt.Appendf(nil, "\x1e%d\x1f", len(notes)),
+					})
+					notes = append(notes, note)
+				}
+			case *parse.IfNode:
+				walk(n.List)
+				walk(n.ElseList)
+			case *parse.RangeNode:
+				walk(n.List)
+				walk(n.ElseList)
+			case *parse.WithNode:
+				walk(n.List)
+				walk(n.ElseList)
+			}
+			nodes = append(nodes, node)
+		}
+		list.Nodes = nodes
+	}
+	walk(tree.Root)
+	return notes
+}
+
+// varActionNote returns the annotation of an action's pipeline. The name of
+// the variable is used when the pipeline only reads a variable, and the
+// pipeline itself when it derives the value from variables some other way.
+// Pipelines which declare variables, and so output nothing, or which do not
+// read pack variables are not annotated.
+func varActionNote(pipe *parse.PipeNode) string {
+	if pipe == nil || len(pipe.Decl) > 0 || !readsPackVars(pipe) {
+		return ""
+	}
+
+	if len(pipe.Cmds) == 1 {
+		args := pipe.Cmds[0].Args
+		switch n := args[0].(type) {
+		case *parse.IdentifierNode:
+			// The var and must_var functions of the v2 syntax.
+			if (n.Ident == "var" || n.Ident == "must_var") && len(args) == 3 {
+				if name, ok := args[1].(*parse.StringNode); ok {
+					return "var." + name.Text
+				}
+			}
+		case *parse.FieldNode:
+			// The .my.<variable> references of the v1 syntax.
+			if len(args) == 1 && len(n.Ident) > 1 && n.Ident[0] == "my" {
+				return "var." + strings.Join(n.Ident[1:], ".")
+			}
+		}
+	}
+	return leftTemplateDelim + " " + pipe.String() + " " + rightTemplateDelim
+}
+
+// readsPackVars reports whether the node reads pack variables, either using
+// the variable functions or fields of the template data.
+func readsPackVars(node parse.Node) bool {
+	switch n := node.(type) {
+	case *parse.PipeNode:
+		return slices.ContainsFunc(n.Cmds, func(cmd *parse.CommandNode) bool { return readsPackVars(cmd) })
+	case *parse.CommandNode:
+		return slices.ContainsFunc(n.Args, readsPackVars)
+	case *parse.ChainNode:
+		return readsPackVars(n.Node)
+	case *parse.IdentifierNode:
+		return n.Ident == "var" || n.Ident == "must_var" || n.Ident == "vars"
+	case *parse.FieldNode:
+		return true
+	}
+	return false
+}
+
+// annotateVars replaces the markers within the rendered content with an HCL
+// comment, above the line holding the marked values, naming the variables or
+// expressions which produced them. Markers within heredocs are removed without
+// annotation, as a comment would change the heredoc's content.
+func annotateVars(content string, notes []string) string {
+	if !strings.Contains(content, "\x1e") {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	var heredoc string
+	for _, line := range lines {
+		var lineNotes []string
+		for _, m := range varMarker.FindAllStringSubmatch(line, -1) {
+			i, _ := strconv.Atoi(m[1])
+			if i < len(notes) && !slices.Contains(lineNotes, notes[i]) {
+				lineNotes = append(lineNotes, notes[i])
+			}
+		}
+		line = varMarker.ReplaceAllString(line, "")
+
+		if heredoc != "" {
+			if strings.TrimSpace(line) == heredoc {
+				heredoc = ""
+			}
+			out = append(out, line)
+			continue
+		}
+
+		if len(lineNotes) > 0 && strings.TrimSpace(line) != "" {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			out = append(out, indent+"# from "+strings.Join(lineNotes, ", "))
+		}
+		if m := hclHeredoc.FindStringSubmatch(line); m != nil {
+			heredoc = m[1]
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
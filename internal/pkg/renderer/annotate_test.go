@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestAnnotateVars(t *testing.T) {
+	src := `job "[[ .my.name ]]" {
+  [[- $dcs := var "datacenters" . ]]
+  datacenters = [[ var "datacenters" . | toJson ]] # [[ len $dcs ]]
+  [[- if var "count" . ]]
+  count = [[ var "count" . ]] # [[ must_var "count" . ]]
+  [[- end ]]
+  type = "[[ "service" ]]"
+  template {
+    data = <<EOF
+port = [[ var "port" . ]]
+EOF
+  }
+  port = [[ var "port" . ]]
+}`
+
+	funcs := template.FuncMap{
+		"var": func(k string, v map[string]any) any { return v[k] },
+		"toJson": func(v any) string {
+			return `["` + strings.Join(v.([]string), `","`) + `"]`
+		},
+	}
+	funcs["must_var"] = funcs["var"]
+
+	tpl, err := template.New("job").Funcs(funcs).Delims(leftTemplateDelim, rightTemplateDelim).Parse(src)
+	must.NoError(t, err)
+
+	notes := markVarActions(tpl.Tree)
+	must.Eq(t, []string{
+		"var.name",
+		`[[ var "datacenters" . | toJson ]]`,
+		"var.count",
+		"var.count",
+		"var.port",
+		"var.port",
+	}, notes)
+
+	var buf strings.Builder
+	must.NoError(t, tpl.Execute(&buf, map[string]any{
+		"my":          map[string]any{"name": "example"},
+		"datacenters": []string{"dc1", "dc2"},
+		"count":       2,
+		"port":        8080,
+	}))
+
+	must.Eq(t, `# from var.name
+job "example" {
+  # from [[ var "datacenters" . | toJson ]]
+  datacenters = ["dc1","dc2"] # 2
+  # from var.count
+  count = 2 # 2
+  type = "service"
+  template {
+    data = <<EOF
+port = 8080
+EOF
+  }
+  # from var.port
+  port = 8080
+}`, annotateVars(buf.String(), notes))
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template/parse"
+)
+
+// dumpTree writes a readable summary of the parse tree of the named template
+// to w. Each node is written on its own line, indented by its depth, so pack
+// authors can see how a template is parsed when its output is surprising.
+func dumpTree(w io.Writer, name string, tree *parse.Tree) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", name)
+	if tree != nil {
+		dumpList(&b, tree.Root, 0)
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dumpList writes the nodes of the list at the passed depth.
+func dumpList(b *strings.Builder, list *parse.ListNode, depth int) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		dumpNode(b, node, depth)
+	}
+}
+
+// dumpNode writes a single node, and the lists of branch nodes, at the
+// passed depth.
+func dumpNode(b *strings.Builder, node parse.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch n := node.(type) {
+	case *parse.TextNode:
+		fmt.Fprintf(b, "%sText %q\n", indent, n.Text)
+	case *parse.ActionNode:
+		fmt.Fprintf(b, "%sAction %s\n", indent, n.Pipe)
+	case *parse.CommentNode:
+		fmt.Fprintf(b, "%sComment %q\n", indent, n.Text)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			fmt.Fprintf(b, "%sTemplate %q %s\n", indent, n.Name, n.Pipe)
+		} else {
+			fmt.Fprintf(b, "%sTemplate %q\n", indent, n.Name)
+		}
+	case *parse.IfNode:
+		dumpBranch(b, "If", &n.BranchNode, depth)
+	case *parse.RangeNode:
+		dumpBranch(b, "Range", &n.BranchNode, depth)
+	case *parse.WithNode:
+		dumpBranch(b, "With", &n.BranchNode, depth)
+	case *parse.BreakNode:
+		fmt.Fprintf(b, "%sBreak\n", indent)
+	case *parse.ContinueNode:
+		fmt.Fprintf(b, "%sContinue\n", indent)
+	default:
+		fmt.Fprintf(b, "%s%T %s\n", indent, node, node)
+	}
+}
+
+// dumpBranch writes an if, range, or with node along with its lists.
+func dumpBranch(b *strings.Builder, kind string, n *parse.BranchNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(b, "%s%s %s\n", indent, kind, n.Pipe)
+	dumpList(b, n.List, depth+1)
+	if n.ElseList != nil {
+		fmt.Fprintf(b, "%sElse\n", indent)
+		dumpList(b, n.ElseList, depth+1)
+	}
+	fmt.Fprintf(b, "%sEnd\n", indent)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestDumpTree(t *testing.T) {
+	src := `job "[[ var "name" . ]]" {
+  [[- /* datacenters */ -]]
+  [[- range $dc := var "datacenters" . ]]
+  dc = [[ $dc ]]
+  [[- else ]]
+  [[ template "default_dc" . ]]
+  [[- end ]]
+}`
+
+	funcs := template.FuncMap{
+		"var": func(k string, v map[string]any) any { return v[k] },
+	}
+	tpl, err := template.New("job").Funcs(funcs).Delims(leftTemplateDelim, rightTemplateDelim).
+		Parse(`[[ define "default_dc" ]]dc1[[ end ]]`)
+	must.NoError(t, err)
+	tpl, err = tpl.New("job.nomad.tpl").Parse(src)
+	must.NoError(t, err)
+
+	var buf strings.Builder
+	must.NoError(t, dumpTree(&buf, "job.nomad.tpl", tpl.Tree))
+	must.Eq(t, `# job.nomad.tpl
+Text "job \""
+Action var "name" .
+Text "\" {"
+Range $dc := var "datacenters" .
+  Text "\n  dc = "
+  Action $dc
+Else
+  Text "\n  "
+  Template "default_dc" .
+End
+Text "\n}"
+
+`, buf.String())
+}
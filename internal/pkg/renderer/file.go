@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SensitiveFile is an auxiliary file written by the fileFromBase64 template
+// function. Its content is a decoded secret, so it must be written with its
+// mode and never displayed.
+type SensitiveFile struct {
+	Content string
+	Mode    fs.FileMode
+}
+
+// errFileOutsideTemplate is returned by the fileFromBase64 function when it is
+// called outside a pack template, such as within outputs.tpl.
+var errFileOutsideTemplate = errors.New("fileFromBase64 can only be used within pack templates")
+
+// sensitiveFileFunc returns the fileFromBase64 template function bound to the
+// named template. The decoded value is recorded as a file alongside the
+// template's own render, at the passed path within the templates directory,
+// and the function renders to nothing.
+func (r *Renderer) sensitiveFileFunc(tplName string) func(string, string, string) (string, error) {
+	packPath, _, _ := strings.Cut(tplName, "/templates/")
+
+	return func(name, mode, value string) (string, error) {
+		if !fs.ValidPath(name) || name == "." {
+			return "", fmt.Errorf("file name %q must be a relative path within the pack", name)
+		}
+		perm, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || perm > 0o777 {
+			return "", fmt.Errorf("file mode %q must be an octal permission, such as 0600", mode)
+		}
+		content, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode file %s: %w", name, err)
+		}
+
+		key := path.Join(packPath, "templates", name)
+
+		r.filesLock.Lock()
+		defer r.filesLock.Unlock()
+
+		if _, ok := r.files[key]; ok {
+			return "", fmt.Errorf("file %s is already defined", name)
+		}
+		r.files[key] = SensitiveFile{Content: string(content), Mode: fs.FileMode(perm)}
+		return "", nil
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestSensitiveFileFunc(t *testing.T) {
+	t.Parallel()
+
+	r := &Renderer{files: make(map[string]SensitiveFile)}
+	fn := r.sensitiveFileFunc("example/templates/example.nomad.tpl")
+
+	out, err := fn("tls/server.key", "0600", "c2VjcmV0")
+	must.NoError(t, err)
+	must.Eq(t, "", out)
+	must.Eq(t, map[string]SensitiveFile{
+		"example/templates/tls/server.key": {Content: "secret", Mode: fs.FileMode(0o600)},
+	}, r.files)
+
+	_, err = fn("tls/server.key", "0600", "c2VjcmV0")
+	must.ErrorContains(t, err, "file tls/server.key is already defined")
+
+	_, err = fn("../server.key", "0600", "c2VjcmV0")
+	must.ErrorContains(t, err, "must be a relative path within the pack")
+
+	_, err = fn("server.crt", "rw", "c2VjcmV0")
+	must.ErrorContains(t, err, "must be an octal permission")
+
+	_, err = fn("server.crt", "0644", "not base64")
+	must.ErrorContains(t, err, "failed to decode file server.crt")
+}
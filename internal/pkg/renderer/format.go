@@ -0,0 +1,15 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// FormatHCL returns the canonical HCL formatting of the passed source. Only
+// whitespace is changed, so comments are kept in place, and are indented along
+// with the line following them.
+func FormatHCL(src []byte) []byte {
+	return hclwrite.Format(src)
+}
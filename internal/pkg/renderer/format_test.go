@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestFormatHCL(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		exp  string
+	}{
+		{
+			name: "line comments",
+			src: `# The job
+job "example" {
+// The datacenters
+datacenters = ["dc1"] # trailing
+type = "service"
+}
+`,
+			exp: `# The job
+job "example" {
+  // The datacenters
+  datacenters = ["dc1"] # trailing
+  type        = "service"
+}
+`,
+		},
+		{
+			name: "block comments",
+			src: `job "example" {
+      /* The group
+         of tasks */
+  group "app" {
+    count = /* instances */ 1
+  }
+}
+`,
+			exp: `job "example" {
+  /* The group
+         of tasks */
+  group "app" {
+    count = /* instances */ 1
+  }
+}
+`,
+		},
+		{
+			name: "comments within lists and objects",
+			src: `job "example" {
+  meta {
+    a = "1" # first
+     # standalone
+    bbb = "2"
+  }
+  args = [
+      "-c", # flag
+      # script
+      "echo",
+  ]
+}
+`,
+			exp: `job "example" {
+  meta {
+    a = "1" # first
+    # standalone
+    bbb = "2"
+  }
+  args = [
+    "-c", # flag
+    # script
+    "echo",
+  ]
+}
+`,
+		},
+		{
+			name: "comments before closing braces",
+			src: `job "example" {
+  group "app" {
+      # nothing in here yet
+  }
+# end of job
+}
+# end of file
+`,
+			exp: `job "example" {
+  group "app" {
+    # nothing in here yet
+  }
+  # end of job
+}
+# end of file
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			must.Eq(t, tc.exp, string(FormatHCL([]byte(tc.src))))
+		})
+	}
+}
@@ -4,15 +4,21 @@
 package renderer
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"net/http"
 	"os"
+	"reflect"
 	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser"
 	"github.com/hashicorp/nomad/api"
+	"github.com/zclconf/go-cty/cty"
 	"golang.org/x/exp/maps"
 )
 
@@ -29,6 +35,11 @@ func funcMap(r *Renderer) template.FuncMap {
 	// Copy the sprig funcs into the funcmap.
 	maps.Copy(f, sprig.TxtFuncMap())
 
+	// Replace the sprig funcs which produce random output, so they can be
+	// seeded. These are rebound to each template's source of randomness
+	// before it is rendered.
+	maps.Copy(f, randomFuncs(rand.New(cryptoSource{})))
+
 	// Add debugging functions. These are useful when debugging templates and
 	// variables.
 	f["spewDump"] = spew.Sdump
@@ -49,6 +60,17 @@ func funcMap(r *Renderer) template.FuncMap {
 		f["nomadNamespaces"] = nomadNamespaces(r.Client)
 		f["nomadNamespace"] = nomadNamespace(r.Client)
 		f["nomadRegions"] = nomadRegions(r.Client)
+		f["nomadVar"] = nomadVar(r.Client, r.AllowExternalLookups)
+	}
+
+	if r != nil {
+		f["output"] = r.recordOutput
+	}
+
+	// Sensitive files are only recorded by pack templates, which rebind this
+	// function so it knows the template which called it.
+	f["fileFromBase64"] = func(string, string, string) (string, error) {
+		return "", errFileOutsideTemplate
 	}
 
 	// Add additional custom functions.
@@ -91,27 +113,78 @@ func nomadRegions(client *api.Client) func() ([]string, error) {
 	return func() ([]string, error) { return client.Regions().List() }
 }
 
-// toStringList takes a list of string and returns the HCL equivalent which is
-// useful when templating jobs and params such as datacenters.
+// nomadVar reads the item with the passed key from the Nomad variable at the
+// passed path. Reading variables is only permitted when external lookups are
+// allowed, since the rendered output then depends on the state of the cluster.
+// Error messages avoid ": " separators, as only the final segment of a template
+// execution error is displayed.
+func nomadVar(client *api.Client, allowed bool) func(string, string) (string, error) {
+	return func(path, key string) (string, error) {
+		if !allowed {
+			return "", fmt.Errorf("reading Nomad variable %q requires external lookups, enable them with --allow-external-lookups", path)
+		}
+
+		// The raw endpoint is used since Variables().Read reports ACL
+		// failures as the variable not being found.
+		var v api.Variable
+		_, err := client.Raw().Query("/v1/var/"+strings.Trim(path, " /"), &v, &api.QueryOptions{})
+		if err != nil {
+			var respErr api.UnexpectedResponseError
+			if errors.As(err, &respErr) {
+				switch respErr.StatusCode() {
+				case http.StatusForbidden:
+					return "", fmt.Errorf("permission denied reading Nomad variable %q (%s), the ACL token must have read access to it",
+						path, respErr.StatusText())
+				case http.StatusNotFound:
+					return "", fmt.Errorf("Nomad variable %q not found", path)
+				}
+			}
+			return "", fmt.Errorf("failed to read Nomad variable %q (%v)", path, err)
+		}
+
+		val, ok := v.Items[key]
+		if !ok {
+			return "", fmt.Errorf("Nomad variable %q has no item %q", path, key)
+		}
+		return val, nil
+	}
+}
+
+// toStringList takes a list of strings and returns the HCL equivalent, which
+// is useful when templating jobs and params such as datacenters. Any slice is
+// accepted, such as the value of a list(string) variable, and a string is
+// split on commas so "dc1,dc2" expands to two entries. The elements are quoted
+// and escaped by HCL, so values are never interpolated.
 func toStringList(l any) (string, error) {
-	var out strings.Builder
-	out.WriteRune('[')
+	var elems []string
 	switch tl := l.(type) {
-	case []any:
-		// If l is a []string, then the caller probably wants that printed
-		// as a list of quoted elements, JSON style.
-		for i, v := range tl {
-			if i > 0 {
-				out.WriteString(", ")
+	case nil:
+	case string:
+		for _, e := range strings.Split(tl, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				elems = append(elems, e)
 			}
-			out.WriteString(fmt.Sprintf("%q", v))
 		}
 	default:
-		out.WriteString(fmt.Sprintf("%q", l))
+		rv := reflect.ValueOf(l)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			elems = append(elems, fmt.Sprint(l))
+			break
+		}
+		for i := 0; i < rv.Len(); i++ {
+			elems = append(elems, fmt.Sprint(rv.Index(i).Interface()))
+		}
+	}
+
+	vals := make([]cty.Value, len(elems))
+	for i, e := range elems {
+		vals[i] = cty.StringVal(e)
+	}
+	list := cty.ListValEmpty(cty.String)
+	if len(vals) > 0 {
+		list = cty.ListVal(vals)
 	}
-	out.WriteRune(']')
-	o := out.String()
-	return o, nil
+	return string(hclwrite.TokensForValue(list).Bytes()), nil
 }
 
 // Spew helper funcs
@@ -13,7 +13,7 @@ import (
 
 func Test_toStringList(t *testing.T) {
 	testCases := []struct {
-		input          []any
+		input          any
 		expectedOutput string
 	}{
 		{
@@ -28,6 +28,22 @@ func Test_toStringList(t *testing.T) {
 			input:          []any{},
 			expectedOutput: `[]`,
 		},
+		{
+			input:          []string{"dc1", "dc2"},
+			expectedOutput: `["dc1", "dc2"]`,
+		},
+		{
+			input:          "dc1, dc2,",
+			expectedOutput: `["dc1", "dc2"]`,
+		},
+		{
+			input:          nil,
+			expectedOutput: `[]`,
+		},
+		{
+			input:          []any{`dc"1`, "${dc2}"},
+			expectedOutput: `["dc\"1", "$${dc2}"]`,
+		},
 	}
 
 	for _, tc := range testCases {
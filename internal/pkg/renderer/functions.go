@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser"
+	"github.com/hashicorp/nomad/api"
+	"golang.org/x/exp/maps"
+)
+
+// The sources of template functions reported by Functions.
+const (
+	FunctionSourcePack   = "nomad-pack"
+	FunctionSourceSprig  = "sprig"
+	FunctionSourcePlugin = "plugin"
+)
+
+// sprigDocs is where the functions provided by sprig are documented.
+const sprigDocs = "https://masterminds.github.io/sprig/"
+
+// Function describes a template function which is available to pack
+// templates.
+type Function struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+}
+
+// funcDescriptions holds the descriptions of the template functions provided
+// by nomad-pack, including those replacing sprig functions of the same name.
+var funcDescriptions = map[string]string{
+	"vars":      "Returns the map of variables of the current pack.",
+	"var":       "Returns the named variable of the current pack, or an empty string if it is not set.",
+	"must_var":  "Returns the named variable of the current pack, erroring if it is not set.",
+	"metas":     "Returns the map of metadata of the current pack.",
+	"meta":      "Returns the named metadata value of the current pack, or an empty string if it is not set.",
+	"must_meta": "Returns the named metadata value of the current pack, erroring if it is not set.",
+	"deps":      "Returns the map of dependencies of the current pack.",
+	"deps_tree": "Returns the names of the current pack and its dependencies in render order.",
+
+	"randAlphaNum": "Returns a random alphanumeric string of the given length, seeded by --render-seed.",
+	"randAlpha":    "Returns a random string of letters of the given length, seeded by --render-seed.",
+	"randNumeric":  "Returns a random string of digits of the given length, seeded by --render-seed.",
+	"randAscii":    "Returns a random printable ASCII string of the given length, seeded by --render-seed.",
+	"randBytes":    "Returns the given number of random bytes encoded as base64, seeded by --render-seed.",
+	"randInt":      "Returns a random integer between the minimum and maximum, seeded by --render-seed.",
+	"uuidv4":       "Returns a random version 4 UUID, seeded by --render-seed.",
+	"shuffle":      "Returns the string with its characters in random order, seeded by --render-seed.",
+
+	"spewDump":                    "Returns a detailed dump of the passed values, useful for debugging.",
+	"spewPrintf":                  "Formats the passed values using spew's detailed verbs.",
+	"customSpew":                  "Returns a spew configuration to pass through the with* functions.",
+	"withIndent":                  "Sets the indentation of a spew configuration.",
+	"withMaxDepth":                "Sets the maximum nesting depth of a spew configuration.",
+	"withDisableMethods":          "Disables invoking error and Stringer methods in a spew configuration.",
+	"withDisablePointerMethods":   "Disables invoking methods on pointer receivers in a spew configuration.",
+	"withDisablePointerAddresses": "Hides pointer addresses in a spew configuration.",
+	"withDisableCapacities":       "Hides the capacities of slices and maps in a spew configuration.",
+	"withContinueOnMethod":        "Continues dumping values after invoking their methods in a spew configuration.",
+	"withSortKeys":                "Sorts map keys in a spew configuration.",
+	"withSpewKeys":                "Dumps map keys with spew in a spew configuration.",
+
+	"nomadNamespaces": "Returns the namespaces of the target Nomad cluster.",
+	"nomadNamespace":  "Returns the named namespace of the target Nomad cluster.",
+	"nomadRegions":    "Returns the regions of the target Nomad cluster.",
+	"nomadVar":        "Returns an item of a Nomad variable, requires --allow-external-lookups.",
+
+	"output":         "Defines a named pack output, only available within outputs.tpl.",
+	"fileContents":   "Returns the contents of the file at the passed path.",
+	"fileFromBase64": "Decodes a base64 value into a sensitive file rendered alongside the template.",
+	"toStringList":   "Formats a list, or a comma-separated string, as an HCL list of quoted strings.",
+}
+
+// Functions returns the template functions available to pack templates,
+// including those provided by the passed plugins, sorted by name.
+func Functions(plugins []*TemplatePlugin) []Function {
+
+	// The Nomad functions are only added when there is a client. The client
+	// is never called, as the functions are only listed.
+	f := funcMap(&Renderer{Client: &api.Client{}})
+	maps.Copy(f, parser.PackTemplateContextFuncsV2())
+	sprigFuncs := sprig.TxtFuncMap()
+
+	out := make([]Function, 0, len(f))
+	for name := range f {
+		fn := Function{Name: name, Source: FunctionSourcePack}
+		if desc, ok := funcDescriptions[name]; ok {
+			fn.Description = desc
+		} else if _, ok := sprigFuncs[name]; ok {
+			fn.Source = FunctionSourceSprig
+			fn.Description = "Provided by sprig, see " + sprigDocs
+		}
+		out = append(out, fn)
+	}
+
+	for _, plugin := range plugins {
+		for _, name := range plugin.funcs {
+			out = append(out, Function{
+				Name:        name,
+				Description: fmt.Sprintf("Provided by the template plugin %s.", plugin.path),
+				Source:      FunctionSourcePlugin,
+			})
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b Function) int { return cmp.Compare(a.Name, b.Name) })
+	return out
+}
@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestFunctions(t *testing.T) {
+	funcs := Functions(nil)
+
+	byName := make(map[string]Function, len(funcs))
+	for _, fn := range funcs {
+		byName[fn.Name] = fn
+
+		// Every function must be described, so new nomad-pack functions are
+		// not added without a description.
+		must.NotEq(t, "", fn.Description, must.Sprintf("function %q has no description", fn.Name))
+	}
+
+	must.Eq(t, FunctionSourcePack, byName["var"].Source)
+	must.Eq(t, FunctionSourcePack, byName["nomadVar"].Source)
+	must.Eq(t, FunctionSourcePack, byName["randAlphaNum"].Source)
+	must.Eq(t, FunctionSourceSprig, byName["upper"].Source)
+
+	for i := 1; i < len(funcs); i++ {
+		must.Less(t, funcs[i].Name, funcs[i-1].Name)
+	}
+}
+
+func TestFunctions_Plugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is a shell script")
+	}
+
+	dir := t.TempDir()
+	writeTestPlugin(t, dir, "shout")
+
+	plugin, err := LoadTemplatePlugin("shout", dir)
+	must.NoError(t, err)
+
+	var found bool
+	for _, fn := range Functions([]*TemplatePlugin{plugin}) {
+		if fn.Name == "shout" {
+			found = true
+			must.Eq(t, FunctionSourcePlugin, fn.Source)
+			must.StrContains(t, fn.Description, plugin.path)
+		}
+	}
+	must.True(t, found)
+}
@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// hclVarRef matches the HCL2 interpolation of an input variable, such as
+// ${var.image}. The optional leading "$" captures escaped interpolations,
+// which HCL renders literally.
+var hclVarRef = regexp.MustCompile(`(\$?)\$\{\s*var\.([A-Za-z_][A-Za-z0-9_-]*)\s*\}`)
+
+// interpolateVars replaces the HCL2 variable interpolations within the passed
+// content with the value of the pack variable of the same name. References
+// whose name matches deferred are left intact, so Nomad resolves them when the
+// job is submitted. Error messages avoid ": " separators, as only the final
+// segment of a render error is displayed.
+func interpolateVars(content string, vars map[string]any, deferred *regexp.Regexp) (string, error) {
+	var err error
+	out := hclVarRef.ReplaceAllStringFunc(content, func(ref string) string {
+		m := hclVarRef.FindStringSubmatch(ref)
+		escaped, name := m[1], m[2]
+		if escaped != "" || deferred.MatchString(name) || err != nil {
+			return ref
+		}
+
+		val, ok := vars[name]
+		if !ok {
+			err = fmt.Errorf("%s does not reference a pack variable and does not match the deferred variables pattern", ref)
+			return ref
+		}
+
+		switch v := val.(type) {
+		case string:
+			// The value is placed within an HCL string, so escape any
+			// sequences HCL would otherwise interpret.
+			return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", "$${", "%{", "%%{").Replace(v)
+		case bool, int, int64, float64:
+			return fmt.Sprint(v)
+		default:
+			err = fmt.Errorf("%s references pack variable %q of type %T, only strings, numbers, and bools can be interpolated", ref, name, val)
+			return ref
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// addVariablesBlock prepends an HCL2 variables block to the passed content,
+// declaring each input variable it references with the value of the pack
+// variable of the same name as the default. The references are left intact,
+// so Nomad resolves them when the job is submitted. Variables already
+// declared by the content are not declared again.
+func addVariablesBlock(content string, vars map[string]any) (string, error) {
+	file, diags := hclsyntax.ParseConfig([]byte(content), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("failed to parse job specification, %s", diags.Errs()[0])
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return content, nil
+	}
+
+	declared := declaredVariables(body)
+	var names []string
+	_ = hclsyntax.VisitAll(body, func(node hclsyntax.Node) hcl.Diagnostics {
+		expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || len(expr.Traversal) < 2 || expr.Traversal.RootName() != "var" {
+			return nil
+		}
+		attr, ok := expr.Traversal[1].(hcl.TraverseAttr)
+		if ok && !declared[attr.Name] && !slices.Contains(names, attr.Name) {
+			names = append(names, attr.Name)
+		}
+		return nil
+	})
+	if len(names) == 0 {
+		return content, nil
+	}
+	slices.Sort(names)
+
+	var b bytes.Buffer
+	b.WriteString("variables {\n")
+	for _, name := range names {
+		val, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("var.%s does not reference a pack variable", name)
+		}
+		hclVal, err := hclValue(val)
+		if err != nil {
+			return "", fmt.Errorf("pack variable %q can not be written as HCL, %v", name, err)
+		}
+		fmt.Fprintf(&b, "  %s = %s\n", name, hclVal)
+	}
+	b.WriteString("}\n\n")
+	return string(hclwrite.Format(b.Bytes())) + content, nil
+}
+
+// declaredVariables returns the names of the variables declared by the
+// variable and variables blocks of the passed body.
+func declaredVariables(body *hclsyntax.Body) map[string]bool {
+	declared := make(map[string]bool)
+	for _, block := range body.Blocks {
+		switch {
+		case block.Type == "variable" && len(block.Labels) == 1:
+			declared[block.Labels[0]] = true
+		case block.Type == "variables":
+			for name := range block.Body.Attributes {
+				declared[name] = true
+			}
+		}
+	}
+	return declared
+}
+
+// hclValue formats a pack variable value as an HCL expression.
+func hclValue(val any) (string, error) {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	ty, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return "", err
+	}
+	v, err := ctyjson.Unmarshal(b, ty)
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.TokensForValue(v).Bytes()), nil
+}
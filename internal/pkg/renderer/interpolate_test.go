@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestInterpolateVars(t *testing.T) {
+	vars := map[string]any{
+		"image":   `redis:"7"`,
+		"count":   2,
+		"enabled": true,
+		"ports":   []any{80, 443},
+	}
+	deferred := regexp.MustCompile(`^nomad_`)
+
+	out, err := interpolateVars(
+		`image = "${var.image}" count = ${ var.count } enabled = ${var.enabled} region = "${var.nomad_region}" raw = "$${var.count}"`,
+		vars, deferred)
+	must.NoError(t, err)
+	must.Eq(t, `image = "redis:\"7\"" count = 2 enabled = true region = "${var.nomad_region}" raw = "$${var.count}"`, out)
+
+	_, err = interpolateVars(`region = "${var.region}"`, vars, deferred)
+	must.ErrorContains(t, err, "${var.region} does not reference a pack variable")
+
+	_, err = interpolateVars(`ports = "${var.ports}"`, vars, deferred)
+	must.ErrorContains(t, err, "only strings, numbers, and bools can be interpolated")
+}
+
+func TestAddVariablesBlock(t *testing.T) {
+	vars := map[string]any{
+		"image": `redis:"${tag}"`,
+		"count": 2,
+		"ports": []any{80, 443},
+		"env":   map[string]any{"LOG": "debug"},
+	}
+
+	job := `job "example" {
+  image = "${var.image}"
+  count = var.count
+  ports = var.ports
+  env   = var.env
+  again = "${var.count}"
+  raw   = "$${var.missing}"
+}`
+	out, err := addVariablesBlock(job, vars)
+	must.NoError(t, err)
+	must.Eq(t, `variables {
+  count = 2
+  env = {
+    LOG = "debug"
+  }
+  image = "redis:\"$${tag}\""
+  ports = [80, 443]
+}
+
+`+job, out)
+
+	// Variables the template declares itself are not declared again.
+	declared := "variable \"count\" {\n  default = 1\n}\n\njob \"example\" {\n  count = var.count\n}"
+	out, err = addVariablesBlock(declared, vars)
+	must.NoError(t, err)
+	must.Eq(t, declared, out)
+
+	_, err = addVariablesBlock(`job "example" { region = "${var.region}" }`, vars)
+	must.ErrorContains(t, err, "var.region does not reference a pack variable")
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// renderLimiter bounds the templates executed concurrently, both by number
+// and by the approximate size of their in-flight output. The memory limit is
+// soft: a template which is already executing is never paused, so the output
+// held can exceed the limit, and a template is always allowed to start when
+// no other is executing, so the render makes progress.
+type renderLimiter struct {
+	parallelism int
+	memLimit    int64
+
+	// stopOnErr stops templates being started once any has failed.
+	stopOnErr bool
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	running  int
+	inFlight int64
+	stopped  bool
+}
+
+// newRenderLimiter returns a limiter allowing parallelism templates to execute
+// at once, pausing new templates while the in-flight output is at least
+// memLimit bytes. A parallelism below one is treated as one, and a memLimit of
+// zero disables the memory limit.
+func newRenderLimiter(parallelism int, memLimit int64, stopOnErr bool) *renderLimiter {
+	l := &renderLimiter{
+		parallelism: max(parallelism, 1),
+		memLimit:    memLimit,
+		stopOnErr:   stopOnErr,
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until another template may start, returning false if no
+// more templates should be started because one has failed.
+func (l *renderLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for !l.stopped && (l.running >= l.parallelism || l.overBudget()) {
+		l.cond.Wait()
+	}
+	if l.stopped {
+		return false
+	}
+	l.running++
+	return true
+}
+
+// overBudget reports whether the in-flight output is at the memory limit
+// while another template is executing. It must be called with mu held.
+func (l *renderLimiter) overBudget() bool {
+	return l.memLimit > 0 && l.running > 0 && l.inFlight >= l.memLimit
+}
+
+// grow records n bytes of output written by an executing template.
+func (l *renderLimiter) grow(n int) {
+	l.mu.Lock()
+	l.inFlight += int64(n)
+	l.mu.Unlock()
+}
+
+// release records that a template which wrote n bytes has finished, waking
+// any templates waiting to start.
+func (l *renderLimiter) release(n int64, failed bool) {
+	l.mu.Lock()
+	l.inFlight -= n
+	l.running--
+	if failed && l.stopOnErr {
+		l.stopped = true
+	}
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// limitedWriter buffers the output of a template, recording its size with the
+// limiter as it is written. Writes which would take the output beyond
+// maxBytes fail, which stops the template executing, so a runaway template
+// cannot exhaust memory. A maxBytes of zero disables the limit.
+type limitedWriter struct {
+	buf      strings.Builder
+	limiter  *renderLimiter
+	maxBytes int64
+	n        int64
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.maxBytes > 0 && w.n+int64(len(p)) > w.maxBytes {
+		return 0, fmt.Errorf("rendered output exceeds the maximum of %d bytes", w.maxBytes)
+	}
+	w.limiter.grow(len(p))
+	w.n += int64(len(p))
+	return w.buf.Write(p)
+}
+
+func (w *limitedWriter) String() string { return w.buf.String() }
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestRenderLimiter(t *testing.T) {
+	t.Run("parallelism", func(t *testing.T) {
+		l := newRenderLimiter(2, 0, false)
+		must.True(t, l.acquire())
+		must.True(t, l.acquire())
+
+		pending := acquireAsync(l)
+		must.False(t, acquiredWithin(pending, 50*time.Millisecond))
+		l.release(0, false)
+		must.True(t, acquiredWithin(pending, time.Second))
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		l := newRenderLimiter(4, 100, false)
+		must.True(t, l.acquire())
+
+		// Below the limit, more templates may start.
+		w := &limitedWriter{limiter: l}
+		_, _ = w.Write(make([]byte, 60))
+		must.True(t, acquiredWithin(acquireAsync(l), time.Second))
+		l.release(0, false)
+
+		// At the limit, new templates are paused until the output is
+		// released.
+		_, _ = w.Write(make([]byte, 40))
+		pending := acquireAsync(l)
+		must.False(t, acquiredWithin(pending, 50*time.Millisecond))
+		l.release(w.n, false)
+		must.True(t, acquiredWithin(pending, time.Second))
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		// A template may always start when no other is executing, even when
+		// the limit is exceeded.
+		l := newRenderLimiter(1, 1, false)
+		must.True(t, l.acquire())
+		l.grow(10)
+		l.release(0, false)
+		must.True(t, l.acquire())
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		l := newRenderLimiter(1, 0, false)
+		must.True(t, l.acquire())
+
+		w := &limitedWriter{limiter: l, maxBytes: 100}
+		_, err := w.Write(make([]byte, 100))
+		must.NoError(t, err)
+
+		// Writes beyond the limit fail without being buffered.
+		_, err = w.Write(make([]byte, 1))
+		must.EqError(t, err, "rendered output exceeds the maximum of 100 bytes")
+		must.Eq(t, 100, w.n)
+		must.Eq(t, 100, len(w.String()))
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		l := newRenderLimiter(2, 0, true)
+		must.True(t, l.acquire())
+		must.True(t, l.acquire())
+
+		pending := acquireAsync(l)
+		l.release(0, true)
+		must.False(t, acquiredWithin(pending, time.Second))
+		must.False(t, l.acquire())
+	})
+}
+
+// acquireAsync calls acquire in the background, returning its result on the
+// channel once it returns.
+func acquireAsync(l *renderLimiter) <-chan bool {
+	done := make(chan bool, 1)
+	go func() { done <- l.acquire() }()
+	return done
+}
+
+// acquiredWithin reports whether the pending acquire allowed a template to
+// start within the timeout.
+func acquiredWithin(pending <-chan bool, timeout time.Duration) bool {
+	select {
+	case ok := <-pending:
+		return ok
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"text/template"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/plugin"
+)
+
+// validFuncName matches identifiers which text/template accepts as function
+// names.
+var validFuncName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TemplatePlugin is an external executable which provides additional template
+// functions to the renderer. Plugins communicate using a simple subprocess
+// protocol:
+//
+//   - "<plugin> functions" must write a JSON array of function names to
+//     stdout.
+//   - "<plugin> call <name>" receives the function arguments as a JSON array
+//     on stdin and must write the JSON encoded result to stdout. A non-zero
+//     exit code is treated as an error, with stderr used as the message.
+type TemplatePlugin struct {
+	path  string
+	funcs []string
+}
+
+// LoadTemplatePlugin verifies the plugin at the passed path lives within the
+// allowed directory and queries it for the functions it provides.
+func LoadTemplatePlugin(pluginPath, allowedDir string) (*TemplatePlugin, error) {
+	resolved, err := plugin.Resolve(pluginPath, allowedDir)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := plugin.Run(resolved, nil, "functions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions of template plugin %s: %w", pluginPath, err)
+	}
+
+	var funcs []string
+	if err := json.Unmarshal(out, &funcs); err != nil {
+		return nil, fmt.Errorf("failed to decode functions of template plugin %s: %w", pluginPath, err)
+	}
+
+	for _, name := range funcs {
+		if !validFuncName.MatchString(name) {
+			return nil, fmt.Errorf("template plugin %s advertised invalid function name %q", pluginPath, name)
+		}
+	}
+
+	return &TemplatePlugin{path: resolved, funcs: funcs}, nil
+}
+
+// Funcs returns the template functions advertised by the plugin. Each
+// function invokes the plugin as a subprocess when called.
+func (p *TemplatePlugin) Funcs() template.FuncMap {
+	f := make(template.FuncMap, len(p.funcs))
+	for _, name := range p.funcs {
+		f[name] = p.call(name)
+	}
+	return f
+}
+
+func (p *TemplatePlugin) call(name string) func(...any) (any, error) {
+	return func(args ...any) (any, error) {
+		if args == nil {
+			args = []any{}
+		}
+		in, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode arguments for %s: %w", name, err)
+		}
+
+		out, err := plugin.Run(p.path, in, "call", name)
+		if err != nil {
+			return nil, fmt.Errorf("template plugin function %s failed: %w", name, err)
+		}
+
+		var result any
+		if err := json.Unmarshal(out, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode result of %s: %w", name, err)
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/shoenig/test/must"
+)
+
+const testPluginScript = `#!/bin/sh
+case "$1" in
+  functions) echo '["shout"]' ;;
+  call) read args; echo "$args" | sed -e 's/^\["\(.*\)"\]$/"\1!"/' ;;
+  *) echo "unknown command" >&2; exit 1 ;;
+esac
+`
+
+func writeTestPlugin(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	must.NoError(t, os.WriteFile(p, []byte(testPluginScript), 0755))
+	return p
+}
+
+func TestTemplatePlugin_Funcs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is a shell script")
+	}
+
+	dir := t.TempDir()
+	writeTestPlugin(t, dir, "shout")
+
+	plugin, err := LoadTemplatePlugin("shout", dir)
+	must.NoError(t, err)
+	must.Eq(t, []string{"shout"}, plugin.funcs)
+
+	tpl, err := template.New("test").Funcs(plugin.Funcs()).Parse(`{{ shout "hello" }}`)
+	must.NoError(t, err)
+
+	var out strings.Builder
+	must.NoError(t, tpl.Execute(&out, nil))
+	must.Eq(t, "hello!", out.String())
+}
+
+func TestTemplatePlugin_NotAllowed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is a shell script")
+	}
+
+	allowed := t.TempDir()
+	other := t.TempDir()
+	p := writeTestPlugin(t, other, "shout")
+
+	_, err := LoadTemplatePlugin(p, allowed)
+	must.ErrorContains(t, err, "not within the allowed directory")
+
+	// Symlinks pointing outside the allowed directory are also rejected.
+	must.NoError(t, os.Symlink(p, filepath.Join(allowed, "shout")))
+	_, err = LoadTemplatePlugin("shout", allowed)
+	must.ErrorContains(t, err, "not within the allowed directory")
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"text/template"
+)
+
+const (
+	randLetters  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	randDigits   = "0123456789"
+	randAlphaNum = randLetters + randDigits
+)
+
+// templateRand returns the source of randomness for the named template. Each
+// template is seeded from the render seed and its name, so the output of one
+// template does not change when others are added or removed.
+func templateRand(seed int64, name string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+// templateRand returns the source of randomness for the named template, which
+// is seeded when a seed is set.
+func (r *Renderer) templateRand(name string) *rand.Rand {
+	if !r.SeedSet {
+		return rand.New(cryptoSource{})
+	}
+	return templateRand(r.Seed, name)
+}
+
+// cryptoSource is a rand.Source reading from the system's cryptographically
+// secure source of randomness, which the random functions use unless a seed is
+// set, matching sprig.
+type cryptoSource struct{}
+
+func (cryptoSource) Seed(int64) {}
+
+func (s cryptoSource) Int63() int64 { return int64(s.Uint64() &^ (1 << 63)) }
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	_, _ = crand.Read(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// randomFuncs returns the template functions which produce random output. They
+// replace the sprig functions of the same name, so a given seed renders
+// identical output.
+func randomFuncs(r *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		"randAlphaNum": func(count int) string { return randString(r, count, randAlphaNum) },
+		"randAlpha":    func(count int) string { return randString(r, count, randLetters) },
+		"randNumeric":  func(count int) string { return randString(r, count, randDigits) },
+		"randAscii":    func(count int) string { return randASCII(r, count) },
+		"randBytes":    func(count int) string { return randBytes(r, count) },
+		"randInt":      func(min, max int) int { return r.Intn(max-min) + min },
+		"uuidv4":       func() string { return uuidv4(r) },
+		"shuffle":      func(s string) string { return shuffle(r, s) },
+	}
+}
+
+func randString(r *rand.Rand, count int, chars string) string {
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = chars[r.Intn(len(chars))]
+	}
+	return string(out)
+}
+
+// randASCII returns a string of printable ASCII characters, matching sprig.
+func randASCII(r *rand.Rand, count int) string {
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = byte(' ' + r.Intn('~'-' '+1))
+	}
+	return string(out)
+}
+
+func randBytes(r *rand.Rand, count int) string {
+	buf := make([]byte, count)
+	_, _ = r.Read(buf)
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// uuidv4 returns a version 4 UUID as described in RFC 4122.
+func uuidv4(r *rand.Rand) string {
+	b := make([]byte, 16)
+	_, _ = r.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func shuffle(r *rand.Rand, s string) string {
+	runes := []rune(s)
+	r.Shuffle(len(runes), func(i, j int) { runes[i], runes[j] = runes[j], runes[i] })
+	return string(runes)
+}
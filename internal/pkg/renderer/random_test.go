@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package renderer
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestRandomFuncs(t *testing.T) {
+	render := func(seed int64, name, src string) string {
+		t.Helper()
+		tpl, err := template.New(name).Funcs(randomFuncs(templateRand(seed, name))).Parse(src)
+		must.NoError(t, err)
+		var out strings.Builder
+		must.NoError(t, tpl.Execute(&out, nil))
+		return out.String()
+	}
+
+	// The same seed and template always render identical output, while
+	// different seeds or templates render different output.
+	const all = `{{ randAlphaNum 8 }}{{ randAlpha 8 }}{{ randNumeric 8 }}{{ randAscii 8 }}{{ randBytes 8 }}{{ randInt 1 100 }}{{ uuidv4 }}{{ shuffle "abcdef" }}`
+	out := render(42, "a.nomad.tpl", all)
+	must.Eq(t, out, render(42, "a.nomad.tpl", all))
+	must.NotEq(t, out, render(43, "a.nomad.tpl", all))
+	must.NotEq(t, out, render(42, "b.nomad.tpl", all))
+
+	testCases := []struct {
+		src      string
+		expected *regexp.Regexp
+	}{
+		{`{{ randAlphaNum 8 }}`, regexp.MustCompile(`^[a-zA-Z0-9]{8}$`)},
+		{`{{ randAlpha 8 }}`, regexp.MustCompile(`^[a-zA-Z]{8}$`)},
+		{`{{ randNumeric 8 }}`, regexp.MustCompile(`^[0-9]{8}$`)},
+		{`{{ randAscii 8 }}`, regexp.MustCompile(`^[ -~]{8}$`)},
+		{`{{ randBytes 3 }}`, regexp.MustCompile(`^[a-zA-Z0-9+/]{4}$`)},
+		{`{{ randInt 5 6 }}`, regexp.MustCompile(`^5$`)},
+		{`{{ uuidv4 }}`, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{`{{ shuffle "aaaa" }}`, regexp.MustCompile(`^aaaa$`)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.src, func(t *testing.T) {
+			must.RegexMatch(t, tc.expected, render(42, "a.nomad.tpl", tc.src))
+		})
+	}
+}
+
+func TestRenderer_templateRand(t *testing.T) {
+	// A seed of zero is a valid seed, so renders identical output.
+	seeded := &Renderer{Seed: 0, SeedSet: true}
+	must.Eq(t, seeded.templateRand("a.nomad.tpl").Int63(), seeded.templateRand("a.nomad.tpl").Int63())
+
+	// Without a seed, the system's source of randomness is used.
+	unseeded := &Renderer{}
+	must.NotEq(t, unseeded.templateRand("a.nomad.tpl").Int63(), unseeded.templateRand("a.nomad.tpl").Int63())
+}
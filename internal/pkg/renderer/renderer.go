@@ -4,13 +4,19 @@
 package renderer
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"maps"
 	"path"
+	"regexp"
+	"runtime/trace"
+	"slices"
 	"strings"
+	"sync"
 	"text/template"
 
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/nomad/api"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser"
@@ -40,11 +46,78 @@ type Renderer struct {
 	// or not
 	Format bool
 
+	// Plugins are the external template plugins whose functions are made
+	// available to the templates in addition to the built-in functions.
+	Plugins []*TemplatePlugin
+
+	// AllowExternalLookups determines whether template functions which read
+	// configuration from the cluster, such as nomadVar, are permitted.
+	AllowExternalLookups bool
+
+	// KeepGoing determines whether a template which fails to parse or render
+	// stops the whole render. When true, the failure is recorded on the
+	// returned Rendered and the remaining templates are still rendered.
+	KeepGoing bool
+
+	// Seed seeds the template functions which produce random output, such as
+	// randAlphaNum and uuidv4, so a given seed renders identical output. It is
+	// only used when SeedSet is true, otherwise the functions read from the
+	// system's cryptographically secure source of randomness.
+	Seed    int64
+	SeedSet bool
+
+	// DeferVars, when set, enables resolving the HCL2 variable interpolations
+	// within job templates, such as ${var.image}, using the pack variable of
+	// the same name. Interpolations whose variable name matches are left for
+	// Nomad to resolve when the job is submitted.
+	DeferVars *regexp.Regexp
+
+	// KeepVariablesBlock leaves the HCL2 variable interpolations within job
+	// templates for Nomad to resolve, prepending a variables block which
+	// declares each referenced variable with the value of the pack variable
+	// of the same name as its default.
+	KeepVariablesBlock bool
+
+	// ShowVars determines whether the rendered job templates are annotated
+	// with HCL comments naming the pack variable, or the expression, which
+	// produced each interpolated value.
+	ShowVars bool
+
+	// Parallelism is the number of templates executed concurrently. Values
+	// below one render the templates one at a time.
+	Parallelism int
+
+	// MemLimit is a soft limit, in bytes, on the output held by the templates
+	// being executed. New templates are not started while the output of
+	// those executing is at the limit. Zero disables the limit.
+	MemLimit int64
+
+	// MaxFileBytes is the maximum number of bytes a single template may
+	// render. A template whose output exceeds it stops executing and fails
+	// to render. Zero disables the limit.
+	MaxFileBytes int64
+
+	// DumpAST, when set, receives a summary of the parse tree of each
+	// template before any are executed. It is a debugging aid for pack
+	// authors and does not change the render.
+	DumpAST io.Writer
+
 	// stores the pack information, variables and tpl, so we can perform the
 	// output template rendering after pack deployment.
 	pack *pack.Pack
 	tpl  *template.Template
 	pv   *parser.ParsedVariables
+
+	// outputs stores the named output values recorded by the output template
+	// function. It is only non-nil while, and after, the output template is
+	// rendered.
+	outputs map[string]any
+
+	// files stores the sensitive files recorded by the fileFromBase64
+	// template function, keyed like the templates. The lock guards it while
+	// templates are rendered concurrently.
+	files     map[string]SensitiveFile
+	filesLock sync.Mutex
 }
 
 // toRender details an individual template to render along with its scoped
@@ -82,6 +155,15 @@ func (r *Renderer) Render(p *pack.Pack, variables *parser.ParsedVariables) (*Ren
 	// save the ParsedVariables into the renderer state
 	r.pv = variables
 
+	if r.DeferVars != nil && variables.IsV1() {
+		return nil, fmt.Errorf("deferring variables is not supported by the v1 parser")
+	}
+	if r.KeepVariablesBlock && variables.IsV1() {
+		return nil, fmt.Errorf("keeping the variables block is not supported by the v1 parser")
+	}
+
+	r.files = make(map[string]SensitiveFile)
+
 	// filesToRender stores all the templates and auxiliary files that should be
 	// rendered
 	filesToRender := map[string]toRender{}
@@ -90,9 +172,21 @@ func (r *Renderer) Render(p *pack.Pack, variables *parser.ParsedVariables) (*Ren
 		return nil, err
 	}
 
+	// Build the function mapping, including any functions provided by
+	// template plugins. Plugins are not allowed to replace built-in functions.
+	funcs := funcMap(r)
+	for _, plugin := range r.Plugins {
+		for name, fn := range plugin.Funcs() {
+			if _, ok := funcs[name]; ok {
+				return nil, fmt.Errorf("template plugin %s function %q conflicts with an existing function", plugin.path, name)
+			}
+			funcs[name] = fn
+		}
+	}
+
 	// Set up our new template, add the function mapping, and set the
 	// delimiters.
-	tpl := template.New("tpl").Funcs(funcMap(r)).Delims(leftTemplateDelim, rightTemplateDelim)
+	tpl := template.New("tpl").Funcs(funcs).Delims(leftTemplateDelim, rightTemplateDelim)
 
 	// Control the behaviour of rendering when it encounters an element
 	// referenced which doesn't exist within the variable mapping.
@@ -102,10 +196,30 @@ func (r *Renderer) Render(p *pack.Pack, variables *parser.ParsedVariables) (*Ren
 		tpl.Option("missingkey=zero")
 	}
 
+	// failed tracks the templates which could not be parsed or rendered when
+	// running with KeepGoing.
+	failed := make(map[string]error)
+
 	for name, src := range filesToRender {
 		if tpl.Lookup(name) == nil {
 			if _, err := tpl.New(name).Parse(src.content); err != nil {
-				return nil, err
+				if !r.KeepGoing {
+					return nil, err
+				}
+				failed[name] = err
+			}
+		}
+	}
+
+	// Dump the parse trees before they are executed, or marked for
+	// annotation, in a consistent order.
+	if r.DumpAST != nil {
+		for _, name := range slices.Sorted(maps.Keys(filesToRender)) {
+			if _, ok := failed[name]; ok {
+				continue
+			}
+			if err := dumpTree(r.DumpAST, name, tpl.Lookup(name).Tree); err != nil {
+				return nil, fmt.Errorf("failed to dump template parse tree: %w", err)
 			}
 		}
 	}
@@ -114,9 +228,14 @@ func (r *Renderer) Render(p *pack.Pack, variables *parser.ParsedVariables) (*Ren
 	rendered := &Rendered{
 		parentRenders:     make(map[string]string),
 		dependencyRenders: make(map[string]string),
+		emptyRenders:      make(map[string]string),
+		sensitiveFiles:    r.files,
 	}
 
-	for name, src := range filesToRender {
+	// Collect the templates to render in a consistent order.
+	var names []string
+	varNotes := make(map[string][]string)
+	for name := range filesToRender {
 
 		// Skip the helper templates as we don't need to render these. They are
 		// called and used from within full templates.
@@ -124,45 +243,64 @@ func (r *Renderer) Render(p *pack.Pack, variables *parser.ParsedVariables) (*Ren
 			continue
 		}
 
-		// Execute the template render and add this to the output unless there
-		// is an error.
-		var buf strings.Builder
+		// Skip any template which has already failed to parse.
+		if _, ok := failed[name]; ok {
+			continue
+		}
+		names = append(names, name)
 
-		dot := src.getDot()
-		if err := tpl.ExecuteTemplate(&buf, name, dot); err != nil {
-			return nil, fmt.Errorf("failed to render %s: %w", name, err)
+		// Mark the values to annotate before executing any template, so the
+		// markers are written alongside them.
+		if r.ShowVars && isJobTemplate(name) {
+			varNotes[name] = markVarActions(tpl.Lookup(name).Tree)
 		}
+	}
+	slices.Sort(names)
 
-		// Even when using "missingkey=zero", missing values will be rendered
-		// when "<no value>" rather than an empty string. This modifies that
-		// behaviour.
-		replacedTpl := strings.ReplaceAll(buf.String(), "<no value>", "")
+	results := r.renderFiles(tpl, names, filesToRender, varNotes)
 
-		// Split the name so the element at index zero becomes the pack name.
-		nameSplit := strings.Split(name, "/")
+	// Without KeepGoing, the first failure stops the render. Templates after
+	// it may not have been rendered at all.
+	if !r.KeepGoing {
+		for _, res := range results {
+			if res.err != nil {
+				return nil, res.err
+			}
+		}
+	}
 
-		// If we encounter a template that's empty (just renders to whitespace),
-		// we skip it.
-		if len(strings.TrimSpace(replacedTpl)) == 0 {
+	for i, name := range names {
+		res := results[i]
+		if res.err != nil {
+			failed[name] = res.err
 			continue
 		}
 
-		if r.Format &&
-			(strings.HasSuffix(name, ".nomad.tpl") || strings.HasSuffix(name, ".hcl.tpl")) {
-			// hclfmt the templates
-			f := hclwrite.Format([]byte(replacedTpl))
-			replacedTpl = string(f)
+		// If we encounter a template that's empty (just renders to whitespace),
+		// we skip it, but record it so it can be reported.
+		if len(strings.TrimSpace(res.content)) == 0 {
+			rendered.emptyRenders[name] = ""
+			continue
 		}
 
+		// Split the name so the element at index zero becomes the pack name.
+		nameSplit := strings.Split(name, "/")
+
 		// Add the rendered pack template to our output, depending on whether
 		// its name matches that of our parent.
 		if nameSplit[0] == p.Name() {
-			rendered.parentRenders[name] = replacedTpl
+			rendered.parentRenders[name] = res.content
 		} else {
-			rendered.dependencyRenders[name] = replacedTpl
+			rendered.dependencyRenders[name] = res.content
 		}
 	}
 
+	// Record the failures in a consistent order, so they are reported the same
+	// way on every run.
+	for _, name := range slices.Sorted(maps.Keys(failed)) {
+		rendered.errs = append(rendered.errs, failed[name])
+	}
+
 	r.pack = p
 	r.tpl = tpl
 	r.pv = variables
@@ -170,6 +308,117 @@ func (r *Renderer) Render(p *pack.Pack, variables *parser.ParsedVariables) (*Ren
 	return rendered, nil
 }
 
+// renderResult is the outcome of rendering a single template.
+type renderResult struct {
+	content string
+	err     error
+}
+
+// renderFiles renders the named templates, executing up to Parallelism of them
+// at once while their in-flight output is within MemLimit. The results are
+// returned in the order of names. Without KeepGoing, no further templates are
+// started once one fails, and the results of those not started are empty.
+func (r *Renderer) renderFiles(tpl *template.Template,
+	names []string,
+	files map[string]toRender,
+	varNotes map[string][]string,
+) []renderResult {
+
+	results := make([]renderResult, len(names))
+	limiter := newRenderLimiter(r.Parallelism, r.MemLimit, !r.KeepGoing)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		if !limiter.acquire() {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := &limitedWriter{limiter: limiter, maxBytes: r.MaxFileBytes}
+			content, err := r.renderFile(tpl, name, files[name], varNotes[name], w)
+			limiter.release(w.n, err != nil)
+			results[i] = renderResult{content: content, err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// renderFile executes the named template, writing its output to w, and
+// returns the content once post-processed. The random functions are seeded
+// per template on a clone of tpl, so templates can be executed concurrently.
+// Templates which render to only whitespace return it unprocessed.
+func (r *Renderer) renderFile(tpl *template.Template,
+	name string,
+	src toRender,
+	varNotes []string,
+	w *limitedWriter,
+) (string, error) {
+
+	// Mark the render of each template in any execution trace.
+	defer trace.StartRegion(context.Background(), "render "+name).End()
+
+	t, err := tpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	t.Funcs(randomFuncs(r.templateRand(name)))
+	t.Funcs(template.FuncMap{"fileFromBase64": r.sensitiveFileFunc(name)})
+
+	// Execute the template render and add this to the output unless there
+	// is an error.
+	if err := t.ExecuteTemplate(w, name, src.getDot()); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+
+	// Even when using "missingkey=zero", missing values will be rendered
+	// when "<no value>" rather than an empty string. This modifies that
+	// behaviour.
+	replacedTpl := strings.ReplaceAll(w.String(), "<no value>", "")
+
+	if varNotes != nil {
+		replacedTpl = annotateVars(replacedTpl, varNotes)
+	}
+
+	if len(strings.TrimSpace(replacedTpl)) == 0 || !isJobTemplate(name) {
+		return replacedTpl, nil
+	}
+
+	// Resolve the HCL2 variable interpolations at pack time, other than
+	// those deferred to Nomad.
+	if r.DeferVars != nil {
+		interpolated, err := interpolateVars(replacedTpl, src.tplCtx.Vars(), r.DeferVars)
+		if err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		replacedTpl = interpolated
+	}
+
+	// Leave the HCL2 variable interpolations for Nomad to resolve, declaring
+	// them with the values of the pack variables.
+	if r.KeepVariablesBlock {
+		withBlock, err := addVariablesBlock(replacedTpl, src.tplCtx.Vars())
+		if err != nil {
+			return "", fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		replacedTpl = withBlock
+	}
+
+	if r.Format {
+		// hclfmt the templates, keeping their comments intact
+		replacedTpl = string(FormatHCL([]byte(replacedTpl)))
+	}
+	return replacedTpl, nil
+}
+
+// isJobTemplate reports whether the named template renders a job
+// specification, rather than an auxiliary file.
+func isJobTemplate(name string) bool {
+	return strings.HasSuffix(name, ".nomad.tpl") || strings.HasSuffix(name, ".hcl.tpl")
+}
+
 // RenderOutput performs the output template rendering.
 func (r *Renderer) RenderOutput() (string, error) {
 
@@ -182,6 +431,9 @@ func (r *Renderer) RenderOutput() (string, error) {
 		return "", err
 	}
 
+	r.outputs = make(map[string]any)
+	r.tpl.Funcs(randomFuncs(r.templateRand(r.pack.OutputTemplateFile.Name)))
+
 	ptc, _ := r.pv.ToPackTemplateContext(r.pack)
 	var buf strings.Builder
 	if err := r.tpl.ExecuteTemplate(&buf, r.pack.OutputTemplateFile.Name, ptc); err != nil {
@@ -191,6 +443,61 @@ func (r *Renderer) RenderOutput() (string, error) {
 	return buf.String(), nil
 }
 
+// RenderHooks renders the pre and post deploy hook templates of the pack into
+// the scripts to run. A hook the pack does not define renders to an empty
+// string.
+func (r *Renderer) RenderHooks() (pre, post string, err error) {
+	if pre, err = r.renderHook(r.pack.PreDeployHookFile); err != nil {
+		return "", "", err
+	}
+	if post, err = r.renderHook(r.pack.PostDeployHookFile); err != nil {
+		return "", "", err
+	}
+	return pre, post, nil
+}
+
+// renderHook renders a single hook template file, which may be nil.
+func (r *Renderer) renderHook(f *pack.File) (string, error) {
+	if f == nil {
+		return "", nil
+	}
+
+	if _, err := r.tpl.New(f.Name).Parse(string(f.Content)); err != nil {
+		return "", err
+	}
+	r.tpl.Funcs(randomFuncs(r.templateRand(f.Name)))
+
+	ptc, _ := r.pv.ToPackTemplateContext(r.pack)
+	var buf strings.Builder
+	if err := r.tpl.ExecuteTemplate(&buf, f.Name, ptc); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", f.Name, err)
+	}
+	return strings.ReplaceAll(buf.String(), "<no value>", ""), nil
+}
+
+// Outputs returns the named output values recorded when rendering the output
+// template. It will be empty if RenderOutput has not been called, or the pack
+// does not define any outputs.
+func (r *Renderer) Outputs() map[string]any {
+	return r.outputs
+}
+
+// recordOutput backs the output template function, storing the value under
+// the passed name so it can be displayed once the pack is deployed.
+func (r *Renderer) recordOutput(name string, value any) (string, error) {
+	if r.outputs == nil {
+		return "", fmt.Errorf("output %q can only be defined within outputs.tpl", name)
+	}
+	if name == "" {
+		return "", fmt.Errorf("output name must not be empty")
+	}
+	if _, ok := r.outputs[name]; ok {
+		return "", fmt.Errorf("output %q is already defined", name)
+	}
+	r.outputs[name] = value
+	return "", nil
+}
+
 // prepareFiles dispatches the request to prepare the Renderer's file configs
 // to the parser version specific implementation
 func (r *Renderer) prepareFiles(p *pack.Pack,
@@ -298,8 +605,15 @@ func prepareFilesV1(p *pack.Pack,
 type Rendered struct {
 	parentRenders     map[string]string
 	dependencyRenders map[string]string
+	emptyRenders      map[string]string
+	sensitiveFiles    map[string]SensitiveFile
+	errs              []error
 }
 
+// Errors returns the errors of templates which failed to parse or render. It
+// is only populated when the Renderer is configured with KeepGoing.
+func (r *Rendered) Errors() []error { return r.errs }
+
 // ParentRenders returns a map of rendered templates belonging to the parent
 // pack. The map key represents the path and file name of the template.
 func (r *Rendered) ParentRenders() map[string]string { return r.parentRenders }
@@ -316,3 +630,14 @@ func (r *Rendered) DependentRenders() map[string]string { return r.dependencyRen
 // LenDependentRenders returns the number of dependent rendered templates that
 // are stored.
 func (r *Rendered) LenDependentRenders() int { return len(r.dependencyRenders) }
+
+// EmptyRenders returns a map of the templates of all packs which rendered to
+// only whitespace, and so are not included in the parent or dependent renders.
+// The map key represents the path and file name of the template, and the value
+// is always empty.
+func (r *Rendered) EmptyRenders() map[string]string { return r.emptyRenders }
+
+// SensitiveFiles returns a map of the files written by the fileFromBase64
+// template function of all packs. The map key represents the path and file
+// name of the file, in the same form as the rendered templates.
+func (r *Rendered) SensitiveFiles() map[string]SensitiveFile { return r.sensitiveFiles }
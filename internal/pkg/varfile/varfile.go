@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/hcl/v2/json"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // DecodeResult is returned by the
@@ -168,12 +169,26 @@ func decode(root *pack.Pack, filename string, src []byte, ctx *hcl.EvalContext,
 	vals := make([]*variables.Override, 0, len(em))
 	for _, kv := range em {
 
-		// Read the value. If that generates diags, collect them, and stop
-		// processing this item.
-		value, vDiags := kv.Value.Value(nil)
-		if vDiags.HasErrors() {
-			diags = diags.Extend(vDiags)
-			continue
+		// Values referring to other variables are kept as expressions, which
+		// are evaluated once all the overrides have been merged.
+		var value cty.Value
+		var expr *variables.Expression
+		if variables.RefersToVariables(kv.Value) {
+			expr = &variables.Expression{
+				Expr:   kv.Value,
+				Source: string(kv.Value.Range().SliceBytes(src)),
+				Range:  kv.Value.Range(),
+			}
+			fixupRange(&expr.Range)
+		} else {
+			// Read the value. If that generates diags, collect them, and stop
+			// processing this item.
+			var vDiags hcl.Diagnostics
+			value, vDiags = kv.Value.Value(nil)
+			if vDiags.HasErrors() {
+				diags = diags.Extend(vDiags)
+				continue
+			}
 		}
 
 		// `steps` are the path components, so named because in the HCL case, they
@@ -236,6 +251,7 @@ func decode(root *pack.Pack, filename string, src []byte, ctx *hcl.EvalContext,
 			Path:  path,
 			Value: value,
 			Type:  value.Type(),
+			Expr:  expr,
 			Range: oRange,
 		}
 		vals = append(vals, &val)
@@ -17,8 +17,10 @@ import (
 )
 
 // DecodeVariableBlock parses a variable definition into a variable. When the
-// provided block or its Body is nil, the function returns (nil, nil)
-func DecodeVariableBlock(block *hcl.Block) (*variables.Variable, hcl.Diagnostics) {
+// provided block or its Body is nil, the function returns (nil, nil). The
+// default may read files relative to packDir using the file function, which
+// is unavailable when packDir is empty.
+func DecodeVariableBlock(block *hcl.Block, packDir string) (*variables.Variable, hcl.Diagnostics) {
 	if block == nil || block.Body == nil {
 		return nil, hcl.Diagnostics{}
 	}
@@ -71,10 +73,38 @@ func DecodeVariableBlock(block *hcl.Block) (*variables.Variable, hcl.Diagnostics
 		v.SetType(ty)
 	}
 
+	// A variable doesn't need to declare whether it is sensitive. If it does,
+	// process this and store it, along with any processing errors.
+	if attr, exists := content.Attributes[schema.VariableAttributeSensitive]; exists {
+		val, sensitiveDiags := attr.Expr.Value(nil)
+		diags = packdiags.SafeDiagnosticsExtend(diags, sensitiveDiags)
+
+		if val.Type() == cty.Bool && !val.IsNull() {
+			v.Sensitive = val.True()
+		} else if !sensitiveDiags.HasErrors() {
+			diags = packdiags.SafeDiagnosticsAppend(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid type for sensitive",
+				Detail: fmt.Sprintf("The sensitive attribute is expected to be of type bool, got %s",
+					val.Type().FriendlyName()),
+				Subject: attr.Range.Ptr(),
+			})
+		}
+	}
+
 	// A variable doesn't need to declare a default. If it does, process this
 	// and store it, along with any processing errors.
-	if attr, exists := content.Attributes[schema.VariableAttributeDefault]; exists {
-		val, valDiags := attr.Expr.Value(nil)
+	funcs := defaultFunctions(v.Name, packDir)
+	if attr, exists := content.Attributes[schema.VariableAttributeDefault]; exists && variables.RefersToVariables(attr.Expr) {
+		// A default which refers to other variables can only be evaluated
+		// once their values are known.
+		v.Expr = &variables.Expression{
+			Expr:      attr.Expr,
+			Range:     attr.Expr.Range(),
+			Functions: funcs,
+		}
+	} else if exists {
+		val, valDiags := attr.Expr.Value(&hcl.EvalContext{Functions: funcs})
 		diags = packdiags.SafeDiagnosticsExtend(diags, valDiags)
 
 		// If the found type isn't cty.NilType, then attempt to covert the
@@ -88,9 +118,91 @@ func DecodeVariableBlock(block *hcl.Block) (*variables.Variable, hcl.Diagnostics
 		v.Value = val
 	}
 
+	// A variable may declare any number of validation blocks. Each is decoded
+	// here but only evaluated once the final variable value is known.
+	for _, block := range content.Blocks {
+		validation, validationDiags := decodeValidationBlock(v.Name, block)
+		diags = packdiags.SafeDiagnosticsExtend(diags, validationDiags)
+		if validation != nil {
+			v.Validations = append(v.Validations, validation)
+		}
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return v, diags
+}
+
+// decodeValidationBlock parses a validation block found within the variable
+// named by name.
+func decodeValidationBlock(name variables.ID, block *hcl.Block) (*variables.Validation, hcl.Diagnostics) {
+	content, diags := block.Body.Content(schema.VariableValidationSchema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	v := &variables.Validation{
+		DeclRange: block.DefRange,
+	}
+
+	if attr, exists := content.Attributes[schema.ValidationAttributeCondition]; exists {
+		v.Condition = attr.Expr
+
+		// The condition is evaluated with only the variable itself in scope,
+		// so ensure it refers to nothing else.
+		hasSelfRef := false
+		for _, traversal := range attr.Expr.Variables() {
+			if isSelfReference(name, traversal) {
+				hasSelfRef = true
+				continue
+			}
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid reference in variable validation",
+				Detail:   fmt.Sprintf("The condition for variable %q can only refer to the variable itself, using var.%s.", name, name),
+				Subject:  traversal.SourceRange().Ptr(),
+			})
+		}
+		if !hasSelfRef {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable validation condition",
+				Detail:   fmt.Sprintf("The condition for variable %q must refer to var.%s in order to test incoming values.", name, name),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	if attr, exists := content.Attributes[schema.ValidationAttributeErrorMessage]; exists {
+		val, msgDiags := attr.Expr.Value(nil)
+		diags = packdiags.SafeDiagnosticsExtend(diags, msgDiags)
+
+		if !msgDiags.HasErrors() && val.Type() == cty.String && !val.IsNull() && val.AsString() != "" {
+			v.ErrorMessage = val.AsString()
+		} else if !msgDiags.HasErrors() {
+			diags = packdiags.SafeDiagnosticsAppend(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid validation error message",
+				Detail:   "The error_message attribute is expected to be a non-empty string.",
+				Subject:  attr.Range.Ptr(),
+			})
+		}
+	}
+
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
 	return v, diags
 }
+
+// isSelfReference returns whether the traversal refers to var.<name>.
+func isSelfReference(name variables.ID, traversal hcl.Traversal) bool {
+	if traversal.RootName() != "var" || len(traversal) < 2 {
+		return false
+	}
+	attr, ok := traversal[1].(hcl.TraverseAttr)
+	return ok && attr.Name == name.String()
+}
@@ -4,6 +4,8 @@
 package decoder
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -16,6 +18,69 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+func TestDecoder_DecodeVariableBlock_Validation(t *testing.T) {
+	ci.Parallel(t)
+
+	t.Run("passes/on good validation", func(t *testing.T) {
+		ci.Parallel(t)
+		out, diags := DecodeVariableBlock(testGetHCLBlock(t, testLoadPackFile(t, []byte(goodValidationVariableHCL))), "")
+		must.SliceEmpty(t, diags)
+		must.Len(t, 1, out.Validations)
+		must.Eq(t, "count must be positive", out.Validations[0].ErrorMessage)
+
+		out.Value = cty.NumberIntVal(1)
+		must.SliceEmpty(t, out.Validate())
+
+		out.Value = cty.NumberIntVal(0)
+		diags = out.Validate()
+		must.Len(t, 1, diags)
+		must.Eq(t, "count must be positive", diags[0].Detail)
+	})
+
+	t.Run("fails/on reference to other variable", func(t *testing.T) {
+		ci.Parallel(t)
+		out, diags := DecodeVariableBlock(testGetHCLBlock(t, testLoadPackFile(t, []byte(badValidationReferenceHCL))), "")
+		must.Nil(t, out)
+		must.True(t, diags.HasErrors())
+		must.StrContains(t, diags.Error(), "can only refer to the variable itself")
+	})
+}
+
+func TestDecoder_DecodeVariableBlock_File(t *testing.T) {
+	ci.Parallel(t)
+
+	packDir := t.TempDir()
+	must.NoError(t, os.MkdirAll(filepath.Join(packDir, "files"), 0o755))
+	must.NoError(t, os.WriteFile(filepath.Join(packDir, "files", "config.yaml"), []byte("key: value\n"), 0o644))
+
+	t.Run("passes/on file within the pack", func(t *testing.T) {
+		ci.Parallel(t)
+		out, diags := DecodeVariableBlock(testGetHCLBlock(t, testLoadPackFile(t, []byte(goodFileDefaultHCL))), packDir)
+		must.SliceEmpty(t, diags)
+		must.Eq(t, cty.StringVal("key: value\n"), out.Default)
+	})
+
+	t.Run("fails/on missing file", func(t *testing.T) {
+		ci.Parallel(t)
+		_, diags := DecodeVariableBlock(testGetHCLBlock(t, testLoadPackFile(t, []byte(badFileMissingHCL))), packDir)
+		must.True(t, diags.HasErrors())
+		must.StrContains(t, diags.Error(), `variable "config": file "files/missing.yaml" not found in the pack`)
+	})
+
+	t.Run("fails/on file outside the pack", func(t *testing.T) {
+		ci.Parallel(t)
+		_, diags := DecodeVariableBlock(testGetHCLBlock(t, testLoadPackFile(t, []byte(badFileOutsideHCL))), packDir)
+		must.True(t, diags.HasErrors())
+		must.StrContains(t, diags.Error(), "must be a relative path within the pack")
+	})
+
+	t.Run("fails/without pack directory", func(t *testing.T) {
+		ci.Parallel(t)
+		_, diags := DecodeVariableBlock(testGetHCLBlock(t, testLoadPackFile(t, []byte(goodFileDefaultHCL))), "")
+		must.True(t, diags.HasErrors())
+	})
+}
+
 func TestDecoder_DecodeVariableBlock(t *testing.T) {
 	ci.Parallel(t)
 
@@ -113,11 +178,44 @@ func TestDecoder_DecodeVariableBlock(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name:  "passes/on sensitive block",
+			input: testGetHCLBlock(t, testLoadPackFile(t, []byte(goodSensitiveVariableHCL))),
+			expectOut: func() *variables.Variable {
+				out := variables.Variable{
+					Name:      "token",
+					Sensitive: true,
+					DeclRange: hcl.Range{
+						Filename: "/fake/test/path",
+						Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+						End:      hcl.Pos{Line: 1, Column: 17, Byte: 16},
+					},
+				}
+				out.SetType(cty.String)
+				return &out
+			}(),
+			expectDiags: hcl.Diagnostics{},
+		},
+		{
+			name:      "fails/on bad sensitive type",
+			input:     testGetHCLBlock(t, testLoadPackFile(t, []byte(badSensitiveType))),
+			expectOut: nil,
+			expectDiags: hcl.Diagnostics{&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid type for sensitive",
+				Detail:   "The sensitive attribute is expected to be of type bool, got string",
+				Subject: &hcl.Range{
+					Filename: "/fake/test/path",
+					Start:    hcl.Pos{Line: 2, Column: 2, Byte: 18},
+					End:      hcl.Pos{Line: 2, Column: 19, Byte: 35},
+				},
+			}},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			ci.Parallel(t)
-			out, diags := DecodeVariableBlock(tc.input)
+			out, diags := DecodeVariableBlock(tc.input, "")
 			must.Eq(t, tc.expectOut, out)
 			if tc.expectDiags != nil {
 				spew.Config.DisableMethods = true
@@ -135,16 +233,56 @@ const goodCompleteVariableHCL = `variable "example" {
 	description = "an example variable"
 }`
 
+const goodSensitiveVariableHCL = `variable "token" {
+	type      = string
+	sensitive = true
+}`
+
 const badContent = `variable "example" {
 	bad {}
 }`
 
+const goodValidationVariableHCL = `variable "count" {
+	type = number
+	validation {
+		condition     = var.count > 0
+		error_message = "count must be positive"
+	}
+}`
+
+const badValidationReferenceHCL = `variable "count" {
+	type = number
+	validation {
+		condition     = var.other > 0
+		error_message = "count must be positive"
+	}
+}`
+
+const goodFileDefaultHCL = `variable "config" {
+	type    = string
+	default = file("files/config.yaml")
+}`
+
+const badFileMissingHCL = `variable "config" {
+	type    = string
+	default = file("files/missing.yaml")
+}`
+
+const badFileOutsideHCL = `variable "config" {
+	type    = string
+	default = file("../config.yaml")
+}`
+
 const badNameText = `variable "!bad!" {}`
 
 const badDescriptionType = `variable "bad" {
 	description = true
 }`
 
+const badSensitiveType = `variable "bad" {
+	sensitive = "yes"
+}`
+
 // loadPackFile takes a pack.File and parses this using a hclparse.Parser. The
 // file can be either HCL and JSON format.
 func testLoadPackFile(t *testing.T, b []byte) hcl.Body {
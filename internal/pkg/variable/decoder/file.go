@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// defaultFunctions returns the functions available to the default of the
+// named variable, declared within the pack directory packDir. If packDir is
+// empty, there are none.
+func defaultFunctions(name variables.ID, packDir string) map[string]function.Function {
+	if packDir == "" {
+		return nil
+	}
+	return map[string]function.Function{
+		"file": fileFunction(name, packDir),
+	}
+}
+
+// fileFunction returns the file function, which returns the contents of the
+// file at the passed path relative to packDir. Its errors name the variable
+// whose default called it.
+func fileFunction(name variables.ID, packDir string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			if !filepath.IsLocal(path) {
+				return cty.NilVal, fmt.Errorf("variable %q: file %q must be a relative path within the pack", name, path)
+			}
+
+			content, err := os.ReadFile(filepath.Join(packDir, path))
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				return cty.NilVal, fmt.Errorf("variable %q: file %q not found in the pack", name, path)
+			case err != nil:
+				return cty.NilVal, fmt.Errorf("variable %q: failed to read file %q: %v", name, path, err)
+			}
+			return cty.StringVal(string(content)), nil
+		},
+	})
+}
@@ -11,18 +11,37 @@ import (
 const DefaultPrefix = "NOMAD_PACK_VAR_"
 
 type EnvLoader struct {
-	prefix string
+	prefix    string
+	lowercase bool
 }
 
 func New() *EnvLoader {
 	return &EnvLoader{prefix: DefaultPrefix}
 }
 
+// NewWithPrefix returns an EnvLoader which reads variables from the env vars
+// starting with the passed prefix. Variable names are lowercased once the
+// prefix is stripped, so PACK_VAR_JOB_NAME sets the job_name variable.
+func NewWithPrefix(prefix string) *EnvLoader {
+	return &EnvLoader{prefix: prefix, lowercase: true}
+}
+
 func (e *EnvLoader) GetVarsFromEnv() map[string]string {
-	if e.prefix == "" {
-		return getVarsFromEnv(DefaultPrefix)
+	prefix := e.prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	vars := getVarsFromEnv(prefix)
+	if !e.lowercase {
+		return vars
 	}
-	return getVarsFromEnv(e.prefix)
+
+	out := make(map[string]string, len(vars))
+	for k, v := range vars {
+		out[strings.ToLower(k)] = v
+	}
+	return out
 }
 
 func getVarsFromEnv(prefix string) map[string]string {
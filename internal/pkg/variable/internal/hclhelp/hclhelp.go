@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/convert"
 )
@@ -15,7 +16,14 @@ import (
 // expression to a hydrated hclsyntax.Expression.
 func ExpressionFromVariableDefinition(file, val string, varType cty.Type) (hclsyntax.Expression, hcl.Diagnostics) {
 	switch varType {
-	case cty.String, cty.Number, cty.NilType:
+	case cty.Number:
+		// Numbers may also be given as an expression over other variables,
+		// such as var.base * 2.
+		if expr, diags := hclsyntax.ParseExpression([]byte(val), file, hcl.Pos{Line: 1, Column: 1}); !diags.HasErrors() && variables.RefersToVariables(expr) {
+			return expr, nil
+		}
+		return &hclsyntax.LiteralValueExpr{Val: cty.StringVal(val)}, nil
+	case cty.String, cty.NilType:
 		return &hclsyntax.LiteralValueExpr{Val: cty.StringVal(val)}, nil
 	default:
 		return hclsyntax.ParseExpression([]byte(val), file, hcl.Pos{Line: 1, Column: 1})
@@ -4,7 +4,9 @@
 package config
 
 import (
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/source"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
 )
 
 type ParserVersion int
@@ -33,6 +35,11 @@ type ParserConfig struct {
 	// absolute pack name. "«root pack name».«child pack».«grandchild pack»"
 	RootVariableFiles map[pack.ID]*pack.File
 
+	// AdditionalVariableFiles contains the additional root variable files of
+	// each pack, keyed by their absolute pack name. Their declarations are
+	// merged with those of the pack's root variable file.
+	AdditionalVariableFiles map[pack.ID][]*pack.File
+
 	// EnvOverrides are key=value variables and take the lowest precedence of
 	// all sources. If the same key is supplied twice, the last wins.
 	EnvOverrides map[string]string
@@ -47,6 +54,16 @@ type ParserConfig struct {
 	// all sources. If the same key is supplied twice, the last wins.
 	FlagOverrides map[string]string
 
+	// VariableSources are consulted, in order, for the value of each variable
+	// not set by the env, file, or flag overrides. The first source to return
+	// a value wins. Used for ParserV2.
+	VariableSources []variables.VariableSource
+
+	// SecretReader reads the secrets referenced by FlagOverrides values in
+	// the form vault:<path>#<field>. If nil, such values are used as given.
+	// Used for ParserV2.
+	SecretReader source.SecretReader
+
 	// IgnoreMissingVars determines whether we error or not on variable overrides
 	// that don't have corresponding vars in the pack.
 	IgnoreMissingVars bool
@@ -5,14 +5,18 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/internal/hclhelp"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser/config"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+	"github.com/zclconf/go-cty/cty"
 	"golang.org/x/exp/maps"
 )
 
@@ -212,3 +216,111 @@ func (pv *ParsedVariables) varFileHeader() string {
 	// This value will be added to the top of the varfile
 	return ""
 }
+
+// SECTION: Assertion helper functions
+
+// CheckRequired returns a diagnostic for every variable which declares no
+// default and is not set by any override, so has no value to render with.
+// Variables with defaults only need to be set to change their value, so
+// variable files may set any subset of the variables. Names use the same form
+// as variable overrides, relative to the root pack.
+func (pv *ParsedVariables) CheckRequired(root pack.ID) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	vars := pv.GetVars()
+
+	pIDs := maps.Keys(vars)
+	slices.Sort(pIDs)
+	for _, pID := range pIDs {
+		vIDs := maps.Keys(vars[pID])
+		slices.Sort(vIDs)
+		for _, vID := range vIDs {
+			v := vars[pID][vID]
+			if v.Value != cty.NilVal {
+				continue
+			}
+
+			name := vID.String()
+			if path, ok := strings.CutPrefix(pID.String(), root.String()+"."); ok {
+				name = path + "." + name
+			}
+			diags = diags.Append(packdiags.DiagMissingRequiredVar(name, v.DeclRange.Ptr()))
+		}
+	}
+
+	return diags
+}
+
+// AssertValues checks that the final value of each asserted variable matches
+// the asserted value. Keys use the same form as variable overrides, so
+// dependency variables are prefixed with the path to the dependency. Values
+// are parsed using the type of the variable they are asserted against. A
+// diagnostic is returned for every failed assertion.
+func (pv *ParsedVariables) AssertValues(root pack.ID, asserts map[string]string) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	vars := pv.GetVars()
+
+	names := maps.Keys(asserts)
+	slices.Sort(names)
+	for _, name := range names {
+		rawVal := asserts[name]
+
+		lines := strings.Split(rawVal, "\n")
+		sub := &hcl.Range{
+			Filename: fmt.Sprintf("<asserted value for var %s>", name),
+			Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
+			End:      hcl.Pos{Line: len(lines), Column: len(lines[len(lines)-1]), Byte: len(rawVal)},
+		}
+
+		pID, vID := root, variables.ID(name)
+		if path, varName, ok := cutLast(name, "."); ok {
+			pID, vID = root.Join(pack.ID(path)), variables.ID(varName)
+		}
+
+		v, ok := vars[pID][vID]
+		if !ok {
+			diags = diags.Append(packdiags.DiagMissingRootVar(name, sub))
+			continue
+		}
+
+		expr, exprDiags := hclhelp.ExpressionFromVariableDefinition(sub.Filename, rawVal, v.Type)
+		if exprDiags.HasErrors() {
+			diags = diags.Extend(exprDiags)
+			continue
+		}
+		expected, valDiags := expr.Value(nil)
+		if valDiags.HasErrors() {
+			diags = diags.Extend(valDiags)
+			continue
+		}
+		if v.Type != cty.NilType {
+			var convDiag *hcl.Diagnostic
+			if expected, convDiag = hclhelp.ConvertValUsingType(expected, v.Type, sub); convDiag != nil {
+				diags = diags.Append(convDiag)
+				continue
+			}
+		}
+
+		if eq := v.Value.Equals(expected); !eq.IsKnown() || eq.False() {
+			expectedStr, actualStr := formatAssertValue(expected), formatAssertValue(v.Value)
+			if v.Sensitive {
+				expectedStr, actualStr = variables.SensitiveValue, variables.SensitiveValue
+			}
+			diags = diags.Append(packdiags.DiagFailedVariableAssertion(name, expectedStr, actualStr, sub))
+		}
+	}
+
+	return diags
+}
+
+func formatAssertValue(v cty.Value) string {
+	return strings.TrimSpace(string(hclwrite.TokensForValue(v).Bytes()))
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return "", s, false
+}
@@ -4,8 +4,19 @@
 package parser
 
 import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/internal/hclhelp"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser/config"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/source"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type Parser interface {
@@ -18,3 +29,49 @@ func NewParser(cfg *config.ParserConfig) (Parser, error) {
 	}
 	return NewParserV2(cfg)
 }
+
+// mergeRootVars adds the root variables of an additional variable file to
+// those already declared for a pack. A variable declared in both results in an
+// error naming each file.
+func mergeRootVars[K ~string](dst, src map[K]*variables.Variable) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, name := range slices.Sorted(maps.Keys(src)) {
+		v := src[name]
+		if existing, ok := dst[name]; ok {
+			diags = packdiags.SafeDiagnosticsAppend(diags,
+				packdiags.DiagDuplicateVariable(string(name), &existing.DeclRange, &v.DeclRange))
+			continue
+		}
+		dst[name] = v
+	}
+	return diags
+}
+
+// packDir returns the root directory of the pack containing the file, against
+// which the file function of variable defaults resolves paths. Files without
+// a path, such as those in tests, have no pack directory.
+func packDir(file *pack.File) string {
+	if file.Path == "" || file.Name == "" {
+		return ""
+	}
+	return strings.TrimSuffix(file.Path, filepath.FromSlash(file.Name))
+}
+
+// readSecretValue reads the field of the secret referenced by the --var value
+// of the named variable, converted to the type of the variable. Values read
+// from secrets should be marked as sensitive by the caller.
+func readSecretValue(reader source.SecretReader, name, path, field string, typ cty.Type, rng *hcl.Range) (cty.Value, hcl.Diagnostics) {
+	secret, err := reader.ReadSecret(path, field)
+	if err != nil {
+		return cty.NilVal, hcl.Diagnostics{packdiags.DiagFailedVariableLookup(name, err, rng)}
+	}
+
+	val := cty.StringVal(secret)
+	if typ != cty.NilType {
+		var convDiag *hcl.Diagnostic
+		if val, convDiag = hclhelp.ConvertValUsingType(val, typ, rng); convDiag != nil {
+			return cty.NilVal, hcl.Diagnostics{convDiag}
+		}
+	}
+	return val, nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/internal/hclhelp"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser/config"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/schema"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/source"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
 	"github.com/spf13/afero"
@@ -119,6 +120,14 @@ func (p *ParserV1) Parse() (*ParsedVariables, hcl.Diagnostics) {
 			}
 		}
 	}
+	// Evaluate any validation rules now the root variables hold their final
+	// values, so all failures are reported together before rendering.
+	for _, packVars := range p.rootVars {
+		for _, v := range packVars {
+			diags = packdiags.SafeDiagnosticsExtend(diags, v.Validate())
+		}
+	}
+
 	out := new(ParsedVariables)
 	out.LoadV1Result(p.rootVars)
 	return out, diags
@@ -356,6 +365,22 @@ func (p *ParserV1) parseCLIVariable(name string, rawVal string) hcl.Diagnostics
 		return hcl.Diagnostics{packdiags.DiagMissingRootVar(name, &fakeRange)}
 	}
 
+	// Values referencing a secret are read from Vault, and are sensitive.
+	if path, field, ok := source.VaultRef(rawVal); ok && p.cfg.SecretReader != nil {
+		val, diags := readSecretValue(p.cfg.SecretReader, name, path, field, existing.Type, &fakeRange)
+		if diags.HasErrors() {
+			return diags
+		}
+		p.cliOverrideVars[packVarName[0]] = append(p.cliOverrideVars[packVarName[0]], &variables.Variable{
+			Name:      variables.ID(packVarName[1]),
+			Type:      val.Type(),
+			Value:     val,
+			Sensitive: true,
+			DeclRange: fakeRange,
+		})
+		return nil
+	}
+
 	expr, diags := hclhelp.ExpressionFromVariableDefinition(fakeRange.Filename, rawVal, existing.Type)
 	if diags.HasErrors() {
 		return diags
@@ -395,15 +420,17 @@ func (p *ParserV1) parseRootFiles() hcl.Diagnostics {
 	// Iterate all our root variable files.
 	for name, file := range p.cfg.RootVariableFiles {
 
-		hclBody, loadDiags := p.loadPackFile(file)
-		diags = packdiags.SafeDiagnosticsExtend(diags, loadDiags)
-
-		content, contentDiags := hclBody.Content(schema.VariableFileSchema)
-		diags = packdiags.SafeDiagnosticsExtend(diags, contentDiags)
-
-		rootVars, parseDiags := p.parseRootBodyContent(content)
+		rootVars, parseDiags := p.parseRootFile(file)
 		diags = packdiags.SafeDiagnosticsExtend(diags, parseDiags)
 
+		// Merge the declarations of any additional variable files, which
+		// must not redeclare a variable.
+		for _, extraFile := range p.cfg.AdditionalVariableFiles[name] {
+			extraVars, extraDiags := p.parseRootFile(extraFile)
+			diags = packdiags.SafeDiagnosticsExtend(diags, extraDiags)
+			diags = packdiags.SafeDiagnosticsExtend(diags, mergeRootVars(rootVars, extraVars))
+		}
+
 		// If we don't have any errors processing the file, and it's content,
 		// add an entry.
 		if !diags.HasErrors() {
@@ -418,9 +445,26 @@ func (p *ParserV1) parseRootFiles() hcl.Diagnostics {
 	return diags
 }
 
+// parseRootFile loads a root variables file and parses the variables it
+// declares.
+func (p *ParserV1) parseRootFile(file *pack.File) (map[string]*variables.Variable, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	hclBody, loadDiags := p.loadPackFile(file)
+	diags = packdiags.SafeDiagnosticsExtend(diags, loadDiags)
+
+	content, contentDiags := hclBody.Content(schema.VariableFileSchema)
+	diags = packdiags.SafeDiagnosticsExtend(diags, contentDiags)
+
+	rootVars, parseDiags := p.parseRootBodyContent(content, packDir(file))
+	diags = packdiags.SafeDiagnosticsExtend(diags, parseDiags)
+
+	return rootVars, diags
+}
+
 // parseRootBodyContent process the body of a root variables file, parsing
-// each variable block found.
-func (p *ParserV1) parseRootBodyContent(body *hcl.BodyContent) (map[string]*variables.Variable, hcl.Diagnostics) {
+// each variable block found. The defaults may read files within packDir.
+func (p *ParserV1) parseRootBodyContent(body *hcl.BodyContent, packDir string) (map[string]*variables.Variable, hcl.Diagnostics) {
 
 	packRootVars := map[string]*variables.Variable{}
 
@@ -429,7 +473,7 @@ func (p *ParserV1) parseRootBodyContent(body *hcl.BodyContent) (map[string]*vari
 	// Due to the parsing that uses variableFileSchema, it is safe to assume
 	// every block has a type "variable".
 	for _, block := range body.Blocks {
-		cfg, cfgDiags := decoder.DecodeVariableBlock(block)
+		cfg, cfgDiags := decoder.DecodeVariableBlock(block, packDir)
 		diags = packdiags.SafeDiagnosticsExtend(diags, cfgDiags)
 		if cfg != nil {
 			packRootVars[cfg.Name.String()] = cfg
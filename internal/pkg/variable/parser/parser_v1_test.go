@@ -290,3 +290,37 @@ func NewStringVariableV1(key, value, kind string) *variables.Variable {
 		DeclRange: hcl.Range{Filename: fmt.Sprintf("<value for var.%s from %s>", key, kind)},
 	}
 }
+
+func TestParserV1_parseCLIVariable_Secret(t *testing.T) {
+	newParser := func() *ParserV1 {
+		return &ParserV1{
+			cfg: &config.ParserConfig{
+				ParentName: "example",
+				SecretReader: &testSecretReader{secrets: map[string]string{
+					"secret/data/app#password": "hunter2",
+				}},
+			},
+			rootVars: map[string]map[string]*variables.Variable{
+				"example": {"password": &variables.Variable{Name: "password", Type: cty.String}},
+			},
+			cliOverrideVars: make(map[string][]*variables.Variable),
+		}
+	}
+
+	p := newParser()
+	must.SliceEmpty(t, p.parseCLIVariable("password", "vault:secret/data/app#password"))
+	must.Eq(t, cty.StringVal("hunter2"), p.cliOverrideVars["example"][0].Value)
+	must.True(t, p.cliOverrideVars["example"][0].Sensitive)
+
+	p = newParser()
+	diags := p.parseCLIVariable("password", "vault:secret/data/missing#password")
+	must.True(t, diags.HasErrors())
+	must.StrContains(t, diags.Error(), `secret "secret/data/missing" not found in Vault`)
+
+	// Values which are not references, such as the Vault Docker image, are
+	// used as given.
+	p = newParser()
+	must.SliceEmpty(t, p.parseCLIVariable("password", "vault:1.15.2"))
+	must.Eq(t, cty.StringVal("vault:1.15.2"), p.cliOverrideVars["example"][0].Value)
+	must.False(t, p.cliOverrideVars["example"][0].Sensitive)
+}
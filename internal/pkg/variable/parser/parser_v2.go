@@ -6,27 +6,26 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
-	"github.com/hashicorp/nomad-pack/internal/pkg/varfile"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/decoder"
-	"github.com/hashicorp/nomad-pack/internal/pkg/variable/envloader"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/internal/hclhelp"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/parser/config"
 	"github.com/hashicorp/nomad-pack/internal/pkg/variable/schema"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/source"
 	"github.com/hashicorp/nomad-pack/sdk/pack"
 	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
-	"github.com/spf13/afero"
 	"github.com/zclconf/go-cty/cty"
 )
 
 type ParserV2 struct {
-	fs  afero.Afero
 	cfg *config.ParserConfig
 
 	// rootVars contains all the root variable declared by all parent and child
@@ -39,6 +38,10 @@ type ParserV2 struct {
 	envOverrideVars  variables.PackIDKeyedVarMap
 	fileOverrideVars variables.PackIDKeyedVarMap
 	flagOverrideVars variables.PackIDKeyedVarMap
+
+	// flagUpdates are the --var overrides which set a key within, or append
+	// to, the value of a variable rather than replacing it.
+	flagUpdates []*varUpdate
 }
 
 func NewParserV2(cfg *config.ParserConfig) (*ParserV2, error) {
@@ -62,9 +65,6 @@ func NewParserV2(cfg *config.ParserConfig) (*ParserV2, error) {
 	}
 
 	return &ParserV2{
-		fs: afero.Afero{
-			Fs: afero.OsFs{},
-		},
 		cfg:              cfg,
 		rootVars:         make(map[pack.ID]map[variables.ID]*variables.Variable),
 		envOverrideVars:  make(variables.PackIDKeyedVarMap),
@@ -82,20 +82,27 @@ func (p *ParserV2) Parse() (*ParsedVariables, hcl.Diagnostics) {
 		return nil, diags
 	}
 
-	// Parse env, file, and CLI overrides.
-	for k, v := range p.cfg.EnvOverrides {
-		envOverrideDiags := p.parseEnvVariable(k, v)
-		diags = packdiags.SafeDiagnosticsExtend(diags, envOverrideDiags)
+	// Parse env, file, and CLI overrides, each read from its built-in
+	// variable source. The values of env vars and flags are raw strings,
+	// which are parsed according to the type of the variable.
+	envSource := source.NewEnvSource(p.cfg.EnvOverrides)
+	for _, name := range envSource.Names() {
+		val, _, _ := envSource.Lookup(name)
+		diags = packdiags.SafeDiagnosticsExtend(diags, p.parseEnvVariable(name, val.AsString()))
 	}
 
-	for _, fileOverride := range p.cfg.FileOverrides {
-		_, fileOverrideDiags := p.newParseOverridesFile(fileOverride)
-		diags = packdiags.SafeDiagnosticsExtend(diags, fileOverrideDiags)
+	fileSource, fileDiags := source.NewFileSource(p.cfg.ParentPack, p.cfg.FileOverrides)
+	diags = packdiags.SafeDiagnosticsExtend(diags, fileDiags)
+	if fileSource != nil {
+		for _, o := range fileSource.Overrides() {
+			p.newHandleOverride(o)
+		}
 	}
 
-	for k, v := range p.cfg.FlagOverrides {
-		flagOverrideDiags := p.parseFlagVariable(k, v)
-		diags = packdiags.SafeDiagnosticsExtend(diags, flagOverrideDiags)
+	flagSource := source.NewFlagSource(p.cfg.FlagOverrides)
+	for _, name := range flagSource.Names() {
+		val, _, _ := flagSource.Lookup(name)
+		diags = packdiags.SafeDiagnosticsExtend(diags, p.parseFlagVariable(name, val.AsString()))
 	}
 
 	if diags.HasErrors() {
@@ -121,33 +128,92 @@ func (p *ParserV2) Parse() (*ParsedVariables, hcl.Diagnostics) {
 		}
 	}
 
+	// Look up the variables which no override has set in the additional
+	// variable sources.
+	diags = packdiags.SafeDiagnosticsExtend(diags, p.resolveFromSources())
+
+	// Apply the updates to parts of variable values, now the values given by
+	// the other overrides are known.
+	diags = packdiags.SafeDiagnosticsExtend(diags, p.applyVariableUpdates())
+
+	// Evaluate any expressions given as variable values, now the values they
+	// refer to are known.
+	for _, packVars := range p.rootVars {
+		diags = packdiags.SafeDiagnosticsExtend(diags, variables.EvaluateExpressions(packVars))
+	}
+
+	// Evaluate any validation rules now the root variables hold their final
+	// values, so all failures are reported together before rendering.
+	for _, packVars := range p.rootVars {
+		for _, v := range packVars {
+			diags = packdiags.SafeDiagnosticsExtend(diags, v.Validate())
+		}
+	}
+
 	out := new(ParsedVariables)
 	out.LoadV2Result(p.rootVars)
 
 	return out, diags
 }
 
-func (p *ParserV2) newParseOverridesFile(file string) (map[string]*hcl.File, hcl.Diagnostics) {
-	var diags hcl.Diagnostics
+// resolveFromSources sets the value of each root variable which is not set by
+// an env, file, or flag override from the first of the configured variable
+// sources to have a value for it.
+func (p *ParserV2) resolveFromSources() hcl.Diagnostics {
+	if len(p.cfg.VariableSources) == 0 {
+		return nil
+	}
 
-	src, err := p.fs.ReadFile(file)
-	if err != nil {
-		return nil, diags.Append(packdiags.DiagFileNotFound(file))
+	overridden := make(map[pack.ID]map[variables.ID]bool)
+	for _, override := range []variables.PackIDKeyedVarMap{p.envOverrideVars, p.fileOverrideVars, p.flagOverrideVars} {
+		for packID, packVars := range override {
+			if overridden[packID] == nil {
+				overridden[packID] = make(map[variables.ID]bool)
+			}
+			for _, v := range packVars {
+				overridden[packID][v.Name] = true
+			}
+		}
 	}
 
-	ovrds := make(variables.Overrides)
+	var diags hcl.Diagnostics
+	depPrefix := p.cfg.ParentPack.ID().String() + "."
+
+	// Look the variables up in a consistent order, so sources which are slow
+	// or rate limited see the same sequence of requests on every run.
+	for _, packID := range slices.Sorted(maps.Keys(p.rootVars)) {
+		for _, varID := range slices.Sorted(maps.Keys(p.rootVars[packID])) {
+			if overridden[packID][varID] {
+				continue
+			}
 
-	// Decode into the local recipient object
-	root := p.cfg.ParentPack
-	if hfm, vfDiags := varfile.Decode(root, file, src, nil, &ovrds); vfDiags.HasErrors() {
-		return hfm, vfDiags.Extend(diags)
-	}
-	for _, o := range ovrds[pack.ID(file)] {
-		// Identify whether this variable override is for a dependency pack
-		// and then handle it accordingly.
-		p.newHandleOverride(o)
+			name := varID.String()
+			if dep, ok := strings.CutPrefix(packID.String(), depPrefix); ok {
+				name = dep + "." + name
+			}
+			fakeRange := overrideRange(name, "", "variable source")
+
+			for _, src := range p.cfg.VariableSources {
+				val, found, err := src.Lookup(name)
+				if err != nil {
+					diags = diags.Append(packdiags.DiagFailedVariableLookup(name, err, &fakeRange))
+					break
+				}
+				if !found {
+					continue
+				}
+
+				existing := p.rootVars[packID][varID]
+				v, valDiags := sourceOverride(existing, varID, val, fakeRange)
+				diags = packdiags.SafeDiagnosticsExtend(diags, valDiags)
+				if !valDiags.HasErrors() {
+					diags = packdiags.SafeDiagnosticsExtend(diags, existing.Merge(v))
+				}
+				break
+			}
+		}
 	}
-	return nil, diags
+	return diags
 }
 
 func (p *ParserV2) newHandleOverride(o *variables.Override) {
@@ -164,6 +230,7 @@ func (p *ParserV2) newHandleOverrideVar(o *variables.Override) {
 		Name:      o.Name,
 		Type:      o.Type,
 		Value:     o.Value,
+		Expr:      o.Expr,
 		DeclRange: o.Range,
 	}
 	p.fileOverrideVars[o.Path] = append(p.fileOverrideVars[o.Path], &v)
@@ -207,15 +274,17 @@ func (p *ParserV2) parseRootFiles() hcl.Diagnostics {
 	// Iterate all our root variable files.
 	for name, file := range p.cfg.RootVariableFiles {
 
-		hclBody, loadDiags := p.loadPackFile(file)
-		diags = packdiags.SafeDiagnosticsExtend(diags, loadDiags)
-
-		content, contentDiags := hclBody.Content(schema.VariableFileSchema)
-		diags = packdiags.SafeDiagnosticsExtend(diags, contentDiags)
-
-		rootVars, parseDiags := p.parseRootBodyContent(content)
+		rootVars, parseDiags := p.parseRootFile(file)
 		diags = packdiags.SafeDiagnosticsExtend(diags, parseDiags)
 
+		// Merge the declarations of any additional variable files, which
+		// must not redeclare a variable.
+		for _, extraFile := range p.cfg.AdditionalVariableFiles[name] {
+			extraVars, extraDiags := p.parseRootFile(extraFile)
+			diags = packdiags.SafeDiagnosticsExtend(diags, extraDiags)
+			diags = packdiags.SafeDiagnosticsExtend(diags, mergeRootVars(rootVars, extraVars))
+		}
+
 		// If we don't have any errors processing the file, and its content,
 		// add an entry.
 		if !diags.HasErrors() {
@@ -226,9 +295,34 @@ func (p *ParserV2) parseRootFiles() hcl.Diagnostics {
 	return diags
 }
 
+// parseRootFile loads a root variables file and parses the variables it
+// declares.
+func (p *ParserV2) parseRootFile(file *pack.File) (map[variables.ID]*variables.Variable, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	hclBody, loadDiags := p.loadPackFile(file)
+	diags = packdiags.SafeDiagnosticsExtend(diags, loadDiags)
+
+	content, contentDiags := hclBody.Content(schema.VariableFileSchema)
+	diags = packdiags.SafeDiagnosticsExtend(diags, contentDiags)
+
+	rootVars, parseDiags := p.parseRootBodyContent(content, packDir(file))
+	diags = packdiags.SafeDiagnosticsExtend(diags, parseDiags)
+
+	// The decoder only has the parsed expressions of defaults, so their
+	// source is taken from the file for use in diagnostics.
+	for _, v := range rootVars {
+		if v.Expr != nil {
+			v.Expr.Source = string(v.Expr.Range.SliceBytes(file.Content))
+		}
+	}
+
+	return rootVars, diags
+}
+
 // parseRootBodyContent process the body of a root variables file, parsing
-// each variable block found.
-func (p *ParserV2) parseRootBodyContent(body *hcl.BodyContent) (map[variables.ID]*variables.Variable, hcl.Diagnostics) {
+// each variable block found. The defaults may read files within packDir.
+func (p *ParserV2) parseRootBodyContent(body *hcl.BodyContent, packDir string) (map[variables.ID]*variables.Variable, hcl.Diagnostics) {
 
 	packRootVars := map[variables.ID]*variables.Variable{}
 
@@ -237,7 +331,7 @@ func (p *ParserV2) parseRootBodyContent(body *hcl.BodyContent) (map[variables.ID
 	// Due to the parsing that uses variableFileSchema, it is safe to assume
 	// every block has a type "variable".
 	for _, block := range body.Blocks {
-		cfg, cfgDiags := decoder.DecodeVariableBlock(block)
+		cfg, cfgDiags := decoder.DecodeVariableBlock(block, packDir)
 		diags = packdiags.SafeDiagnosticsExtend(diags, cfgDiags)
 		if cfg != nil {
 			packRootVars[cfg.Name] = cfg
@@ -251,49 +345,88 @@ func (p *ParserV2) parseEnvVariable(name string, rawVal string) hcl.Diagnostics
 
 }
 func (p *ParserV2) parseFlagVariable(name string, rawVal string) hcl.Diagnostics {
-	return p.parseVariableImpl(name, rawVal, p.flagOverrideVars, "-var", "arguments")
-}
-
-func (p *ParserV2) parseVariableImpl(name, rawVal string, tgt variables.PackIDKeyedVarMap, typeTxt, rangeDesc string) hcl.Diagnostics {
-	if rangeDesc == "environment" {
-		name = strings.TrimPrefix(name, envloader.DefaultPrefix)
+	if path, field, ok := source.VaultRef(rawVal); ok && p.cfg.SecretReader != nil {
+		return p.parseSecretFlagVariable(name, rawVal, path, field)
 	}
 
-	// Split the name to see if we have a namespace CLI variable for a child
-	// pack and set the default packVarName.
-	splitName := strings.Split(name, ".")
-
-	// Generate a filename based on the incoming var, so we have some context for
-	// any HCL diagnostics.
+	update, diags := p.parseVariableUpdate(name, rawVal)
+	if diags.HasErrors() {
+		return diags
+	}
+	if update != nil {
+		p.flagUpdates = append(p.flagUpdates, update)
+		return nil
+	}
+	return p.parseVariableImpl(name, rawVal, p.flagOverrideVars, "-var", "arguments")
+}
 
+// overrideRange generates a range covering the value of an override, with a
+// filename based on the incoming var, so we have some context for any HCL
+// diagnostics.
+func overrideRange(name, rawVal, rangeDesc string) hcl.Range {
 	// Get a reasonable count for the lines in the provided value. You'd think
 	// these had to be flat, but naaah.
 	lines := strings.Split(rawVal, "\n")
 	lc := len(lines)
 	endCol := len(lines[lc-1])
 
-	fakeRange := hcl.Range{
+	return hcl.Range{
 		Filename: fmt.Sprintf("<value for var %s from %s>", name, rangeDesc),
 		Start:    hcl.Pos{Line: 1, Column: 1, Byte: 0},
 		End:      hcl.Pos{Line: lc, Column: endCol, Byte: len(rawVal)},
 	}
+}
 
-	var varPID pack.ID
-	var varVID variables.ID
-
+// overrideTarget splits the name of an override into the ID of the pack and
+// the variable it sets. Names containing dots set the variables of child
+// packs.
+func (p *ParserV2) overrideTarget(name string) (pack.ID, variables.ID) {
+	splitName := strings.Split(name, ".")
 	if len(splitName) > 1 {
 		// TODO: This is another part that needs to be smart about parsing into the
 		// names so we could potentially set a value inside of an object.
-		varPID = p.cfg.ParentPack.ID().Join(
+		return p.cfg.ParentPack.ID().Join(
 			pack.ID(strings.Join(splitName[0:len(splitName)-1], ".")),
-		)
-		varVID = variables.ID(splitName[len(splitName)-1])
-	} else {
-		// There are no dots in the path; it must refer to the root pack.
-		varPID = p.cfg.ParentPack.ID()
-		varVID = variables.ID(splitName[0])
+		), variables.ID(splitName[len(splitName)-1])
+	}
+
+	// There are no dots in the path; it must refer to the root pack.
+	return p.cfg.ParentPack.ID(), variables.ID(splitName[0])
+}
+
+// parseSecretFlagVariable sets the variable to the value of the field of the
+// secret at the path, referenced by the --var value rawVal in the form
+// vault:<path>#<field>. The value is converted to the type of the variable,
+// and marked as sensitive so it is redacted wherever it would otherwise be
+// shown.
+func (p *ParserV2) parseSecretFlagVariable(name, rawVal, path, field string) hcl.Diagnostics {
+	fakeRange := overrideRange(name, rawVal, "arguments")
+	varPID, varVID := p.overrideTarget(name)
+
+	existing, exists := p.rootVars[varPID][varVID]
+	if !exists {
+		return hcl.Diagnostics{packdiags.DiagMissingRootVar(name, &fakeRange)}
 	}
 
+	val, diags := readSecretValue(p.cfg.SecretReader, name, path, field, existing.Type, &fakeRange)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	p.flagOverrideVars[varPID] = append(p.flagOverrideVars[varPID], &variables.Variable{
+		Name:      varVID,
+		Type:      val.Type(),
+		Value:     val,
+		Sensitive: true,
+		DeclRange: fakeRange,
+	})
+	return nil
+}
+
+func (p *ParserV2) parseVariableImpl(name, rawVal string, tgt variables.PackIDKeyedVarMap, typeTxt, rangeDesc string) hcl.Diagnostics {
+	fakeRange := overrideRange(name, rawVal, rangeDesc)
+	varPID, varVID := p.overrideTarget(name)
+
 	// If the variable has not been configured in the root then exit. This is a
 	// standard requirement, especially because we would be unable to ensure a
 	// consistent type.
@@ -303,34 +436,79 @@ func (p *ParserV2) parseVariableImpl(name, rawVal string, tgt variables.PackIDKe
 		return hcl.Diagnostics{packdiags.DiagMissingRootVar(name, &fakeRange)}
 	}
 
-	expr, diags := hclhelp.ExpressionFromVariableDefinition(fakeRange.Filename, rawVal, existing.Type)
+	v, diags := rawOverride(existing, varVID, rawVal, fakeRange)
 	if diags.HasErrors() {
 		return diags
 	}
+	tgt[varPID] = append(tgt[varPID], v)
+	return nil
+}
+
+// rawOverride returns the override of the existing variable by the raw value
+// of an env var, flag, or variable source, which is parsed according to the
+// type of the variable. Values referring to other variables are evaluated
+// once all overrides have been merged.
+func rawOverride(existing *variables.Variable, varVID variables.ID, rawVal string, fakeRange hcl.Range) (*variables.Variable, hcl.Diagnostics) {
+	expr, diags := hclhelp.ExpressionFromVariableDefinition(fakeRange.Filename, rawVal, existing.Type)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if variables.RefersToVariables(expr) {
+		return &variables.Variable{
+			Name: varVID,
+			Expr: &variables.Expression{
+				Expr:   expr,
+				Source: rawVal,
+				Range:  fakeRange,
+			},
+			DeclRange: fakeRange,
+		}, nil
+	}
 
 	val, diags := expr.Value(nil)
 	if diags.HasErrors() {
-		return diags
+		return nil, diags
 	}
 
 	// If our stored type isn't cty.NilType then attempt to covert the override
-	// variable, so we know they are compatible.
+	// variable, so we know they are compatible. Literal values have no range
+	// of their own, so the range of the override names the variable.
 	if existing.Type != cty.NilType {
 		var err *hcl.Diagnostic
-		val, err = hclhelp.ConvertValUsingType(val, existing.Type, expr.Range().Ptr())
+		val, err = hclhelp.ConvertValUsingType(val, existing.Type, &fakeRange)
 		if err != nil {
-			return hcl.Diagnostics{err}
+			return nil, hcl.Diagnostics{err}
 		}
 	}
 
-	// We have a verified override variable.
-	v := variables.Variable{
+	return &variables.Variable{
 		Name:      varVID,
 		Type:      val.Type(),
 		Value:     val,
 		DeclRange: fakeRange,
+	}, nil
+}
+
+// sourceOverride returns the override of the existing variable by the value
+// looked up in a variable source. Strings are parsed as raw values, in the
+// same way as --var values, and other values are converted to the type of the
+// variable.
+func sourceOverride(existing *variables.Variable, varVID variables.ID, val cty.Value, fakeRange hcl.Range) (*variables.Variable, hcl.Diagnostics) {
+	if val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+		return rawOverride(existing, varVID, val.AsString(), fakeRange)
 	}
-	tgt[varPID] = append(tgt[varPID], &v)
 
-	return nil
+	if existing.Type != cty.NilType {
+		var convDiag *hcl.Diagnostic
+		if val, convDiag = hclhelp.ConvertValUsingType(val, existing.Type, &fakeRange); convDiag != nil {
+			return nil, hcl.Diagnostics{convDiag}
+		}
+	}
+	return &variables.Variable{
+		Name:      varVID,
+		Type:      val.Type(),
+		Value:     val,
+		DeclRange: fakeRange,
+	}, nil
 }
@@ -80,7 +80,6 @@ func TestParserV2_parseFlagVariable(t *testing.T) {
 		{
 			name: "non-namespaced variable",
 			inputParser: &ParserV2{
-				fs:  afero.Afero{Fs: afero.OsFs{}},
 				cfg: &config.ParserConfig{ParentPack: testpack()},
 				rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 					"example": {
@@ -111,7 +110,6 @@ func TestParserV2_parseFlagVariable(t *testing.T) {
 		{
 			name: "namespaced variable",
 			inputParser: &ParserV2{
-				fs:  afero.Afero{Fs: afero.OsFs{}},
 				cfg: &config.ParserConfig{ParentPack: testpack()},
 				rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 					"example": {
@@ -132,7 +130,6 @@ func TestParserV2_parseFlagVariable(t *testing.T) {
 		},
 		{
 			inputParser: &ParserV2{
-				fs:               afero.Afero{Fs: afero.OsFs{}},
 				cfg:              &config.ParserConfig{ParentPack: testpack()},
 				rootVars:         map[pack.ID]map[variables.ID]*variables.Variable{},
 				flagOverrideVars: make(variables.PackIDKeyedVarMap),
@@ -146,7 +143,6 @@ func TestParserV2_parseFlagVariable(t *testing.T) {
 		{
 			name: "unconvertable variable",
 			inputParser: &ParserV2{
-				fs:  afero.Afero{Fs: afero.OsFs{}},
 				cfg: &config.ParserConfig{ParentPack: testpack()},
 				rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 					"example": {
@@ -227,7 +223,6 @@ func TestParserV2_parseEnvVariable(t *testing.T) {
 			name:   "non-namespaced variable",
 			envKey: "NOMAD_PACK_VAR_region",
 			inputParser: &ParserV2{
-				fs:  afero.Afero{Fs: afero.OsFs{}},
 				cfg: &config.ParserConfig{ParentPack: testpack()},
 				rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 					"example": {
@@ -256,7 +251,6 @@ func TestParserV2_parseEnvVariable(t *testing.T) {
 		{
 			name: "namespaced variable",
 			inputParser: &ParserV2{
-				fs:  afero.Afero{Fs: afero.OsFs{}},
 				cfg: &config.ParserConfig{ParentPack: testpack()},
 				rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 					"example": {
@@ -276,7 +270,6 @@ func TestParserV2_parseEnvVariable(t *testing.T) {
 		{
 			name: "root variable absent",
 			inputParser: &ParserV2{
-				fs:               afero.Afero{Fs: afero.OsFs{}},
 				cfg:              &config.ParserConfig{ParentPack: testpack()},
 				rootVars:         map[pack.ID]map[variables.ID]*variables.Variable{},
 				flagOverrideVars: make(variables.PackIDKeyedVarMap),
@@ -291,7 +284,6 @@ func TestParserV2_parseEnvVariable(t *testing.T) {
 			envKey:   "NOMAD_PACK_VAR_example.region",
 			envValue: `{region: "dc1}`,
 			inputParser: &ParserV2{
-				fs:  afero.Afero{Fs: afero.OsFs{}},
 				cfg: &config.ParserConfig{ParentPack: testpack()},
 				rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 					"example": {
@@ -323,7 +315,7 @@ func TestParserV2_parseEnvVariable(t *testing.T) {
 			must.MapContainsKey(t, em, mapKey)
 
 			tV := em[mapKey]
-			actualErr := tc.inputParser.parseEnvVariable(getEnvKey(tc), tV)
+			actualErr := tc.inputParser.parseEnvVariable(mapKey, tV)
 
 			if tc.expectedError {
 				t.Log(actualErr.Error())
@@ -338,27 +330,21 @@ func TestParserV2_parseEnvVariable(t *testing.T) {
 }
 
 func TestParserV2_parseHeredocAtEOF(t *testing.T) {
-	inputParser := &ParserV2{
-		fs: afero.Afero{Fs: afero.OsFs{}},
-		cfg: &config.ParserConfig{
-			ParentPack:        testpack("variable_test_pack"),
-			RootVariableFiles: map[pack.ID]*pack.File{},
-		},
-		rootVars:         map[pack.ID]map[variables.ID]*variables.Variable{},
-		fileOverrideVars: make(variables.PackIDKeyedVarMap),
-	}
-
 	fixtureRoot := testfixture.AbsPath(t, "v2/variable_test")
 	p, err := loader.Load(fixtureRoot + "/variable_test")
 	must.NoError(t, err)
 	must.NotNil(t, p)
 
-	inputParser.cfg.RootVariableFiles = p.RootVariableFiles()
+	inputParser, err := NewParserV2(&config.ParserConfig{
+		ParentPack:        p,
+		RootVariableFiles: p.RootVariableFiles(),
+		FileOverrides:     []string{path.Join(fixtureRoot, "/heredoc.vars.hcl")},
+	})
+	must.NoError(t, err)
 
-	_, diags := inputParser.newParseOverridesFile(path.Join(fixtureRoot, "/heredoc.vars.hcl"))
+	pv, diags := inputParser.Parse()
 	must.False(t, diags.HasErrors(), must.Sprintf("diags: %v", diags))
-	must.Len(t, 1, inputParser.fileOverrideVars["variable_test_pack"])
-	must.Eq(t, "heredoc\n", inputParser.fileOverrideVars["variable_test_pack"][0].Value.AsString())
+	must.Eq(t, "heredoc\n", pv.v2Vars[p.ID()]["input"].Value.AsString())
 }
 
 func TestParserV2_VariableOverrides(t *testing.T) {
@@ -433,6 +419,558 @@ func TestParserV2_VariableOverrides(t *testing.T) {
 	}
 }
 
+func TestParserV2_VariableValidation(t *testing.T) {
+	rootVarFile := &pack.File{
+		Name: "variables.hcl",
+		Path: "/fake/example/variables.hcl",
+		Content: []byte(`variable "count" {
+  type    = number
+  default = 1
+  validation {
+    condition     = var.count > 0
+    error_message = "count must be greater than zero"
+  }
+  validation {
+    condition     = var.count <= 5
+    error_message = "count must be at most five"
+  }
+  validation {
+    condition     = floor(var.count) == var.count
+    error_message = "count must be a whole number"
+  }
+}`),
+	}
+
+	testcases := []struct {
+		Name   string
+		Input  string
+		Expect []string
+	}{
+		{
+			Name:  "default passes",
+			Input: "",
+		},
+		{
+			Name:  "override passes",
+			Input: "5",
+		},
+		{
+			Name:   "single failure",
+			Input:  "6",
+			Expect: []string{"count must be at most five"},
+		},
+		{
+			Name:   "multiple failures",
+			Input:  "-1.5",
+			Expect: []string{"count must be greater than zero", "count must be a whole number"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := &config.ParserConfig{
+				ParentPack:        testpack(),
+				RootVariableFiles: map[pack.ID]*pack.File{"example": rootVarFile},
+			}
+			if tc.Input != "" {
+				cfg.FlagOverrides = map[string]string{"count": tc.Input}
+			}
+
+			p, err := NewParserV2(cfg)
+			must.NoError(t, err)
+
+			_, diags := p.Parse()
+			if len(tc.Expect) == 0 {
+				must.SliceEmpty(t, diags)
+				return
+			}
+
+			must.True(t, diags.HasErrors())
+			var details []string
+			for _, diag := range diags {
+				must.Eq(t, "Invalid value for variable", diag.Summary)
+				details = append(details, diag.Detail)
+			}
+			must.Eq(t, tc.Expect, details)
+		})
+	}
+}
+
+func TestParserV2_VariableExpressions(t *testing.T) {
+	rootVarFile := &pack.File{
+		Name: "variables.hcl",
+		Path: "/fake/example/variables.hcl",
+		Content: []byte(`variable "base" {
+  type    = number
+  default = 2
+}
+variable "factor" {
+  type    = number
+  default = 3
+}
+variable "name" {
+  type    = string
+  default = "web"
+}
+variable "replicas" {
+  type    = number
+  default = var.base * var.factor
+}`),
+	}
+
+	testcases := []struct {
+		Name   string
+		Flags  map[string]string
+		File   string
+		Expect int64
+		Error  string
+	}{
+		{
+			Name:   "default expression",
+			Expect: 6,
+		},
+		{
+			Name:   "default expression with override",
+			Flags:  map[string]string{"base": "4"},
+			Expect: 12,
+		},
+		{
+			Name:   "flag expression",
+			Flags:  map[string]string{"replicas": "max(var.base, var.factor) + 1"},
+			Expect: 4,
+		},
+		{
+			Name:   "file expression",
+			File:   `replicas = var.factor * 10`,
+			Expect: 30,
+		},
+		{
+			Name:   "chained expressions",
+			Flags:  map[string]string{"base": "var.factor + 1"},
+			Expect: 12,
+		},
+		{
+			Name:  "type error",
+			Flags: map[string]string{"replicas": "var.name * 2"},
+			Error: `The expression "var.name * 2" given for variable "replicas" is invalid`,
+		},
+		{
+			Name:  "result type error",
+			File:  `replicas = "${var.name}-1"`,
+			Error: `The expression "\"${var.name}-1\"" given for variable "replicas" is invalid: the result is not compatible`,
+		},
+		{
+			Name:  "unknown variable",
+			Flags: map[string]string{"replicas": "var.missing + 1"},
+			Error: `The expression "var.missing + 1" given for variable "replicas" is invalid`,
+		},
+		{
+			Name:  "cycle",
+			Flags: map[string]string{"base": "var.replicas + 1"},
+			Error: `refers back to the variable itself`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := &config.ParserConfig{
+				ParentPack:        testpack(),
+				RootVariableFiles: map[pack.ID]*pack.File{"example": rootVarFile},
+				FlagOverrides:     tc.Flags,
+			}
+			if tc.File != "" {
+				varFile := path.Join(t.TempDir(), "overrides.hcl")
+				must.NoError(t, afero.WriteFile(afero.OsFs{}, varFile, []byte(tc.File), 0o644))
+				cfg.FileOverrides = []string{varFile}
+			}
+
+			p, err := NewParserV2(cfg)
+			must.NoError(t, err)
+
+			pv, diags := p.Parse()
+			if tc.Error != "" {
+				must.True(t, diags.HasErrors())
+				must.StrContains(t, diags.Error(), tc.Error)
+				return
+			}
+
+			must.SliceEmpty(t, diags)
+			replicas, _ := pv.v2Vars["example"]["replicas"].Value.AsBigFloat().Int64()
+			must.Eq(t, tc.Expect, replicas)
+		})
+	}
+}
+
+func TestParserV2_VariableUpdates(t *testing.T) {
+	rootVarFile := &pack.File{
+		Name: "variables.hcl",
+		Path: "/fake/example/variables.hcl",
+		Content: []byte(`variable "labels" {
+  type    = map(string)
+  default = { env = "prod" }
+}
+variable "ports" {
+  type    = list(number)
+  default = [80]
+}
+variable "app" {
+  type = object({
+    replicas = number
+    meta     = map(string)
+  })
+  default = { replicas = 1, meta = {} }
+}`),
+	}
+
+	testcases := []struct {
+		Name   string
+		Flags  map[string]string
+		File   string
+		Var    variables.ID
+		Expect cty.Value
+		Error  string
+	}{
+		{
+			Name:   "set map key",
+			Flags:  map[string]string{"labels.team": "payments"},
+			Var:    "labels",
+			Expect: cty.MapVal(map[string]cty.Value{"env": cty.StringVal("prod"), "team": cty.StringVal("payments")}),
+		},
+		{
+			Name:   "set map key over file",
+			File:   `labels = { env = "dev", tier = "web" }`,
+			Flags:  map[string]string{"labels.env": "staging"},
+			Var:    "labels",
+			Expect: cty.MapVal(map[string]cty.Value{"env": cty.StringVal("staging"), "tier": cty.StringVal("web")}),
+		},
+		{
+			Name:  "set nested object key",
+			Flags: map[string]string{"app.replicas": "3", "app.meta.owner": "platform"},
+			Var:   "app",
+			Expect: cty.ObjectVal(map[string]cty.Value{
+				"replicas": cty.NumberIntVal(3),
+				"meta":     cty.MapVal(map[string]cty.Value{"owner": cty.StringVal("platform")}),
+			}),
+		},
+		{
+			Name:   "append to list",
+			Flags:  map[string]string{"ports+": "8080"},
+			Var:    "ports",
+			Expect: cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(8080)}),
+		},
+		{
+			Name:   "append list to list over file",
+			File:   `ports = [443]`,
+			Flags:  map[string]string{"ports+": "[8080, 9090]"},
+			Var:    "ports",
+			Expect: cty.ListVal([]cty.Value{cty.NumberIntVal(443), cty.NumberIntVal(8080), cty.NumberIntVal(9090)}),
+		},
+		{
+			Name:  "map key type mismatch",
+			Flags: map[string]string{"app.replicas": "many"},
+			Error: "a number is required",
+		},
+		{
+			Name:  "unknown object attribute",
+			Flags: map[string]string{"app.missing": "1"},
+			Error: `The value given for "app.missing" can not be applied to the variable: type object has no attribute "missing".`,
+		},
+		{
+			Name:  "key of list",
+			Flags: map[string]string{"ports.first": "1"},
+			Error: "a value of type list of number has no keys",
+		},
+		{
+			Name:  "append element type mismatch",
+			Flags: map[string]string{"ports+": "http"},
+			Error: "a number is required",
+		},
+		{
+			Name:  "append to map",
+			Flags: map[string]string{"labels+": "x"},
+			Error: `variable "labels" of type map of string is not a list`,
+		},
+		{
+			Name:  "append to missing variable",
+			Flags: map[string]string{"missing+": "1"},
+			Error: `There is no variable named "missing"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := &config.ParserConfig{
+				ParentPack:        testpack(),
+				RootVariableFiles: map[pack.ID]*pack.File{"example": rootVarFile},
+				FlagOverrides:     tc.Flags,
+			}
+			if tc.File != "" {
+				varFile := path.Join(t.TempDir(), "overrides.hcl")
+				must.NoError(t, afero.WriteFile(afero.OsFs{}, varFile, []byte(tc.File), 0o644))
+				cfg.FileOverrides = []string{varFile}
+			}
+
+			p, err := NewParserV2(cfg)
+			must.NoError(t, err)
+
+			pv, diags := p.Parse()
+			if tc.Error != "" {
+				must.True(t, diags.HasErrors())
+				must.StrContains(t, diags.Error(), tc.Error)
+				return
+			}
+
+			must.SliceEmpty(t, diags)
+			got := pv.v2Vars["example"][tc.Var].Value
+			must.True(t, tc.Expect.RawEquals(got), must.Sprintf("expected %#v, got %#v", tc.Expect, got))
+		})
+	}
+}
+
+// testSource is a VariableSource of fixed values, recording the names it was
+// asked to look up.
+type testSource struct {
+	vals    map[string]cty.Value
+	err     error
+	lookups []string
+}
+
+func (s *testSource) Lookup(name string) (cty.Value, bool, error) {
+	s.lookups = append(s.lookups, name)
+	if s.err != nil {
+		return cty.NilVal, false, s.err
+	}
+	val, ok := s.vals[name]
+	return val, ok, nil
+}
+
+func TestParserV2_VariableSources(t *testing.T) {
+	rootVarFiles := map[pack.ID]*pack.File{
+		"example": {
+			Name: "variables.hcl",
+			Path: "/fake/example/variables.hcl",
+			Content: []byte(`variable "region" {
+  type    = string
+  default = "global"
+}
+variable "replicas" {
+  type    = number
+  default = 1
+}`),
+		},
+		"example.child": {
+			Name:    "variables.hcl",
+			Path:    "/fake/example/deps/child/variables.hcl",
+			Content: []byte(`variable "image" { type = string }`),
+		},
+	}
+
+	t.Run("resolves unset variables", func(t *testing.T) {
+		first := &testSource{vals: map[string]cty.Value{
+			"region":      cty.StringVal("eu-west"),
+			"child.image": cty.StringVal("redis:7"),
+		}}
+		second := &testSource{vals: map[string]cty.Value{
+			"region":   cty.StringVal("us-east"),
+			"replicas": cty.StringVal("3"),
+		}}
+
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: rootVarFiles,
+			FlagOverrides:     map[string]string{"replicas": "5"},
+			VariableSources:   []variables.VariableSource{first, second},
+		})
+		must.NoError(t, err)
+
+		pv, diags := p.Parse()
+		must.SliceEmpty(t, diags)
+
+		// The first source with a value wins, and variables set by an
+		// override are not looked up.
+		must.Eq(t, cty.StringVal("eu-west"), pv.v2Vars["example"]["region"].Value)
+		must.Eq(t, cty.StringVal("redis:7"), pv.v2Vars["example.child"]["image"].Value)
+		replicas, _ := pv.v2Vars["example"]["replicas"].Value.AsBigFloat().Int64()
+		must.Eq(t, 5, replicas)
+		must.Eq(t, []string{"region", "child.image"}, first.lookups)
+		must.SliceEmpty(t, second.lookups)
+	})
+
+	t.Run("converts to the variable type", func(t *testing.T) {
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: rootVarFiles,
+			VariableSources: []variables.VariableSource{&testSource{vals: map[string]cty.Value{
+				"replicas": cty.StringVal("many"),
+			}}},
+		})
+		must.NoError(t, err)
+
+		_, diags := p.Parse()
+		must.True(t, diags.HasErrors())
+		must.StrContains(t, diags.Error(), "a number is required")
+	})
+
+	t.Run("reports lookup errors", func(t *testing.T) {
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: rootVarFiles,
+			VariableSources:   []variables.VariableSource{&testSource{err: fmt.Errorf("connection refused")}},
+		})
+		must.NoError(t, err)
+
+		_, diags := p.Parse()
+		must.True(t, diags.HasErrors())
+		must.StrContains(t, diags.Error(), `A variable source failed to look up the value of "region": connection refused.`)
+	})
+}
+
+// testSecretReader is a SecretReader of fixed secrets, keyed by path and
+// field separated by "#".
+type testSecretReader struct {
+	secrets map[string]string
+}
+
+func (r *testSecretReader) ReadSecret(path, field string) (string, error) {
+	secret, ok := r.secrets[path+"#"+field]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in Vault", path)
+	}
+	return secret, nil
+}
+
+func TestParserV2_SecretFlagVariables(t *testing.T) {
+	rootVarFiles := map[pack.ID]*pack.File{
+		"example": {
+			Name: "variables.hcl",
+			Path: "/fake/example/variables.hcl",
+			Content: []byte(`variable "password" {
+  type = string
+}
+variable "port" {
+  type    = number
+  default = 5432
+}`),
+		},
+	}
+	reader := &testSecretReader{secrets: map[string]string{
+		"secret/data/app#password": "hunter2",
+		"secret/data/app#port":     "6432",
+	}}
+
+	t.Run("resolves secrets", func(t *testing.T) {
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: rootVarFiles,
+			FlagOverrides: map[string]string{
+				"password": "vault:secret/data/app#password",
+				"port":     "vault:secret/data/app#port",
+			},
+			SecretReader: reader,
+		})
+		must.NoError(t, err)
+
+		pv, diags := p.Parse()
+		must.SliceEmpty(t, diags)
+
+		password := pv.v2Vars["example"]["password"]
+		must.Eq(t, cty.StringVal("hunter2"), password.Value)
+		must.True(t, password.Sensitive)
+		port, _ := pv.v2Vars["example"]["port"].Value.AsBigFloat().Int64()
+		must.Eq(t, 6432, port)
+		must.True(t, pv.v2Vars["example"]["port"].Sensitive)
+	})
+
+	t.Run("uses values as given without a reader", func(t *testing.T) {
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: rootVarFiles,
+			FlagOverrides:     map[string]string{"password": "vault:secret/data/app#password"},
+		})
+		must.NoError(t, err)
+
+		pv, diags := p.Parse()
+		must.SliceEmpty(t, diags)
+		must.Eq(t, cty.StringVal("vault:secret/data/app#password"), pv.v2Vars["example"]["password"].Value)
+		must.False(t, pv.v2Vars["example"]["password"].Sensitive)
+	})
+
+	t.Run("reports read errors", func(t *testing.T) {
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: rootVarFiles,
+			FlagOverrides:     map[string]string{"password": "vault:secret/data/missing#password"},
+			SecretReader:      reader,
+		})
+		must.NoError(t, err)
+
+		_, diags := p.Parse()
+		must.True(t, diags.HasErrors())
+		must.StrContains(t, diags.Error(), `secret "secret/data/missing" not found in Vault`)
+	})
+
+	t.Run("uses values which are not references as given", func(t *testing.T) {
+		// The Vault Docker image is a common value which is not a reference.
+		for _, val := range []string{"vault:1.15.2", "vault:secret/data/app", "vault:#password"} {
+			p, err := NewParserV2(&config.ParserConfig{
+				ParentPack:        testpack(),
+				RootVariableFiles: rootVarFiles,
+				FlagOverrides:     map[string]string{"password": val},
+				SecretReader:      reader,
+			})
+			must.NoError(t, err)
+
+			pv, diags := p.Parse()
+			must.SliceEmpty(t, diags)
+			must.Eq(t, cty.StringVal(val), pv.v2Vars["example"]["password"].Value)
+			must.False(t, pv.v2Vars["example"]["password"].Sensitive)
+		}
+	})
+}
+
+func TestParsedVariables_CheckRequired(t *testing.T) {
+	rootVarFile := &pack.File{
+		Name: "variables.hcl",
+		Path: "/fake/example/variables.hcl",
+		Content: []byte(`variable "count" {
+  type    = number
+  default = 1
+}
+variable "owner" {
+  type = string
+}
+variable "optional" {
+  type    = string
+  default = null
+}`),
+	}
+
+	parse := func(t *testing.T, flags map[string]string) *ParsedVariables {
+		t.Helper()
+		p, err := NewParserV2(&config.ParserConfig{
+			ParentPack:        testpack(),
+			RootVariableFiles: map[pack.ID]*pack.File{"example": rootVarFile},
+			FlagOverrides:     flags,
+		})
+		must.NoError(t, err)
+		pv, diags := p.Parse()
+		must.SliceEmpty(t, diags)
+		return pv
+	}
+
+	// Only the variable without a default is required.
+	diags := parse(t, nil).CheckRequired("example")
+	must.Len(t, 1, diags)
+	must.Eq(t, "Missing value for required variable", diags[0].Summary)
+	must.StrContains(t, diags[0].Detail, `"owner"`)
+
+	// Setting the required variable alone keeps the other defaults.
+	pv := parse(t, map[string]string{"owner": "me"})
+	must.SliceEmpty(t, pv.CheckRequired("example"))
+	count, _ := pv.v2Vars["example"]["count"].Value.AsBigFloat().Int64()
+	must.Eq(t, 1, count)
+}
+
 type testParserV2Option func(*ParserV2)
 
 func WithEnvVar(key, value string) testParserV2Option {
@@ -456,7 +994,6 @@ func WithFileVar(key, value string) testParserV2Option {
 func NewTestInputParserV2(opts ...testParserV2Option) *ParserV2 {
 
 	p := &ParserV2{
-		fs:  afero.Afero{Fs: afero.OsFs{}},
 		cfg: &config.ParserConfig{ParentPack: testpack()},
 		rootVars: map[pack.ID]map[variables.ID]*variables.Variable{
 			"example": {
@@ -102,6 +102,9 @@ const CurrentPackKey = "_self"
 // getVars retrieves the `vars` map of the PackData at the `CurrentPackKey` key
 func (p PackTemplateContext) getVars() map[string]any { return p.getPack().vars }
 
+// Vars returns the `vars` map of the PackData at the `CurrentPackKey` key
+func (p PackTemplateContext) Vars() map[string]any { return p.getVars() }
+
 // getMetas retrieves the `meta` map of the PackData at the `CurrentPackKey` key
 func (p PackTemplateContext) getMetas() map[string]any { return p.getPack().meta }
 
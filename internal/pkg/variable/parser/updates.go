@@ -0,0 +1,247 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/internal/hclhelp"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// varUpdate is a --var override which updates part of the value of a variable
+// rather than replacing it, either by setting a key within a map or object
+// variable, given as name.key=value, or by appending to a list or set
+// variable, given as name+=value. Updates are applied once all other overrides
+// have been merged, so they update the value given by variable files.
+type varUpdate struct {
+	// name is the name given to --var, used to identify the update in
+	// diagnostics.
+	name string
+
+	packID pack.ID
+	varID  variables.ID
+
+	// keys is the path of the key to set within the variable value. It is
+	// empty when appending.
+	keys   []string
+	append bool
+
+	// typ is the declared type of the variable. Variable files replace the
+	// type of the variable with that of their value, so the declared type is
+	// kept to check the updated value against.
+	typ cty.Type
+
+	rawVal string
+	rng    hcl.Range
+}
+
+// parseVariableUpdate returns the update described by the --var name, or nil
+// if the name replaces the whole value of a variable. Names are resolved to
+// the variable of the deepest pack first, so existing pack-namespaced names
+// keep their meaning.
+func (p *ParserV2) parseVariableUpdate(name, rawVal string) (*varUpdate, hcl.Diagnostics) {
+	baseName, isAppend := strings.CutSuffix(name, "+")
+	splitName := strings.Split(baseName, ".")
+
+	for i := len(splitName) - 1; i >= 0; i-- {
+		varPID := p.cfg.ParentPack.ID()
+		if i > 0 {
+			varPID = varPID.Join(pack.ID(strings.Join(splitName[:i], ".")))
+		}
+		varVID := variables.ID(splitName[i])
+		existing, ok := p.rootVars[varPID][varVID]
+		if !ok {
+			continue
+		}
+
+		keys := splitName[i+1:]
+		if !isAppend && len(keys) == 0 {
+			return nil, nil
+		}
+
+		rng := overrideRange(name, rawVal, "arguments")
+		if isAppend && len(keys) > 0 {
+			return nil, hcl.Diagnostics{packdiags.DiagInvalidVariableUpdate(name,
+				"appending to a key within a variable is not supported", &rng)}
+		}
+		return &varUpdate{
+			name:   name,
+			packID: varPID,
+			varID:  varVID,
+			keys:   keys,
+			append: isAppend,
+			typ:    existing.Type,
+			rawVal: rawVal,
+			rng:    rng,
+		}, nil
+	}
+
+	// Names which do not refer to any variable are reported as missing by
+	// the usual override handling, except appends, whose name would not
+	// match the variable.
+	if isAppend {
+		rng := overrideRange(name, rawVal, "arguments")
+		return nil, hcl.Diagnostics{packdiags.DiagMissingRootVar(baseName, &rng)}
+	}
+	return nil, nil
+}
+
+// applyVariableUpdates applies the --var updates to the root variables, in
+// name order so the result does not depend on the order of the flags.
+func (p *ParserV2) applyVariableUpdates() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	sort.Slice(p.flagUpdates, func(i, j int) bool { return p.flagUpdates[i].name < p.flagUpdates[j].name })
+
+	for _, u := range p.flagUpdates {
+		v := p.rootVars[u.packID][u.varID]
+		if v.Expr != nil {
+			diags = diags.Append(u.diag(fmt.Sprintf("the value of variable %q is an expression", u.varID)))
+			continue
+		}
+
+		var val cty.Value
+		var updateDiags hcl.Diagnostics
+		if u.append {
+			val, updateDiags = u.appendValue(v.Value, u.typ)
+		} else {
+			val, updateDiags = u.setKey(v.Value, u.typ, u.keys)
+		}
+		if updateDiags.HasErrors() {
+			diags = diags.Extend(updateDiags)
+			continue
+		}
+
+		if isTyped(u.typ) {
+			var err *hcl.Diagnostic
+			if val, err = hclhelp.ConvertValUsingType(val, u.typ, u.rng.Ptr()); err != nil {
+				diags = diags.Append(err)
+				continue
+			}
+			v.Type = u.typ
+		}
+		v.Value = val
+	}
+	return diags
+}
+
+// setKey returns the value with the key at the path set to the update value,
+// creating any maps along the path which do not exist.
+func (u *varUpdate) setKey(val cty.Value, typ cty.Type, keys []string) (cty.Value, hcl.Diagnostics) {
+	key := keys[0]
+
+	var keyType cty.Type
+	switch {
+	case !isTyped(typ):
+		keyType = cty.NilType
+	case typ.IsMapType():
+		keyType = typ.ElementType()
+	case typ.IsObjectType():
+		if !typ.HasAttribute(key) {
+			return cty.NilVal, hcl.Diagnostics{u.diag(fmt.Sprintf("type %s has no attribute %q", typ.FriendlyName(), key))}
+		}
+		keyType = typ.AttributeType(key)
+	default:
+		return cty.NilVal, hcl.Diagnostics{u.diag(fmt.Sprintf("a value of type %s has no keys", typ.FriendlyName()))}
+	}
+
+	elems := make(map[string]cty.Value)
+	if val != cty.NilVal && !val.IsNull() {
+		if !val.Type().IsMapType() && !val.Type().IsObjectType() {
+			return cty.NilVal, hcl.Diagnostics{u.diag(fmt.Sprintf("a value of type %s has no keys", val.Type().FriendlyName()))}
+		}
+		for k, elem := range val.AsValueMap() {
+			elems[k] = elem
+		}
+	}
+
+	if len(keys) > 1 {
+		elem, diags := u.setKey(elems[key], keyType, keys[1:])
+		if diags.HasErrors() {
+			return cty.NilVal, diags
+		}
+		elems[key] = elem
+		return cty.ObjectVal(elems), nil
+	}
+
+	elem, diags := u.parseValue(keyType)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	elems[key] = elem
+	return cty.ObjectVal(elems), nil
+}
+
+// appendValue returns the list value with the update value appended. A list
+// is appended element by element, so several values can be appended at once.
+func (u *varUpdate) appendValue(val cty.Value, typ cty.Type) (cty.Value, hcl.Diagnostics) {
+	elemType := cty.NilType
+	if isTyped(typ) {
+		if !typ.IsListType() && !typ.IsSetType() {
+			return cty.NilVal, hcl.Diagnostics{u.diag(fmt.Sprintf("variable %q of type %s is not a list", u.varID, typ.FriendlyName()))}
+		}
+		elemType = typ.ElementType()
+	}
+
+	var elems []cty.Value
+	if val != cty.NilVal && !val.IsNull() {
+		t := val.Type()
+		if !t.IsListType() && !t.IsSetType() && !t.IsTupleType() {
+			return cty.NilVal, hcl.Diagnostics{u.diag(fmt.Sprintf("variable %q of type %s is not a list", u.varID, t.FriendlyName()))}
+		}
+		elems = val.AsValueSlice()
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(u.rawVal), "[") {
+		if expr, diags := hclsyntax.ParseExpression([]byte(u.rawVal), u.rng.Filename, hcl.InitialPos); !diags.HasErrors() {
+			if list, diags := expr.Value(nil); !diags.HasErrors() && (list.Type().IsTupleType() || list.Type().IsListType()) {
+				return cty.TupleVal(append(elems, list.AsValueSlice()...)), nil
+			}
+		}
+	}
+
+	elem, diags := u.parseValue(elemType)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	return cty.TupleVal(append(elems, elem)), nil
+}
+
+// parseValue parses the update value as the passed type, in the same way as
+// the values of --var overrides which replace a whole variable.
+func (u *varUpdate) parseValue(typ cty.Type) (cty.Value, hcl.Diagnostics) {
+	expr, diags := hclhelp.ExpressionFromVariableDefinition(u.rng.Filename, u.rawVal, typ)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, diags
+	}
+	if !isTyped(typ) {
+		return val, nil
+	}
+	val, err := hclhelp.ConvertValUsingType(val, typ, u.rng.Ptr())
+	if err != nil {
+		return cty.NilVal, hcl.Diagnostics{err}
+	}
+	return val, nil
+}
+
+func (u *varUpdate) diag(detail string) *hcl.Diagnostic {
+	return packdiags.DiagInvalidVariableUpdate(u.name, detail, u.rng.Ptr())
+}
+
+// isTyped reports whether the type constrains the values of a variable.
+func isTyped(typ cty.Type) bool {
+	return typ != cty.NilType && typ != cty.DynamicPseudoType
+}
@@ -9,6 +9,12 @@ const (
 	VariableAttributeType        = "type"
 	VariableAttributeDefault     = "default"
 	VariableAttributeDescription = "description"
+	VariableAttributeSensitive   = "sensitive"
+
+	VariableBlockValidation = "validation"
+
+	ValidationAttributeCondition    = "condition"
+	ValidationAttributeErrorMessage = "error_message"
 )
 
 // VariableFileSchema defines the hcl.BlockHeaderSchema for each root variable
@@ -29,5 +35,18 @@ var VariableBlockSchema = &hcl.BodySchema{
 		{Name: VariableAttributeDescription},
 		{Name: VariableAttributeDefault},
 		{Name: VariableAttributeType},
+		{Name: VariableAttributeSensitive},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: VariableBlockValidation},
+	},
+}
+
+// VariableValidationSchema defines the hcl.BodySchema for a validation block
+// within a root variable block.
+var VariableValidationSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: ValidationAttributeCondition, Required: true},
+		{Name: ValidationAttributeErrorMessage, Required: true},
 	},
 }
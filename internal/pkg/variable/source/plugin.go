@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/plugin"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+)
+
+// pluginSource is a VariableSource backed by an external executable, which
+// implements the protocol served by variables.ServeSourcePlugin.
+type pluginSource struct {
+	name string
+	path string
+}
+
+// LoadPlugin verifies the variable source plugin at the passed path lives
+// within the allowed directory, and returns the VariableSource it provides.
+func LoadPlugin(pluginPath, allowedDir string) (variables.VariableSource, error) {
+	resolved, err := plugin.Resolve(pluginPath, allowedDir)
+	if err != nil {
+		return nil, err
+	}
+	return &pluginSource{name: pluginPath, path: resolved}, nil
+}
+
+// Lookup satisfies the Lookup function of the VariableSource interface. The
+// plugin is run once for each variable looked up.
+func (s *pluginSource) Lookup(name string) (cty.Value, bool, error) {
+	out, err := plugin.Run(s.path, nil, "lookup", name)
+	if err != nil {
+		return cty.NilVal, false, fmt.Errorf("variable source plugin %s failed: %w", s.name, err)
+	}
+
+	var result variables.SourcePluginResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return cty.NilVal, false, fmt.Errorf("failed to decode result of variable source plugin %s: %w", s.name, err)
+	}
+	if !result.Found {
+		return cty.NilVal, false, nil
+	}
+	if result.Value == nil {
+		return cty.NilVal, false, fmt.Errorf("variable source plugin %s found no value", s.name)
+	}
+	return result.Value.Value, true, nil
+}
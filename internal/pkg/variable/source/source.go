@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package source provides the built-in implementations of the VariableSource
+// interface, reading the --var flags, env vars, and variable files, along
+// with sources backed by plugins and the secrets referenced by --var values.
+package source
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
+	"github.com/hashicorp/nomad-pack/internal/pkg/varfile"
+	"github.com/hashicorp/nomad-pack/internal/pkg/variable/envloader"
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+	"github.com/hashicorp/nomad-pack/sdk/pack/variables"
+)
+
+// StringSource is a VariableSource of raw values keyed by variable name, as
+// given by the --var flags and env vars.
+type StringSource map[string]string
+
+// NewFlagSource returns a VariableSource reading the values passed by --var
+// flags, keyed by variable name.
+func NewFlagSource(vars map[string]string) StringSource { return StringSource(vars) }
+
+// NewEnvSource returns a VariableSource reading the values of env vars, keyed
+// by variable name. The NOMAD_PACK_VAR_ prefix is stripped from the keys.
+func NewEnvSource(vars map[string]string) StringSource {
+	out := make(StringSource, len(vars))
+	for k, v := range vars {
+		out[strings.TrimPrefix(k, envloader.DefaultPrefix)] = v
+	}
+	return out
+}
+
+// Names returns the names of the variables the source has values for, in
+// name order.
+func (s StringSource) Names() []string { return slices.Sorted(maps.Keys(s)) }
+
+// Lookup satisfies the Lookup function of the VariableSource interface. The
+// raw values are returned as strings, which the parser converts to the type
+// of the variable, so hello and ["dc1"] are read as --var would read them.
+func (s StringSource) Lookup(name string) (cty.Value, bool, error) {
+	raw, ok := s[name]
+	if !ok {
+		return cty.NilVal, false, nil
+	}
+	return cty.StringVal(raw), true, nil
+}
+
+// FileSource is a VariableSource of the overrides decoded from variable files.
+type FileSource struct {
+	overrides []*variables.Override
+	byName    map[string]*variables.Override
+}
+
+// NewFileSource returns a VariableSource reading the variable files of the
+// root pack, as passed to --var-file. Values set by more than one file are
+// taken from the last.
+func NewFileSource(root *pack.Pack, files []string) (*FileSource, hcl.Diagnostics) {
+	out := &FileSource{byName: make(map[string]*variables.Override)}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, hcl.Diagnostics{packdiags.DiagFileNotFound(file)}
+		}
+
+		ovrds := make(variables.Overrides)
+		if _, diags := varfile.Decode(root, file, src, nil, &ovrds); diags.HasErrors() {
+			return nil, diags
+		}
+
+		for _, o := range ovrds[pack.ID(file)] {
+			name := o.Name.String()
+			if dep, ok := strings.CutPrefix(o.Path.String(), root.ID().String()+"."); ok {
+				name = dep + "." + name
+			}
+			out.overrides = append(out.overrides, o)
+			out.byName[name] = o
+		}
+	}
+	return out, nil
+}
+
+// Overrides returns the overrides decoded from the variable files, in the
+// order they were read. Unlike Lookup, the overrides keep any expression
+// referring to other variables, for the parser to evaluate.
+func (s *FileSource) Overrides() []*variables.Override { return s.overrides }
+
+// Lookup satisfies the Lookup function of the VariableSource interface.
+func (s *FileSource) Lookup(name string) (cty.Value, bool, error) {
+	o, ok := s.byName[name]
+	if !ok {
+		return cty.NilVal, false, nil
+	}
+	if o.Expr != nil {
+		return cty.NilVal, false, fmt.Errorf("value %q refers to other variables", o.Expr.Source)
+	}
+	return o.Value, true, nil
+}
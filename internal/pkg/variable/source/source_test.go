@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package source
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/shoenig/test/must"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/nomad-pack/sdk/pack"
+)
+
+func TestSource_FlagSource(t *testing.T) {
+	ci.Parallel(t)
+
+	src := NewFlagSource(map[string]string{
+		"greeting":    "hello",
+		"count":       "3",
+		"datacenters": `["dc1", "dc2"]`,
+	})
+
+	// Values are returned as given, to be parsed according to the type of
+	// the variable.
+	testCases := []struct {
+		name   string
+		expect cty.Value
+		found  bool
+	}{
+		{name: "greeting", expect: cty.StringVal("hello"), found: true},
+		{name: "count", expect: cty.StringVal("3"), found: true},
+		{name: "datacenters", expect: cty.StringVal(`["dc1", "dc2"]`), found: true},
+		{name: "missing", expect: cty.NilVal},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, found, err := src.Lookup(tc.name)
+			must.NoError(t, err)
+			must.Eq(t, tc.found, found)
+			must.True(t, tc.expect.RawEquals(val), must.Sprintf("expected %#v, got %#v", tc.expect, val))
+		})
+	}
+	must.Eq(t, []string{"count", "datacenters", "greeting"}, src.Names())
+}
+
+func TestSource_EnvSource(t *testing.T) {
+	ci.Parallel(t)
+
+	src := NewEnvSource(map[string]string{"NOMAD_PACK_VAR_region": "eu-west", "child.image": "redis"})
+
+	val, found, err := src.Lookup("region")
+	must.NoError(t, err)
+	must.True(t, found)
+	must.Eq(t, cty.StringVal("eu-west"), val)
+
+	val, found, err = src.Lookup("child.image")
+	must.NoError(t, err)
+	must.True(t, found)
+	must.Eq(t, cty.StringVal("redis"), val)
+}
+
+func TestSource_FileSource(t *testing.T) {
+	ci.Parallel(t)
+
+	root := &pack.Pack{Metadata: &pack.Metadata{Pack: &pack.MetadataPack{Name: "example"}}}
+	dir := t.TempDir()
+	first := path.Join(dir, "first.hcl")
+	second := path.Join(dir, "second.hcl")
+	refs := path.Join(dir, "refs.hcl")
+	must.NoError(t, os.WriteFile(first, []byte("region = \"eu-west\"\nchild.image = \"redis:6\"\n"), 0o644))
+	must.NoError(t, os.WriteFile(second, []byte("child.image = \"redis:7\"\n"), 0o644))
+	must.NoError(t, os.WriteFile(refs, []byte("replicas = var.base * 2\n"), 0o644))
+
+	src, diags := NewFileSource(root, []string{first, second, refs})
+	must.SliceEmpty(t, diags)
+	must.Len(t, 4, src.Overrides())
+
+	val, found, err := src.Lookup("region")
+	must.NoError(t, err)
+	must.True(t, found)
+	must.Eq(t, cty.StringVal("eu-west"), val)
+
+	// Later files take precedence.
+	val, found, err = src.Lookup("child.image")
+	must.NoError(t, err)
+	must.True(t, found)
+	must.Eq(t, cty.StringVal("redis:7"), val)
+
+	_, found, err = src.Lookup("missing")
+	must.NoError(t, err)
+	must.False(t, found)
+
+	_, _, err = src.Lookup("replicas")
+	must.ErrorContains(t, err, "refers to other variables")
+
+	_, diags = NewFileSource(root, []string{path.Join(dir, "missing.hcl")})
+	must.True(t, diags.HasErrors())
+	must.StrContains(t, diags.Error(), "could not be read")
+}
+
+const testPluginScript = `#!/bin/sh
+case "$1 $2" in
+  "lookup region") echo '{"found":true,"value":"eu-west"}' ;;
+  "lookup ports") echo '{"found":true,"value":[80,443]}' ;;
+  "lookup broken") echo "backend unavailable" >&2; exit 1 ;;
+  *) echo '{"found":false}' ;;
+esac
+`
+
+func TestSource_Plugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugin is a shell script")
+	}
+	ci.Parallel(t)
+
+	dir := t.TempDir()
+	must.NoError(t, os.WriteFile(path.Join(dir, "config-service"), []byte(testPluginScript), 0o755))
+
+	src, err := LoadPlugin("config-service", dir)
+	must.NoError(t, err)
+
+	val, found, err := src.Lookup("region")
+	must.NoError(t, err)
+	must.True(t, found)
+	must.Eq(t, cty.StringVal("eu-west"), val)
+
+	val, found, err = src.Lookup("ports")
+	must.NoError(t, err)
+	must.True(t, found)
+	must.True(t, cty.TupleVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}).RawEquals(val))
+
+	_, found, err = src.Lookup("missing")
+	must.NoError(t, err)
+	must.False(t, found)
+
+	_, _, err = src.Lookup("broken")
+	must.ErrorContains(t, err, "variable source plugin config-service failed")
+	must.ErrorContains(t, err, "backend unavailable")
+
+	_, err = LoadPlugin(path.Join(t.TempDir(), "config-service"), dir)
+	must.Error(t, err)
+}
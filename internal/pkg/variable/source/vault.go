@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// VaultPrefix prefixes the --var values which reference a Vault secret, in
+// the form vault:<path>#<field>.
+const VaultPrefix = "vault:"
+
+// SecretReader reads the fields of secrets referenced by --var values.
+type SecretReader interface {
+	// ReadSecret returns the value of the field of the secret at the path.
+	ReadSecret(path, field string) (string, error)
+}
+
+// ParseVaultRef splits a reference to a Vault secret, without VaultPrefix,
+// into the path of the secret and the name of the field.
+func ParseVaultRef(ref string) (string, string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", "", fmt.Errorf("invalid Vault reference %q, must be in the form %s<path>#<field>", VaultPrefix+ref, VaultPrefix)
+	}
+	return strings.Trim(path, "/"), field, nil
+}
+
+// VaultRef reports whether the --var value references a Vault secret, and if
+// so returns the path of the secret and the name of the field. Only values
+// which parse as vault:<path>#<field> are references, so other values which
+// happen to start with VaultPrefix, such as the vault:1.15.2 Docker image,
+// are used as given.
+func VaultRef(val string) (string, string, bool) {
+	ref, ok := strings.CutPrefix(val, VaultPrefix)
+	if !ok {
+		return "", "", false
+	}
+	path, field, err := ParseVaultRef(ref)
+	if err != nil {
+		return "", "", false
+	}
+	return path, field, true
+}
+
+// vaultReader is the SecretReader reading secrets from Vault. The client is
+// only created when the first secret is read, so commands which do not
+// reference secrets do not require Vault to be configured.
+type vaultReader struct {
+	cfg *vault.Config
+
+	once   sync.Once
+	client *vault.Client
+	err    error
+}
+
+// NewVaultReader returns a SecretReader reading secrets from Vault, using the
+// standard Vault client configuration, such as VAULT_ADDR and VAULT_TOKEN.
+func NewVaultReader() SecretReader { return &vaultReader{} }
+
+// ReadSecret satisfies the ReadSecret function of the SecretReader interface.
+// The fields of secrets in version 2 of the KV secrets engine are read from
+// within the data of the secret.
+func (r *vaultReader) ReadSecret(path, field string) (string, error) {
+	r.once.Do(func() {
+		cfg := r.cfg
+		if cfg == nil {
+			cfg = vault.DefaultConfig()
+			if cfg.Error != nil {
+				r.err = fmt.Errorf("failed to configure Vault client: %w", cfg.Error)
+				return
+			}
+		}
+		if r.client, r.err = vault.NewClient(cfg); r.err != nil {
+			r.err = fmt.Errorf("failed to create Vault client: %w", r.err)
+			return
+		}
+		if r.client.Token() == "" {
+			r.err = errors.New("no Vault token is set, set VAULT_TOKEN to read secrets from Vault")
+		}
+	})
+	if r.err != nil {
+		return "", r.err
+	}
+
+	secret, err := r.client.Logical().Read(path)
+	if err != nil {
+		var respErr *vault.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusForbidden {
+			return "", fmt.Errorf("permission denied reading Vault secret %q, check the Vault token is valid and its policies allow reading the secret", path)
+		}
+		return "", fmt.Errorf("failed to read Vault secret %q: %w", path, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("secret %q not found in Vault", path)
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok := data[field]; !ok {
+			data = nested
+		}
+	}
+	val, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("secret %q in Vault has no field %q", path, field)
+	}
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	out, err := json.Marshal(val)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field %q of Vault secret %q: %w", field, path, err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package source
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/shoenig/test/must"
+)
+
+func TestSource_ParseVaultRef(t *testing.T) {
+	path, field, err := ParseVaultRef("secret/data/app#password")
+	must.NoError(t, err)
+	must.Eq(t, "secret/data/app", path)
+	must.Eq(t, "password", field)
+
+	for _, ref := range []string{"secret/data/app", "#password", "secret/data/app#"} {
+		_, _, err = ParseVaultRef(ref)
+		must.ErrorContains(t, err, "must be in the form vault:<path>#<field>")
+	}
+}
+
+func TestSource_VaultRef(t *testing.T) {
+	path, field, ok := VaultRef("vault:secret/data/app#password")
+	must.True(t, ok)
+	must.Eq(t, "secret/data/app", path)
+	must.Eq(t, "password", field)
+
+	for _, val := range []string{"vault:1.15.2", "vault:secret/data/app", "secret/data/app#password", ""} {
+		_, _, ok = VaultRef(val)
+		must.False(t, ok, must.Sprintf("value %q", val))
+	}
+}
+
+func TestSource_VaultReader(t *testing.T) {
+	// The token is read from the environment, as with the Vault CLI.
+	t.Setenv("VAULT_TOKEN", "test-token")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"hunter2","port":5432},"metadata":{"version":1}}}`))
+		case "/v1/kv/app":
+			_, _ = w.Write([]byte(`{"data":{"password":"hunter3"}}`))
+		case "/v1/secret/data/denied":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := vault.DefaultConfig()
+	cfg.Address = srv.URL
+	r := &vaultReader{cfg: cfg}
+
+	val, err := r.ReadSecret("secret/data/app", "password")
+	must.NoError(t, err)
+	must.Eq(t, "hunter2", val)
+
+	val, err = r.ReadSecret("secret/data/app", "port")
+	must.NoError(t, err)
+	must.Eq(t, "5432", val)
+
+	val, err = r.ReadSecret("kv/app", "password")
+	must.NoError(t, err)
+	must.Eq(t, "hunter3", val)
+
+	_, err = r.ReadSecret("secret/data/app", "username")
+	must.EqError(t, err, `secret "secret/data/app" in Vault has no field "username"`)
+
+	_, err = r.ReadSecret("secret/data/missing", "password")
+	must.EqError(t, err, `secret "secret/data/missing" not found in Vault`)
+
+	_, err = r.ReadSecret("secret/data/denied", "password")
+	must.ErrorContains(t, err, `permission denied reading Vault secret "secret/data/denied"`)
+
+	t.Setenv("VAULT_TOKEN", "")
+	_, err = (&vaultReader{cfg: cfg}).ReadSecret("secret/data/app", "password")
+	must.ErrorContains(t, err, "no Vault token is set")
+}
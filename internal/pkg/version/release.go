@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+)
+
+// DefaultReleaseURL is the endpoint queried for the latest release of Nomad
+// Pack. It returns a JSON object whose version field is the latest version.
+const DefaultReleaseURL = "https://api.releases.hashicorp.com/v1/releases/nomad-pack/latest"
+
+// release is the subset of the release endpoint response which is used.
+type release struct {
+	Version string `json:"version"`
+}
+
+// LatestRelease queries the release endpoint at url for the version of the
+// latest release.
+func LatestRelease(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create release request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to query latest release: unexpected status %q", resp.Status)
+	}
+
+	var r release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("failed to decode latest release: %w", err)
+	}
+	if r.Version == "" {
+		return "", fmt.Errorf("latest release does not include a version")
+	}
+	return strings.TrimPrefix(r.Version, "v"), nil
+}
+
+// IsOutdated reports whether the version of this binary is older than the
+// passed latest version. Prereleases are older than the release of the same
+// version.
+func IsOutdated(latest string) (bool, error) {
+	current := Version
+	if Prerelease != "" && !strings.HasSuffix(current, "-"+Prerelease) {
+		current += "-" + Prerelease
+	}
+
+	currentVersion, err := goversion.NewVersion(current)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse version %q: %w", current, err)
+	}
+	latestVersion, err := goversion.NewVersion(latest)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse latest version %q: %w", latest, err)
+	}
+	return currentVersion.LessThan(latestVersion), nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package version
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestLatestRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest":
+			_, _ = w.Write([]byte(`{"name": "nomad-pack", "version": "v0.3.0"}`))
+		case "/empty":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	latest, err := LatestRelease(context.Background(), srv.URL+"/latest")
+	must.NoError(t, err)
+	must.Eq(t, "0.3.0", latest)
+
+	_, err = LatestRelease(context.Background(), srv.URL+"/empty")
+	must.ErrorContains(t, err, "does not include a version")
+
+	_, err = LatestRelease(context.Background(), srv.URL+"/missing")
+	must.ErrorContains(t, err, "unexpected status")
+}
+
+func TestIsOutdated(t *testing.T) {
+	oldVersion, oldPrerelease := Version, Prerelease
+	t.Cleanup(func() { Version, Prerelease = oldVersion, oldPrerelease })
+
+	testCases := []struct {
+		version    string
+		prerelease string
+		latest     string
+		outdated   bool
+	}{
+		{version: "0.2.1", latest: "0.3.0", outdated: true},
+		{version: "0.3.0", latest: "0.3.0"},
+		{version: "0.4.0", latest: "0.3.0"},
+		{version: "0.3.0", prerelease: "dev", latest: "0.3.0", outdated: true},
+		{version: "0.3.1", prerelease: "dev", latest: "0.3.0"},
+	}
+	for _, tc := range testCases {
+		Version, Prerelease = tc.version, tc.prerelease
+		outdated, err := IsOutdated(tc.latest)
+		must.NoError(t, err)
+		must.Eq(t, tc.outdated, outdated, must.Sprintf("version %s-%s against %s", tc.version, tc.prerelease, tc.latest))
+	}
+
+	_, err := IsOutdated("not-a-version")
+	must.ErrorContains(t, err, "failed to parse latest version")
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+)
+
+// CheckACLCapabilities checks the Nomad ACL token has each capability the pack
+// declares it requires, in the namespace of every parsed job. The policies of
+// the token and its roles are read and evaluated locally, so a missing
+// capability is reported before any job is submitted rather than as a
+// permission denied error part way through the deployment. Clusters without
+// ACLs enabled, and management tokens, pass the check.
+func (r *Runner) CheckACLCapabilities(errCtx *errors.UIErrorContext) []*errors.WrappedUIContext {
+	if len(r.runnerCfg.ACLCapabilities) == 0 {
+		return nil
+	}
+
+	if len(r.parsedTemplates) < 1 {
+		return []*errors.WrappedUIContext{newNoParsedTemplatesError(validationSubjACLEval, errCtx)}
+	}
+
+	tokenACL, err := r.tokenACL()
+	if err != nil {
+		return []*errors.WrappedUIContext{{
+			Err:     err,
+			Subject: validationSubjACLEval,
+			Context: errCtx,
+		}}
+	}
+	if tokenACL == nil {
+		return nil
+	}
+
+	// Collect the missing capabilities of each namespace, so jobs deployed
+	// to the same namespace are reported once.
+	missing := make(map[string][]string)
+	for _, parsedJob := range r.parsedTemplates {
+		ns := api.DefaultNamespace
+		if parsedJob.Job().Namespace != nil && *parsedJob.Job().Namespace != "" {
+			ns = *parsedJob.Job().Namespace
+		}
+		if _, ok := missing[ns]; ok {
+			continue
+		}
+		missing[ns] = nil
+		for _, capability := range r.runnerCfg.ACLCapabilities {
+			if !tokenACL.AllowNamespaceOperation(ns, capability) {
+				missing[ns] = append(missing[ns], capability)
+			}
+		}
+	}
+
+	var lines []string
+	for _, ns := range slices.Sorted(maps.Keys(missing)) {
+		if len(missing[ns]) > 0 {
+			lines = append(lines, fmt.Sprintf("namespace %q: %s", ns, strings.Join(missing[ns], ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []*errors.WrappedUIContext{{
+		Err:     fmt.Errorf("the ACL token is missing capabilities required by the pack:\n  * %s", strings.Join(lines, "\n  * ")),
+		Subject: validationSubjACL,
+		Context: errCtx,
+	}}
+}
+
+// tokenACL returns the ACL of the token used by the client, built from the
+// policies attached to the token and its roles. A nil ACL is returned when the
+// cluster does not have ACLs enabled or the token is a management token.
+func (r *Runner) tokenACL() (*acl.ACL, error) {
+	token, _, err := r.client.ACLTokens().Self(nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "ACL support disabled") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read ACL token: %w", err)
+	}
+	if token.Type == "management" {
+		return nil, nil
+	}
+
+	policyNames := slices.Clone(token.Policies)
+	for _, roleLink := range token.Roles {
+		role, _, err := r.client.ACLRoles().Get(roleLink.ID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACL role %q: %w", roleLink.Name, err)
+		}
+		for _, policyLink := range role.Policies {
+			policyNames = append(policyNames, policyLink.Name)
+		}
+	}
+	slices.Sort(policyNames)
+	policyNames = slices.Compact(policyNames)
+
+	policies := make([]*acl.Policy, 0, len(policyNames))
+	for _, name := range policyNames {
+		policy, _, err := r.client.ACLPolicies().Info(name, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACL policy %q: %w", name, err)
+		}
+		parsed, err := acl.Parse(policy.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ACL policy %q: %w", name, err)
+		}
+		policies = append(policies, parsed)
+	}
+
+	return acl.NewACL(false, policies)
+}
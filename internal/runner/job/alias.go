@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// dnsLabelRegex matches names which are valid DNS labels as described in
+// RFC 1123, so aliased jobs can be addressed by service discovery.
+var dnsLabelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// AliasedName returns the name prefixed by the alias, or the name unchanged if
+// the alias is empty.
+func AliasedName(alias, name string) string {
+	if alias == "" {
+		return name
+	}
+	return alias + "-" + name
+}
+
+// ValidateAlias returns an error if the alias cannot prefix DNS-safe names.
+func ValidateAlias(alias string) error {
+	if !dnsLabelRegex.MatchString(alias) {
+		return fmt.Errorf("alias %q must only contain lowercase letters, digits, and hyphens, and start and end with a letter or digit", alias)
+	}
+	return nil
+}
+
+// ApplyAlias prefixes the ID and name of the job with the alias, erroring if
+// the resulting job name is not DNS-safe.
+func ApplyAlias(job *api.Job, alias string) error {
+	id := AliasedName(alias, *job.ID)
+	if !dnsLabelRegex.MatchString(id) {
+		return fmt.Errorf("aliased job name %q is not DNS-safe, it must be at most 63 lowercase letters, digits, and hyphens, and start and end with a letter or digit", id)
+	}
+	job.ID = &id
+
+	name := id
+	if job.Name != nil {
+		name = AliasedName(alias, *job.Name)
+	}
+	job.Name = &name
+	return nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/shoenig/test/must"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
+)
+
+func TestAliasedName(t *testing.T) {
+	must.Eq(t, "web", AliasedName("", "web"))
+	must.Eq(t, "tenant-a-web", AliasedName("tenant-a", "web"))
+}
+
+func TestValidateAlias(t *testing.T) {
+	for _, alias := range []string{"a", "tenant-a", "t1"} {
+		must.NoError(t, ValidateAlias(alias), must.Sprint(alias))
+	}
+	for _, alias := range []string{"", "Tenant", "tenant_a", "-a", "a-", "a.b", strings.Repeat("a", 64)} {
+		must.Error(t, ValidateAlias(alias), must.Sprint(alias))
+	}
+}
+
+func TestApplyAlias(t *testing.T) {
+	job := &api.Job{ID: pointer.Of("web"), Name: pointer.Of("web")}
+	must.NoError(t, ApplyAlias(job, "tenant-a"))
+	must.Eq(t, "tenant-a-web", *job.ID)
+	must.Eq(t, "tenant-a-web", *job.Name)
+
+	err := ApplyAlias(&api.Job{ID: pointer.Of("simple_raw_exec"), Name: pointer.Of("simple_raw_exec")}, "tenant-a")
+	must.ErrorContains(t, err, `aliased job name "tenant-a-simple_raw_exec" is not DNS-safe`)
+
+	err = ApplyAlias(&api.Job{ID: pointer.Of(strings.Repeat("a", 60))}, "tenant-a")
+	must.ErrorContains(t, err, "is not DNS-safe")
+}
@@ -3,6 +3,11 @@
 
 package job
 
+import (
+	"regexp"
+	"time"
+)
+
 // CLIConfig contains all possible configurations required by the Nomad Pack
 // CLI in order to render, plan, run, and destroy job templates.
 type CLIConfig struct {
@@ -21,12 +26,89 @@ type RunCLIConfig struct {
 	EnableRollback  bool
 	PreserveCounts  bool
 	PolicyOverride  bool
+
+	// OnlyChanged skips the registration of jobs whose planned diff against
+	// the currently deployed version shows no changes.
+	OnlyChanged bool
+
+	// Detach avoids waiting for the evaluation created by registering each
+	// job to complete.
+	Detach bool
+
+	// WaitTimeout is how long to wait for the evaluation of each job to
+	// complete when not detached. Zero waits until the command is
+	// interrupted.
+	WaitTimeout time.Duration
+
+	// NoSource avoids attaching the rendered template to each job as its
+	// submission source, which is otherwise shown by the Nomad UI.
+	NoSource bool
+
+	// PolicyDir is the directory of OPA policies each job is evaluated
+	// against before it is registered. Jobs denied by a policy are not
+	// registered, unless PolicyWarnOnly is set.
+	PolicyDir string
+
+	// PolicyWarnOnly outputs the policy denies as warnings rather than
+	// blocking the run.
+	PolicyWarnOnly bool
+
+	// FollowLogs streams the logs of the tasks of each job once deployed,
+	// until interrupted.
+	FollowLogs bool
+
+	// Priority overrides the scheduling priority of each job when set to a
+	// value other than zero.
+	Priority int
+
+	// PatchFile is the path of a JSON Patch document applied to each job
+	// before it is submitted.
+	PatchFile string
+
+	// CheckIndexSet is true when CheckIndex was passed, as a CheckIndex of
+	// zero then requires that the job does not yet exist.
+	CheckIndexSet bool
+
+	// CheckCurrentIndex registers each job only if its job modify index is
+	// unchanged from when the pack was rendered, so jobs updated by another
+	// deployment in the meantime are not overwritten.
+	CheckCurrentIndex bool
 }
 
+// The output formats supported by the Nomad Pack plan command.
+const (
+	PlanFormatDiff  = "diff"
+	PlanFormatPatch = "patch"
+	PlanFormatJSON  = "json"
+)
+
 // PlanCLIConfig specifies the configuration that is used by the Nomad Pack
 // plan command.
 type PlanCLIConfig struct {
 	PolicyOverride bool
 	Verbose        bool
 	Diff           bool
+
+	// IgnoreWarnings is the list of patterns used to filter job warnings
+	// returned by the Nomad plan endpoint. Any warning which matches one of
+	// the patterns is not displayed, and does not affect the exit code.
+	IgnoreWarnings []*regexp.Regexp
+
+	// Format is the output format of the plan. PlanFormatPatch replaces the
+	// diff and scheduler output with a JSON Patch from the deployed job to
+	// the planned job. PlanFormatJSON replaces them with a summary of each
+	// job's changes, including the resources it requests.
+	Format string
+
+	// DiffIgnore is the list of paths to fields which are removed from both
+	// the deployed and planned job before they are compared by the patch and
+	// json formats, in addition to the fields managed by the Nomad servers.
+	// The diff format is not filtered, as its diff is computed by the servers.
+	DiffIgnore []DiffIgnorePath
+}
+
+// MachineReadable reports whether the plan output format is intended to be
+// read by other tools, in which case no other output is written.
+func (c *PlanCLIConfig) MachineReadable() bool {
+	return c.Format == PlanFormatPatch || c.Format == PlanFormatJSON
 }
@@ -20,10 +20,12 @@ func (r *Runner) CheckForConflicts(errCtx *errors.UIErrorContext) []*errors.Wrap
 	}
 
 	for tplName, jobSpec := range r.parsedTemplates {
-		if err := r.checkForConflict(jobSpec.GetName()); err != nil {
+		modifyIndex, err := r.checkForConflict(jobSpec)
+		if err != nil {
 			outputErrors = append(outputErrors, newValidationDeployerError(err, validationSubjConflict, tplName))
 			continue
 		}
+		r.modifyIndexes[tplName] = modifyIndex
 	}
 
 	if len(outputErrors) > 0 {
@@ -33,24 +35,26 @@ func (r *Runner) CheckForConflicts(errCtx *errors.UIErrorContext) []*errors.Wrap
 }
 
 // checkForConflict performs a lookup against Nomad, to check whether the
-// supplied job is found. If the job is found, we confirm if it belongs to this
-// Nomad Pack deployment. In the event it doesn't this will result in an error.
-func (r *Runner) checkForConflict(jobName string) error {
-	existing, _, err := r.client.Jobs().Info(jobName, &api.QueryOptions{})
+// supplied job is found in its namespace. If the job is found, we confirm if
+// it belongs to this Nomad Pack deployment. In the event it doesn't this will
+// result in an error. The job modify index of the existing job is returned,
+// or zero if there is none.
+func (r *Runner) checkForConflict(jobSpec ParsedTemplate) (uint64, error) {
+	existing, _, err := r.client.Jobs().Info(jobSpec.GetName(), r.newQueryOptsFromJob(jobSpec))
 	if err != nil && !errIsNotFound(err) {
-		return err
+		return 0, err
 	}
 
 	// If no existing job, no possible error condition.
 	if existing == nil {
-		return nil
+		return 0, nil
 	}
 
 	// if there is a job with this name, that has no meta, it was
 	// created by something other than the package manager and this
 	// process should fail.
 	if existing.Meta == nil {
-		return ErrExistsNonPack{*existing.ID}
+		return 0, ErrExistsNonPack{*existing.ID}
 	}
 
 	meta := existing.Meta
@@ -60,16 +64,16 @@ func (r *Runner) checkForConflict(jobName string) error {
 	// process should abort.
 	existingDeploymentName, ok := meta[PackDeploymentNameKey]
 	if !ok {
-		return ErrExistsNonPack{*existing.ID}
+		return 0, ErrExistsNonPack{*existing.ID}
 	}
 
 	// If there is a job with this ID, and a different deployment name, this
 	// process should abort.
 	if existingDeploymentName != r.runnerCfg.DeploymentName {
-		return ErrExistsInDeployment{*existing.ID, existingDeploymentName}
+		return 0, ErrExistsInDeployment{*existing.ID, existingDeploymentName}
 	}
 
-	return nil
+	return *existing.JobModifyIndex, nil
 }
 
 type ErrExistsNonPack struct {
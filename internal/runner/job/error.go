@@ -11,14 +11,26 @@ import (
 )
 
 const (
-	validationSubjParseFailed = "failed to parse job specification"
-	validationSubjConflict    = "failed job conflict validation"
+	validationSubjParseFailed  = "failed to parse job specification"
+	validationSubjConflict     = "failed job conflict validation"
+	validationSubjAlias        = "failed to alias job"
+	validationSubjDatacenters  = "failed datacenters validation"
+	validationSubjPolicy       = "failed policy check"
+	validationSubjPolicyEval   = "failed to check policies"
+	validationSubjACL          = "failed ACL capability check"
+	validationSubjACLEval      = "failed to check ACL capabilities"
+	validationSubjPatch        = "failed to patch job"
+	validationSubjDependencies = "failed to order jobs by dependencies"
 )
 
 var (
 	// enforceIndexRegex is a regular expression which extracts the enforcement
 	// error.
 	enforceIndexRegex = regexp.MustCompile(`\((Enforcing job modify index.*)\)`)
+
+	// currentIndexRegex is a regular expression which extracts the current
+	// job modify index from the enforcement error.
+	currentIndexRegex = regexp.MustCompile(`conflicting job modify index: (\d+)`)
 )
 
 // newValidationDeployerError is a small helper to create a
@@ -62,8 +74,20 @@ func generateRegisterError(err error, errCtx *errors.UIErrorContext, jobName str
 		matches := enforceIndexRegex.FindStringSubmatch(err.Error())
 		if len(matches) == 2 {
 			deployErr.Subject = "failed to register job due to check index failure"
+			deployErr.Err = errors.New(matches[1] +
+				"; the job has changed since the pack was rendered, render and plan the pack again before running it")
+
+			if current := currentIndexRegex.FindStringSubmatch(matches[1]); len(current) == 2 {
+				registerErr.Add("Current Job Modify Index: ", current[1])
+			}
 		}
 	}
 
 	return &deployErr
 }
+
+// isSubmissionError reports whether the Nomad registration API error was
+// caused by the job submission source, rather than the job itself.
+func isSubmissionError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "submission")
+}
@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DiffIgnorePath is a JSONPath to fields of the JSON representation of a job
+// which are removed from both the deployed and planned job before they are
+// compared. Paths are made of member names separated by dots, and array
+// indexes in brackets, such as $.TaskGroups[0].Meta.deployed_at. The
+// wildcard * matches every member of an object or element of an array.
+type DiffIgnorePath struct {
+	raw      string
+	segments []pathSegment
+}
+
+type pathSegment struct {
+	// name is the object member matched by the segment. It is empty for
+	// segments matching array elements.
+	name string
+
+	// index is the array element matched by the segment, or -1 to match every
+	// element.
+	index int
+	array bool
+}
+
+func (s pathSegment) matchesMember(name string) bool {
+	return !s.array && (s.name == "*" || s.name == name)
+}
+
+func (s pathSegment) matchesElement(i int) bool {
+	return s.array && (s.index == -1 || s.index == i)
+}
+
+// ParseDiffIgnorePath parses the JSONPath, which may omit the leading $.
+func ParseDiffIgnorePath(path string) (DiffIgnorePath, error) {
+	out := DiffIgnorePath{raw: path}
+
+	rest := strings.TrimPrefix(path, "$")
+	if rest != path && rest != "" && rest[0] != '.' && rest[0] != '[' {
+		return out, fmt.Errorf("invalid diff ignore path %q, expected a member or index after $", path)
+	}
+	rest = strings.TrimPrefix(rest, ".")
+
+	for rest != "" {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return out, fmt.Errorf("invalid diff ignore path %q, missing closing ]", path)
+			}
+			seg := pathSegment{array: true, index: -1}
+			if token := rest[1:end]; token != "*" {
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 {
+					return out, fmt.Errorf("invalid diff ignore path %q, %q is not an array index or *", path, token)
+				}
+				seg.index = i
+			}
+			out.segments = append(out.segments, seg)
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return out, fmt.Errorf("invalid diff ignore path %q, member names can not be empty", path)
+			}
+			out.segments = append(out.segments, pathSegment{name: rest[:end]})
+			rest = rest[end:]
+		}
+
+		// Members are separated by dots, while indexes follow directly.
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return out, fmt.Errorf("invalid diff ignore path %q, member names can not be empty", path)
+			}
+		} else if rest != "" && rest[0] != '[' {
+			return out, fmt.Errorf("invalid diff ignore path %q", path)
+		}
+	}
+
+	if len(out.segments) == 0 {
+		return out, fmt.Errorf("invalid diff ignore path %q, the whole job can not be ignored", path)
+	}
+	return out, nil
+}
+
+// String returns the path as it was passed to ParseDiffIgnorePath.
+func (p DiffIgnorePath) String() string { return p.raw }
+
+// strip removes every value matched by the path from the decoded JSON
+// document, returning the resulting document. Paths which match nothing leave
+// the document unchanged.
+func (p DiffIgnorePath) strip(doc any) any {
+	return stripSegments(doc, p.segments)
+}
+
+func stripSegments(doc any, segments []pathSegment) any {
+	seg, last := segments[0], len(segments) == 1
+
+	switch node := doc.(type) {
+	case map[string]any:
+		for name, elem := range node {
+			if !seg.matchesMember(name) {
+				continue
+			}
+			if last {
+				delete(node, name)
+			} else {
+				node[name] = stripSegments(elem, segments[1:])
+			}
+		}
+
+	case []any:
+		if last {
+			kept := node[:0]
+			for i, elem := range node {
+				if !seg.matchesElement(i) {
+					kept = append(kept, elem)
+				}
+			}
+			return kept
+		}
+		for i, elem := range node {
+			if seg.matchesElement(i) {
+				node[i] = stripSegments(elem, segments[1:])
+			}
+		}
+	}
+	return doc
+}
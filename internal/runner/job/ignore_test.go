@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestParseDiffIgnorePath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected []pathSegment
+		err      string
+	}{
+		{
+			path:     "$.Meta.deployed_at",
+			expected: []pathSegment{{name: "Meta"}, {name: "deployed_at"}},
+		},
+		{
+			path:     "Meta",
+			expected: []pathSegment{{name: "Meta"}},
+		},
+		{
+			path: "$.TaskGroups[*].Tasks[1].*",
+			expected: []pathSegment{
+				{name: "TaskGroups"}, {array: true, index: -1},
+				{name: "Tasks"}, {array: true, index: 1},
+				{name: "*"},
+			},
+		},
+		{path: "$", err: "the whole job can not be ignored"},
+		{path: "", err: "the whole job can not be ignored"},
+		{path: "$Meta", err: "expected a member or index after $"},
+		{path: "$.Meta..a", err: "member names can not be empty"},
+		{path: "$.Meta.", err: "member names can not be empty"},
+		{path: "$.TaskGroups[0", err: "missing closing ]"},
+		{path: "$.TaskGroups[a]", err: `"a" is not an array index or *`},
+		{path: "$.TaskGroups[0]Name", err: "invalid diff ignore path"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			path, err := ParseDiffIgnorePath(tc.path)
+			if tc.err != "" {
+				must.ErrorContains(t, err, tc.err)
+				return
+			}
+			must.NoError(t, err)
+			must.Eq(t, tc.expected, path.segments)
+			must.Eq(t, tc.path, path.String())
+		})
+	}
+}
+
+func TestDiffIgnorePath_strip(t *testing.T) {
+	testCases := []struct {
+		path     string
+		doc      string
+		expected string
+	}{
+		{
+			path:     "$.Meta.deployed_at",
+			doc:      `{"Meta":{"deployed_at":"now","team":"a"}}`,
+			expected: `{"Meta":{"team":"a"}}`,
+		},
+		{
+			path:     "$.TaskGroups[*].Meta",
+			doc:      `{"TaskGroups":[{"Name":"a","Meta":{}},{"Name":"b"}]}`,
+			expected: `{"TaskGroups":[{"Name":"a"},{"Name":"b"}]}`,
+		},
+		{
+			path:     "$.TaskGroups[1].*",
+			doc:      `{"TaskGroups":[{"Name":"a"},{"Name":"b","Count":2}]}`,
+			expected: `{"TaskGroups":[{"Name":"a"},{}]}`,
+		},
+		{
+			path:     "$.Datacenters[0]",
+			doc:      `{"Datacenters":["a","b"]}`,
+			expected: `{"Datacenters":["b"]}`,
+		},
+		{
+			path:     "$.Missing.Field",
+			doc:      `{"Meta":{"a":"b"}}`,
+			expected: `{"Meta":{"a":"b"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			path, err := ParseDiffIgnorePath(tc.path)
+			must.NoError(t, err)
+
+			var doc any
+			must.NoError(t, json.Unmarshal([]byte(tc.doc), &doc))
+			out, err := json.Marshal(path.strip(doc))
+			must.NoError(t, err)
+			must.Eq(t, tc.expected, string(out))
+		})
+	}
+}
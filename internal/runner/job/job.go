@@ -4,12 +4,19 @@
 package job
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
-	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/nomad/api"
+	"github.com/zclconf/go-cty/cty"
 
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
@@ -35,6 +42,15 @@ type Runner struct {
 	// deployedJobs tracks the jobs that have successfully been deployed to
 	// Nomad so that in the event of a failure, we can attempt to rollback.
 	deployedJobs []ParsedTemplate
+
+	// modifyIndexes holds the job modify index of each job, keyed by
+	// template name, as found when checking for conflicts. Jobs which do
+	// not yet exist have an index of zero.
+	modifyIndexes map[string]uint64
+
+	// deployOrder holds the names of the parsed templates in the order their
+	// jobs are deployed, so that each job follows the jobs it depends on.
+	deployOrder []string
 }
 
 type ParsedTemplate struct {
@@ -69,6 +85,7 @@ func NewDeployer(client *api.Client, cfg *CLIConfig) runner.Runner {
 		cfg:             cfg,
 		rawTemplates:    make(map[string]string),
 		parsedTemplates: make(map[string]ParsedTemplate),
+		modifyIndexes:   make(map[string]uint64),
 	}
 }
 
@@ -85,6 +102,10 @@ func (r *Runner) CanonicalizeTemplates() []*errors.WrappedUIContext {
 	for _, jobSpec := range r.parsedTemplates {
 		r.handleConsulAndVault(jobSpec.Job())
 		r.setJobMeta(jobSpec.Job())
+
+		if r.cfg.RunConfig.Priority != 0 {
+			jobSpec.Job().Priority = pointer.Of(r.cfg.RunConfig.Priority)
+		}
 	}
 
 	return nil
@@ -98,31 +119,88 @@ func (r *Runner) ParsedTemplates() any { return r.parsedTemplates }
 func (r *Runner) Name() string { return "job" }
 
 // Deploy satisfies the Deploy function of the runner.Runner interface.
-func (r *Runner) Deploy(ui terminal.UI, errorContext *errors.UIErrorContext) *errors.WrappedUIContext {
+func (r *Runner) Deploy(ctx context.Context, ui terminal.UI, errorContext *errors.UIErrorContext) *errors.WrappedUIContext {
 
-	for tplName, jobSpec := range r.parsedTemplates {
+	for _, tplName := range r.deployOrder {
+		jobSpec := r.parsedTemplates[tplName]
 
 		// tplErrorContext forms the basis for error output context as is
 		// appended to when new information becomes available.
 		tplErrorContext := errorContext.Copy()
 		tplErrorContext.Add(errors.UIContextPrefixTemplateName, tplName)
 
-		// submit the source of the job to Nomad, too
-		submission := &api.JobSubmission{
-			Source: r.rawTemplates[tplName],
-			Format: "hcl2",
+		// If the user only wants to submit changed jobs, plan the job first
+		// and skip it if the diff shows no changes.
+		if r.cfg.RunConfig.OnlyChanged {
+			changed, err := r.hasChanges(jobSpec)
+			if err != nil {
+				r.rollback(ui)
+				return &errors.WrappedUIContext{
+					Err:     err,
+					Subject: "failed to determine job changes",
+					Context: tplErrorContext,
+				}
+			}
+			if !changed {
+				ui.Info(fmt.Sprintf("Job '%s' in pack deployment '%s' unchanged, skipping",
+					*jobSpec.Job().ID, r.runnerCfg.DeploymentName))
+				continue
+			}
 		}
 
 		registerOpts := api.RegisterOptions{
-			EnforceIndex:   r.cfg.RunConfig.CheckIndex > 0,
+			EnforceIndex:   r.cfg.RunConfig.CheckIndexSet || r.cfg.RunConfig.CheckIndex > 0,
 			ModifyIndex:    r.cfg.RunConfig.CheckIndex,
 			PolicyOverride: r.cfg.RunConfig.PolicyOverride,
 			PreserveCounts: r.cfg.RunConfig.PreserveCounts,
-			Submission:     submission,
+		}
+
+		// Only register the job if it has not changed since the pack was
+		// rendered.
+		if r.cfg.RunConfig.CheckCurrentIndex {
+			registerOpts.EnforceIndex = true
+			registerOpts.ModifyIndex = r.modifyIndexes[tplName]
+		}
+
+		// submit the source of the job to Nomad, too, so the UI can show the
+		// exact specification which was deployed.
+		if !r.cfg.RunConfig.NoSource {
+			registerOpts.Submission = &api.JobSubmission{
+				Source: r.rawTemplates[tplName],
+				Format: "hcl2",
+			}
+
+			// A patched job no longer matches its template, so the patched
+			// job is submitted as the source instead, without the tokens.
+			if r.cfg.RunConfig.PatchFile != "" {
+				src, err := json.MarshalIndent(map[string]*api.Job{"Job": withoutTokens(jobSpec.Job())}, "", "  ")
+				if err != nil {
+					r.rollback(ui)
+					return &errors.WrappedUIContext{
+						Err:     fmt.Errorf("failed to encode job source: %w", err),
+						Subject: validationSubjPatch,
+						Context: tplErrorContext,
+					}
+				}
+				registerOpts.Submission = &api.JobSubmission{
+					Source: string(src),
+					Format: "json",
+				}
+			}
 		}
 
 		// Submit the job
 		result, _, err := r.client.Jobs().RegisterOpts(jobSpec.Job(), &registerOpts, r.newWriteOptsFromJob(jobSpec))
+
+		// Clusters which do not support storing the job source ignore it, but
+		// should one reject it, register the job without the source rather
+		// than failing the deployment.
+		if err != nil && registerOpts.Submission != nil && isSubmissionError(err) {
+			ui.Warning(fmt.Sprintf("Job '%s' source was rejected by Nomad, registering without it: %v",
+				*jobSpec.Job().ID, err))
+			registerOpts.Submission = nil
+			result, _, err = r.client.Jobs().RegisterOpts(jobSpec.Job(), &registerOpts, r.newWriteOptsFromJob(jobSpec))
+		}
 		if err != nil {
 			r.rollback(ui)
 			return generateRegisterError(err, tplErrorContext, jobSpec.GetName())
@@ -143,6 +221,19 @@ func (r *Runner) Deploy(ui terminal.UI, errorContext *errors.UIErrorContext) *er
 		r.deployedJobs = append(r.deployedJobs, jobSpec)
 		ui.Info(fmt.Sprintf("Job '%s' in pack deployment '%s' registered successfully",
 			*jobSpec.Job().ID, r.runnerCfg.DeploymentName))
+
+		// Unless detached, wait for the scheduler to process the evaluation
+		// so failures are reported before moving on to the next job.
+		if !r.cfg.RunConfig.Detach && result.EvalID != "" {
+			if err := r.waitForEvaluation(ctx, ui, jobSpec, result.EvalID); err != nil {
+				r.rollback(ui)
+				return &errors.WrappedUIContext{
+					Err:     err,
+					Subject: "failed to complete evaluation",
+					Context: tplErrorContext,
+				}
+			}
+		}
 	}
 
 	return nil
@@ -213,6 +304,17 @@ func (r *Runner) handleConsulAndVault(job *api.Job) {
 	}
 }
 
+// withoutTokens returns a copy of the job without the Consul and Vault tokens
+// set by handleConsulAndVault. The copy is used wherever the job is encoded
+// other than to register it, such as the job source stored by Nomad, so the
+// tokens are never stored or output.
+func withoutTokens(job *api.Job) *api.Job {
+	redacted := *job
+	redacted.ConsulToken = nil
+	redacted.VaultToken = nil
+	return &redacted
+}
+
 // determines next launch time and outputs to terminal
 func (r *Runner) handlePeriodicJobResponse(ui terminal.UI, job *api.Job) {
 	if job.Periodic != nil && job.Periodic.TimeZone != nil {
@@ -230,6 +332,42 @@ func (r *Runner) handlePeriodicJobResponse(ui terminal.UI, job *api.Job) {
 	}
 }
 
+// waitForEvaluation blocks until the evaluation with the passed ID reaches a
+// terminal status, returning an error if it did not complete successfully.
+// Waiting stops once the context is cancelled or the wait timeout passes.
+func (r *Runner) waitForEvaluation(ctx context.Context, ui terminal.UI, job ParsedTemplate, evalID string) error {
+	if timeout := r.cfg.RunConfig.WaitTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	opts := r.newQueryOptsFromJob(job).WithContext(ctx)
+
+	for {
+		eval, meta, err := r.client.Evaluations().Info(evalID, opts)
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("evaluation %s did not complete within %s", evalID, r.cfg.RunConfig.WaitTimeout)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped waiting for evaluation %s: %w", evalID, ctx.Err())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query evaluation %s: %w", evalID, err)
+		}
+
+		switch eval.Status {
+		case api.EvalStatusComplete:
+			ui.Info(fmt.Sprintf("Evaluation %s finished with status %q", evalID, eval.Status))
+			return nil
+		case api.EvalStatusFailed, api.EvalStatusCancelled:
+			return fmt.Errorf("evaluation %s finished with status %q: %s",
+				evalID, eval.Status, eval.StatusDescription)
+		}
+
+		opts.WaitIndex = meta.LastIndex
+	}
+}
+
 // ParseTemplates satisfies the ParseTemplates function of the deploy.Deployer
 // interface.
 func (r *Runner) ParseTemplates() []*errors.WrappedUIContext {
@@ -239,19 +377,19 @@ func (r *Runner) ParseTemplates() []*errors.WrappedUIContext {
 	for tplName, tpl := range r.rawTemplates {
 		// if a template contains region or namespace information, it needs to be passed
 		// to the client before calling the parse methods, otherwise they might fail in
-		// case ACL restricts our permissions
-		namespaceRe := regexp.MustCompile(`(?m)namespace = \"(\w+)`)
-		regionRe := regexp.MustCompile(`(?m)region = \"(\w+)`)
-
-		if nsRes := namespaceRe.FindStringSubmatch(tpl); len(nsRes) > 1 {
-			r.client.SetNamespace(nsRes[1])
+		// case ACL restricts our permissions. A copy of the client is used, so
+		// the namespace of one job is not used for the jobs which do not set
+		// their own.
+		client := *r.client
+		namespace, region := templateTarget(tpl)
+		if namespace != "" {
+			client.SetNamespace(namespace)
 		}
-
-		if regRes := regionRe.FindStringSubmatch(tpl); len(regRes) > 1 {
-			r.client.SetRegion(regRes[1])
+		if region != "" {
+			client.SetRegion(region)
 		}
 
-		ncJob, err := r.client.Jobs().ParseHCLOpts(&api.JobsParseRequest{
+		ncJob, err := client.Jobs().ParseHCLOpts(&api.JobsParseRequest{
 			JobHCL:       tpl,
 			Canonicalize: false,
 		})
@@ -263,7 +401,12 @@ func (r *Runner) ParseTemplates() []*errors.WrappedUIContext {
 			continue
 		}
 
-		job, err := r.client.Jobs().ParseHCLOpts(&api.JobsParseRequest{
+		if err := validateDatacenters(ncJob); err != nil {
+			outputErrors = append(outputErrors, newValidationDeployerError(err, validationSubjDatacenters, tplName))
+			continue
+		}
+
+		job, err := client.Jobs().ParseHCLOpts(&api.JobsParseRequest{
 			JobHCL:       tpl,
 			Canonicalize: true,
 		})
@@ -275,6 +418,15 @@ func (r *Runner) ParseTemplates() []*errors.WrappedUIContext {
 			continue
 		}
 
+		// Prefix the job with the alias before it is checked for conflicts,
+		// so each aliased instance of the pack is managed independently.
+		if r.runnerCfg.Alias != "" {
+			if err := ApplyAlias(job, r.runnerCfg.Alias); err != nil {
+				outputErrors = append(outputErrors, newValidationDeployerError(err, validationSubjAlias, tplName))
+				continue
+			}
+		}
+
 		// Store the parsed job file. This means we do not have to do this
 		// again when moving onto the actual deployment. Keeping the original
 		// and the canonicalized version of the job allows us to inspect the
@@ -288,5 +440,86 @@ func (r *Runner) ParseTemplates() []*errors.WrappedUIContext {
 		}
 	}
 
-	return outputErrors
+	if len(outputErrors) > 0 {
+		return outputErrors
+	}
+
+	// Order the jobs by the dependencies declared in their meta, using the
+	// IDs of the jobs as written in the templates, before any alias.
+	jobIDs := make(map[string]string, len(r.parsedTemplates))
+	dependsOn := make(map[string][]string, len(r.parsedTemplates))
+	for tplName, parsed := range r.parsedTemplates {
+		jobIDs[tplName] = *parsed.original.ID
+		dependsOn[tplName] = ParseDependsOn(parsed.original.Meta[DependsOnKey])
+	}
+	order, err := DependencyOrder(slices.Sorted(maps.Keys(r.parsedTemplates)), jobIDs, dependsOn)
+	if err != nil {
+		return []*errors.WrappedUIContext{{
+			Err:     err,
+			Subject: validationSubjDependencies,
+			Context: errors.NewUIErrorContext(),
+		}}
+	}
+	r.deployOrder = order
+
+	return nil
+}
+
+// templateTarget returns the namespace and region set by the job block of the
+// rendered template, which may have come from pack variables. Values which
+// are not literals, such as HCL2 variable interpolations left for Nomad, are
+// not returned.
+func templateTarget(tpl string) (string, string) {
+	file, diags := hclsyntax.ParseConfig([]byte(tpl), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", ""
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return "", ""
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "job" {
+			continue
+		}
+		return literalAttr(block.Body, "namespace"), literalAttr(block.Body, "region")
+	}
+	return "", ""
+}
+
+// literalAttr returns the value of the named attribute of the body when it
+// is a literal string.
+func literalAttr(body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !val.Type().Equals(cty.String) || val.IsNull() {
+		return ""
+	}
+	return val.AsString()
+}
+
+// validateDatacenters checks the datacenters set by the job specification, as
+// a template which renders a list variable incorrectly, such as when it is
+// empty or quoted as a single string, produces a job which can never be
+// placed. Jobs which do not set datacenters use the Nomad default.
+func validateDatacenters(job *api.Job) error {
+	if job.Datacenters == nil {
+		return nil
+	}
+	if len(job.Datacenters) == 0 {
+		return errors.New("datacenters must not be empty, or the job can never be placed")
+	}
+	for _, dc := range job.Datacenters {
+		switch {
+		case strings.TrimSpace(dc) == "":
+			return errors.New("datacenters must not contain empty names")
+		case strings.ContainsAny(dc, "[] \t,"):
+			return fmt.Errorf("datacenter %q is not a valid name, render list variables with toStringList rather than as a string", dc)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/shoenig/test/must"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
+)
+
+func TestValidateDatacenters(t *testing.T) {
+	testCases := []struct {
+		name        string
+		datacenters []string
+		expectErr   string
+	}{
+		{name: "unset"},
+		{name: "valid", datacenters: []string{"dc1", "eu-west-*"}},
+		{name: "empty", datacenters: []string{}, expectErr: "datacenters must not be empty"},
+		{name: "blank name", datacenters: []string{"dc1", " "}, expectErr: "must not contain empty names"},
+		{name: "stringified list", datacenters: []string{"[dc1 dc2]"}, expectErr: `datacenter "[dc1 dc2]" is not a valid name`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateDatacenters(&api.Job{Datacenters: tc.datacenters})
+			if tc.expectErr == "" {
+				must.NoError(t, err)
+			} else {
+				must.ErrorContains(t, err, tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestWithoutTokens(t *testing.T) {
+	j := &api.Job{
+		ID:             pointer.Of("example"),
+		ConsulToken:    pointer.Of("consul-secret"),
+		VaultToken:     pointer.Of("vault-secret"),
+		VaultNamespace: pointer.Of("apps"),
+	}
+
+	redacted := withoutTokens(j)
+	must.Nil(t, redacted.ConsulToken)
+	must.Nil(t, redacted.VaultToken)
+	must.Eq(t, "example", *redacted.ID)
+	must.Eq(t, "apps", *redacted.VaultNamespace)
+
+	// The job registered with Nomad keeps the tokens.
+	must.Eq(t, "consul-secret", *j.ConsulToken)
+	must.Eq(t, "vault-secret", *j.VaultToken)
+}
+
+func TestTemplateTarget(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tpl       string
+		namespace string
+		region    string
+	}{
+		{name: "unset", tpl: `job "example" {}`},
+		{
+			name: "literal",
+			tpl: `job "example" {
+  region    = "eu-west"
+  namespace = "tenant-a"
+
+  group "app" {
+    task "server" {
+      consul {
+        namespace = "other"
+      }
+    }
+  }
+}`,
+			namespace: "tenant-a",
+			region:    "eu-west",
+		},
+		{name: "interpolated", tpl: `job "example" { namespace = "${var.ns}" }`},
+		{name: "invalid", tpl: `job "example" { namespace = `},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			namespace, region := templateTarget(tc.tpl)
+			must.Eq(t, tc.namespace, namespace)
+			must.Eq(t, tc.region, region)
+		})
+	}
+}
+
+func TestRunner_waitForEvaluation(t *testing.T) {
+	// The evaluation never leaves the pending status, so waiting only stops
+	// on timeout or cancellation.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		must.NoError(t, json.NewEncoder(w).Encode(&api.Evaluation{ID: "eval", Status: api.EvalStatusPending}))
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	must.NoError(t, err)
+	jobSpec := ParsedTemplate{original: &api.Job{}, canonical: &api.Job{}}
+
+	r := &Runner{client: client, cfg: &CLIConfig{RunConfig: &RunCLIConfig{WaitTimeout: 50 * time.Millisecond}}}
+	err = r.waitForEvaluation(context.Background(), nil, jobSpec, "eval")
+	must.ErrorContains(t, err, "evaluation eval did not complete within 50ms")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.cfg.RunConfig.WaitTimeout = 0
+	err = r.waitForEvaluation(ctx, nil, jobSpec, "eval")
+	must.ErrorContains(t, err, "stopped waiting for evaluation eval")
+}
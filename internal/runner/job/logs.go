@@ -0,0 +1,198 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// logStreams are the task log streams followed by FollowLogs.
+var logStreams = []string{"stdout", "stderr"}
+
+// logPrinter serializes the output of the goroutines following logs, so the
+// lines of different streams are never interleaved mid-line.
+type logPrinter struct {
+	mu sync.Mutex
+	ui terminal.UI
+}
+
+func (p *logPrinter) output(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ui.Output(msg)
+}
+
+func (p *logPrinter) info(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ui.Info(msg)
+}
+
+func (p *logPrinter) warning(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ui.Warning(msg)
+}
+
+// FollowLogs satisfies the FollowLogs function of the runner.Runner
+// interface. Each line is prefixed with the allocation, task, and stream it
+// was written to, so the interleaved output of several tasks can be told
+// apart.
+func (r *Runner) FollowLogs(ctx context.Context, ui terminal.UI) *errors.WrappedUIContext {
+	var wg sync.WaitGroup
+	printer := &logPrinter{ui: ui}
+
+	for _, jobSpec := range r.deployedJobs {
+		job := jobSpec.Job()
+
+		// Periodic and parameterized jobs do not place allocations until they
+		// are launched or dispatched.
+		if job.IsPeriodic() || job.IsParameterized() {
+			printer.info(fmt.Sprintf("Job '%s' does not place allocations directly, not following logs", *job.ID))
+			continue
+		}
+
+		allocs, err := r.waitForAllocations(ctx, printer, jobSpec)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return &errors.WrappedUIContext{
+				Err:     err,
+				Subject: "failed to follow logs",
+				Context: errors.NewUIErrorContext(),
+			}
+		}
+
+		for _, alloc := range allocs {
+			for _, task := range slices.Sorted(maps.Keys(alloc.TaskStates)) {
+				for _, stream := range logStreams {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						r.followTaskLogs(ctx, printer, jobSpec, alloc, task, stream)
+					}()
+				}
+			}
+		}
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// waitForAllocations blocks until none of the allocations of the deployed
+// version of the job are pending, returning them once at least one exists.
+func (r *Runner) waitForAllocations(ctx context.Context, printer *logPrinter, jobSpec ParsedTemplate) ([]*api.Allocation, error) {
+	opts := r.newQueryOptsFromJob(jobSpec).WithContext(ctx)
+	jobID := *jobSpec.Job().ID
+
+	job, _, err := r.client.Jobs().Info(jobID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job %s: %w", jobID, err)
+	}
+
+	printer.info(fmt.Sprintf("Waiting for the allocations of job '%s' to start", jobID))
+
+	for {
+		stubs, meta, err := r.client.Jobs().Allocations(jobID, false, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list allocations of job %s: %w", jobID, err)
+		}
+
+		var current []*api.AllocationListStub
+		pending := false
+		for _, stub := range stubs {
+			if stub.JobVersion != *job.Version {
+				continue
+			}
+			current = append(current, stub)
+			pending = pending || stub.ClientStatus == api.AllocClientStatusPending
+		}
+
+		if len(current) > 0 && !pending {
+			allocs := make([]*api.Allocation, 0, len(current))
+			for _, stub := range current {
+				alloc, _, err := r.client.Allocations().Info(stub.ID, opts)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read allocation %s: %w", stub.ID, err)
+				}
+				allocs = append(allocs, alloc)
+			}
+			return allocs, nil
+		}
+
+		opts.WaitIndex = meta.LastIndex
+	}
+}
+
+// followTaskLogs streams a single log stream of the task, outputting each
+// line, until the context is cancelled or the task stops.
+func (r *Runner) followTaskLogs(
+	ctx context.Context,
+	printer *logPrinter,
+	jobSpec ParsedTemplate,
+	alloc *api.Allocation,
+	task, stream string,
+) {
+	prefix := fmt.Sprintf("[%s %s %s] ", alloc.ID[:8], task, stream)
+
+	// Closing the cancel channel on return stops the stream, whether the
+	// context was cancelled or the stream ended with an error.
+	cancelCh := make(chan struct{})
+	defer close(cancelCh)
+
+	frames, errCh := r.client.AllocFS().Logs(alloc, true, task, stream, api.OriginStart, 0, cancelCh, r.newQueryOptsFromJob(jobSpec))
+
+	// Frames are not split on line boundaries, so buffer any partial line
+	// until the rest of it arrives.
+	var buf []byte
+	flush := func(all bool) {
+		for {
+			idx := bytes.IndexByte(buf, '\n')
+			if idx < 0 {
+				break
+			}
+			printer.output(prefix + string(buf[:idx]))
+			buf = buf[idx+1:]
+		}
+		if all && len(buf) > 0 {
+			printer.output(prefix + string(buf))
+			buf = nil
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flush(true)
+			return
+		case err := <-errCh:
+			flush(true)
+			if err != nil && ctx.Err() == nil {
+				printer.warning(fmt.Sprintf("Stopped following %s of task %q in allocation %s: %v", stream, task, alloc.ID[:8], err))
+			}
+			return
+		case frame, ok := <-frames:
+			if !ok {
+				flush(true)
+				return
+			}
+			if frame != nil {
+				buf = append(buf, frame.Data...)
+				flush(false)
+			}
+		}
+	}
+}
@@ -12,6 +12,10 @@ const (
 	PackDeploymentNameKey = "pack.deployment_name"
 	PackJobKey            = "pack.job"
 	PackRefKey            = "pack.version"
+
+	// PackStoppedKey is set to "true" on the jobs stopped using stop
+	// --keep-meta, marking them as intentionally stopped rather than stale.
+	PackStoppedKey = "pack.stopped"
 )
 
 // add metadata to the job for in cluster querying and management
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DependsOnKey is the job meta key listing, separated by commas, the IDs of
+// the other jobs of the pack which must be submitted before the job.
+const DependsOnKey = "depends_on"
+
+// ParseDependsOn returns the job IDs listed by a DependsOnKey meta value.
+func ParseDependsOn(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// TemplateDependsOn returns the job IDs listed by the DependsOnKey meta of the
+// job block of the rendered template, which may be set within a meta block or
+// a meta attribute. Values which are not literals are not returned.
+func TemplateDependsOn(tpl string) []string {
+	file, diags := hclsyntax.ParseConfig([]byte(tpl), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type != "job" {
+			continue
+		}
+		for _, meta := range block.Body.Blocks {
+			if meta.Type == "meta" {
+				return ParseDependsOn(literalAttr(meta.Body, DependsOnKey))
+			}
+		}
+		if attr, ok := block.Body.Attributes["meta"]; ok {
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || !val.CanIterateElements() || !val.Type().HasAttribute(DependsOnKey) {
+				return nil
+			}
+			if v := val.GetAttr(DependsOnKey); v.Type().Equals(cty.String) && !v.IsNull() {
+				return ParseDependsOn(v.AsString())
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// DependencyOrder sorts the names so that each job follows the jobs it
+// depends on. The IDs of the jobs and the IDs they depend on are keyed by
+// name; names without a job ID, such as templates which are not jobs, have
+// no dependencies. Names are otherwise kept in the order passed. An error is
+// returned if a job depends on a job which is not named, or if the
+// dependencies form a cycle.
+func DependencyOrder(names []string, jobIDs map[string]string, dependsOn map[string][]string) ([]string, error) {
+	byID := make(map[string]string, len(jobIDs))
+	for _, name := range names {
+		if id, ok := jobIDs[name]; ok {
+			byID[id] = name
+		}
+	}
+
+	// Resolve the dependencies of each name to the names which must precede
+	// it.
+	after := make(map[string][]string, len(dependsOn))
+	for _, name := range names {
+		for _, id := range dependsOn[name] {
+			dep, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("job %q depends on unknown job %q", jobIDs[name], id)
+			}
+			after[name] = append(after[name], dep)
+		}
+	}
+
+	ordered := make([]string, 0, len(names))
+	done := make(map[string]bool, len(names))
+	for len(ordered) < len(names) {
+		// Take the first remaining name whose dependencies are all done, so
+		// the passed order is kept where the dependencies allow.
+		idx := slices.IndexFunc(names, func(name string) bool {
+			return !done[name] && !slices.ContainsFunc(after[name], func(dep string) bool { return !done[dep] })
+		})
+		if idx < 0 {
+			return nil, dependencyCycleError(names, jobIDs, after, done)
+		}
+		done[names[idx]] = true
+		ordered = append(ordered, names[idx])
+	}
+	return ordered, nil
+}
+
+// dependencyCycleError returns an error describing a cycle among the names
+// which are not done, each of which has a dependency which is not done.
+func dependencyCycleError(names []string, jobIDs map[string]string, after map[string][]string, done map[string]bool) error {
+	var cycle []string
+	seen := map[string]int{}
+	name := names[slices.IndexFunc(names, func(name string) bool { return !done[name] })]
+	for {
+		if start, ok := seen[name]; ok {
+			cycle = append(cycle[start:], jobIDs[name])
+			break
+		}
+		seen[name] = len(cycle)
+		cycle = append(cycle, jobIDs[name])
+		name = after[name][slices.IndexFunc(after[name], func(dep string) bool { return !done[dep] })]
+	}
+	return fmt.Errorf("jobs have a dependency cycle: %s", strings.Join(cycle, " -> "))
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestParseDependsOn(t *testing.T) {
+	must.Nil(t, ParseDependsOn(""))
+	must.Eq(t, []string{"db"}, ParseDependsOn("db"))
+	must.Eq(t, []string{"db", "cache"}, ParseDependsOn(" db, cache ,"))
+}
+
+func TestTemplateDependsOn(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tpl    string
+		expect []string
+	}{
+		{
+			name: "meta block",
+			tpl: `job "app" {
+  meta {
+    depends_on = "db,cache"
+  }
+}`,
+			expect: []string{"db", "cache"},
+		},
+		{
+			name:   "meta attribute",
+			tpl:    `job "app" { meta = { depends_on = "db" } }`,
+			expect: []string{"db"},
+		},
+		{
+			name: "no meta",
+			tpl:  `job "app" {}`,
+		},
+		{
+			name: "no dependencies",
+			tpl: `job "app" {
+  meta {
+    owner = "ops"
+  }
+}`,
+		},
+		{
+			name: "not a job",
+			tpl:  `#!/bin/sh`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			must.Eq(t, tc.expect, TemplateDependsOn(tc.tpl))
+		})
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	jobIDs := map[string]string{"a.nomad": "app", "c.nomad": "cache", "d.nomad": "db"}
+
+	// Names without dependencies keep their order.
+	order, err := DependencyOrder([]string{"a.nomad", "c.nomad", "d.nomad", "README.md"}, jobIDs, nil)
+	must.NoError(t, err)
+	must.Eq(t, []string{"a.nomad", "c.nomad", "d.nomad", "README.md"}, order)
+
+	order, err = DependencyOrder([]string{"a.nomad", "c.nomad", "d.nomad", "README.md"}, jobIDs, map[string][]string{
+		"a.nomad": {"cache", "db"},
+		"c.nomad": {"db"},
+	})
+	must.NoError(t, err)
+	must.Eq(t, []string{"d.nomad", "c.nomad", "a.nomad", "README.md"}, order)
+
+	_, err = DependencyOrder([]string{"a.nomad"}, jobIDs, map[string][]string{"a.nomad": {"queue"}})
+	must.EqError(t, err, `job "app" depends on unknown job "queue"`)
+
+	_, err = DependencyOrder([]string{"a.nomad", "c.nomad", "d.nomad"}, jobIDs, map[string][]string{
+		"a.nomad": {"db"},
+		"c.nomad": {"app"},
+		"d.nomad": {"cache"},
+	})
+	must.EqError(t, err, "jobs have a dependency cycle: app -> db -> cache -> app")
+
+	_, err = DependencyOrder([]string{"a.nomad"}, jobIDs, map[string][]string{"a.nomad": {"app"}})
+	must.EqError(t, err, "jobs have a dependency cycle: app -> app")
+}
@@ -0,0 +1,523 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+)
+
+// serverJobFields are the job fields managed by the Nomad servers. They are
+// excluded from job patches, as they do not originate from the job
+// specification. The job level update block is also excluded, since the
+// servers copy it into each group, where any change is reported.
+var serverJobFields = []string{
+	"CreateIndex",
+	"JobModifyIndex",
+	"ModifyIndex",
+	"NomadTokenID",
+	"Stable",
+	"Status",
+	"StatusDescription",
+	"SubmitTime",
+	"Update",
+	"Version",
+	"VersionTag",
+}
+
+var patchPathEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// patchOperation is a single RFC 6902 JSON Patch operation.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// jobPatch returns the JSON Patch which transforms the deployed job into the
+// rendered job. A nil deployed job results in a patch adding the whole job.
+// Fields matched by the ignore paths are removed from both jobs first.
+func jobPatch(deployed, rendered *api.Job, ignore []DiffIgnorePath) ([]patchOperation, error) {
+	to, err := patchDocument(rendered, ignore)
+	if err != nil {
+		return nil, err
+	}
+	if deployed == nil {
+		return newPatch().add("", to).ops, nil
+	}
+
+	from, err := patchDocument(deployed, ignore)
+	if err != nil {
+		return nil, err
+	}
+	return newPatch().diff("", from, to).ops, nil
+}
+
+// patchDocument converts the job into its generic JSON representation,
+// without the fields managed by the Nomad servers or matched by the ignore
+// paths.
+func patchDocument(job *api.Job, ignore []DiffIgnorePath) (map[string]any, error) {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+	for _, field := range serverJobFields {
+		delete(doc, field)
+	}
+	for _, path := range ignore {
+		path.strip(doc)
+	}
+	return doc, nil
+}
+
+type patch struct {
+	ops []patchOperation
+}
+
+func newPatch() *patch { return &patch{ops: []patchOperation{}} }
+
+// diff appends the operations needed to transform from into to, both of
+// which are located at path. Objects and arrays are compared recursively;
+// any other differing value is replaced. Values left unset in to are assumed
+// to keep the default the Nomad servers assigned to them, and are skipped.
+func (p *patch) diff(path string, from, to any) *patch {
+	if to == nil || to == "" {
+		return p
+	}
+
+	switch fromVal := from.(type) {
+	case map[string]any:
+		toVal, ok := to.(map[string]any)
+		if !ok {
+			return p.replace(path, to)
+		}
+
+		keys := make([]string, 0, len(fromVal)+len(toVal))
+		for k := range fromVal {
+			keys = append(keys, k)
+		}
+		for k := range toVal {
+			if _, ok := fromVal[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			keyPath := path + "/" + patchPathEscaper.Replace(k)
+			fromElem, inFrom := fromVal[k]
+			toElem, inTo := toVal[k]
+			switch {
+			case !inTo:
+				p.remove(keyPath)
+			case !inFrom:
+				p.add(keyPath, toElem)
+			default:
+				p.diff(keyPath, fromElem, toElem)
+			}
+		}
+
+	case []any:
+		toVal, ok := to.([]any)
+		if !ok {
+			return p.replace(path, to)
+		}
+
+		common := min(len(fromVal), len(toVal))
+		for i := 0; i < common; i++ {
+			p.diff(path+"/"+strconv.Itoa(i), fromVal[i], toVal[i])
+		}
+		// Remove trailing elements from the end so the indexes of the
+		// remaining elements are unaffected.
+		for i := len(fromVal) - 1; i >= common; i-- {
+			p.remove(path + "/" + strconv.Itoa(i))
+		}
+		for i := common; i < len(toVal); i++ {
+			p.add(path+"/"+strconv.Itoa(i), toVal[i])
+		}
+
+	default:
+		if !reflect.DeepEqual(from, to) {
+			p.replace(path, to)
+		}
+	}
+	return p
+}
+
+func (p *patch) add(path string, value any) *patch {
+	return p.append("add", path, value)
+}
+
+func (p *patch) replace(path string, value any) *patch {
+	return p.append("replace", path, value)
+}
+
+func (p *patch) remove(path string) *patch {
+	p.ops = append(p.ops, patchOperation{Op: "remove", Path: path})
+	return p
+}
+
+func (p *patch) append(op, path string, value any) *patch {
+	// The value was decoded from JSON, so encoding it again cannot fail.
+	b, _ := json.Marshal(value)
+	p.ops = append(p.ops, patchOperation{Op: op, Path: path, Value: b})
+	return p
+}
+
+var patchPathUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// ApplyPatch applies the JSON Patch within the configured patch file to each
+// parsed job. The patch operates on the JSON representation of the job used
+// by the Nomad API, which is also the format output by plan --format=patch,
+// and the patched job replaces the parsed job.
+func (r *Runner) ApplyPatch(errCtx *errors.UIErrorContext) []*errors.WrappedUIContext {
+	if len(r.parsedTemplates) < 1 {
+		return []*errors.WrappedUIContext{newNoParsedTemplatesError(validationSubjPatch, errCtx)}
+	}
+
+	ops, err := loadPatch(r.cfg.RunConfig.PatchFile)
+	if err != nil {
+		return []*errors.WrappedUIContext{{Err: err, Subject: validationSubjPatch, Context: errCtx}}
+	}
+
+	var outputErrors []*errors.WrappedUIContext
+	for _, tplName := range slices.Sorted(maps.Keys(r.parsedTemplates)) {
+		parsedJob := r.parsedTemplates[tplName]
+
+		tplErrorContext := errCtx.Copy()
+		tplErrorContext.Add(errors.UIContextPrefixTemplateName, tplName)
+		tplErrorContext.Add(errors.UIContextPrefixJobName, parsedJob.GetName())
+
+		job, err := patchJob(parsedJob.Job(), ops)
+		if err == nil {
+			err = validateDatacenters(job)
+		}
+		if err != nil {
+			outputErrors = append(outputErrors, &errors.WrappedUIContext{
+				Err:     err,
+				Subject: validationSubjPatch,
+				Context: tplErrorContext,
+			})
+			continue
+		}
+
+		// The region and namespace the job is written to are those set by
+		// the original job specification, so carry over any the patch sets.
+		if !reflect.DeepEqual(job.Region, parsedJob.canonical.Region) {
+			parsedJob.original.Region = job.Region
+		}
+		if !reflect.DeepEqual(job.Namespace, parsedJob.canonical.Namespace) {
+			parsedJob.original.Namespace = job.Namespace
+		}
+		parsedJob.canonical = job
+		r.parsedTemplates[tplName] = parsedJob
+	}
+
+	if len(outputErrors) > 0 {
+		return outputErrors
+	}
+	return nil
+}
+
+// loadPatch reads the JSON Patch document within the file.
+func loadPatch(path string) ([]patchOperation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch file: %w", err)
+	}
+
+	var ops []patchOperation
+	if err := json.Unmarshal(b, &ops); err != nil {
+		return nil, fmt.Errorf("failed to decode patch file %s: %w", path, err)
+	}
+	return ops, nil
+}
+
+// patchJob returns a copy of the job with the patch applied. The patched job
+// must only use the fields of the job, so paths which are misspelled are
+// reported rather than ignored.
+func patchJob(job *api.Job, ops []patchOperation) (*api.Job, error) {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %w", err)
+	}
+	doc, err := decodePatchValue(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+
+	if doc, err = applyPatch(doc, ops); err != nil {
+		return nil, err
+	}
+
+	if b, err = json.Marshal(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode patched job: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var patched api.Job
+	if err := dec.Decode(&patched); err != nil {
+		return nil, fmt.Errorf("patched job is not valid: %w", err)
+	}
+	patched.Canonicalize()
+	return &patched, nil
+}
+
+// decodePatchValue decodes the JSON value, keeping numbers as json.Number so
+// large integers, such as durations, are not rounded.
+func decodePatchValue(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// applyPatch applies the RFC 6902 JSON Patch operations to the document in
+// order, returning the patched document. The document is modified in place.
+// The error of a failing operation identifies it by its index and path.
+func applyPatch(doc any, ops []patchOperation) (any, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %q) failed: %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc any, op patchOperation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("value is required")
+		}
+		value, err := decodePatchValue(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode value: %w", err)
+		}
+
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			if doc, err = removeValue(doc, path); err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, value)
+		default:
+			current, err := getValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				b, _ := json.Marshal(current)
+				return nil, fmt.Errorf("value is %s, not %s", b, op.Value)
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return removeValue(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+
+		if op.Op == "copy" {
+			return addValue(doc, path, clonePatchValue(value))
+		}
+		if len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return nil, errors.New("a value cannot be moved into itself")
+		}
+		if doc, err = removeValue(doc, from); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	}
+	return nil, fmt.Errorf("unsupported operation %q", op.Op)
+}
+
+// parsePointer splits the RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("path %q must be empty or start with /", ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = patchPathUnescaper.Replace(token)
+	}
+	return tokens, nil
+}
+
+// getValue returns the value at the path within the document.
+func getValue(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", token)
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%q is not within an object or array", token)
+		}
+	}
+	return doc, nil
+}
+
+// addValue adds the value at the path, replacing any object member of the
+// same name, or inserting it into an array.
+func addValue(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, key string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[key] = value
+			return node, nil
+		case []any:
+			i, err := arrayIndex(key, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(node, i, value), nil
+		}
+		return nil, fmt.Errorf("%q is not within an object or array", key)
+	})
+}
+
+// removeValue removes the value at the path, which must exist.
+func removeValue(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("the whole document cannot be removed")
+	}
+	return updateParent(doc, path, func(parent any, key string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[key]; !ok {
+				return nil, fmt.Errorf("member %q does not exist", key)
+			}
+			delete(node, key)
+			return node, nil
+		case []any:
+			i, err := arrayIndex(key, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Delete(node, i, i+1), nil
+		}
+		return nil, fmt.Errorf("%q is not within an object or array", key)
+	})
+}
+
+// updateParent replaces the object or array holding the final token of the
+// path with the result of fn, as inserting into an array may reallocate it.
+func updateParent(doc any, path []string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	child, err := getValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := doc.(type) {
+	case map[string]any:
+		node[path[0]] = child
+	case []any:
+		// The index was validated when the child was looked up.
+		i, _ := arrayIndex(path[0], len(node), false)
+		node[i] = child
+	}
+	return doc, nil
+}
+
+// arrayIndex parses the token as an index into an array of length n. When
+// inserting, the index may also be n, or "-" to append to the array.
+func arrayIndex(token string, n int, insert bool) (int, error) {
+	if token == "-" && insert {
+		return n, nil
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || strings.TrimLeft(token, "0123456789") != "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%q is not a valid array index", token)
+	}
+	if i > n || (i == n && !insert) {
+		return 0, fmt.Errorf("array index %d is out of range", i)
+	}
+	return i, nil
+}
+
+// clonePatchValue returns a deep copy of the decoded JSON value.
+func clonePatchValue(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(node))
+		for k, elem := range node {
+			out[k] = clonePatchValue(elem)
+		}
+		return out
+	case []any:
+		out := make([]any, len(node))
+		for i, elem := range node {
+			out[i] = clonePatchValue(elem)
+		}
+		return out
+	}
+	return v
+}
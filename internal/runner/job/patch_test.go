@@ -0,0 +1,278 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/shoenig/test/must"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
+)
+
+func Test_patchDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		from     string
+		to       string
+		expected string
+	}{
+		{
+			name:     "no changes",
+			from:     `{"a":1,"b":{"c":[1,2]}}`,
+			to:       `{"a":1,"b":{"c":[1,2]}}`,
+			expected: `[]`,
+		},
+		{
+			name:     "replace nested value",
+			from:     `{"a":{"b":{"c":1}}}`,
+			to:       `{"a":{"b":{"c":2}}}`,
+			expected: `[{"op":"replace","path":"/a/b/c","value":2}]`,
+		},
+		{
+			name:     "add and remove keys",
+			from:     `{"a":1,"b":2}`,
+			to:       `{"b":2,"c":3}`,
+			expected: `[{"op":"remove","path":"/a"},{"op":"add","path":"/c","value":3}]`,
+		},
+		{
+			name:     "unset values keep defaults",
+			from:     `{"a":"x","b":1}`,
+			to:       `{"a":"","b":null}`,
+			expected: `[]`,
+		},
+		{
+			name:     "replace with empty collection",
+			from:     `{"a":{"b":1},"c":[1]}`,
+			to:       `{"a":{},"c":[]}`,
+			expected: `[{"op":"remove","path":"/a/b"},{"op":"remove","path":"/c/0"}]`,
+		},
+		{
+			name:     "escaped keys",
+			from:     `{"a/b":1,"c~d":1}`,
+			to:       `{"a/b":2,"c~d":2}`,
+			expected: `[{"op":"replace","path":"/a~1b","value":2},{"op":"replace","path":"/c~0d","value":2}]`,
+		},
+		{
+			name:     "grow array",
+			from:     `{"a":[1]}`,
+			to:       `{"a":[1,2,3]}`,
+			expected: `[{"op":"add","path":"/a/1","value":2},{"op":"add","path":"/a/2","value":3}]`,
+		},
+		{
+			name:     "shrink array",
+			from:     `{"a":[1,2,3]}`,
+			to:       `{"a":[4]}`,
+			expected: `[{"op":"replace","path":"/a/0","value":4},{"op":"remove","path":"/a/2"},{"op":"remove","path":"/a/1"}]`,
+		},
+		{
+			name:     "change type",
+			from:     `{"a":{"b":1}}`,
+			to:       `{"a":[1]}`,
+			expected: `[{"op":"replace","path":"/a","value":[1]}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var from, to any
+			must.NoError(t, json.Unmarshal([]byte(tc.from), &from))
+			must.NoError(t, json.Unmarshal([]byte(tc.to), &to))
+
+			out, err := json.Marshal(newPatch().diff("", from, to).ops)
+			must.NoError(t, err)
+			must.Eq(t, tc.expected, string(out))
+		})
+	}
+}
+
+func Test_jobPatch(t *testing.T) {
+	rendered := &api.Job{ID: pointer.Of("example"), Priority: pointer.Of(60)}
+
+	// Jobs which are not deployed are added whole.
+	ops, err := jobPatch(nil, rendered, nil)
+	must.NoError(t, err)
+	must.Len(t, 1, ops)
+	must.Eq(t, "add", ops[0].Op)
+	must.Eq(t, "", ops[0].Path)
+
+	// Fields managed by the servers are not part of the patch.
+	deployed := &api.Job{
+		ID:          pointer.Of("example"),
+		Priority:    pointer.Of(50),
+		Version:     pointer.Of(uint64(3)),
+		ModifyIndex: pointer.Of(uint64(42)),
+	}
+	ops, err = jobPatch(deployed, rendered, nil)
+	must.NoError(t, err)
+	must.Eq(t, []patchOperation{{Op: "replace", Path: "/Priority", Value: json.RawMessage("60")}}, ops)
+
+	// Ignored fields are removed from both jobs.
+	ignore, err := ParseDiffIgnorePath("$.Priority")
+	must.NoError(t, err)
+	ops, err = jobPatch(deployed, rendered, []DiffIgnorePath{ignore})
+	must.NoError(t, err)
+	must.SliceEmpty(t, ops)
+}
+
+func Test_applyPatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+		err      string
+	}{
+		{
+			name:     "add members and elements",
+			doc:      `{"a":{"b":1},"c":[1,3]}`,
+			patch:    `[{"op":"add","path":"/a/d","value":2},{"op":"add","path":"/c/1","value":2},{"op":"add","path":"/c/-","value":4}]`,
+			expected: `{"a":{"b":1,"d":2},"c":[1,2,3,4]}`,
+		},
+		{
+			name:     "remove and replace",
+			doc:      `{"a":1,"b":[1,2,3]}`,
+			patch:    `[{"op":"remove","path":"/a"},{"op":"remove","path":"/b/0"},{"op":"replace","path":"/b/1","value":{"c":null}}]`,
+			expected: `{"b":[2,{"c":null}]}`,
+		},
+		{
+			name:     "move and copy",
+			doc:      `{"a":{"b":[1]},"c":{}}`,
+			patch:    `[{"op":"copy","from":"/a/b","path":"/c/b"},{"op":"move","from":"/a","path":"/d"},{"op":"add","path":"/c/b/-","value":2}]`,
+			expected: `{"c":{"b":[1,2]},"d":{"b":[1]}}`,
+		},
+		{
+			name:     "test and escaped paths",
+			doc:      `{"a/b":{"c~d":5000000000000000001}}`,
+			patch:    `[{"op":"test","path":"/a~1b/c~0d","value":5000000000000000001},{"op":"replace","path":"/a~1b/c~0d","value":"x"}]`,
+			expected: `{"a/b":{"c~d":"x"}}`,
+		},
+		{
+			name:     "replace whole document",
+			doc:      `{"a":1}`,
+			patch:    `[{"op":"replace","path":"","value":[1]}]`,
+			expected: `[1]`,
+		},
+		{
+			name:  "failed test",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"add","path":"/b","value":2},{"op":"test","path":"/a","value":2}]`,
+			err:   `patch operation 1 (test "/a") failed: value is 1, not 2`,
+		},
+		{
+			name:  "missing member",
+			doc:   `{"a":{}}`,
+			patch: `[{"op":"replace","path":"/a/b/c","value":1}]`,
+			err:   `patch operation 0 (replace "/a/b/c") failed: member "b" does not exist`,
+		},
+		{
+			name:  "index out of range",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"add","path":"/a/2","value":1}]`,
+			err:   "array index 2 is out of range",
+		},
+		{
+			name:  "invalid index",
+			doc:   `{"a":[1]}`,
+			patch: `[{"op":"remove","path":"/a/-"}]`,
+			err:   `"-" is not a valid array index`,
+		},
+		{
+			name:  "missing value",
+			doc:   `{}`,
+			patch: `[{"op":"add","path":"/a"}]`,
+			err:   "value is required",
+		},
+		{
+			name:  "move into itself",
+			doc:   `{"a":{}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a/b"}]`,
+			err:   "a value cannot be moved into itself",
+		},
+		{
+			name:  "unsupported operation",
+			doc:   `{}`,
+			patch: `[{"op":"merge","path":"/a"}]`,
+			err:   `unsupported operation "merge"`,
+		},
+		{
+			name:  "invalid path",
+			doc:   `{}`,
+			patch: `[{"op":"remove","path":"a"}]`,
+			err:   `path "a" must be empty or start with /`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := decodePatchValue([]byte(tc.doc))
+			must.NoError(t, err)
+			var ops []patchOperation
+			must.NoError(t, json.Unmarshal([]byte(tc.patch), &ops))
+
+			out, err := applyPatch(doc, ops)
+			if tc.err != "" {
+				must.ErrorContains(t, err, tc.err)
+				return
+			}
+			must.NoError(t, err)
+			b, err := json.Marshal(out)
+			must.NoError(t, err)
+			must.Eq(t, tc.expected, string(b))
+		})
+	}
+}
+
+func Test_applyPatch_Diff(t *testing.T) {
+	// Applying the patch generated between two documents transforms the
+	// first into the second.
+	from := `{"a":{"b":1,"c":[1,2,3]},"d":"x","e/f":[{"g":1}]}`
+	to := `{"a":{"c":[4]},"d":{"h":true},"e/f":[{"g":2},{"g":3}],"i":[]}`
+
+	fromDoc, err := decodePatchValue([]byte(from))
+	must.NoError(t, err)
+	toDoc, err := decodePatchValue([]byte(to))
+	must.NoError(t, err)
+
+	out, err := applyPatch(fromDoc, newPatch().diff("", clonePatchValue(fromDoc), toDoc).ops)
+	must.NoError(t, err)
+	must.Eq(t, toDoc, out)
+}
+
+func Test_patchJob(t *testing.T) {
+	job := &api.Job{
+		ID:          pointer.Of("example"),
+		Datacenters: []string{"dc1"},
+		TaskGroups: []*api.TaskGroup{{
+			Name:  pointer.Of("app"),
+			Count: pointer.Of(1),
+			Tasks: []*api.Task{{Name: "app", Driver: "raw_exec"}},
+		}},
+	}
+	job.Canonicalize()
+
+	var ops []patchOperation
+	must.NoError(t, json.Unmarshal([]byte(`[
+		{"op": "replace", "path": "/TaskGroups/0/Count", "value": 3},
+		{"op": "add", "path": "/Constraints", "value": [{"LTarget": "${node.class}", "RTarget": "edge", "Operand": "="}]},
+		{"op": "add", "path": "/Namespace", "value": "edge"}
+	]`), &ops))
+
+	patched, err := patchJob(job, ops)
+	must.NoError(t, err)
+	must.Eq(t, 3, *patched.TaskGroups[0].Count)
+	must.Eq(t, []*api.Constraint{api.NewConstraint("${node.class}", "=", "edge")}, patched.Constraints)
+	must.Eq(t, "edge", *patched.Namespace)
+	must.Eq(t, *job.TaskGroups[0].Tasks[0].KillTimeout, *patched.TaskGroups[0].Tasks[0].KillTimeout)
+
+	// The job passed is not changed.
+	must.Eq(t, 1, *job.TaskGroups[0].Count)
+
+	// Fields which are not part of the job are rejected.
+	must.NoError(t, json.Unmarshal([]byte(`[{"op": "add", "path": "/TaskGroups/0/Cuont", "value": 3}]`), &ops))
+	_, err = patchJob(job, ops)
+	must.ErrorContains(t, err, `patched job is not valid: json: unknown field "Cuont"`)
+}
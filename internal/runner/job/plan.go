@@ -4,7 +4,10 @@
 package job
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/hashicorp/nomad/api"
 
@@ -48,7 +51,7 @@ func (r *Runner) PlanDeployment(ui terminal.UI, errCtx *errors.UIErrorContext) (
 
 		// Set up the options.
 		planOpts := &api.PlanOptions{
-			Diff:           r.cfg.PlanConfig.Diff,
+			Diff:           r.cfg.PlanConfig.Diff || r.cfg.PlanConfig.MachineReadable(),
 			PolicyOverride: r.cfg.PlanConfig.PolicyOverride,
 		}
 
@@ -69,7 +72,9 @@ func (r *Runner) PlanDeployment(ui terminal.UI, errCtx *errors.UIErrorContext) (
 		}
 
 		exitCode = runner.HigherPlanCode(exitCode, r.outputPlannedJob(ui, parsedJob.Job(), planResponse))
-		r.formatJobModifyIndex(planResponse.JobModifyIndex, ui)
+		if !r.cfg.PlanConfig.MachineReadable() {
+			r.formatJobModifyIndex(planResponse.JobModifyIndex, ui)
+		}
 	}
 
 	if outputErrors != nil || len(outputErrors) > 0 {
@@ -119,7 +124,10 @@ func (r *Runner) multiRegionPlan(
 	}
 
 	for regionName, resp := range plans {
-		ui.Info(fmt.Sprintf("Region: %q", regionName))
+		job.Region = &regionName
+		if !r.cfg.PlanConfig.MachineReadable() {
+			ui.Info(fmt.Sprintf("Region: %q", regionName))
+		}
 		exitCode = runner.HigherPlanCode(exitCode, r.outputPlannedJob(ui, job, resp))
 	}
 
@@ -127,9 +135,18 @@ func (r *Runner) multiRegionPlan(
 }
 
 func (r *Runner) outputPlannedJob(ui terminal.UI, job *api.Job, resp *api.JobPlanResponse) int {
+	switch r.cfg.PlanConfig.Format {
+	case PlanFormatPatch:
+		return r.outputJobPatch(ui, job, resp)
+	case PlanFormatJSON:
+		return r.outputPlanSummary(ui, job, resp)
+	}
 
 	// Print the diff if not disabled
 	if r.cfg.PlanConfig.Diff {
+		if len(r.cfg.PlanConfig.DiffIgnore) > 0 {
+			ui.Info("The diff is computed by the Nomad servers, so still shows changes to fields ignored by --diff-ignore.")
+		}
 		formatJobDiff(*resp.Diff, r.cfg.PlanConfig.Verbose, ui)
 	}
 
@@ -137,9 +154,10 @@ func (r *Runner) outputPlannedJob(ui terminal.UI, job *api.Job, resp *api.JobPla
 	ui.Header("Scheduler dry-run:")
 	formatDryRun(resp, job, ui)
 
-	// Print any warnings if there are any
-	if resp.Warnings != "" {
-		ui.Warning(fmt.Sprintf("\nJob Warnings:\n%s", resp.Warnings))
+	// Print any warnings if there are any, removing those the user has asked
+	// us to ignore.
+	if warnings := filterWarnings(resp.Warnings, r.cfg.PlanConfig.IgnoreWarnings); warnings != "" {
+		ui.Warning(fmt.Sprintf("\nJob Warnings:\n%s", warnings))
 	}
 
 	// Print preemptions if there are any
@@ -147,7 +165,120 @@ func (r *Runner) outputPlannedJob(ui terminal.UI, job *api.Job, resp *api.JobPla
 		formatPreemptions(ui, resp)
 	}
 
-	return getExitCode(resp)
+	// The diff is computed by the Nomad servers so still shows any ignored
+	// fields, which only count towards the exit code.
+	var deployed *api.Job
+	if len(r.cfg.PlanConfig.DiffIgnore) > 0 {
+		var err error
+		if deployed, err = r.deployedJob(job); err != nil {
+			ui.ErrorWithContext(err, "failed to read deployed job", errors.UIContextPrefixJobName+*job.Name)
+			return runner.PlanCodeError
+		}
+	}
+	return r.planExitCode(ui, deployed, job, resp)
+}
+
+// outputJobPatch writes the JSON Patch which transforms the deployed version of
+// the job into the planned job. Jobs which are not yet deployed produce a patch
+// adding the whole job.
+func (r *Runner) outputJobPatch(ui terminal.UI, job *api.Job, resp *api.JobPlanResponse) int {
+	deployed, err := r.deployedJob(job)
+	if err != nil {
+		ui.ErrorWithContext(err, "failed to read deployed job", errors.UIContextPrefixJobName+*job.Name)
+		return runner.PlanCodeError
+	}
+
+	// Trust the servers when they report no changes, as they account for
+	// defaults which cannot be detected from the job alone.
+	ops := []patchOperation{}
+	if resp.Diff == nil || resp.Diff.Type != "None" {
+		if ops, err = jobPatch(deployed, job, r.cfg.PlanConfig.DiffIgnore); err != nil {
+			ui.ErrorWithContext(err, "failed to generate job patch", errors.UIContextPrefixJobName+*job.Name)
+			return runner.PlanCodeError
+		}
+	}
+
+	if err := writePlanJSON(ui, ops); err != nil {
+		ui.ErrorWithContext(err, "failed to write job patch", errors.UIContextPrefixJobName+*job.Name)
+		return runner.PlanCodeError
+	}
+
+	return r.planExitCode(ui, deployed, job, resp)
+}
+
+// planExitCode returns the exit code of the plan of the job. Differences only
+// within ignored fields do not count as changes, while warnings which are not
+// ignored are reported the same as changes, so they are not missed by scripts.
+func (r *Runner) planExitCode(ui terminal.UI, deployed, job *api.Job, resp *api.JobPlanResponse) int {
+	ignored, err := r.ignoredChanges(deployed, job)
+	if err != nil {
+		ui.ErrorWithContext(err, "failed to compare deployed job", errors.UIContextPrefixJobName+*job.Name)
+		return runner.PlanCodeError
+	}
+
+	exitCode := runner.PlanCodeNoUpdates
+	if !ignored {
+		exitCode = getExitCode(resp)
+	}
+	if filterWarnings(resp.Warnings, r.cfg.PlanConfig.IgnoreWarnings) != "" {
+		exitCode = runner.HigherPlanCode(exitCode, runner.PlanCodeUpdates)
+	}
+	return exitCode
+}
+
+// ignoredChanges reports whether every difference between the deployed and
+// planned job is within the fields the plan was asked to ignore, in which
+// case the plan is considered to make no changes. Without any ignore paths,
+// the changes reported by the Nomad servers are always trusted.
+func (r *Runner) ignoredChanges(deployed, job *api.Job) (bool, error) {
+	if deployed == nil || len(r.cfg.PlanConfig.DiffIgnore) == 0 {
+		return false, nil
+	}
+	ops, err := jobPatch(deployed, job, r.cfg.PlanConfig.DiffIgnore)
+	if err != nil {
+		return false, err
+	}
+	return len(ops) == 0, nil
+}
+
+// deployedJob returns the deployed version of the passed job, or nil if the
+// job is not yet deployed.
+func (r *Runner) deployedJob(job *api.Job) (*api.Job, error) {
+	deployed, _, err := r.client.Jobs().Info(*job.ID, r.newQueryOptsFromClientJob(job))
+	if err != nil {
+		if errIsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return deployed, nil
+}
+
+// writePlanJSON writes the value as indented JSON directly to stdout, so the
+// output is kept when running quietly.
+func writePlanJSON(ui terminal.UI, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	stdout, _, err := ui.OutputWriters()
+	if err != nil {
+		return fmt.Errorf("failed to get output writers: %w", err)
+	}
+	fmt.Fprintln(stdout, string(out))
+	return nil
+}
+
+// hasChanges plans the passed job and reports whether its diff against the
+// deployed version contains any changes. Jobs which do not yet exist are
+// always considered changed.
+func (r *Runner) hasChanges(job ParsedTemplate) (bool, error) {
+	resp, _, err := r.client.Jobs().PlanOpts(job.Job(), &api.PlanOptions{Diff: true}, r.newWriteOptsFromJob(job))
+	if err != nil {
+		return false, err
+	}
+	return resp.Diff == nil || resp.Diff.Type != "None", nil
 }
 
 // formatJobModifyIndex produces a help string that displays the job modify
@@ -165,3 +296,53 @@ func getExitCode(resp *api.JobPlanResponse) int {
 
 	return runner.PlanCodeNoUpdates
 }
+
+// filterWarnings removes any warning from the Nomad formatted warnings string
+// which matches one of the passed patterns. Nomad formats multiple warnings
+// as a bulleted list with a count header, so the header is regenerated to
+// reflect the remaining warnings. An empty string is returned if all warnings
+// were filtered.
+func filterWarnings(warnings string, ignore []*regexp.Regexp) string {
+	if warnings == "" || len(ignore) == 0 {
+		return warnings
+	}
+
+	// Pull out the individual warnings. If the string does not contain any
+	// bulleted items, it is treated as a single warning.
+	var items []string
+	for _, line := range strings.Split(warnings, "\n") {
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "* "); ok {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		items = []string{strings.TrimSpace(warnings)}
+	}
+
+	var kept []string
+	for _, item := range items {
+		if !matchesAny(item, ignore) {
+			kept = append(kept, item)
+		}
+	}
+
+	switch len(kept) {
+	case 0:
+		return ""
+	case len(items):
+		return warnings
+	case 1:
+		return fmt.Sprintf("1 warning:\n\n* %s", kept[0])
+	default:
+		return fmt.Sprintf("%d warnings:\n\n* %s", len(kept), strings.Join(kept, "\n* "))
+	}
+}
+
+func matchesAny(s string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
+	"github.com/hashicorp/nomad-pack/internal/runner"
+	"github.com/hashicorp/nomad/api"
+	"github.com/shoenig/test/must"
+)
+
+func Test_filterWarnings(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputWarnings  string
+		inputIgnore    []*regexp.Regexp
+		expectedOutput string
+	}{
+		{
+			name:           "no patterns",
+			inputWarnings:  "1 warning:\n\n* Group \"cache\" has warnings",
+			inputIgnore:    nil,
+			expectedOutput: "1 warning:\n\n* Group \"cache\" has warnings",
+		},
+		{
+			name:           "no warnings",
+			inputWarnings:  "",
+			inputIgnore:    []*regexp.Regexp{regexp.MustCompile("cache")},
+			expectedOutput: "",
+		},
+		{
+			name:           "all filtered",
+			inputWarnings:  "1 warning:\n\n* Group \"cache\" has warnings",
+			inputIgnore:    []*regexp.Regexp{regexp.MustCompile("cache")},
+			expectedOutput: "",
+		},
+		{
+			name:           "none filtered",
+			inputWarnings:  "1 warning:\n\n* Group \"cache\" has warnings",
+			inputIgnore:    []*regexp.Regexp{regexp.MustCompile("web")},
+			expectedOutput: "1 warning:\n\n* Group \"cache\" has warnings",
+		},
+		{
+			name:           "partially filtered",
+			inputWarnings:  "3 warnings:\n\n* Group \"cache\" has warnings\n* Group \"web\" has warnings\n* Group \"db\" has warnings",
+			inputIgnore:    []*regexp.Regexp{regexp.MustCompile("web")},
+			expectedOutput: "2 warnings:\n\n* Group \"cache\" has warnings\n* Group \"db\" has warnings",
+		},
+		{
+			name:           "partially filtered to one",
+			inputWarnings:  "2 warnings:\n\n* Group \"cache\" has warnings\n* Group \"web\" has warnings",
+			inputIgnore:    []*regexp.Regexp{regexp.MustCompile(`^Group "web"`)},
+			expectedOutput: "1 warning:\n\n* Group \"cache\" has warnings",
+		},
+		{
+			name:           "unformatted warning",
+			inputWarnings:  "Group \"cache\" has warnings",
+			inputIgnore:    []*regexp.Regexp{regexp.MustCompile("cache")},
+			expectedOutput: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			must.Eq(t, tc.expectedOutput, filterWarnings(tc.inputWarnings, tc.inputIgnore))
+		})
+	}
+}
+
+func TestRunner_planExitCode_Warnings(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputIgnore      []*regexp.Regexp
+		expectedExitCode int
+	}{
+		{
+			name:             "no patterns",
+			inputIgnore:      nil,
+			expectedExitCode: runner.PlanCodeUpdates,
+		},
+		{
+			name:             "one warning filtered",
+			inputIgnore:      []*regexp.Regexp{regexp.MustCompile("cache")},
+			expectedExitCode: runner.PlanCodeUpdates,
+		},
+		{
+			name:             "all warnings filtered",
+			inputIgnore:      []*regexp.Regexp{regexp.MustCompile("cache"), regexp.MustCompile("web")},
+			expectedExitCode: runner.PlanCodeNoUpdates,
+		},
+	}
+
+	job := &api.Job{Name: pointer.Of("example")}
+	resp := &api.JobPlanResponse{
+		Annotations: &api.PlanAnnotations{},
+		Warnings:    "2 warnings:\n\n* Group \"cache\" has warnings\n* Group \"web\" has warnings",
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Runner{cfg: &CLIConfig{PlanConfig: &PlanCLIConfig{IgnoreWarnings: tc.inputIgnore}}}
+			must.Eq(t, tc.expectedExitCode, r.planExitCode(nil, nil, job, resp))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// policyQuery is the Rego query evaluated for each job. Policies add a message
+// to the deny set for each rule the job breaks.
+const policyQuery = "data.nomad_pack.deny"
+
+// policyInput is the document passed to the policies as input.
+type policyInput struct {
+	Job  *api.Job        `json:"job"`
+	Pack policyInputPack `json:"pack"`
+}
+
+type policyInputPack struct {
+	Name           string `json:"name"`
+	Ref            string `json:"ref"`
+	Registry       string `json:"registry"`
+	DeploymentName string `json:"deployment_name"`
+}
+
+// CheckPolicies evaluates each parsed job against the OPA policies within the
+// configured policy directory, using the opa executable. A job denied by any
+// policy results in an error listing the messages of the failing rules, unless
+// the policies only warn, in which case the messages are output as warnings.
+func (r *Runner) CheckPolicies(ui terminal.UI, errCtx *errors.UIErrorContext) []*errors.WrappedUIContext {
+	var outputErrors []*errors.WrappedUIContext
+
+	if len(r.parsedTemplates) < 1 {
+		outputErrors = append(outputErrors, newNoParsedTemplatesError(validationSubjPolicyEval, errCtx))
+		return outputErrors
+	}
+
+	opaPath, err := exec.LookPath("opa")
+	if err != nil {
+		return []*errors.WrappedUIContext{{
+			Err:     fmt.Errorf("the opa executable is required to check policies: %w", err),
+			Subject: validationSubjPolicyEval,
+			Context: errCtx,
+		}}
+	}
+
+	for _, tplName := range slices.Sorted(maps.Keys(r.parsedTemplates)) {
+		parsedJob := r.parsedTemplates[tplName]
+
+		tplErrorContext := errCtx.Copy()
+		tplErrorContext.Add(errors.UIContextPrefixTemplateName, tplName)
+		tplErrorContext.Add(errors.UIContextPrefixJobName, parsedJob.GetName())
+
+		// Policies never need the tokens, which denies could otherwise
+		// output.
+		input := policyInput{
+			Job: withoutTokens(parsedJob.Job()),
+			Pack: policyInputPack{
+				Name:           r.runnerCfg.PackName,
+				Ref:            r.runnerCfg.PackRef,
+				Registry:       r.runnerCfg.RegistryName,
+				DeploymentName: r.runnerCfg.DeploymentName,
+			},
+		}
+
+		denies, err := evalPolicies(opaPath, r.cfg.RunConfig.PolicyDir, input)
+		if err != nil {
+			outputErrors = append(outputErrors, &errors.WrappedUIContext{
+				Err:     err,
+				Subject: validationSubjPolicyEval,
+				Context: tplErrorContext,
+			})
+			continue
+		}
+		if len(denies) == 0 {
+			continue
+		}
+
+		if r.cfg.RunConfig.PolicyWarnOnly {
+			for _, deny := range denies {
+				ui.Warning(fmt.Sprintf("Job %q denied by policy: %s", parsedJob.GetName(), deny))
+			}
+			continue
+		}
+
+		outputErrors = append(outputErrors, &errors.WrappedUIContext{
+			Err:     fmt.Errorf("job denied by policy:\n  * %s", strings.Join(denies, "\n  * ")),
+			Subject: validationSubjPolicy,
+			Context: tplErrorContext,
+		})
+	}
+
+	if len(outputErrors) > 0 {
+		return outputErrors
+	}
+	return nil
+}
+
+// evalPolicies runs opa to evaluate the policies within dir against the input,
+// returning the messages of the deny rules which match.
+func evalPolicies(opaPath, dir string, input any) ([]string, error) {
+	in, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(opaPath, "eval", "--format=json", "--stdin-input", "--data", dir, policyQuery)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to evaluate policies: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+
+	// opa reports the value of the query for each result, and no results when
+	// no policy defines the query.
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value []any `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("failed to decode policy result: %w", err)
+	}
+
+	var denies []string
+	for _, result := range out.Result {
+		for _, expr := range result.Expressions {
+			for _, value := range expr.Value {
+				if s, ok := value.(string); ok {
+					denies = append(denies, s)
+					continue
+				}
+				b, _ := json.Marshal(value)
+				denies = append(denies, string(b))
+			}
+		}
+	}
+	return denies, nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+// testOPAScript stands in for opa eval, denying jobs named "forbidden" and
+// failing to evaluate policy directories named "broken".
+const testOPAScript = `#!/bin/sh
+case "$5" in
+  */broken) echo "1 error occurred: rego_parse_error" >&2; exit 2 ;;
+esac
+if grep -q '"Name":"forbidden"'; then
+  echo '{"result":[{"expressions":[{"value":["job name is forbidden",{"rule":"name"}]}]}]}'
+else
+  echo '{"result":[{"expressions":[{"value":[]}]}]}'
+fi
+`
+
+func Test_evalPolicies(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test opa is a shell script")
+	}
+
+	dir := t.TempDir()
+	opaPath := filepath.Join(dir, "opa")
+	must.NoError(t, os.WriteFile(opaPath, []byte(testOPAScript), 0755))
+
+	denies, err := evalPolicies(opaPath, dir, map[string]any{"job": map[string]any{"Name": "allowed"}})
+	must.NoError(t, err)
+	must.SliceEmpty(t, denies)
+
+	// Non-string deny values are reported as JSON.
+	denies, err = evalPolicies(opaPath, dir, map[string]any{"job": map[string]any{"Name": "forbidden"}})
+	must.NoError(t, err)
+	must.Eq(t, []string{"job name is forbidden", `{"rule":"name"}`}, denies)
+
+	_, err = evalPolicies(opaPath, filepath.Join(dir, "broken"), map[string]any{})
+	must.ErrorContains(t, err, "rego_parse_error")
+}
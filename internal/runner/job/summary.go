@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"slices"
+
+	"github.com/hashicorp/nomad/api"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
+	"github.com/hashicorp/nomad-pack/internal/runner"
+	"github.com/hashicorp/nomad-pack/terminal"
+)
+
+// planSummary is the machine-readable summary of the plan of a single job,
+// written by the json plan format.
+type planSummary struct {
+	Job    string `json:"job"`
+	Region string `json:"region,omitempty"`
+
+	// DiffType is the type of the change reported by the Nomad servers, such
+	// as Added, Edited, or None.
+	DiffType string `json:"diff_type"`
+
+	// Changes is true when the plan places, stops, or updates allocations.
+	Changes bool `json:"changes"`
+
+	// Resources is the change in the resources requested by the job, summed
+	// across its task groups.
+	Resources resourceDelta `json:"resources"`
+
+	Groups []groupResourceDelta `json:"groups"`
+}
+
+// resourceDelta is the change in the resources requested by the tasks of a
+// job or task group, multiplied by the number of instances of each group.
+type resourceDelta struct {
+	CPU      int `json:"cpu_mhz"`
+	MemoryMB int `json:"memory_mb"`
+}
+
+// groupResourceDelta is the change in the resources requested by a single task
+// group.
+type groupResourceDelta struct {
+	Name string `json:"name"`
+	resourceDelta
+}
+
+// outputPlanSummary writes the summary of the planned job, including its
+// resource changes from the deployed version of the job. Jobs which are not
+// yet deployed count as adding all their resources.
+func (r *Runner) outputPlanSummary(ui terminal.UI, job *api.Job, resp *api.JobPlanResponse) int {
+	deployed, err := r.deployedJob(job)
+	if err != nil {
+		ui.ErrorWithContext(err, "failed to read deployed job", errors.UIContextPrefixJobName+*job.Name)
+		return runner.PlanCodeError
+	}
+
+	exitCode := r.planExitCode(ui, deployed, job, resp)
+	if exitCode == runner.PlanCodeError {
+		return exitCode
+	}
+
+	summary := planSummary{
+		Job:     *job.Name,
+		Changes: exitCode == runner.PlanCodeUpdates,
+		Groups:  resourceDeltas(deployed, job),
+	}
+	if job.Region != nil {
+		summary.Region = *job.Region
+	}
+	if resp.Diff != nil {
+		summary.DiffType = resp.Diff.Type
+	}
+	for _, group := range summary.Groups {
+		summary.Resources.CPU += group.CPU
+		summary.Resources.MemoryMB += group.MemoryMB
+	}
+
+	if err := writePlanJSON(ui, summary); err != nil {
+		ui.ErrorWithContext(err, "failed to write plan summary", errors.UIContextPrefixJobName+*job.Name)
+		return runner.PlanCodeError
+	}
+	return exitCode
+}
+
+// resourceDeltas returns the change in requested resources of each task group
+// from the deployed job to the rendered job, in name order. A nil deployed job
+// results in every group adding its resources, and groups which are removed
+// from the rendered job release theirs.
+func resourceDeltas(deployed, rendered *api.Job) []groupResourceDelta {
+	from, to := groupResources(deployed), groupResources(rendered)
+
+	names := make([]string, 0, len(from)+len(to))
+	for name := range from {
+		names = append(names, name)
+	}
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	deltas := make([]groupResourceDelta, 0, len(names))
+	for _, name := range names {
+		deltas = append(deltas, groupResourceDelta{
+			Name: name,
+			resourceDelta: resourceDelta{
+				CPU:      to[name].CPU - from[name].CPU,
+				MemoryMB: to[name].MemoryMB - from[name].MemoryMB,
+			},
+		})
+	}
+	return deltas
+}
+
+// groupResources returns the total resources requested by each task group of
+// the job, keyed by the group name.
+func groupResources(job *api.Job) map[string]resourceDelta {
+	out := make(map[string]resourceDelta)
+	if job == nil {
+		return out
+	}
+
+	for _, group := range job.TaskGroups {
+		if group.Name == nil {
+			continue
+		}
+
+		count := 1
+		if group.Count != nil {
+			count = *group.Count
+		}
+
+		var total resourceDelta
+		for _, task := range group.Tasks {
+			if task.Resources == nil {
+				continue
+			}
+			if task.Resources.CPU != nil {
+				total.CPU += *task.Resources.CPU * count
+			}
+			if task.Resources.MemoryMB != nil {
+				total.MemoryMB += *task.Resources.MemoryMB * count
+			}
+		}
+		out[*group.Name] = total
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package job
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/shoenig/test/must"
+
+	"github.com/hashicorp/nomad-pack/internal/pkg/helper/pointer"
+)
+
+func Test_resourceDeltas(t *testing.T) {
+	testGroup := func(name string, count, cpu, memory int) *api.TaskGroup {
+		return &api.TaskGroup{
+			Name:  pointer.Of(name),
+			Count: pointer.Of(count),
+			Tasks: []*api.Task{
+				{Resources: &api.Resources{CPU: pointer.Of(cpu), MemoryMB: pointer.Of(memory)}},
+				{Resources: &api.Resources{CPU: pointer.Of(50), MemoryMB: pointer.Of(64)}},
+			},
+		}
+	}
+
+	rendered := &api.Job{TaskGroups: []*api.TaskGroup{
+		testGroup("web", 3, 200, 256),
+		testGroup("cache", 1, 100, 512),
+	}}
+
+	// Jobs which are not deployed add all their resources.
+	must.Eq(t, []groupResourceDelta{
+		{Name: "cache", resourceDelta: resourceDelta{CPU: 150, MemoryMB: 576}},
+		{Name: "web", resourceDelta: resourceDelta{CPU: 750, MemoryMB: 960}},
+	}, resourceDeltas(nil, rendered))
+
+	// Changed counts and resources are multiplied out, and removed groups
+	// release their resources.
+	deployed := &api.Job{TaskGroups: []*api.TaskGroup{
+		testGroup("web", 2, 200, 128),
+		testGroup("worker", 2, 500, 1024),
+	}}
+	must.Eq(t, []groupResourceDelta{
+		{Name: "cache", resourceDelta: resourceDelta{CPU: 150, MemoryMB: 576}},
+		{Name: "web", resourceDelta: resourceDelta{CPU: 250, MemoryMB: 576}},
+		{Name: "worker", resourceDelta: resourceDelta{CPU: -1100, MemoryMB: -2176}},
+	}, resourceDeltas(deployed, rendered))
+
+	must.Eq(t, []groupResourceDelta{
+		{Name: "cache", resourceDelta: resourceDelta{}},
+		{Name: "web", resourceDelta: resourceDelta{}},
+	}, resourceDeltas(rendered, rendered))
+}
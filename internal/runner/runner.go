@@ -4,6 +4,8 @@
 package runner
 
 import (
+	"context"
+
 	"github.com/hashicorp/nomad-pack/internal/pkg/errors"
 	"github.com/hashicorp/nomad-pack/terminal"
 )
@@ -17,6 +19,14 @@ type Config struct {
 	PathPath       string
 	PackRef        string
 	RegistryName   string
+
+	// Alias prefixes the names of the jobs, so the pack can be deployed more
+	// than once without the jobs colliding.
+	Alias string
+
+	// ACLCapabilities are the Nomad ACL namespace capabilities the pack
+	// declares it requires.
+	ACLCapabilities []string
 }
 
 // PlanCode* is the set of expected error codes that Runner.PlanDeployment
@@ -47,15 +57,33 @@ type Runner interface {
 	// the rendered object matches exactly what would be deployed.
 	CanonicalizeTemplates() []*errors.WrappedUIContext
 
+	// ApplyPatch applies the configured patch to each parsed template, so
+	// the objects can be adjusted without changing the pack.
+	ApplyPatch(*errors.UIErrorContext) []*errors.WrappedUIContext
+
 	// CheckForConflicts iterates over parsed templates, and checks for
 	// conflicts with running packs.
 	CheckForConflicts(*errors.UIErrorContext) []*errors.WrappedUIContext
 
+	// CheckACLCapabilities checks the Nomad ACL token has the capabilities
+	// the pack requires in the namespace of each parsed template.
+	CheckACLCapabilities(*errors.UIErrorContext) []*errors.WrappedUIContext
+
+	// CheckPolicies evaluates the parsed templates against the configured
+	// policies. Denies which only warn are output via the terminal.UI.
+	CheckPolicies(terminal.UI, *errors.UIErrorContext) []*errors.WrappedUIContext
+
 	// Deploy the rendered templates to the Nomad cluster. A single error is
 	// returned as any error encountered is terminal. Any warnings and errors
 	// that need to be displayed to the console should be printed within the
-	// function and is why the UI and UIErrorContext is passed.
-	Deploy(terminal.UI, *errors.UIErrorContext) *errors.WrappedUIContext
+	// function and is why the UI and UIErrorContext is passed. Waiting for
+	// the deployment stops once the context is cancelled.
+	Deploy(context.Context, terminal.UI, *errors.UIErrorContext) *errors.WrappedUIContext
+
+	// FollowLogs streams the stdout and stderr of the tasks of each deployed
+	// object to the terminal.UI until the context is cancelled or the tasks
+	// stop.
+	FollowLogs(context.Context, terminal.UI) *errors.WrappedUIContext
 
 	// DestroyDeployment destroys the deployment as provided by the
 	// configuration set within SetDeployerConfig.
@@ -7,14 +7,15 @@ import (
 	"errors"
 )
 
-// Metadata is the contents of the Pack metadata.hcl file. It contains
-// high-level information about the pack which is useful for operators and is
-// also exposed as template variables during rendering.
+// Metadata is the contents of the Pack metadata.hcl or metadata.json file. It
+// contains high-level information about the pack which is useful for
+// operators and is also exposed as template variables during rendering.
 type Metadata struct {
 	App          *MetadataApp         `hcl:"app,block"`
 	Pack         *MetadataPack        `hcl:"pack,block"`
 	Integration  *MetadataIntegration `hcl:"integration,block"`
 	Dependencies []*Dependency        `hcl:"dependency,block"`
+	ACL          *MetadataACL         `hcl:"acl,block"`
 }
 
 // MetadataApp contains information regarding the application that the pack is
@@ -90,6 +91,15 @@ type MetadataIntegration struct {
 	Name string `hcl:"name,optional"`
 }
 
+// MetadataACL declares the Nomad ACL capabilities the pack requires, so the
+// token used to deploy it can be checked before any job is submitted.
+type MetadataACL struct {
+
+	// Capabilities are the namespace capabilities, such as "submit-job" or
+	// "read-logs", which the token must have in the namespace of each job.
+	Capabilities []string `hcl:"capabilities,optional"`
+}
+
 // ConvertToMapInterface returns a map[string]any representation of the
 // metadata object. The conversion doesn't take into account empty values and
 // will add them.
@@ -6,6 +6,7 @@ package pack
 import (
 	"errors"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -67,11 +68,22 @@ type Pack struct {
 	// with any override variables and stored within Variables.
 	RootVariableFile *File
 
+	// VariableFiles are any additional files which declare root variables,
+	// found as "*.variables.hcl" files or within the "variables" directory.
+	// Their declarations are merged with those of the RootVariableFile.
+	VariableFiles []*File
+
 	// OutputTemplateFile contains the optional output template file. If this
 	// string is empty, it is assumed there is no output template to render and
 	// print.
 	OutputTemplateFile *File
 
+	// PreDeployHookFile and PostDeployHookFile contain the optional hook
+	// templates, which render to scripts that can be run before and after
+	// the pack is deployed. They are only used for the parent pack.
+	PreDeployHookFile  *File
+	PostDeployHookFile *File
+
 	// dependencies are the packs that this pack depends on. There is no
 	// guarantee that this is populated. This is a private field so access can
 	// be controlled by the appropriate functions.
@@ -137,6 +149,24 @@ func (p *Pack) AddDependencies(packs ...*Pack) {
 	}
 }
 
+// RequiredACLCapabilities returns the Nomad ACL capabilities declared by the
+// pack and its dependencies, in name order and without duplicates.
+func (p *Pack) RequiredACLCapabilities() []string {
+	var out []string
+	p.requiredACLCapabilities(&out)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+func (p *Pack) requiredACLCapabilities(acc *[]string) {
+	if p.Metadata != nil && p.Metadata.ACL != nil {
+		*acc = append(*acc, p.Metadata.ACL.Capabilities...)
+	}
+	for _, dep := range p.dependencies {
+		dep.requiredACLCapabilities(acc)
+	}
+}
+
 // Dependencies returns the list of dependencies the Pack has.
 func (p *Pack) Dependencies() []*Pack { return p.dependencies }
 
@@ -165,6 +195,30 @@ func (p *Pack) rootVariableFiles(parentID ID, acc *map[ID]*File) {
 	}
 }
 
+// AdditionalVariableFiles generates a mapping of the additional variable files
+// of the pack and all dependencies. Packs without additional variable files are
+// not included.
+func (p *Pack) AdditionalVariableFiles() map[ID][]*File {
+	out := map[ID][]*File{}
+	if len(p.VariableFiles) > 0 {
+		out[p.ID()] = p.VariableFiles
+	}
+	for _, dep := range p.dependencies {
+		dep.additionalVariableFiles(p.ID(), out)
+	}
+	return out
+}
+
+func (p *Pack) additionalVariableFiles(parentID ID, acc map[ID][]*File) {
+	depID := parentID.Join(p.ID())
+	if len(p.VariableFiles) > 0 {
+		acc[depID] = p.VariableFiles
+	}
+	for _, dep := range p.dependencies {
+		dep.additionalVariableFiles(depID, acc)
+	}
+}
+
 // Validate the pack for terminal problems that can easily be detected at this
 // stage. Anything that has potential to cause a panic should ideally be caught
 // here.
@@ -134,6 +134,31 @@ func TestPack_RootVariableFiles(t *testing.T) {
 	}
 }
 
+func TestPack_AdditionalVariableFiles(t *testing.T) {
+	ci.Parallel(t)
+
+	extra := &File{
+		Name:    "variables/extra.hcl",
+		Path:    "/opt/packs/dep1/variables/extra.hcl",
+		Content: []byte(`variable "hoo" {default = "har"}`),
+	}
+	inputPack := &Pack{
+		Metadata: &Metadata{Pack: &MetadataPack{Name: "example"}},
+		dependencies: []*Pack{
+			{
+				Metadata:      &Metadata{Pack: &MetadataPack{Name: "dep1"}},
+				VariableFiles: []*File{extra},
+			},
+			{
+				Metadata: &Metadata{Pack: &MetadataPack{Name: "dep2"}},
+			},
+		},
+	}
+
+	// Packs without additional variable files are not included.
+	must.Eq(t, map[ID][]*File{"example.dep1": {extra}}, inputPack.AdditionalVariableFiles())
+}
+
 func TestPack_IsValidName(t *testing.T) {
 	testCases := []struct {
 		name  string
@@ -155,3 +180,22 @@ func TestPack_IsValidName(t *testing.T) {
 		})
 	}
 }
+
+func TestPack_RequiredACLCapabilities(t *testing.T) {
+	ci.Parallel(t)
+
+	p := &Pack{Metadata: &Metadata{
+		Pack: &MetadataPack{Name: "parent"},
+		ACL:  &MetadataACL{Capabilities: []string{"submit-job", "read-logs"}},
+	}}
+	must.SliceEmpty(t, (&Pack{Metadata: &Metadata{Pack: &MetadataPack{Name: "none"}}}).RequiredACLCapabilities())
+
+	p.AddDependencies(
+		&Pack{Metadata: &Metadata{
+			Pack: &MetadataPack{Name: "child"},
+			ACL:  &MetadataACL{Capabilities: []string{"submit-job", "dispatch-job"}},
+		}},
+		&Pack{Metadata: &Metadata{Pack: &MetadataPack{Name: "no_acl"}}},
+	)
+	must.Eq(t, []string{"dispatch-job", "read-logs", "submit-job"}, p.RequiredACLCapabilities())
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package variables
+
+import (
+	"maps"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/nomad-pack/internal/pkg/errors/packdiags"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// RefersToVariables reports whether the expression refers to any variable as
+// var.<name>, in which case it can only be evaluated by EvaluateExpressions.
+func RefersToVariables(expr hcl.Expression) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() == "var" {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateExpressions evaluates the expressions given as variable values into
+// concrete values. The expressions may refer to the other variables of the same
+// pack as var.<name>, so they are evaluated in dependency order, and may use
+// the same functions as validation conditions.
+func EvaluateExpressions(vars map[ID]*Variable) hcl.Diagnostics {
+	e := &expressionEvaluator{
+		vars:   vars,
+		active: make(map[ID]bool),
+	}
+
+	// Evaluate in name order so that the diagnostics are consistent.
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name.String())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e.evaluate(ID(name))
+	}
+	return e.diags
+}
+
+// expressionEvaluator tracks the state of a single EvaluateExpressions call.
+type expressionEvaluator struct {
+	vars  map[ID]*Variable
+	diags hcl.Diagnostics
+
+	// active holds the variables whose expressions are being evaluated, and
+	// is used to detect expressions which refer back to themselves.
+	active map[ID]bool
+}
+
+// evaluate sets the value of the named variable from its expression, first
+// evaluating the expressions of any variables it refers to. Variables which
+// fail to evaluate are left without an expression or value, so they are only
+// reported once.
+func (e *expressionEvaluator) evaluate(name ID) {
+	v, ok := e.vars[name]
+	if !ok || v.Expr == nil {
+		return
+	}
+	expr := v.Expr
+
+	if e.active[name] {
+		e.diags = e.diags.Append(packdiags.DiagVariableExpressionCycle(name.String(), expr.Source, expr.Range.Ptr()))
+		v.Expr = nil
+		return
+	}
+	e.active[name] = true
+	defer delete(e.active, name)
+
+	for _, traversal := range expr.Expr.Variables() {
+		if traversal.RootName() != "var" || len(traversal) < 2 {
+			continue
+		}
+		if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+			e.evaluate(ID(attr.Name))
+		}
+	}
+
+	// A variable referenced by the expression may have been part of a cycle
+	// which has already been reported.
+	if v.Expr == nil {
+		return
+	}
+	v.Expr = nil
+
+	values := make(map[string]cty.Value, len(e.vars))
+	for n, other := range e.vars {
+		if other.Value != cty.NilVal {
+			values[n.String()] = other.Value
+		}
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{"var": cty.ObjectVal(values)},
+		Functions: validationFunctions,
+	}
+	if len(expr.Functions) > 0 {
+		ctx.Functions = maps.Clone(validationFunctions)
+		maps.Copy(ctx.Functions, expr.Functions)
+	}
+
+	val, valDiags := expr.Expr.Value(ctx)
+	if valDiags.HasErrors() {
+		for _, diag := range valDiags {
+			if diag.Severity == hcl.DiagError {
+				e.diags = e.diags.Append(packdiags.DiagInvalidVariableExpression(name.String(), expr.Source, diag.Detail, expr.Range.Ptr()))
+			}
+		}
+		return
+	}
+
+	if v.Type != cty.NilType {
+		var err error
+		if val, err = convert.Convert(val, v.Type); err != nil {
+			e.diags = e.diags.Append(packdiags.DiagInvalidVariableExpression(name.String(), expr.Source,
+				"the result is not compatible with the variable's type constraint: "+err.Error()+".", expr.Range.Ptr()))
+			return
+		}
+	}
+	v.Value = val
+}
@@ -14,6 +14,7 @@ type Override struct {
 	Path  pack.ID
 	Type  cty.Type
 	Value cty.Value
+	Expr  *Expression
 	Range hcl.Range
 }
 
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package variables
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// VariableSource supplies the values of pack variables, such as from an
+// external configuration service. Sources are consulted for the variables
+// which are not set by variable files, --var flags, or env vars.
+type VariableSource interface {
+	// Lookup returns the value of the named variable, and whether the source
+	// has a value for it. As with --var, the variables of dependency packs are
+	// named by their path from the parent pack, such as "child.region". The
+	// value is converted to the type of the variable by the caller, with
+	// strings parsed in the same way as --var values.
+	Lookup(name string) (cty.Value, bool, error)
+}
+
+// SourcePluginResult is written to stdout by a variable source plugin in
+// response to a lookup.
+type SourcePluginResult struct {
+	// Found is true when the source has a value for the variable.
+	Found bool `json:"found"`
+
+	// Value is the value of the variable, which is only set when found.
+	Value *ctyjson.SimpleJSONValue `json:"value,omitempty"`
+}
+
+// ServeSourcePlugin runs the VariableSource as a variable source plugin,
+// which nomad-pack loads with the --var-source flag. Plugins communicate using
+// the same subprocess protocol as template plugins:
+//
+//   - "<plugin> lookup <name>" must write a JSON encoded SourcePluginResult
+//     to stdout. A non-zero exit code is treated as an error, with stderr
+//     used as the message.
+//
+// The args exclude the name of the executable, and the returned exit code
+// should be passed to os.Exit:
+//
+//	func main() {
+//		os.Exit(variables.ServeSourcePlugin(src, os.Args[1:], os.Stdout, os.Stderr))
+//	}
+func ServeSourcePlugin(src VariableSource, args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 || args[0] != "lookup" {
+		fmt.Fprintln(stderr, "usage: lookup <name>")
+		return 1
+	}
+
+	val, found, err := src.Lookup(args[1])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	result := SourcePluginResult{Found: found}
+	if found {
+		result.Value = &ctyjson.SimpleJSONValue{Value: val}
+	}
+	if err := json.NewEncoder(stdout).Encode(result); err != nil {
+		fmt.Fprintf(stderr, "failed to encode value of %s: %v\n", args[1], err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package variables
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/ci"
+	"github.com/shoenig/test/must"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type testSource map[string]cty.Value
+
+func (s testSource) Lookup(name string) (cty.Value, bool, error) {
+	if name == "broken" {
+		return cty.NilVal, false, errors.New("backend unavailable")
+	}
+	val, ok := s[name]
+	return val, ok, nil
+}
+
+func TestServeSourcePlugin(t *testing.T) {
+	ci.Parallel(t)
+
+	src := testSource{
+		"region": cty.StringVal("eu-west"),
+		"ports":  cty.ListVal([]cty.Value{cty.NumberIntVal(80), cty.NumberIntVal(443)}),
+	}
+
+	testCases := []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{args: []string{"lookup", "region"}, stdout: `{"found":true,"value":"eu-west"}`},
+		{args: []string{"lookup", "ports"}, stdout: `{"found":true,"value":[80,443]}`},
+		{args: []string{"lookup", "missing"}, stdout: `{"found":false}`},
+		{args: []string{"lookup", "broken"}, code: 1, stderr: "backend unavailable"},
+		{args: []string{"functions"}, code: 1, stderr: "usage: lookup <name>"},
+	}
+	for _, tc := range testCases {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			must.Eq(t, tc.code, ServeSourcePlugin(src, tc.args, &stdout, &stderr))
+			must.Eq(t, tc.stdout, strings.TrimSpace(stdout.String()))
+			must.Eq(t, tc.stderr, strings.TrimSpace(stderr.String()))
+		})
+	}
+}
@@ -14,8 +14,29 @@ import (
 	"github.com/mitchellh/go-wordwrap"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
 
+// validationFunctions are the functions available to validation conditions.
+var validationFunctions = map[string]function.Function{
+	"abs":      stdlib.AbsoluteFunc,
+	"ceil":     stdlib.CeilFunc,
+	"contains": stdlib.ContainsFunc,
+	"floor":    stdlib.FloorFunc,
+	"length":   stdlib.LengthFunc,
+	"lower":    stdlib.LowerFunc,
+	"max":      stdlib.MaxFunc,
+	"min":      stdlib.MinFunc,
+	"regex":    stdlib.RegexFunc,
+	"regexall": stdlib.RegexAllFunc,
+	"upper":    stdlib.UpperFunc,
+}
+
+// SensitiveValue is shown in place of the value of a sensitive variable in
+// output intended for people, such as generated variable files and errors.
+const SensitiveValue = "(sensitive)"
+
 type PackIDKeyedVarMap map[pack.ID][]*Variable
 
 type ID string
@@ -50,15 +71,89 @@ type Variable struct {
 	// value into a Go type value.
 	Value cty.Value
 
+	// Sensitive marks the variable value as secret. The value is still used
+	// when rendering templates, but is redacted wherever it would otherwise
+	// be shown to the user.
+	Sensitive bool
+
+	// Expr is set when the variable value is given as an expression over the
+	// other variables of the pack. It is evaluated into Value once all
+	// overrides have been merged.
+	Expr *Expression
+
+	// Validations are the optional rules the variable value must satisfy.
+	// They are evaluated once all overrides have been merged.
+	Validations []*Validation
+
 	// DeclRange is the position marker of the variable within the file it was
 	// read from. This is used for diagnostics.
 	DeclRange hcl.Range
 }
 
+// Validation is a single validation rule declared within a variable block.
+type Validation struct {
+
+	// Condition is an expression which must evaluate to true for the variable
+	// value to be considered valid. It may only refer to the variable being
+	// validated as var.<name>.
+	Condition hcl.Expression
+
+	// ErrorMessage is returned to the user when Condition evaluates to false.
+	ErrorMessage string
+
+	// DeclRange is the position marker of the validation block.
+	DeclRange hcl.Range
+}
+
+// Expression is a variable value given as an expression which refers to other
+// variables of the same pack, such as `var.base * var.factor`.
+type Expression struct {
+
+	// Expr is the parsed expression.
+	Expr hcl.Expression
+
+	// Source is the expression as written, which is used in diagnostics.
+	Source string
+
+	// Range is the position marker of the expression. This is used for
+	// diagnostics.
+	Range hcl.Range
+
+	// Functions are available to the expression in addition to those
+	// available to validation conditions, such as the file function
+	// available to defaults.
+	Functions map[string]function.Function
+}
+
 func (v *Variable) SetDescription(d string) { v.Description = d; v.hasDescription = true }
 func (v *Variable) SetDefault(d cty.Value)  { v.Default = d; v.hasDefault = true }
 func (v *Variable) SetType(t cty.Type)      { v.Type = t; v.hasType = true }
 
+// HasDefault reports whether the variable declares a default value.
+func (v *Variable) HasDefault() bool { return v.hasDefault }
+
+// TypeString returns the type of the variable formatted as it is declared, or
+// an empty string if the variable does not declare a type.
+func (v *Variable) TypeString() string {
+	if !v.hasType {
+		return ""
+	}
+	return printType(v.Type)
+}
+
+// DefaultString returns the default value of the variable formatted as it is
+// declared, or an empty string if the variable does not declare a default.
+// The defaults of sensitive variables are redacted.
+func (v *Variable) DefaultString() string {
+	switch {
+	case !v.hasDefault:
+		return ""
+	case v.Sensitive:
+		return SensitiveValue
+	}
+	return printDefault(v.Default)
+}
+
 func (v *Variable) Equal(ivp *Variable) bool {
 	if v == ivp {
 		return true
@@ -71,7 +166,8 @@ func (v *Variable) Equal(ivp *Variable) bool {
 		cv.hasDefault == ov.hasDefault &&
 		cv.Type == ov.Type &&
 		cv.hasType == ov.hasType &&
-		cv.Value == ov.Value
+		cv.Value == ov.Value &&
+		cv.Sensitive == ov.Sensitive
 
 	return eq
 }
@@ -99,6 +195,16 @@ func (v *Variable) AsOverrideString(pID pack.ID) string {
 		out.WriteString(fmt.Sprintf("#   type: %s\n", printType(v.Type)))
 	}
 
+	if v.Sensitive {
+		// Sensitive values are left for the user to provide, rather than
+		// being written out.
+		if v.hasDefault {
+			out.WriteString(fmt.Sprintf("#   default: %s\n", SensitiveValue))
+		}
+		out.WriteString(fmt.Sprintf("#\n# %s=%s\n\n\n", rvn, SensitiveValue))
+		return out.String()
+	}
+
 	if v.hasDefault {
 		out.WriteString(fmt.Sprintf("#   default: %s\n", printDefault(v.Default)))
 	}
@@ -113,6 +219,51 @@ func (v *Variable) AsOverrideString(pID pack.ID) string {
 	return out.String()
 }
 
+// Validate evaluates every validation rule against the current variable value
+// and returns a diagnostic for each rule which fails, so that pack consumers
+// are shown all problems at once.
+func (v *Variable) Validate() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if v.Value == cty.NilVal {
+		return diags
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{v.Name.String(): v.Value}),
+		},
+		Functions: validationFunctions,
+	}
+
+	for _, validation := range v.Validations {
+		result, valDiags := validation.Condition.Value(ctx)
+		if valDiags.HasErrors() {
+			diags = diags.Extend(valDiags)
+			continue
+		}
+
+		result, err := convert.Convert(result, cty.Bool)
+		if err != nil || result.IsNull() || !result.IsKnown() {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid validation result",
+				Detail:   fmt.Sprintf("The validation condition for variable %q must return either true or false.", v.Name),
+				Subject:  validation.Condition.Range().Ptr(),
+			})
+			continue
+		}
+
+		if result.False() {
+			diags = diags.Append(packdiags.DiagFailedVariableValidation(
+				validation.ErrorMessage,
+				v.DeclRange.Ptr(),
+			))
+		}
+	}
+
+	return diags
+}
+
 func (v *Variable) Merge(in *Variable) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 	if in.Default != cty.NilVal {
@@ -120,8 +271,22 @@ func (v *Variable) Merge(in *Variable) hcl.Diagnostics {
 		v.Default = in.Default
 	}
 
+	// Overrides can not make a sensitive variable insensitive, as they are
+	// not declarations.
+	if in.Sensitive {
+		v.Sensitive = true
+	}
+
 	if in.Value != cty.NilVal {
 		v.Value = in.Value
+		v.Expr = nil
+	}
+
+	// An expression replaces the value, as it can only be evaluated once all
+	// the other variables are known.
+	if in.Expr != nil {
+		v.Expr = in.Expr
+		v.Value = cty.NilVal
 	}
 
 	if in.Type != cty.NilType {
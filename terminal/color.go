@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package terminal
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/mitchellh/go-glint"
+)
+
+const (
+	// ColorAuto styles output only when stdout is a terminal and the NO_COLOR
+	// env var is unset.
+	ColorAuto = "auto"
+
+	// ColorAlways styles output even when it is piped or redirected.
+	ColorAlways = "always"
+
+	// ColorNever never styles output.
+	ColorNever = "never"
+)
+
+// ColorModes are the modes accepted by SetColorMode.
+var ColorModes = []string{ColorAuto, ColorAlways, ColorNever}
+
+// autoNoColor is the styling decision made by TTY detection when the process
+// started, which is restored by ColorAuto.
+var autoNoColor = color.NoColor
+
+// SetColorMode controls whether the UIs in this package style their output.
+// It should be called before any output is written.
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, "":
+		color.NoColor = autoNoColor
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	default:
+		return fmt.Errorf("unknown color mode %q, must be one of %v", mode, ColorModes)
+	}
+	return nil
+}
+
+// styled wraps glint.Style, dropping the style options when output is not to
+// be styled so the glint-based UI follows the same decision as the others.
+func styled(inner glint.Component, opts ...glint.StyleOption) glint.Component {
+	if color.NoColor {
+		opts = nil
+	}
+	return glint.Style(inner, opts...)
+}
@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package terminal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestSetColorMode(t *testing.T) {
+	t.Cleanup(func() { must.NoError(t, SetColorMode(ColorAuto)) })
+
+	var ui basicUI
+	output := func() string {
+		var buf bytes.Buffer
+		ui.Output("careful", WithWarningStyle(), WithWriter(&buf))
+		return buf.String()
+	}
+
+	must.NoError(t, SetColorMode(ColorAlways))
+	must.StrContains(t, output(), "\x1b[33m")
+
+	must.NoError(t, SetColorMode(ColorNever))
+	must.Eq(t, "careful\n", output())
+
+	must.ErrorContains(t, SetColorMode("sometimes"), `unknown color mode "sometimes"`)
+}
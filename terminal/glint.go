@@ -91,6 +91,18 @@ func (ui *glintUI) Interactive() bool {
 	return isatty.IsTerminal(os.Stdin.Fd())
 }
 
+// clearScreen is the ANSI escape sequence which moves the cursor to the top
+// left of the screen and clears it.
+const clearScreen = "\033[H\033[2J"
+
+// Clear clears the terminal so output can be redrawn from the top of the
+// screen.
+func (ui *glintUI) Clear() {
+	// Render any pending output first, so it is not drawn after the clear.
+	ui.d.RenderFrame()
+	fmt.Fprint(color.Output, clearScreen)
+}
+
 // Output implements UI
 func (ui *glintUI) Output(msg string, raw ...any) {
 	// Render row and reset
@@ -119,7 +131,7 @@ func (ui *glintUI) Output(msg string, raw ...any) {
 		lines := strings.Split(msg, "\n")
 		if len(lines) > 0 {
 			ui.d.Append(glint.Finalize(
-				glint.Style(
+				styled(
 					glint.Text("! "+lines[0]),
 					cs...,
 				),
@@ -173,7 +185,7 @@ func (ui *glintUI) Output(msg string, raw ...any) {
 	}
 
 	ui.d.Append(glint.Finalize(
-		glint.Style(
+		styled(
 			glint.Text(msg),
 			cs...,
 		),
@@ -250,7 +262,7 @@ func (ui *glintUI) AppendToRow(msg string, raw ...any) {
 		cs = append(cs, glint.Color("lightYellow"))
 	}
 
-	ui.row = append(ui.row, glint.Style(
+	ui.row = append(ui.row, styled(
 		glint.Text(msg),
 		cs...,
 	))
@@ -361,7 +373,7 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 	// function in ui.go
 	// Title the error output in red with the subject.
 	d.Append(glint.Layout(
-		glint.Style(
+		styled(
 			glint.Text(fmt.Sprintf("! %s\n", helper.Title(sub))),
 			glint.Color("red"),
 		),
@@ -369,7 +381,7 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 
 	// Add the error string as well as the error type to the output.
 	d.Append(glint.Layout(
-		glint.Style(glint.Text("    Error:   "), glint.Bold()),
+		styled(glint.Text("    Error:   "), glint.Bold()),
 		glint.Text(err.Error()),
 	).Row())
 
@@ -396,11 +408,11 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 				// There is something odd going on if we don't get a 2 split
 				// if we get 1, print the whole thing out.
 				d.Append(glint.Layout(
-					glint.Style(glint.Text("    " + splits[0])),
+					styled(glint.Text("    " + splits[0])),
 				).Row())
 			default:
 				d.Append(glint.Layout(
-					glint.Style(glint.Text("    "+splits[0]+":   "), glint.Bold()),
+					styled(glint.Text("    "+splits[0]+":   "), glint.Bold()),
 					glint.Text(strings.Join(splits[1:], ": "))).Row())
 			}
 		}
@@ -413,14 +425,14 @@ func (ui *glintUI) ErrorWithContext(err error, sub string, ctx ...string) {
 	// this within the ctx loop.
 	if len(ctx) > 0 {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text("    Context: "), glint.Bold()),
+			styled(glint.Text("    Context: "), glint.Bold()),
 		).Row())
 	}
 
 	// Iterate the addition context items and append these to the output.
 	for _, additionCTX := range ctx {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text(fmt.Sprintf("        - %s", additionCTX))),
+			styled(glint.Text(fmt.Sprintf("        - %s", additionCTX))),
 		).Row())
 	}
 	// Add a new line
@@ -25,6 +25,10 @@ import (
 
 type nonInteractiveUI struct {
 	mu sync.Mutex
+
+	// out overrides the writer messages are written to when set. Writers
+	// passed using WithWriter still take precedence.
+	out io.Writer
 }
 
 func NonInteractiveUI(ctx context.Context) UI {
@@ -45,6 +49,9 @@ func (ui *nonInteractiveUI) Interactive() bool {
 func (ui *nonInteractiveUI) Output(msg string, raw ...any) {
 	ui.mu.Lock()
 	defer ui.mu.Unlock()
+	if ui.out != nil {
+		raw = append([]any{WithWriter(ui.out)}, raw...)
+	}
 	msg, style, w := Interpret(msg, raw...)
 
 	switch style {
@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package terminal
+
+// quietUI wraps a UI, discarding headers, status updates, and informational
+// messages. Tables and named values are passed through, as they are the result
+// of the commands which output them, while errors and warnings are written to
+// the stderr writer of the wrapped UI.
+type quietUI struct {
+	UI
+
+	// errUI writes the errors.
+	errUI UI
+}
+
+// QuietUI returns a UI which only outputs results and errors, for use when
+// scripting commands. Input and the output writers are passed through to ui.
+func QuietUI(ui UI) UI {
+	errUI := ui
+	if _, stderr, err := ui.OutputWriters(); err == nil && stderr != nil {
+		errUI = &nonInteractiveUI{out: stderr}
+	}
+	return &quietUI{UI: ui, errUI: errUI}
+}
+
+// Output implements UI
+func (ui *quietUI) Output(msg string, raw ...any) {
+	if _, style, _ := Interpret(msg, raw...); isErrorStyle(style) {
+		ui.errUI.Output(msg, raw...)
+	}
+}
+
+// AppendToRow implements UI
+func (ui *quietUI) AppendToRow(msg string, raw ...any) {
+	if _, style, _ := Interpret(msg, raw...); isErrorStyle(style) {
+		ui.errUI.AppendToRow(msg, raw...)
+	}
+}
+
+// NamedValues implements UI
+func (ui *quietUI) NamedValues(rows []NamedValue, opts ...Option) {
+	ui.UI.NamedValues(rows, opts...)
+}
+
+// Status implements UI
+func (ui *quietUI) Status() Status { return quietStatus{} }
+
+// Table implements UI
+func (ui *quietUI) Table(tbl *Table, opts ...Option) { ui.UI.Table(tbl, opts...) }
+
+// Debug implements UI
+func (ui *quietUI) Debug(string) {}
+
+// Error implements UI
+func (ui *quietUI) Error(msg string) { ui.errUI.Error(msg) }
+
+// ErrorWithContext implements UI
+func (ui *quietUI) ErrorWithContext(err error, sub string, ctx ...string) {
+	ui.errUI.ErrorWithContext(err, sub, ctx...)
+}
+
+// Header implements UI
+func (ui *quietUI) Header(string) {}
+
+// Info implements UI
+func (ui *quietUI) Info(string) {}
+
+// Success implements UI
+func (ui *quietUI) Success(string) {}
+
+// Trace implements UI
+func (ui *quietUI) Trace(string) {}
+
+// Warning implements UI
+func (ui *quietUI) Warning(msg string) { ui.errUI.Warning(msg) }
+
+// WarningBold implements UI
+func (ui *quietUI) WarningBold(msg string) { ui.errUI.WarningBold(msg) }
+
+func isErrorStyle(style string) bool {
+	return style == ErrorStyle || style == ErrorBoldStyle
+}
+
+// quietStatus implements Status, discarding all updates.
+type quietStatus struct{}
+
+func (quietStatus) Update(string)       {}
+func (quietStatus) Step(string, string) {}
+func (quietStatus) Close() error        { return nil }
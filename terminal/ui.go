@@ -264,7 +264,7 @@ func ErrorWithContext(err error, sub string, ctx ...string) {
 
 	// Title the error output in red with the subject.
 	d.Append(glint.Layout(
-		glint.Style(
+		styled(
 			glint.Text(fmt.Sprintf("! %s\n", helper.Title(sub))),
 			glint.Color("red"),
 		),
@@ -272,7 +272,7 @@ func ErrorWithContext(err error, sub string, ctx ...string) {
 
 	// Add the error string as well as the error type to the output.
 	d.Append(glint.Layout(
-		glint.Style(glint.Text("    Error:   "), glint.Bold()),
+		styled(glint.Text("    Error:   "), glint.Bold()),
 		glint.Text(err.Error()),
 	).Row())
 
@@ -280,14 +280,14 @@ func ErrorWithContext(err error, sub string, ctx ...string) {
 	// this within the ctx loop.
 	if len(ctx) > 0 {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text("    Context: "), glint.Bold()),
+			styled(glint.Text("    Context: "), glint.Bold()),
 		).Row())
 	}
 
 	// Iterate the addition context items and append these to the output.
 	for _, additionCTX := range ctx {
 		d.Append(glint.Layout(
-			glint.Style(glint.Text(fmt.Sprintf("        - %s", additionCTX))),
+			styled(glint.Text(fmt.Sprintf("        - %s", additionCTX))),
 		).Row())
 	}
 